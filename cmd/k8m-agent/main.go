@@ -0,0 +1,32 @@
+// k8m-agent 部署在无法被 k8m 直接访问的集群内，主动连接 k8m 建立反向隧道
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/weibaohui/k8m/pkg/agent"
+	"k8s.io/klog/v2"
+)
+
+var Version string
+
+func main() {
+	opts := agent.Options{Version: Version}
+	klog.InitFlags(nil)
+	flag.StringVar(&opts.Server, "server", os.Getenv("K8M_SERVER"), "k8m 访问地址，例如 https://k8m.example.com，也可通过环境变量 K8M_SERVER 设置")
+	flag.StringVar(&opts.Token, "token", os.Getenv("K8M_AGENT_TOKEN"), "注册 Token，也可通过环境变量 K8M_AGENT_TOKEN 设置")
+	flag.BoolVar(&opts.Insecure, "insecure", os.Getenv("K8M_INSECURE") == "true", "跳过 k8m 证书校验")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	klog.Infof("k8m-agent %s 启动，连接 %s", Version, opts.Server)
+	if err := agent.Run(ctx, opts); err != nil {
+		klog.Fatalf("k8m-agent 运行失败: %v", err)
+	}
+}
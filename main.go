@@ -35,6 +35,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/controller/param"
 	"github.com/weibaohui/k8m/pkg/controller/pod"
 	"github.com/weibaohui/k8m/pkg/controller/rs"
+	"github.com/weibaohui/k8m/pkg/controller/scim"
 	"github.com/weibaohui/k8m/pkg/controller/sso"
 	"github.com/weibaohui/k8m/pkg/controller/storageclass"
 	"github.com/weibaohui/k8m/pkg/controller/sts"
@@ -87,6 +88,14 @@ func Init() {
 
 	// 初始化 AI 内置模型参数（通过统一接口）
 	aiService.AIService().SetVars(InnerApiKey, InnerApiUrl, InnerModel)
+	// LDAP 用户定时同步
+	service.GroupMappingService().StartLdapSync()
+	// 登录会话吊销列表同步
+	service.SessionService().Start()
+	// 审计日志转发
+	service.AuditService().Start()
+	// 登录记录清理
+	service.LoginSecurityService().Start()
 	go func() {
 		// 初始化kom
 		// 先注册回调，后面集群连接后，需要执行回调
@@ -105,6 +114,7 @@ func Init() {
 
 		// 再注册其他集群
 		service.ClusterService().ScanClustersInDB()
+		service.ClusterService().ScanClustersInAgent()
 		service.ClusterService().ScanClustersInDir(cfg.KubeConfig)
 		service.ClusterService().RegisterClustersByPath(cfg.KubeConfig)
 
@@ -198,6 +208,16 @@ func buildRouter(mgr *plugins.Manager, r chi.Router) http.Handler {
 		sso.RegisterAuthRoutes(auth)
 	})
 
+	// Agent 反向隧道，使用注册 Token 认证
+	r.Route("/agent", func(ag chi.Router) {
+		cluster.RegisterAgentTunnelRoutes(ag)
+	})
+
+	// SCIM 2.0 用户与用户组同步，使用 SCIM 令牌认证
+	r.Route("/scim/v2", func(sc chi.Router) {
+		scim.RegisterScimRoutes(sc)
+	})
+
 	r.Route("/", func(root chi.Router) {
 		mgr.RegisterRootRoutes(root)
 	})
@@ -257,10 +277,16 @@ func buildRouter(mgr *plugins.Manager, r chi.Router) http.Handler {
 		config.RegisterConditionRoutes(sadmin)
 		config.RegisterSSOConfigRoutes(sadmin)
 		config.RegisterLdapConfigRoutes(sadmin)
+		config.RegisterAuditSinkRoutes(sadmin)
+		config.RegisterScimTokenRoutes(sadmin)
 		config.RegisterConfigRoutes(sadmin)
 		user.RegisterClusterPermissionRoutes(sadmin)
 		user.RegisterAdminUserRoutes(sadmin)
+		user.RegisterAdminSessionRoutes(sadmin)
+		user.RegisterAdminLoginHistoryRoutes(sadmin)
 		user.RegisterAdminUserGroupRoutes(sadmin)
+		user.RegisterCustomRoleRoutes(sadmin)
+		user.RegisterGroupMappingRoutes(sadmin)
 		cluster.RegisterAdminClusterRoutes(sadmin)
 		menu.RegisterAdminMenuRoutes(sadmin)
 		mgr.RegisterAdminRoutes(sadmin)
@@ -0,0 +1,211 @@
+// Package agent 实现集群 Agent 与 k8m 之间的反向隧道。
+// Agent 部署在目标集群内，主动通过 WebSocket 连接 k8m，在该连接上运行 yamux 多路复用：
+// k8m 打开的每个流都是一个发往 Agent 的 HTTP 连接，由 Agent 使用集群内凭据转发到 API Server；
+// Agent 打开的流用于上报心跳。
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ConnectPath k8m 接收 Agent 连接的地址
+	ConnectPath = "/agent/connect"
+	// HeaderVersion Agent 版本
+	HeaderVersion = "X-K8m-Agent-Version"
+
+	// HeartbeatInterval Agent 上报心跳的间隔
+	HeartbeatInterval = 15 * time.Second
+	// HeartbeatTimeout 超过该时间未收到心跳，k8m 认为 Agent 已离线
+	HeartbeatTimeout = 3 * HeartbeatInterval
+
+	maxBackoff = time.Minute
+)
+
+// Heartbeat Agent 定期上报的心跳
+type Heartbeat struct {
+	AgentVersion string    `json:"agent_version"`
+	KubeVersion  string    `json:"kube_version"`
+	Time         time.Time `json:"time"`
+}
+
+// YamuxConfig 隧道两端共用的多路复用配置
+func YamuxConfig() *yamux.Config {
+	cfg := yamux.DefaultConfig()
+	cfg.KeepAliveInterval = HeartbeatInterval
+	cfg.LogOutput = io.Discard
+	return cfg
+}
+
+// Options Agent 运行参数
+type Options struct {
+	Server   string // k8m 访问地址，例如 https://k8m.example.com
+	Token    string // 注册 Token
+	Insecure bool   // 跳过 k8m 证书校验
+	Version  string // Agent 版本
+}
+
+// Run 持续连接 k8m，断开后按指数退避重连，直到 ctx 结束
+func Run(ctx context.Context, opts Options) error {
+	if opts.Server == "" || opts.Token == "" {
+		return fmt.Errorf("k8m 地址与注册 Token 不能为空")
+	}
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("读取集群内配置失败: %w", err)
+	}
+	proxy, err := newAPIServerProxy(restConfig)
+	if err != nil {
+		return err
+	}
+	kubeVersion := ""
+	if cs, err := kubernetes.NewForConfig(restConfig); err == nil {
+		if info, err := cs.Discovery().ServerVersion(); err == nil {
+			kubeVersion = info.GitVersion
+		}
+	}
+
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := serve(ctx, opts, proxy, kubeVersion)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// 连接保持一段时间后断开，重置退避时间
+		if time.Since(start) > maxBackoff {
+			backoff = time.Second
+		}
+		klog.Errorf("与 k8m 的隧道断开: %v，%s 后重连", err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// newAPIServerProxy 使用集群内 ServiceAccount 凭据转发请求到 API Server
+func newAPIServerProxy(restConfig *rest.Config) (http.Handler, error) {
+	target, err := url.Parse(restConfig.Host)
+	if err != nil {
+		return nil, fmt.Errorf("解析 API Server 地址失败: %w", err)
+	}
+	transport, err := rest.TransportFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	// watch 等长连接需要及时刷新
+	proxy.FlushInterval = -1
+	return proxy, nil
+}
+
+// serve 建立一次隧道并阻塞，直到隧道断开
+func serve(ctx context.Context, opts Options, proxy http.Handler, kubeVersion string) error {
+	u, err := connectURL(opts.Server)
+	if err != nil {
+		return err
+	}
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 15 * time.Second,
+	}
+	if opts.Insecure {
+		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+opts.Token)
+	header.Set(HeaderVersion, opts.Version)
+
+	ws, resp, err := dialer.DialContext(ctx, u, header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("连接 k8m 失败: %s %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+		return fmt.Errorf("连接 k8m 失败: %w", err)
+	}
+	session, err := yamux.Server(NewConn(ws), YamuxConfig())
+	if err != nil {
+		_ = ws.Close()
+		return err
+	}
+	defer session.Close()
+	klog.Infof("已连接 k8m %s", opts.Server)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-session.CloseChan():
+		}
+		_ = session.Close()
+	}()
+	go sendHeartbeats(session, opts.Version, kubeVersion)
+
+	// session 实现了 net.Listener，k8m 打开的每个流即一个 HTTP 连接
+	server := &http.Server{Handler: proxy, ReadHeaderTimeout: 30 * time.Second}
+	return server.Serve(session)
+}
+
+// sendHeartbeats 在独立的流上定期上报心跳
+func sendHeartbeats(session *yamux.Session, agentVersion, kubeVersion string) {
+	stream, err := session.Open()
+	if err != nil {
+		klog.Errorf("打开心跳流失败: %v", err)
+		_ = session.Close()
+		return
+	}
+	defer stream.Close()
+	enc := json.NewEncoder(stream)
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		hb := Heartbeat{AgentVersion: agentVersion, KubeVersion: kubeVersion, Time: time.Now()}
+		if err := enc.Encode(&hb); err != nil {
+			klog.Errorf("上报心跳失败: %v", err)
+			_ = session.Close()
+			return
+		}
+		select {
+		case <-session.CloseChan():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// connectURL 将 k8m 访问地址转换为 WebSocket 连接地址
+func connectURL(server string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(server, "/"))
+	if err != nil {
+		return "", fmt.Errorf("解析 k8m 地址失败: %w", err)
+	}
+	switch u.Scheme {
+	case "https", "wss":
+		u.Scheme = "wss"
+	case "http", "ws":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("k8m 地址必须以 http:// 或 https:// 开头")
+	}
+	u.Path += ConnectPath
+	return u.String(), nil
+}
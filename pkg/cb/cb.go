@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/weibaohui/k8m/pkg/comm"
+	"github.com/weibaohui/k8m/pkg/comm/utils/audit"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/service"
@@ -50,6 +51,7 @@ func RegisterDefaultCallbacks(cluster *service.ClusterConfig) func() {
 
 // handleCommonLogic 根据用户在指定集群上的角色和命名空间权限，校验其是否有执行指定 Kubernetes 操作（如读取、变更、Exec 等）的权限。
 // 平台管理员拥有所有权限，集群管理员拥有全部操作权限，特定操作（如 Exec、只读）需具备对应角色及命名空间权限。
+// 自定义角色按资源的 API组、类型及操作动词匹配规则，拒绝规则优先。
 // 若为内部监听（如 node watch），则跳过权限校验。
 //
 // 参数：
@@ -70,7 +72,23 @@ func handleCommonLogic(k8s *kom.Kubectl, action string) error {
 		nsList = append(nsList, ns)
 	}
 	name := stmt.Name
-	return comm.CheckPermissionLogic(ctx, cluster, nsList, ns, name, action)
+	err := comm.CheckResourcePermission(ctx, cluster, nsList, ns, name, action, stmt.GVK.Group, stmt.GVK.Kind)
+	if err != nil {
+		username, _ := ctx.Value(constants.JwtUserName).(string)
+		service.AuditService().Emit(&audit.Event{
+			Type:      audit.EventPermissionDenied,
+			Action:    action,
+			User:      username,
+			Cluster:   cluster,
+			Namespace: ns,
+			Group:     stmt.GVK.Group,
+			Kind:      stmt.GVK.Kind,
+			Name:      name,
+			Result:    audit.ResultDenied,
+			Message:   err.Error(),
+		})
+	}
+	return err
 }
 func saveLog2DB(k8s *kom.Kubectl, action string, err error) {
 	stmt := k8s.Statement
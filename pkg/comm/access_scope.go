@@ -0,0 +1,77 @@
+package comm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+)
+
+// AccessScope 在用户自身权限之上进一步收窄的访问范围，如带范围限制的 MCP Key。
+// 通过 context 传递，在 kom 回调的权限校验中优先生效，只能缩小权限，不能扩大权限。
+type AccessScope struct {
+	// Clusters 允许访问的集群，为空表示不限制
+	Clusters []string
+	// Namespaces 允许访问的命名空间，为空表示不限制
+	Namespaces []string
+	// ReadOnly 只读，拒绝 create/update/patch/delete/exec 等变更操作
+	ReadOnly bool
+	// Denied 非空时拒绝一切访问，内容为拒绝原因，如密钥过期、来源IP不在白名单
+	Denied string
+}
+
+// WithAccessScope 将访问范围写入 context
+func WithAccessScope(ctx context.Context, scope *AccessScope) context.Context {
+	return context.WithValue(ctx, constants.AccessScope, scope)
+}
+
+// GetAccessScope 从 context 中读取访问范围，未设置时返回 nil
+func GetAccessScope(ctx context.Context) *AccessScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(constants.AccessScope).(*AccessScope)
+	return scope
+}
+
+// IsWriteAction 判断操作是否为变更类操作
+func IsWriteAction(action string) bool {
+	switch action {
+	case "delete", "update", "patch", "create", "exec":
+		return true
+	}
+	return false
+}
+
+// Check 校验操作是否在访问范围内。
+// 命名空间与用户角色的处理保持一致：未指定命名空间的读取操作不做命名空间限制，
+// 但未指定命名空间的变更操作在命名空间受限时一律拒绝，避免越权修改集群级资源。
+func (s *AccessScope) Check(cluster string, nsList []string, action string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Denied != "" {
+		return fmt.Errorf("访问被拒绝: %s", s.Denied)
+	}
+	if len(s.Clusters) > 0 && !slices.Contains(s.Clusters, cluster) {
+		return fmt.Errorf("访问范围不包含集群[%s]", cluster)
+	}
+	if s.ReadOnly && IsWriteAction(action) {
+		return fmt.Errorf("访问范围为只读，禁止执行[%s]操作", action)
+	}
+	if len(s.Namespaces) > 0 {
+		if len(nsList) == 0 {
+			if IsWriteAction(action) {
+				return fmt.Errorf("访问范围限定了命名空间[%s]，禁止执行集群级[%s]操作", strings.Join(s.Namespaces, ","), action)
+			}
+			return nil
+		}
+		if !utils.AllIn(nsList, s.Namespaces) {
+			return fmt.Errorf("访问范围不包含命名空间[%s]", strings.Join(nsList, ","))
+		}
+	}
+	return nil
+}
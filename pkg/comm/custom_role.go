@@ -0,0 +1,87 @@
+package comm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"k8s.io/klog/v2"
+)
+
+// WithPermissionVerb 声明本次操作的业务动词，如 scale、restart、port-forward。
+// 其后 kom 回调中的底层操作（get、patch 等）按该动词匹配自定义角色规则，内置角色仍按底层操作校验
+func WithPermissionVerb(ctx context.Context, verb string) context.Context {
+	return context.WithValue(ctx, constants.PermissionVerb, verb)
+}
+
+// permissionVerb 获取用于匹配自定义角色规则的动词，优先使用 context 中声明的业务动词
+func permissionVerb(ctx context.Context, action string) string {
+	if verb, ok := ctx.Value(constants.PermissionVerb).(string); ok && verb != "" {
+		return verb
+	}
+	if action == "describe" {
+		return models.VerbGet
+	}
+	return action
+}
+
+// isReadVerb 只读类动词，命名空间受限的授权仍允许跨命名空间读取，与内置角色保持一致
+func isReadVerb(verb string) bool {
+	return verb == models.VerbGet || verb == models.VerbList || verb == models.VerbLogs
+}
+
+// checkCustomRoles 按用户在集群上的自定义角色授权校验操作。
+// 返回 error 表示命中拒绝规则；返回 true 表示命中允许规则且命名空间在授权范围内
+func checkCustomRoles(username, cluster string, clusterUserRoles []*models.ClusterUserRole, nsList []string, name, verb, group, kind string) (bool, error) {
+	grants := slice.Filter(clusterUserRoles, func(index int, item *models.ClusterUserRole) bool {
+		return item.Cluster == cluster && item.Role == constants.RoleClusterCustom && item.CustomRole != ""
+	})
+	allowed := false
+	for _, grant := range grants {
+		role, err := service.UserService().GetCustomRole(grant.CustomRole)
+		if err != nil {
+			klog.V(6).Infof("用户[%s]集群[%s]自定义角色读取失败: %v", username, cluster, err)
+			continue
+		}
+		for _, rule := range role.Rules {
+			if !rule.Match(verb, group, kind, name) {
+				continue
+			}
+			if rule.IsDeny() {
+				if customGrantCoversNamespaces(grant, nsList) {
+					return false, fmt.Errorf("用户[%s]在集群[%s]的自定义角色[%s]禁止对[%s]执行[%s]操作", username, cluster, role.Name, kind, verb)
+				}
+				continue
+			}
+			if customGrantAllowsNamespaces(grant, nsList, verb) {
+				allowed = true
+			}
+		}
+	}
+	return allowed, nil
+}
+
+// customGrantCoversNamespaces 拒绝规则的作用范围：授权未限定命名空间，或操作涉及授权内的命名空间
+func customGrantCoversNamespaces(grant *models.ClusterUserRole, nsList []string) bool {
+	if len(nsList) == 0 || grant.Namespaces == "" {
+		return true
+	}
+	return utils.AnyIn(nsList, strings.Split(grant.Namespaces, ","))
+}
+
+// customGrantAllowsNamespaces 允许规则的作用范围，命名空间白名单、黑名单与内置角色一致。
+// 未指定命名空间的变更操作在命名空间受限时一律拒绝，避免越权修改集群级资源
+func customGrantAllowsNamespaces(grant *models.ClusterUserRole, nsList []string, verb string) bool {
+	if len(nsList) == 0 {
+		return grant.Namespaces == "" || isReadVerb(verb)
+	}
+	if grant.BlacklistNamespaces != "" && utils.AnyIn(nsList, strings.Split(grant.BlacklistNamespaces, ",")) {
+		return false
+	}
+	return grant.Namespaces == "" || utils.AllIn(nsList, strings.Split(grant.Namespaces, ","))
+}
@@ -16,6 +16,12 @@ import (
 // CheckPermissionLogic
 // return err
 func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string, ns, name, action string) error {
+	return CheckResourcePermission(ctx, cluster, nsList, ns, name, action, "", "")
+}
+
+// CheckResourcePermission 校验用户对指定资源的操作权限，group、kind 用于匹配自定义角色规则。
+// 自定义角色的拒绝规则优先于一切授权；命中允许规则时直接放行，否则继续按内置角色校验。
+func CheckResourcePermission(ctx context.Context, cluster string, nsList []string, ns, name, action, group, kind string) error {
 
 	// 内部监听增加一个认证机制，不用做权限校验
 	// 比如node watch
@@ -23,6 +29,11 @@ func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string,
 		return nil
 	}
 
+	// 访问范围（如受限的 MCP Key）只缩小权限，对平台管理员同样生效
+	if err := GetAccessScope(ctx).Check(cluster, nsList, action); err != nil {
+		return err
+	}
+
 	username := fmt.Sprintf("%s", ctx.Value(constants.JwtUserName))
 
 	if username == "" {
@@ -51,6 +62,16 @@ func CheckPermissionLogic(ctx context.Context, cluster string, nsList []string,
 		return fmt.Errorf("用户[%s]没有集群[%s]访问权限", username, cluster)
 	}
 
+	// 自定义角色按资源类型、动词校验，拒绝规则直接阻止，允许规则直接放行
+	verb := permissionVerb(ctx, action)
+	if allowed, err := checkCustomRoles(username, cluster, clusterUserRoles, nsList, name, verb, group, kind); err != nil {
+		return err
+	} else if allowed {
+		klog.V(6).Infof("cb: cluster= %s,user= %s,  operation=%s, verb=%s, resource=[%s/%s/%s/%s] 自定义角色放行",
+			cluster, username, action, verb, group, kind, ns, name)
+		return nil
+	}
+
 	// 下面都是有集群的访问权限的情况，需要进一步区分是什么类型的操作。
 	// 以及是否有namespace的权限
 
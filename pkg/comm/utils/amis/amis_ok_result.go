@@ -1,6 +1,7 @@
 package amis
 
 import (
+	"github.com/weibaohui/k8m/pkg/comm/utils/impersonate"
 	"github.com/weibaohui/k8m/pkg/response"
 )
 
@@ -17,9 +18,14 @@ func WriteJsonOKMsg(c *response.Context, msg string) {
 	})
 }
 func WriteJsonError(c *response.Context, err error) {
+	msg := err.Error()
+	// 集群 RBAC 拒绝访问时给出明确提示，常见于开启了用户模拟模式的集群
+	if forbidden, ok := impersonate.ForbiddenMessage(err); ok {
+		msg = forbidden
+	}
 	c.JSON(200, response.H{
 		"status": 1,
-		"msg":    err.Error(),
+		"msg":    msg,
 	})
 }
 func WriteJsonErrorOrOK(c *response.Context, err error) {
@@ -27,6 +27,12 @@ func GetLoginUser(c *response.Context) string {
 	return user
 }
 
+// GetLoginSessionID 获取当前登录会话ID，API 令牌登录时为空
+func GetLoginSessionID(c *response.Context) string {
+	sid, _ := c.Request.Context().Value(constants.JwtSessionID).(string)
+	return sid
+}
+
 func GetContextWithUser(c *response.Context) context.Context {
 	user := GetLoginUser(c)
 	ctx := context.WithValue(c.Request.Context(), constants.JwtUserName, user)
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testEvent(action string) *Event {
+	return &Event{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), Type: EventOperation, Action: action,
+		User: "alice", Cluster: "dev", Kind: "Pod", Name: "nginx]", Result: ResultSuccess}
+}
+
+func TestSpoolReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenSpool(dir, 1)
+	if err != nil {
+		t.Fatalf("打开队列失败: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := s.Append([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+	records, pos, err := s.Read(2)
+	if err != nil || len(records) != 2 || string(records[0]) != "0" {
+		t.Fatalf("读取结果错误: %q %v", records, err)
+	}
+	// 未提交时重复读取到相同记录
+	if again, _, _ := s.Read(2); string(again[0]) != "0" {
+		t.Errorf("未提交的记录应重复读取")
+	}
+	if err := s.Commit(pos); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	_ = s.Close()
+
+	s, err = OpenSpool(dir, 1)
+	if err != nil {
+		t.Fatalf("重新打开队列失败: %v", err)
+	}
+	defer s.Close()
+	_ = s.Append([]byte("3"))
+	records, _, _ = s.Read(10)
+	if len(records) != 1 || string(records[0]) != "2" {
+		t.Fatalf("重启后应从提交位置继续读取: %q", records)
+	}
+	_, pos, _ = s.Read(10)
+	_ = s.Commit(pos)
+	records, _, _ = s.Read(10)
+	if len(records) != 1 || string(records[0]) != "3" {
+		t.Fatalf("应继续读取新分段: %q", records)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		size, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(size))
+		buf := make([]byte, n)
+		_, _ = io.ReadFull(r, buf)
+		got <- string(buf)
+	}()
+
+	sink, err := NewSyslogSink(SyslogConfig{Address: ln.Addr().String(), Protocol: "tcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write([]*Event{testEvent("delete")}); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	msg := <-got
+	if !strings.HasPrefix(msg, "<109>1 2025-01-02T03:04:05.000000Z ") {
+		t.Errorf("消息头错误: %s", msg)
+	}
+	if !strings.Contains(msg, " k8m ") || !strings.Contains(msg, ` operation [k8m@32473 user="alice"`) || !strings.Contains(msg, `name="nginx\]"]`) {
+		t.Errorf("结构化数据错误: %s", msg)
+	}
+}
+
+func TestFileRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, _ := NewFileSink(FileConfig{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	defer sink.Close()
+	big := testEvent("create")
+	big.Detail = strings.Repeat("x", 600<<10)
+	for i := 0; i < 4; i++ {
+		if err := sink.Write([]*Event{big}); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("缺少文件 %s", name)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("超出保留数量的文件应被删除")
+	}
+}
+
+func TestForwarderRetry(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 || r.Header.Get("Authorization") != "Bearer t" || r.URL.Path != "/v1/logs" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []struct {
+						Body struct {
+							StringValue string `json:"stringValue"`
+						} `json:"body"`
+					} `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, rec := range body.ResourceLogs[0].ScopeLogs[0].LogRecords {
+			var e Event
+			_ = json.Unmarshal([]byte(rec.Body.StringValue), &e)
+			received = append(received, e.Action)
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := NewOTLPSink(OTLPConfig{Endpoint: srv.URL, Headers: ParseHeaders("Authorization: Bearer t")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool, _ := OpenSpool(t.TempDir(), 0)
+	for _, action := range []string{"create", "delete"} {
+		data, _ := json.Marshal(testEvent(action))
+		_ = spool.Append(data)
+	}
+	f := NewForwarder(sink, spool)
+	f.Start()
+	defer f.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if f.Status().Sent == 2 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	status := f.Status()
+	mu.Lock()
+	defer mu.Unlock()
+	if status.Sent != 2 || status.Pending != 0 || strings.Join(received, ",") != "create,delete" {
+		t.Fatalf("重试后应按序送达全部事件: %+v %v", status, received)
+	}
+	if status.LastError == "" {
+		t.Errorf("应记录首次发送失败的原因")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f := NewFilter("operation, login", "delete")
+	if !f.Match(testEvent("delete")) || f.Match(testEvent("create")) {
+		t.Errorf("按操作类型过滤错误")
+	}
+	e := testEvent("delete")
+	e.Type = EventShell
+	if f.Match(e) || !NewFilter("", "").Match(e) {
+		t.Errorf("按事件类型过滤错误")
+	}
+	if _, err := NewSyslogSink(SyslogConfig{Address: "bad"}); err == nil {
+		t.Errorf("地址格式错误时应失败")
+	}
+	if _, err := NewOTLPSink(OTLPConfig{Endpoint: "grpc://x"}); err == nil {
+		t.Errorf("不支持的协议应失败")
+	}
+}
+
+func TestForwarderHandover(t *testing.T) {
+	spool, _ := OpenSpool(t.TempDir(), 0)
+	defer spool.Close()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, _ := NewFileSink(FileConfig{Path: path})
+	f := NewForwarder(sink, spool)
+	f.Start()
+	f.Stop()
+
+	// 转发器停止后队列仍可写入，由新的转发器继续发送
+	data, _ := json.Marshal(testEvent("create"))
+	if err := spool.Append(data); err != nil {
+		t.Fatalf("转发器停止后写入队列失败: %v", err)
+	}
+	sink, _ = NewFileSink(FileConfig{Path: path})
+	f = NewForwarder(sink, spool)
+	f.Start()
+	defer f.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && f.Status().Sent == 0 {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if status := f.Status(); status.Sent != 1 || status.Pending != 0 {
+		t.Fatalf("新的转发器应发送交接后写入的事件: %+v", status)
+	}
+}
@@ -0,0 +1,78 @@
+// Package audit 将审计事件实时转发到外部系统（syslog、JSON Lines 文件、OpenTelemetry），
+// 每个目标使用独立的本地落盘队列，目标不可用时事件暂存在本地，恢复后按顺序补发
+package audit
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// 事件类型
+const (
+	EventOperation        = "operation"         // 资源操作
+	EventShell            = "shell"             // 容器、节点终端命令
+	EventLogin            = "login"             // 登录
+	EventPermissionDenied = "permission_denied" // 权限拒绝
+	EventMCPTool          = "mcp_tool"          // MCP 工具调用
+)
+
+// EventTypes 全部事件类型
+var EventTypes = []string{EventOperation, EventShell, EventLogin, EventPermissionDenied, EventMCPTool}
+
+// 事件结果
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultDenied  = "denied"
+)
+
+// Event 审计事件
+type Event struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`   // 事件类型
+	Action    string    `json:"action"` // 操作类型，如 delete、exec、password、工具名称
+	User      string    `json:"user,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Group     string    `json:"group,omitempty"`
+	Kind      string    `json:"kind,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Result    string    `json:"result"`            // success/failure/denied
+	Message   string    `json:"message,omitempty"` // 失败原因
+	Detail    string    `json:"detail,omitempty"`  // 操作参数、执行的命令等
+}
+
+// Filter 按事件类型、操作类型过滤事件，为空表示不限制
+type Filter struct {
+	Types   []string
+	Actions []string
+}
+
+// NewFilter 由逗号分隔的事件类型、操作类型创建过滤器
+func NewFilter(types, actions string) Filter {
+	return Filter{Types: splitList(types), Actions: splitList(actions)}
+}
+
+// Match 判断事件是否需要发送
+func (f Filter) Match(e *Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if len(f.Actions) > 0 && !slices.Contains(f.Actions, e.Action) {
+		return false
+	}
+	return true
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
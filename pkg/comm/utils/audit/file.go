@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 未配置时使用的文件轮转参数
+const (
+	DefaultFileMaxSizeMB  = 100
+	DefaultFileMaxBackups = 10
+)
+
+// FileConfig JSON Lines 文件目标配置
+type FileConfig struct {
+	Path       string
+	MaxSizeMB  int // 单个文件大小上限，超过后轮转
+	MaxBackups int // 保留的历史文件数，path.1 为最新
+}
+
+type fileSink struct {
+	cfg  FileConfig
+	file *os.File
+	size int64
+}
+
+// NewFileSink 创建按大小轮转的 JSON Lines 文件目标，每行一条事件
+func NewFileSink(cfg FileConfig) (Sink, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("请填写文件路径")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = DefaultFileMaxSizeMB
+	}
+	if cfg.MaxBackups <= 0 {
+		cfg.MaxBackups = DefaultFileMaxBackups
+	}
+	return &fileSink{cfg: cfg}, nil
+}
+
+func (s *fileSink) Write(events []*Event) error {
+	var data []byte
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	if s.size > 0 && s.size+int64(len(data)) > int64(s.cfg.MaxSizeMB)<<20 {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("写入审计文件失败: %w", err)
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSink) open() error {
+	if s.file != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cfg.Path), 0o750); err != nil {
+		return fmt.Errorf("创建审计文件目录失败: %w", err)
+	}
+	f, err := os.OpenFile(s.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("打开审计文件失败: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+// rotate 依次将 path.N-1 重命名为 path.N，当前文件重命名为 path.1，超出保留数量的文件被覆盖
+func (s *fileSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	for i := s.cfg.MaxBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", s.cfg.Path, i), fmt.Sprintf("%s.%d", s.cfg.Path, i+1))
+	}
+	if err := os.Rename(s.cfg.Path, s.cfg.Path+".1"); err != nil {
+		return fmt.Errorf("轮转审计文件失败: %w", err)
+	}
+	return s.open()
+}
+
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
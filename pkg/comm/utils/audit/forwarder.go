@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	forwardBatchSize  = 100
+	forwardIdleWait   = 5 * time.Second
+	forwardMinBackoff = time.Second
+	forwardMaxBackoff = time.Minute
+)
+
+// Status 目标的发送状态
+type Status struct {
+	Pending     int64     `json:"pending"` // 队列中未发送的数据量（字节）
+	Sent        int64     `json:"sent"`    // 本次启动后发送成功的事件数
+	LastSentAt  time.Time `json:"last_sent_at"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at"`
+}
+
+// Forwarder 从队列中按序读取事件发送到目标，失败时按指数退避重试，成功后才提交读取位置
+type Forwarder struct {
+	sink  Sink
+	spool *Spool
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewForwarder 创建转发器，调用 Start 后开始发送
+func NewForwarder(sink Sink, spool *Spool) *Forwarder {
+	return &Forwarder{sink: sink, spool: spool, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// Spool 转发器使用的队列
+func (f *Forwarder) Spool() *Spool {
+	return f.spool
+}
+
+// Start 启动发送协程
+func (f *Forwarder) Start() {
+	go f.run()
+}
+
+// Stop 停止发送并关闭目标，正在发送的批次会等待其完成。队列由调用方关闭，可交给新的转发器继续发送
+func (f *Forwarder) Stop() {
+	close(f.stop)
+	<-f.done
+	_ = f.sink.Close()
+}
+
+// Status 发送状态
+func (f *Forwarder) Status() Status {
+	f.mu.Lock()
+	status := f.status
+	f.mu.Unlock()
+	status.Pending = f.spool.Pending()
+	return status
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+	backoff := forwardMinBackoff
+	for {
+		// 写入队列时不逐条刷盘，每批发送前统一刷盘
+		if err := f.spool.Sync(); err != nil {
+			klog.V(4).Infof("审计队列 %s 刷盘失败: %v", f.spool.dir, err)
+		}
+		records, pos, err := f.spool.Read(forwardBatchSize)
+		if err == nil && len(records) == 0 {
+			select {
+			case <-f.stop:
+				return
+			case <-f.spool.Notify():
+			case <-time.After(forwardIdleWait):
+			}
+			continue
+		}
+		if err == nil {
+			err = f.send(records)
+		}
+		if err == nil {
+			err = f.spool.Commit(pos)
+		}
+		if err != nil {
+			f.fail(err)
+			select {
+			case <-f.stop:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, forwardMaxBackoff)
+			continue
+		}
+		backoff = forwardMinBackoff
+	}
+}
+
+func (f *Forwarder) send(records [][]byte) error {
+	events := make([]*Event, 0, len(records))
+	for _, record := range records {
+		var e Event
+		if err := json.Unmarshal(record, &e); err != nil {
+			klog.Errorf("审计队列 %s 中的事件格式错误，已跳过: %v", f.spool.dir, err)
+			continue
+		}
+		events = append(events, &e)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if err := f.sink.Write(events); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.status.Sent += int64(len(events))
+	f.status.LastSentAt = time.Now()
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *Forwarder) fail(err error) {
+	klog.V(4).Infof("审计队列 %s 发送失败，稍后重试: %v", f.spool.dir, err)
+	f.mu.Lock()
+	f.status.LastError = err.Error()
+	f.status.LastErrorAt = time.Now()
+	f.mu.Unlock()
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const otlpTimeout = 15 * time.Second
+
+// OTLPConfig OpenTelemetry 日志目标配置，使用 OTLP/HTTP JSON 协议
+type OTLPConfig struct {
+	Endpoint           string            // Collector 地址，如 http://otel-collector:4318，未以 /v1/logs 结尾时自动追加
+	Headers            map[string]string // 附加请求头，如认证信息
+	CACert             string
+	InsecureSkipVerify bool
+}
+
+type otlpSink struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewOTLPSink 创建 OTLP/HTTP 日志目标
+func NewOTLPSink(cfg OTLPConfig) (Sink, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("OTLP 地址格式错误，应为 http(s)://host:port")
+	}
+	if !strings.HasSuffix(u.Path, "/v1/logs") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/logs"
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if u.Scheme == "https" {
+		if transport.TLSClientConfig, err = tlsConfig(u.Hostname(), cfg.CACert, cfg.InsecureSkipVerify); err != nil {
+			return nil, err
+		}
+	}
+	return &otlpSink{
+		endpoint: u.String(),
+		headers:  cfg.Headers,
+		client:   &http.Client{Timeout: otlpTimeout, Transport: transport},
+	}, nil
+}
+
+// ParseHeaders 解析每行一个的 Key: Value 请求头
+func ParseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			headers[k] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
+
+func (s *otlpSink) Write(events []*Event) error {
+	body, err := json.Marshal(otlpRequest(events))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送 OTLP 日志失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("发送 OTLP 日志失败: HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *otlpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string          `json:"timeUnixNano"`
+	ObservedTimeUnixNano string          `json:"observedTimeUnixNano"`
+	SeverityNumber       int             `json:"severityNumber"`
+	SeverityText         string          `json:"severityText"`
+	Body                 map[string]any  `json:"body"`
+	Attributes           []otlpAttribute `json:"attributes"`
+}
+
+// otlpRequest 生成 ExportLogsServiceRequest，事件字段同时写入 k8m.audit.* 属性便于检索
+func otlpRequest(events []*Event) map[string]any {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]otlpLogRecord, 0, len(events))
+	for _, e := range events {
+		body, _ := json.Marshal(e)
+		severity, text := 9, "INFO"
+		if e.Result != ResultSuccess {
+			severity, text = 13, "WARN"
+		}
+		record := otlpLogRecord{
+			TimeUnixNano:         strconv.FormatInt(e.Time.UnixNano(), 10),
+			ObservedTimeUnixNano: now,
+			SeverityNumber:       severity,
+			SeverityText:         text,
+			Body:                 map[string]any{"stringValue": string(body)},
+		}
+		for _, p := range [][2]string{
+			{"type", e.Type}, {"action", e.Action}, {"user", e.User}, {"role", e.Role}, {"client_ip", e.ClientIP},
+			{"cluster", e.Cluster}, {"namespace", e.Namespace}, {"group", e.Group}, {"kind", e.Kind}, {"name", e.Name},
+			{"result", e.Result}, {"message", e.Message},
+		} {
+			if p[1] != "" {
+				record.Attributes = append(record.Attributes, otlpAttribute{Key: "k8m.audit." + p[0], Value: map[string]any{"stringValue": p[1]}})
+			}
+		}
+		records = append(records, record)
+	}
+	return map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpAttribute{
+				{Key: "service.name", Value: map[string]any{"stringValue": "k8m"}},
+			}},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]any{"name": "k8m.audit"},
+				"logRecords": records,
+			}},
+		}},
+	}
+}
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// 目标类型
+const (
+	SinkSyslog = "syslog"
+	SinkFile   = "file"
+	SinkOTLP   = "otlp"
+)
+
+// Sink 审计事件发送目标。Write 返回错误时整批事件保留在队列中稍后重试，
+// 因此目标可能收到重复事件，需按至少一次投递处理
+type Sink interface {
+	Write(events []*Event) error
+	Close() error
+}
+
+// tlsConfig 生成 TLS 配置，caCert 为 PEM 格式的自定义 CA 证书
+func tlsConfig(serverName, caCert string, insecureSkipVerify bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: insecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, errors.New("CA 证书格式错误")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
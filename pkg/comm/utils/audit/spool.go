@@ -0,0 +1,289 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// DefaultSpoolMaxMB 未配置时本地队列的容量上限
+const DefaultSpoolMaxMB = 256
+
+const (
+	spoolSegmentSize = 4 << 20 // 单个分段文件大小
+	spoolSegmentExt  = ".seg"
+	spoolOffsetFile  = "offset"
+)
+
+// Position 队列读取位置
+type Position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// Spool 落盘队列。记录以行为单位追加到分段文件，发送成功后提交读取位置，
+// 进程重启后从上次提交的位置继续发送。超过容量上限时丢弃最旧的分段。
+// 写入时不逐条刷盘，由转发协程调用 Sync 批量刷盘
+type Spool struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	segments []uint64 // 按序排列，最后一个为当前写入分段
+	sizes    map[uint64]int64
+	w        *os.File
+	dirty    bool // 写入分段有未刷盘的数据
+	read     Position
+	notify   chan struct{}
+}
+
+// OpenSpool 打开或创建队列目录，maxMB 为容量上限
+func OpenSpool(dir string, maxMB int) (*Spool, error) {
+	if maxMB <= 0 {
+		maxMB = DefaultSpoolMaxMB
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("创建审计队列目录失败: %w", err)
+	}
+	s := &Spool{dir: dir, maxBytes: int64(maxMB) << 20, sizes: map[uint64]int64{}, notify: make(chan struct{}, 1)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(entry.Name(), spoolSegmentExt), 10, 64)
+		if err != nil || !strings.HasSuffix(entry.Name(), spoolSegmentExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		s.segments = append(s.segments, seq)
+		s.sizes[seq] = info.Size()
+	}
+	slices.Sort(s.segments)
+
+	if data, err := os.ReadFile(filepath.Join(dir, spoolOffsetFile)); err == nil {
+		_ = json.Unmarshal(data, &s.read)
+	}
+	// 上次退出时写入分段的末尾可能不完整，总是从新分段开始写入
+	if err := s.newSegment(); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(s.segments, s.read.Segment) {
+		s.read = Position{Segment: s.segments[0]}
+	}
+	// 删除已发送完但未来得及清理的分段
+	for s.segments[0] < s.read.Segment {
+		s.removeSegment(s.segments[0])
+	}
+	return s, nil
+}
+
+// Append 追加一条记录
+func (s *Spool) Append(record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return errors.New("审计队列已关闭")
+	}
+	last := s.segments[len(s.segments)-1]
+	if s.sizes[last] >= spoolSegmentSize {
+		if err := s.newSegment(); err != nil {
+			return err
+		}
+		last = s.segments[len(s.segments)-1]
+	}
+	for s.total()+int64(len(record)) > s.maxBytes && len(s.segments) > 1 {
+		dropped := s.segments[0]
+		klog.Warningf("审计队列 %s 超过容量上限，丢弃最旧的未发送数据 %d 字节", s.dir, s.sizes[dropped])
+		s.removeSegment(dropped)
+	}
+	line := make([]byte, 0, len(record)+1)
+	n, err := s.w.Write(append(append(line, record...), '\n'))
+	s.sizes[last] += int64(n)
+	s.dirty = true
+	if err != nil {
+		return fmt.Errorf("写入审计队列失败: %w", err)
+	}
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Sync 将已写入的记录刷到磁盘，刷盘期间不阻塞写入
+func (s *Spool) Sync() error {
+	s.mu.Lock()
+	w, dirty := s.w, s.dirty
+	s.dirty = false
+	s.mu.Unlock()
+	if w == nil || !dirty {
+		return nil
+	}
+	// 期间切换分段或关闭队列时，旧文件在关闭前已刷盘
+	if err := w.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// SetMaxMB 调整容量上限，下次写入时生效
+func (s *Spool) SetMaxMB(maxMB int) {
+	if maxMB <= 0 {
+		maxMB = DefaultSpoolMaxMB
+	}
+	s.mu.Lock()
+	s.maxBytes = int64(maxMB) << 20
+	s.mu.Unlock()
+}
+
+// Read 从当前读取位置读取最多 max 条记录，返回读取后的位置，发送成功后调用 Commit 提交
+func (s *Spool) Read(max int) ([][]byte, Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		last := s.read.Segment == s.segments[len(s.segments)-1]
+		records, pos, err := s.readSegment(max)
+		if os.IsNotExist(err) && !last {
+			err = nil
+		}
+		if err != nil || len(records) > 0 || last {
+			return records, pos, err
+		}
+		// 非写入分段已读完或已不存在，删除后继续读取下一分段
+		s.removeSegment(s.read.Segment)
+		if err := s.saveOffset(); err != nil {
+			return nil, s.read, err
+		}
+	}
+}
+
+func (s *Spool) readSegment(max int) ([][]byte, Position, error) {
+	pos := s.read
+	f, err := os.Open(s.segmentPath(pos.Segment))
+	if err != nil {
+		return nil, pos, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(pos.Offset, io.SeekStart); err != nil {
+		return nil, pos, err
+	}
+	r := bufio.NewReader(f)
+	var records [][]byte
+	for len(records) < max {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// 末尾不完整的行可能仍在写入，留待下次读取
+			break
+		}
+		pos.Offset += int64(len(line))
+		if len(line) > 1 {
+			records = append(records, line[:len(line)-1])
+		}
+	}
+	return records, pos, nil
+}
+
+// Commit 提交读取位置，之前的记录不再发送
+func (s *Spool) Commit(pos Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pos.Segment != s.read.Segment || pos.Offset <= s.read.Offset {
+		return nil
+	}
+	s.read = pos
+	return s.saveOffset()
+}
+
+// Pending 未发送的数据量（字节）
+func (s *Spool) Pending() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total() - s.read.Offset
+}
+
+// Notify 有新记录写入时收到通知
+func (s *Spool) Notify() <-chan struct{} {
+	return s.notify
+}
+
+// Close 关闭队列，未发送的记录保留在磁盘上
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return nil
+	}
+	err := errors.Join(s.w.Sync(), s.w.Close())
+	s.w = nil
+	return err
+}
+
+func (s *Spool) newSegment() error {
+	var seq uint64 = 1
+	if len(s.segments) > 0 {
+		seq = s.segments[len(s.segments)-1] + 1
+	}
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("创建审计队列文件失败: %w", err)
+	}
+	if s.w != nil {
+		_ = s.w.Sync()
+		_ = s.w.Close()
+	}
+	s.w = f
+	s.dirty = false
+	s.segments = append(s.segments, seq)
+	s.sizes[seq] = 0
+	return nil
+}
+
+// removeSegment 删除分段，读取位置位于该分段时移到下一分段开头
+func (s *Spool) removeSegment(seq uint64) {
+	if err := os.Remove(s.segmentPath(seq)); err != nil && !os.IsNotExist(err) {
+		klog.Errorf("删除审计队列文件失败: %v", err)
+	}
+	i := slices.Index(s.segments, seq)
+	s.segments = slices.Delete(s.segments, i, i+1)
+	delete(s.sizes, seq)
+	if s.read.Segment == seq {
+		s.read = Position{Segment: s.segments[i]}
+	}
+}
+
+func (s *Spool) saveOffset() error {
+	data, _ := json.Marshal(s.read)
+	tmp := filepath.Join(s.dir, spoolOffsetFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, spoolOffsetFile))
+}
+
+// total 从读取分段开始的数据总量
+func (s *Spool) total() int64 {
+	var total int64
+	for _, seq := range s.segments {
+		if seq >= s.read.Segment {
+			total += s.sizes[seq]
+		}
+	}
+	return total
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSegmentExt))
+}
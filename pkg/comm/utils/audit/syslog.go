@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 未配置时使用的 syslog 参数
+const (
+	DefaultSyslogFacility = 13 // log audit
+	DefaultSyslogAppName  = "k8m"
+)
+
+// syslogSDID 结构化数据ID，32473 为 RFC 5612 中用于示例与文档的企业号
+const syslogSDID = "k8m@32473"
+
+const syslogTimeout = 10 * time.Second
+
+// SyslogConfig syslog 目标配置
+type SyslogConfig struct {
+	Address            string // host:port
+	Protocol           string // udp/tcp/tls
+	Facility           int
+	AppName            string
+	CACert             string // TLS 自定义 CA 证书
+	InsecureSkipVerify bool
+}
+
+type syslogSink struct {
+	cfg      SyslogConfig
+	hostname string
+	tls      *tls.Config
+	conn     net.Conn
+}
+
+// NewSyslogSink 创建 RFC 5424 syslog 目标。UDP 每条事件一个报文，TCP、TLS 按 RFC 6587 使用八位组计数分帧
+func NewSyslogSink(cfg SyslogConfig) (Sink, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("syslog 地址格式错误，应为 host:port: %w", err)
+	}
+	switch cfg.Protocol {
+	case "":
+		cfg.Protocol = "udp"
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("不支持的 syslog 协议: %s", cfg.Protocol)
+	}
+	if cfg.Facility <= 0 || cfg.Facility > 23 {
+		cfg.Facility = DefaultSyslogFacility
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = DefaultSyslogAppName
+	}
+	s := &syslogSink{cfg: cfg, hostname: "-"}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		s.hostname = name
+	}
+	if cfg.Protocol == "tls" {
+		if s.tls, err = tlsConfig(host, cfg.CACert, cfg.InsecureSkipVerify); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *syslogSink) Write(events []*Event) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+	for _, e := range events {
+		msg, err := s.format(e)
+		if err != nil {
+			return err
+		}
+		if s.cfg.Protocol != "udp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			// 连接可能已断开，下次重新建立
+			_ = s.Close()
+			return fmt.Errorf("发送 syslog 失败: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	var err error
+	if s.cfg.Protocol == "tls" {
+		s.conn, err = tls.DialWithDialer(dialer, "tcp", s.cfg.Address, s.tls)
+	} else {
+		s.conn, err = dialer.Dial(s.cfg.Protocol, s.cfg.Address)
+	}
+	if err != nil {
+		s.conn = nil
+		return fmt.Errorf("连接 syslog 服务器失败: %w", err)
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format 生成 RFC 5424 消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG，MSG 为事件 JSON
+func (s *syslogSink) format(e *Event) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	severity := 5 // notice
+	if e.Result != ResultSuccess {
+		severity = 4 // warning
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s [%s", s.cfg.Facility*8+severity,
+		e.Time.Format("2006-01-02T15:04:05.000000Z07:00"), s.hostname, s.cfg.AppName, os.Getpid(), e.Type, syslogSDID)
+	for _, p := range [][2]string{
+		{"user", e.User}, {"client_ip", e.ClientIP}, {"action", e.Action}, {"result", e.Result},
+		{"cluster", e.Cluster}, {"namespace", e.Namespace}, {"kind", e.Kind}, {"name", e.Name},
+	} {
+		if p[1] != "" {
+			fmt.Fprintf(&b, " %s=\"%s\"", p[0], escapeSDParam(p[1]))
+		}
+	}
+	b.WriteString("] ")
+	b.Write(body)
+	return []byte(b.String()), nil
+}
+
+// escapeSDParam 转义结构化数据参数值中的 "、\、]
+func escapeSDParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
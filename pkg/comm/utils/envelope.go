@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// secretKeySize 信封加密使用 AES-256
+const secretKeySize = 32
+
+// NewSecretKey 生成随机的 AES-256 密钥
+func NewSecretKey() ([]byte, error) {
+	key := make([]byte, secretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeriveSecretKey 由配置的密钥材料得到 AES-256 密钥。
+// 材料为 base64 编码的 32 字节时直接使用，否则取其 SHA-256 摘要，便于直接配置口令
+func DeriveSecretKey(material string) []byte {
+	material = strings.TrimSpace(material)
+	if raw, err := base64.StdEncoding.DecodeString(material); err == nil && len(raw) == secretKeySize {
+		return raw
+	}
+	sum := sha256.Sum256([]byte(material))
+	return sum[:]
+}
+
+// SecretKeyID 密钥指纹，用于标识数据密钥由哪个主密钥加密，不泄露密钥本身
+func SecretKeyID(key []byte) string {
+	sum := sha256.Sum256(append([]byte("k8m-secret-key:"), key...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// GCMEncrypt 使用 AES-GCM 加密，输出为 nonce||密文
+func GCMEncrypt(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// GCMDecrypt 解密 GCMEncrypt 的输出
+func GCMDecrypt(key []byte, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("密文长度错误")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
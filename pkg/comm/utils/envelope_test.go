@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestGCMEncryptDecrypt(t *testing.T) {
+	key, err := NewSecretKey()
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	plaintext := []byte("apiVersion: v1\nkind: Config\n")
+
+	data, err := GCMEncrypt(key, plaintext)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	got, err := GCMDecrypt(key, data)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("解密结果不一致: %q", got)
+	}
+
+	// 每次加密使用随机 nonce，相同明文的密文不同
+	again, _ := GCMEncrypt(key, plaintext)
+	if bytes.Equal(again, data) {
+		t.Errorf("相同明文两次加密结果相同")
+	}
+
+	other, _ := NewSecretKey()
+	if _, err := GCMDecrypt(other, data); err == nil {
+		t.Errorf("使用错误的密钥解密应失败")
+	}
+	if _, err := GCMDecrypt(key, data[:8]); err == nil {
+		t.Errorf("密文被截断时解密应失败")
+	}
+}
+
+func TestDeriveSecretKey(t *testing.T) {
+	raw := bytes.Repeat([]byte{7}, 32)
+	if got := DeriveSecretKey(base64.StdEncoding.EncodeToString(raw)); !bytes.Equal(got, raw) {
+		t.Errorf("base64 编码的 32 字节密钥应直接使用")
+	}
+
+	a := DeriveSecretKey("my-passphrase")
+	if len(a) != 32 {
+		t.Fatalf("派生密钥长度错误: %d", len(a))
+	}
+	if !bytes.Equal(a, DeriveSecretKey(" my-passphrase\n")) {
+		t.Errorf("口令首尾空白不应影响派生结果")
+	}
+	if SecretKeyID(a) == SecretKeyID(DeriveSecretKey("other")) {
+		t.Errorf("不同密钥的指纹不应相同")
+	}
+}
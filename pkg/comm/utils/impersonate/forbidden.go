@@ -0,0 +1,32 @@
+package impersonate
+
+import (
+	"errors"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ForbiddenMessage 将 API Server 拒绝访问（403）的错误转为易读的提示，以区分 k8m 自身的权限校验。
+// 不是拒绝访问的错误返回 false
+func ForbiddenMessage(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	msg := err.Error()
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		if status.Status().Reason != metav1.StatusReasonForbidden {
+			return "", false
+		}
+		msg = status.Status().Message
+	} else if !strings.Contains(msg, " is forbidden: ") {
+		// 调用方可能未保留原始错误类型，按 API Server 的错误格式识别
+		return "", false
+	}
+	if strings.Contains(msg, "cannot impersonate") {
+		return "集群凭据缺少模拟用户的权限，请为 k8m 使用的身份授予 users、groups 的 impersonate 权限: " + msg, true
+	}
+	return "Kubernetes RBAC 拒绝访问，请联系集群管理员授权: " + msg, true
+}
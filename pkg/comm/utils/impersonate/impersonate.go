@@ -0,0 +1,147 @@
+// Package impersonate 为访问 Kubernetes API 的请求附加 Impersonate-User/Impersonate-Group 请求头，
+// 使 API Server 按实际操作人执行 RBAC 鉴权并记录审计日志。
+// 集群凭据本身需具备 impersonate 权限
+package impersonate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/client-go/transport"
+)
+
+// reservedPrefix Kubernetes 内置身份的前缀，禁止模拟，避免同名用户或用户组借此提权
+const reservedPrefix = "system:"
+
+// Identity 要模拟的 Kubernetes 身份
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// ErrNoIdentity 请求上下文未携带用户身份
+var ErrNoIdentity = errors.New("未携带用户身份，已阻止访问开启用户模拟模式的集群")
+
+// Resolver 根据请求上下文获取要模拟的身份。返回 nil 时使用集群凭据本身的身份，仅限可信的内部任务；
+// 无法确定身份时返回 ErrNoIdentity，请求被拒绝
+type Resolver func(ctx context.Context) (*Identity, error)
+
+// Wrap 返回用于 rest.Config.Wrap 的包装函数，每个请求按上下文中的用户附加模拟请求头。
+// host 为 rest.Config.Host，用于识别带路径前缀的 API Server 地址下的发现类请求
+func Wrap(host string, resolve Resolver) transport.WrapperFunc {
+	prefix := ""
+	if u, err := url.Parse(host); err == nil {
+		prefix = strings.TrimSuffix(u.Path, "/")
+	}
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{delegate: rt, resolve: resolve, prefix: prefix}
+	}
+}
+
+type roundTripper struct {
+	delegate http.RoundTripper
+	resolve  Resolver
+	prefix   string
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	identity, err := t.resolve(req.Context())
+	if errors.Is(err, ErrNoIdentity) && t.isDiscovery(req) {
+		// kom 注册集群、心跳检测等读取 API 元数据的请求不携带上下文，
+		// 这些接口 Kubernetes 默认对全部已认证用户开放，使用集群凭据本身的身份
+		return t.delegate.RoundTrip(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return t.delegate.RoundTrip(req)
+	}
+	cfg, err := identity.config()
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewImpersonatingRoundTripper(cfg, t.delegate).RoundTrip(req)
+}
+
+func (t *roundTripper) WrappedRoundTripper() http.RoundTripper { return t.delegate }
+
+// isDiscovery 判断是否为只读的发现类请求：/version、/api、/api/{version}、/apis、/apis/{group}、
+// /apis/{group}/{version} 以及 /openapi 下的接口，资源请求一律不算
+func (t *roundTripper) isDiscovery(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	path, ok := strings.CutPrefix(req.URL.Path, t.prefix+"/")
+	if !ok {
+		return false
+	}
+	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	switch parts[0] {
+	case "version":
+		return len(parts) == 1
+	case "api":
+		return len(parts) <= 2
+	case "apis":
+		return len(parts) <= 3
+	case "openapi":
+		return true
+	}
+	return false
+}
+
+// WithContext 返回用于 rest.Config.Wrap 的包装函数，为不向请求传递上下文的客户端（如 Helm SDK）
+// 指定用户身份所在的上下文，需在 Wrap 之后包装。请求自身的取消与超时依然生效
+func WithContext(ctx context.Context) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &contextRoundTripper{delegate: rt, ctx: ctx}
+	}
+}
+
+type contextRoundTripper struct {
+	delegate http.RoundTripper
+	ctx      context.Context
+}
+
+func (t *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.delegate.RoundTrip(req.WithContext(valueContext{Context: req.Context(), values: t.ctx}))
+}
+
+func (t *contextRoundTripper) WrappedRoundTripper() http.RoundTripper { return t.delegate }
+
+// valueContext 取消与超时沿用请求上下文，取值优先使用指定的上下文
+type valueContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valueContext) Value(key any) any {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// config 校验身份并转换为 client-go 的模拟配置，system: 前缀的用户组直接忽略
+func (i *Identity) config() (transport.ImpersonationConfig, error) {
+	user := strings.TrimSpace(i.User)
+	if user == "" {
+		return transport.ImpersonationConfig{}, fmt.Errorf("模拟用户为空，已阻止访问集群")
+	}
+	if strings.HasPrefix(user, reservedPrefix) {
+		return transport.ImpersonationConfig{}, fmt.Errorf("禁止模拟 Kubernetes 内置用户[%s]", user)
+	}
+	cfg := transport.ImpersonationConfig{UserName: user}
+	for _, group := range i.Groups {
+		group = strings.TrimSpace(group)
+		if group == "" || strings.HasPrefix(group, reservedPrefix) {
+			continue
+		}
+		cfg.Groups = append(cfg.Groups, group)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,149 @@
+package impersonate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/transport"
+)
+
+type userKey struct{}
+
+type recorder struct {
+	header http.Header
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.header = req.Header.Clone()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func TestWrap(t *testing.T) {
+	rec := &recorder{}
+	rt := Wrap("https://k8s.example.com/proxy/", func(ctx context.Context) (*Identity, error) {
+		user, _ := ctx.Value(userKey{}).(string)
+		switch user {
+		case "":
+			return nil, ErrNoIdentity
+		case "internal":
+			return nil, nil
+		}
+		return &Identity{User: user, Groups: []string{"dev", "system:masters", " ops "}}, nil
+	})(rec)
+
+	send := func(method, path, user string) error {
+		rec.header = nil
+		req, _ := http.NewRequest(method, "https://k8s.example.com/proxy"+path, nil)
+		if user != "" {
+			req = req.WithContext(context.WithValue(req.Context(), userKey{}, user))
+		}
+		_, err := rt.RoundTrip(req)
+		return err
+	}
+
+	if err := send(http.MethodGet, "/api/v1/pods", "alice"); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := rec.header.Get(transport.ImpersonateUserHeader); got != "alice" {
+		t.Errorf("模拟用户错误: %q", got)
+	}
+	if got := rec.header.Values(transport.ImpersonateGroupHeader); !slices.Equal(got, []string{"dev", "ops"}) {
+		t.Errorf("模拟用户组错误: %v", got)
+	}
+
+	if err := send(http.MethodGet, "/api/v1/pods", "internal"); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := rec.header.Get(transport.ImpersonateUserHeader); got != "" {
+		t.Errorf("内部任务不应模拟用户: %q", got)
+	}
+
+	if err := send(http.MethodGet, "/api/v1/pods", "system:admin"); err == nil {
+		t.Errorf("模拟内置用户应被阻止")
+	}
+
+	// 未携带身份时仅放行发现类请求
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{http.MethodGet, "/version", true},
+		{http.MethodGet, "/api", true},
+		{http.MethodGet, "/api/v1", true},
+		{http.MethodGet, "/apis", true},
+		{http.MethodGet, "/apis/apps/v1", true},
+		{http.MethodGet, "/openapi/v3/apis/apps/v1", true},
+		{http.MethodGet, "/api/v1/secrets", false},
+		{http.MethodGet, "/apis/apps/v1/deployments", false},
+		{http.MethodGet, "/api/v1/namespaces/default/pods/version", false},
+		{http.MethodPost, "/api/v1", false},
+		{http.MethodGet, "/healthz", false},
+	}
+	for _, tt := range tests {
+		err := send(tt.method, tt.path, "")
+		if allowed := err == nil; allowed != tt.want {
+			t.Errorf("%s %s 未携带身份时放行=%v，期望 %v", tt.method, tt.path, allowed, tt.want)
+		}
+		if err != nil && !errors.Is(err, ErrNoIdentity) {
+			t.Errorf("%s %s 错误类型不符: %v", tt.method, tt.path, err)
+		}
+	}
+
+	// 未包含路径前缀的请求不视为发现类请求
+	req, _ := http.NewRequest(http.MethodGet, "https://k8s.example.com/version", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Errorf("路径前缀不符时应被阻止")
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	rec := &recorder{}
+	rt := Wrap("https://k8s.example.com", func(ctx context.Context) (*Identity, error) {
+		user, _ := ctx.Value(userKey{}).(string)
+		if user == "" {
+			return nil, ErrNoIdentity
+		}
+		return &Identity{User: user}, nil
+	})(rec)
+	rt = WithContext(context.WithValue(context.Background(), userKey{}, "alice"))(rt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://k8s.example.com/api/v1/pods", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := rec.header.Get(transport.ImpersonateUserHeader); got != "alice" {
+		t.Errorf("应按指定上下文模拟用户: %q", got)
+	}
+
+	cancel()
+	if err := (valueContext{Context: ctx, values: context.Background()}).Err(); err == nil {
+		t.Errorf("应保留请求上下文的取消状态")
+	}
+}
+
+func TestForbiddenMessage(t *testing.T) {
+	gr := schema.GroupResource{Resource: "pods"}
+	forbidden := apierrors.NewForbidden(gr, "nginx", errors.New(`User "alice" cannot get resource "pods"`))
+	if msg, ok := ForbiddenMessage(fmt.Errorf("获取资源失败: %w", forbidden)); !ok || !strings.Contains(msg, "RBAC") {
+		t.Errorf("应识别为RBAC拒绝: %q", msg)
+	}
+	impersonateErr := apierrors.NewForbidden(schema.GroupResource{Resource: "users"}, "alice", errors.New(`User "k8m" cannot impersonate resource "users"`))
+	if msg, ok := ForbiddenMessage(impersonateErr); !ok || !strings.Contains(msg, "impersonate 权限") {
+		t.Errorf("应识别为缺少模拟权限: %q", msg)
+	}
+	if _, ok := ForbiddenMessage(fmt.Errorf("%v", forbidden)); !ok {
+		t.Errorf("未保留错误类型时应按错误格式识别")
+	}
+	if _, ok := ForbiddenMessage(apierrors.NewNotFound(gr, "nginx")); ok {
+		t.Errorf("资源不存在不应识别为拒绝访问")
+	}
+}
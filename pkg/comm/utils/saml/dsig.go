@@ -0,0 +1,164 @@
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	nsDSig       = "http://www.w3.org/2000/09/xmldsig#"
+	algExcC14N   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+// 支持的摘要算法
+var digestMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2000/09/xmldsig#sha1":        crypto.SHA1,
+	"http://www.w3.org/2001/04/xmlenc#sha256":       crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmlenc#sha512":       crypto.SHA512,
+}
+
+// 支持的签名算法
+var signatureMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2000/09/xmldsig#rsa-sha1":          crypto.SHA1,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256":   crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha384":   crypto.SHA384,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512":   crypto.SHA512,
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512": crypto.SHA512,
+}
+
+// signatureOf 获取元素直接包含的 ds:Signature
+func signatureOf(el *Element) *Element {
+	return el.Child(nsDSig, "Signature")
+}
+
+// verifySignature 校验元素上的 enveloped 签名。
+// 签名只能引用元素自身的 ID，证书只信任 IdP 元数据中的签名证书，忽略 KeyInfo 中携带的证书
+func verifySignature(el *Element, certs []*x509.Certificate) error {
+	sig := signatureOf(el)
+	if sig == nil {
+		return fmt.Errorf("元素 %s 未签名", el.Local)
+	}
+	signedInfo := sig.Child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("签名缺少 SignedInfo")
+	}
+
+	c14nMethod := signedInfo.Child(nsDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.Attr("Algorithm") != algExcC14N {
+		return fmt.Errorf("不支持的规范化算法，仅支持 %s", algExcC14N)
+	}
+	sigMethod := signedInfo.Child(nsDSig, "SignatureMethod")
+	if sigMethod == nil {
+		return fmt.Errorf("签名缺少 SignatureMethod")
+	}
+	sigHash, ok := signatureMethods[sigMethod.Attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("不支持的签名算法: %s", sigMethod.Attr("Algorithm"))
+	}
+
+	refs := signedInfo.ChildElements(nsDSig, "Reference")
+	if len(refs) != 1 {
+		return fmt.Errorf("签名必须且只能包含一个 Reference")
+	}
+	ref := refs[0]
+	id := el.Attr("ID")
+	if id == "" || ref.Attr("URI") != "#"+id {
+		return fmt.Errorf("签名引用 %q 与元素ID %q 不一致", ref.Attr("URI"), id)
+	}
+	var prefixes []string
+	if transforms := ref.Child(nsDSig, "Transforms"); transforms != nil {
+		for _, t := range transforms.ChildElements(nsDSig, "Transform") {
+			switch t.Attr("Algorithm") {
+			case algEnveloped:
+			case algExcC14N:
+				prefixes = inclusivePrefixes(t)
+			default:
+				return fmt.Errorf("不支持的签名转换算法: %s", t.Attr("Algorithm"))
+			}
+		}
+	}
+	digestMethod := ref.Child(nsDSig, "DigestMethod")
+	if digestMethod == nil {
+		return fmt.Errorf("签名缺少 DigestMethod")
+	}
+	digestHash, ok := digestMethods[digestMethod.Attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("不支持的摘要算法: %s", digestMethod.Attr("Algorithm"))
+	}
+	digestValue := ref.Child(nsDSig, "DigestValue")
+	if digestValue == nil {
+		return fmt.Errorf("签名缺少 DigestValue")
+	}
+	expected, err := decodeBase64(digestValue.Text())
+	if err != nil {
+		return fmt.Errorf("DigestValue 格式错误: %w", err)
+	}
+	h := digestHash.New()
+	h.Write(canonicalize(el, sig, prefixes))
+	if !bytes.Equal(h.Sum(nil), expected) {
+		return fmt.Errorf("签名摘要不匹配，断言内容可能被篡改")
+	}
+
+	sigValue := sig.Child(nsDSig, "SignatureValue")
+	if sigValue == nil {
+		return fmt.Errorf("签名缺少 SignatureValue")
+	}
+	signature, err := decodeBase64(sigValue.Text())
+	if err != nil {
+		return fmt.Errorf("SignatureValue 格式错误: %w", err)
+	}
+	h = sigHash.New()
+	h.Write(canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod)))
+	hashed := h.Sum(nil)
+	for _, cert := range certs {
+		if verifyWithKey(cert.PublicKey, sigHash, hashed, signature) {
+			return nil
+		}
+	}
+	return fmt.Errorf("签名校验失败，请确认 IdP 元数据中的签名证书")
+}
+
+func verifyWithKey(pub any, hash crypto.Hash, hashed, signature []byte) bool {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, hash, hashed, signature) == nil
+	case *ecdsa.PublicKey:
+		// XML-DSig 中 ECDSA 签名为 r||s 定长拼接
+		if len(signature)%2 != 0 {
+			return false
+		}
+		half := len(signature) / 2
+		r := new(big.Int).SetBytes(signature[:half])
+		s := new(big.Int).SetBytes(signature[half:])
+		return ecdsa.Verify(key, hashed, r, s)
+	}
+	return false
+}
+
+// inclusivePrefixes 读取 ec:InclusiveNamespaces 的 PrefixList
+func inclusivePrefixes(el *Element) []string {
+	inc := el.Child(algExcC14N, "InclusiveNamespaces")
+	if inc == nil {
+		return nil
+	}
+	return strings.Fields(inc.Attr("PrefixList"))
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,121 @@
+package saml
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// IDPMetadata 从 IdP 元数据中提取的登录所需信息
+type IDPMetadata struct {
+	EntityID     string
+	SSOURL       string // 单点登录地址
+	SSOBinding   string // 单点登录地址使用的绑定方式，优先 HTTP-Redirect
+	Certificates []*x509.Certificate
+}
+
+type entityDescriptor struct {
+	EntityID         string            `xml:"entityID,attr"`
+	IDPSSODescriptor *idpSSODescriptor `xml:"urn:oasis:names:tc:SAML:2.0:metadata IDPSSODescriptor"`
+}
+
+type entitiesDescriptor struct {
+	EntityDescriptors []entityDescriptor `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+}
+
+type idpSSODescriptor struct {
+	KeyDescriptors []struct {
+		Use          string   `xml:"use,attr"`
+		Certificates []string `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo>X509Data>X509Certificate"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:metadata KeyDescriptor"`
+	SingleSignOnServices []struct {
+		Binding  string `xml:"Binding,attr"`
+		Location string `xml:"Location,attr"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:metadata SingleSignOnService"`
+}
+
+// ParseIDPMetadata 解析 IdP 元数据，支持 EntityDescriptor 及包含多个实体的 EntitiesDescriptor
+func ParseIDPMetadata(data []byte) (*IDPMetadata, error) {
+	// 先用 DOM 解析一遍，拒绝 DOCTYPE
+	root, err := ParseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("IdP 元数据格式错误: %w", err)
+	}
+
+	var entities []entityDescriptor
+	switch {
+	case root.Is(nsMetadata, "EntityDescriptor"):
+		var ed entityDescriptor
+		if err := xml.Unmarshal(data, &ed); err != nil {
+			return nil, fmt.Errorf("IdP 元数据格式错误: %w", err)
+		}
+		entities = append(entities, ed)
+	case root.Is(nsMetadata, "EntitiesDescriptor"):
+		var eds entitiesDescriptor
+		if err := xml.Unmarshal(data, &eds); err != nil {
+			return nil, fmt.Errorf("IdP 元数据格式错误: %w", err)
+		}
+		entities = eds.EntityDescriptors
+	default:
+		return nil, fmt.Errorf("IdP 元数据根元素应为 EntityDescriptor")
+	}
+
+	for _, ed := range entities {
+		if ed.IDPSSODescriptor == nil {
+			continue
+		}
+		md := &IDPMetadata{EntityID: ed.EntityID}
+		for _, sso := range ed.IDPSSODescriptor.SingleSignOnServices {
+			if sso.Binding == BindingHTTPRedirect || (sso.Binding == BindingHTTPPost && md.SSOBinding != BindingHTTPRedirect) {
+				md.SSOURL, md.SSOBinding = sso.Location, sso.Binding
+			}
+		}
+		if md.SSOURL == "" {
+			return nil, fmt.Errorf("IdP 元数据中没有 HTTP-Redirect 或 HTTP-POST 的 SingleSignOnService")
+		}
+		for _, kd := range ed.IDPSSODescriptor.KeyDescriptors {
+			if kd.Use != "" && kd.Use != "signing" {
+				continue
+			}
+			for _, c := range kd.Certificates {
+				der, err := decodeBase64(c)
+				if err != nil {
+					return nil, fmt.Errorf("IdP 签名证书格式错误: %w", err)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("IdP 签名证书解析失败: %w", err)
+				}
+				md.Certificates = append(md.Certificates, cert)
+			}
+		}
+		if len(md.Certificates) == 0 {
+			return nil, fmt.Errorf("IdP 元数据中没有签名证书")
+		}
+		return md, nil
+	}
+	return nil, fmt.Errorf("IdP 元数据中没有 IDPSSODescriptor")
+}
+
+// SPMetadata 生成 SP 元数据，供导入到 IdP
+func SPMetadata(entityID, acsURL string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<md:EntityDescriptor xmlns:md="` + nsMetadata + `" entityID="` + escapeAttr(entityID) + `">` + "\n")
+	buf.WriteString(`  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + nsProtocol + `">` + "\n")
+	buf.WriteString(`    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified</md:NameIDFormat>` + "\n")
+	buf.WriteString(`    <md:AssertionConsumerService Binding="` + BindingHTTPPost + `" Location="` + escapeAttr(acsURL) + `" index="0" isDefault="true"/>` + "\n")
+	buf.WriteString(`  </md:SPSSODescriptor>` + "\n")
+	buf.WriteString(`</md:EntityDescriptor>` + "\n")
+	return buf.Bytes()
+}
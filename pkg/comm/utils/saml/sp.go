@@ -0,0 +1,289 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const statusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+
+// MaxClockSkew 校验断言有效期时允许的时钟偏差
+const MaxClockSkew = 3 * time.Minute
+
+// ServiceProvider SAML 2.0 服务提供方（k8m）
+type ServiceProvider struct {
+	EntityID string       // SP 实体ID，需与 IdP 中配置的一致
+	ACSURL   string       // 断言消费地址，IdP 以 HTTP-POST 方式回传 SAMLResponse
+	IDP      *IDPMetadata // IdP 元数据
+}
+
+// Assertion 校验通过后的断言内容
+type Assertion struct {
+	ID           string
+	NameID       string
+	Attributes   map[string][]string // 同时以 Name 与 FriendlyName 为键
+	NotOnOrAfter time.Time           // 断言失效时间，用于防重放
+}
+
+// NewRequestID 生成 AuthnRequest ID，ID 必须以字母或下划线开头
+func NewRequestID() string {
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return "_" + hex.EncodeToString(b)
+}
+
+// AuthnRequest 生成登录请求。
+// IdP 支持 HTTP-Redirect 时返回跳转地址；仅支持 HTTP-POST 时返回自动提交的表单页面
+func (sp *ServiceProvider) AuthnRequest(id, relayState string, now time.Time) (redirectURL string, postForm []byte, err error) {
+	req := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s"><saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"/></samlp:AuthnRequest>`,
+		nsProtocol, nsAssertion, id, now.UTC().Format(time.RFC3339), escapeAttr(sp.IDP.SSOURL),
+		escapeAttr(sp.ACSURL), BindingHTTPPost, escapeText(sp.EntityID))
+
+	if sp.IDP.SSOBinding == BindingHTTPPost {
+		form := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+  <head><title>SAML Login</title></head>
+  <body onload="document.forms[0].submit()">
+    <form method="post" action="%s">
+      <input type="hidden" name="SAMLRequest" value="%s"/>
+      <input type="hidden" name="RelayState" value="%s"/>
+      <noscript><button type="submit">继续登录</button></noscript>
+    </form>
+  </body>
+</html>
+`, html.EscapeString(sp.IDP.SSOURL), base64.StdEncoding.EncodeToString([]byte(req)), html.EscapeString(relayState))
+		return "", []byte(form), nil
+	}
+
+	// HTTP-Redirect 绑定：DEFLATE 压缩后 Base64 编码
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err = w.Write([]byte(req)); err != nil {
+		return "", nil, err
+	}
+	if err = w.Close(); err != nil {
+		return "", nil, err
+	}
+	u, err := url.Parse(sp.IDP.SSOURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("IdP 登录地址错误: %w", err)
+	}
+	q := u.Query()
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil, nil
+}
+
+// ParseResponse 解析并校验 IdP 回传的 SAMLResponse。
+// 要求 Response 或 Assertion 至少一处由 IdP 签名，且只从签名覆盖的节点中读取数据，避免签名包装攻击
+func (sp *ServiceProvider) ParseResponse(samlResponse, requestID string, now time.Time) (*Assertion, error) {
+	data, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("SAMLResponse 格式错误: %w", err)
+	}
+	root, err := ParseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("SAMLResponse 格式错误: %w", err)
+	}
+	if !root.Is(nsProtocol, "Response") {
+		return nil, fmt.Errorf("SAMLResponse 根元素应为 Response")
+	}
+	if err := checkUniqueIDs(root); err != nil {
+		return nil, err
+	}
+
+	if status := root.Child(nsProtocol, "Status"); status != nil {
+		code := status.Child(nsProtocol, "StatusCode")
+		if code == nil || code.Attr("Value") != statusSuccess {
+			value := ""
+			if code != nil {
+				value = code.Attr("Value")
+			}
+			msg := ""
+			if m := status.Child(nsProtocol, "StatusMessage"); m != nil {
+				msg = m.Text()
+			}
+			return nil, fmt.Errorf("IdP 认证失败: %s %s", value, msg)
+		}
+	} else {
+		return nil, fmt.Errorf("SAMLResponse 缺少 Status")
+	}
+	if dest := root.Attr("Destination"); dest != "" && dest != sp.ACSURL {
+		return nil, fmt.Errorf("SAMLResponse 的 Destination %s 与回调地址 %s 不一致", dest, sp.ACSURL)
+	}
+	if root.Attr("InResponseTo") != requestID {
+		return nil, fmt.Errorf("SAMLResponse 与登录请求不匹配，请重新登录")
+	}
+	if issuer := root.Child(nsAssertion, "Issuer"); issuer != nil && issuer.Text() != sp.IDP.EntityID {
+		return nil, fmt.Errorf("SAMLResponse 的签发方 %s 与 IdP %s 不一致", issuer.Text(), sp.IDP.EntityID)
+	}
+
+	responseSigned := signatureOf(root) != nil
+	if responseSigned {
+		if err := verifySignature(root, sp.IDP.Certificates); err != nil {
+			return nil, err
+		}
+	}
+	if len(root.ChildElements(nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, fmt.Errorf("暂不支持加密断言，请在 IdP 中关闭断言加密")
+	}
+	assertions := root.ChildElements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("SAMLResponse 必须且只能包含一个 Assertion")
+	}
+	el := assertions[0]
+	if signatureOf(el) != nil {
+		if err := verifySignature(el, sp.IDP.Certificates); err != nil {
+			return nil, err
+		}
+	} else if !responseSigned {
+		return nil, fmt.Errorf("SAMLResponse 与 Assertion 均未签名")
+	}
+
+	return sp.validateAssertion(el, requestID, now)
+}
+
+func (sp *ServiceProvider) validateAssertion(el *Element, requestID string, now time.Time) (*Assertion, error) {
+	issuer := el.Child(nsAssertion, "Issuer")
+	if issuer == nil || issuer.Text() != sp.IDP.EntityID {
+		return nil, fmt.Errorf("断言签发方与 IdP %s 不一致", sp.IDP.EntityID)
+	}
+
+	a := &Assertion{ID: el.Attr("ID"), Attributes: map[string][]string{}}
+
+	subject := el.Child(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("断言缺少 Subject")
+	}
+	if nameID := subject.Child(nsAssertion, "NameID"); nameID != nil {
+		a.NameID = nameID.Text()
+	}
+	bearer := false
+	for _, sc := range subject.ChildElements(nsAssertion, "SubjectConfirmation") {
+		if sc.Attr("Method") != "urn:oasis:names:tc:SAML:2.0:cm:bearer" {
+			continue
+		}
+		data := sc.Child(nsAssertion, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+		if data.Attr("Recipient") != sp.ACSURL || data.Attr("InResponseTo") != requestID {
+			continue
+		}
+		notOnOrAfter, err := parseTime(data.Attr("NotOnOrAfter"))
+		if err != nil || notOnOrAfter.IsZero() || !now.Before(notOnOrAfter.Add(MaxClockSkew)) {
+			continue
+		}
+		bearer = true
+		a.NotOnOrAfter = notOnOrAfter
+	}
+	if !bearer {
+		return nil, fmt.Errorf("断言没有可用的 bearer SubjectConfirmation，请检查回调地址或是否已过期")
+	}
+
+	conditions := el.Child(nsAssertion, "Conditions")
+	if conditions == nil {
+		return nil, fmt.Errorf("断言缺少 Conditions")
+	}
+	notBefore, err := parseTime(conditions.Attr("NotBefore"))
+	if err != nil {
+		return nil, err
+	}
+	if !notBefore.IsZero() && now.Add(MaxClockSkew).Before(notBefore) {
+		return nil, fmt.Errorf("断言尚未生效，请检查服务器时间")
+	}
+	notOnOrAfter, err := parseTime(conditions.Attr("NotOnOrAfter"))
+	if err != nil {
+		return nil, err
+	}
+	if !notOnOrAfter.IsZero() {
+		if !now.Before(notOnOrAfter.Add(MaxClockSkew)) {
+			return nil, fmt.Errorf("断言已过期")
+		}
+		if notOnOrAfter.After(a.NotOnOrAfter) {
+			a.NotOnOrAfter = notOnOrAfter
+		}
+	}
+	for _, ar := range conditions.ChildElements(nsAssertion, "AudienceRestriction") {
+		matched := false
+		for _, aud := range ar.ChildElements(nsAssertion, "Audience") {
+			if aud.Text() == sp.EntityID {
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("断言的 Audience 不包含 SP 实体ID %s", sp.EntityID)
+		}
+	}
+
+	for _, stmt := range el.ChildElements(nsAssertion, "AttributeStatement") {
+		for _, attr := range stmt.ChildElements(nsAssertion, "Attribute") {
+			var values []string
+			for _, v := range attr.ChildElements(nsAssertion, "AttributeValue") {
+				values = append(values, v.Text())
+			}
+			values = trimValues(values)
+			for _, key := range []string{attr.Attr("Name"), attr.Attr("FriendlyName")} {
+				if key != "" {
+					a.Attributes[key] = append(a.Attributes[key], values...)
+				}
+			}
+		}
+	}
+	return a, nil
+}
+
+// checkUniqueIDs 文档中的 ID 不允许重复，防止签名引用被替换到伪造节点
+func checkUniqueIDs(root *Element) error {
+	ids := map[string]bool{}
+	var dup string
+	root.walk(func(e *Element) {
+		if id := e.Attr("ID"); id != "" {
+			if ids[id] {
+				dup = id
+			}
+			ids[id] = true
+		}
+	})
+	if dup != "" {
+		return fmt.Errorf("SAMLResponse 中存在重复的ID %s", dup)
+	}
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("断言时间格式错误: %s", s)
+	}
+	return t, nil
+}
+
+// trimValues 清理属性值中的空白与空项
+func trimValues(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIDP = "https://idp.example.com/metadata"
+	testSP  = "https://k8m.example.com/auth/saml/corp/metadata"
+	testACS = "https://k8m.example.com/auth/saml/corp/callback"
+)
+
+func newTestIDP(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	metadata := fmt.Sprintf(`<md:EntityDescriptor xmlns:md="%s" xmlns:ds="%s" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="%s">
+    <md:KeyDescriptor use="signing"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>
+      %s
+    </ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:SingleSignOnService Binding="%s" Location="https://idp.example.com/sso/post"/>
+    <md:SingleSignOnService Binding="%s" Location="https://idp.example.com/sso/redirect"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, nsMetadata, nsDSig, testIDP, nsProtocol, base64.StdEncoding.EncodeToString(der), BindingHTTPPost, BindingHTTPRedirect)
+	return key, []byte(metadata)
+}
+
+func testAssertion(id, requestID string, now time.Time) string {
+	return fmt.Sprintf(`<saml:Assertion xmlns:saml="%s" xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" ID="%s" Version="2.0" IssueInstant="%s">
+    <saml:Issuer>%s</saml:Issuer>
+    <saml:Subject>
+      <saml:NameID>alice@example.com</saml:NameID>
+      <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+        <saml:SubjectConfirmationData InResponseTo="%s" Recipient="%s" NotOnOrAfter="%s"/>
+      </saml:SubjectConfirmation>
+    </saml:Subject>
+    <saml:Conditions NotBefore="%s" NotOnOrAfter="%s">
+      <saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction>
+    </saml:Conditions>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="http://schemas.xmlsoap.org/claims/Group" FriendlyName="groups">
+        <saml:AttributeValue xsi:type="xs:string">dev</saml:AttributeValue>
+        <saml:AttributeValue xsi:type="xs:string">ops</saml:AttributeValue>
+      </saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>`, nsAssertion, id, now.UTC().Format(time.RFC3339), testIDP, requestID, testACS,
+		now.Add(5*time.Minute).UTC().Format(time.RFC3339), now.Add(-time.Minute).UTC().Format(time.RFC3339),
+		now.Add(5*time.Minute).UTC().Format(time.RFC3339), testSP)
+}
+
+// signAssertion 按 enveloped-signature + exc-c14n 为断言签名，签名插入在 Issuer 之后
+func signAssertion(t *testing.T, key *rsa.PrivateKey, assertion string) string {
+	el, err := ParseXML([]byte(assertion))
+	if err != nil {
+		t.Fatalf("解析断言失败: %v", err)
+	}
+	digest := sha256.Sum256(canonicalize(el, nil, nil))
+	sig := fmt.Sprintf(`<ds:Signature xmlns:ds="%s"><ds:SignedInfo><ds:CanonicalizationMethod Algorithm="%s"/><ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"/><ds:Reference URI="#%s"><ds:Transforms><ds:Transform Algorithm="%s"/><ds:Transform Algorithm="%s"/></ds:Transforms><ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/><ds:DigestValue>%s</ds:DigestValue></ds:Reference></ds:SignedInfo><ds:SignatureValue>SIGNATURE</ds:SignatureValue></ds:Signature>`,
+		nsDSig, algExcC14N, el.Attr("ID"), algEnveloped, algExcC14N, base64.StdEncoding.EncodeToString(digest[:]))
+	idx := strings.Index(assertion, "</saml:Issuer>") + len("</saml:Issuer>")
+	signed := assertion[:idx] + sig + assertion[idx:]
+
+	el, err = ParseXML([]byte(signed))
+	if err != nil {
+		t.Fatalf("解析签名断言失败: %v", err)
+	}
+	hashed := sha256.Sum256(canonicalize(signatureOf(el).Child(nsDSig, "SignedInfo"), nil, nil))
+	value, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	return strings.Replace(signed, "SIGNATURE", base64.StdEncoding.EncodeToString(value), 1)
+}
+
+func testResponse(requestID string, assertions ...string) string {
+	xml := fmt.Sprintf(`<samlp:Response xmlns:samlp="%s" xmlns:saml="%s" ID="_resp" Version="2.0" Destination="%s" InResponseTo="%s">
+  <saml:Issuer>%s</saml:Issuer>
+  <samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>
+  %s
+</samlp:Response>`, nsProtocol, nsAssertion, testACS, requestID, testIDP, statusSuccess, strings.Join(assertions, "\n"))
+	return base64.StdEncoding.EncodeToString([]byte(xml))
+}
+
+func TestParseResponse(t *testing.T) {
+	key, metadata := newTestIDP(t)
+	idp, err := ParseIDPMetadata(metadata)
+	if err != nil {
+		t.Fatalf("解析 IdP 元数据失败: %v", err)
+	}
+	if idp.SSOBinding != BindingHTTPRedirect || idp.SSOURL != "https://idp.example.com/sso/redirect" {
+		t.Errorf("应优先使用 HTTP-Redirect 登录地址: %s %s", idp.SSOBinding, idp.SSOURL)
+	}
+	sp := &ServiceProvider{EntityID: testSP, ACSURL: testACS, IDP: idp}
+	now := time.Now()
+	requestID := NewRequestID()
+	signed := signAssertion(t, key, testAssertion("_a1", requestID, now))
+
+	a, err := sp.ParseResponse(testResponse(requestID, signed), requestID, now)
+	if err != nil {
+		t.Fatalf("校验签名断言失败: %v", err)
+	}
+	if a.NameID != "alice@example.com" || strings.Join(a.Attributes["groups"], ",") != "dev,ops" {
+		t.Errorf("断言内容解析错误: %+v", a)
+	}
+
+	if _, err := sp.ParseResponse(testResponse(requestID, signed), "_other", now); err == nil {
+		t.Errorf("InResponseTo 不匹配时应失败")
+	}
+	if _, err := sp.ParseResponse(testResponse(requestID, signed), requestID, now.Add(time.Hour)); err == nil {
+		t.Errorf("断言过期后应失败")
+	}
+	if _, err := sp.ParseResponse(testResponse(requestID, testAssertion("_a1", requestID, now)), requestID, now); err == nil {
+		t.Errorf("未签名的断言应失败")
+	}
+	tampered := strings.Replace(signed, "alice@example.com", "admin", 1)
+	if _, err := sp.ParseResponse(testResponse(requestID, tampered), requestID, now); err == nil {
+		t.Errorf("篡改后的断言应失败")
+	}
+	// 签名包装：追加一个伪造断言，或复用已签名断言的ID
+	forged := strings.Replace(testAssertion("_a2", requestID, now), "alice@example.com", "admin", 1)
+	if _, err := sp.ParseResponse(testResponse(requestID, signed, forged), requestID, now); err == nil {
+		t.Errorf("包含多个断言时应失败")
+	}
+	wrapped := strings.Replace(signed, "</saml:Issuer>", "</saml:Issuer><saml:Advice>"+strings.Replace(forged, `ID="_a2"`, `ID="_a1"`, 1)+"</saml:Advice>", 1)
+	if _, err := sp.ParseResponse(testResponse(requestID, wrapped), requestID, now); err == nil {
+		t.Errorf("存在重复ID时应失败")
+	}
+}
+
+func TestParseXMLRejectsDoctype(t *testing.T) {
+	doc := `<!DOCTYPE x [<!ENTITY a "aaaa">]><x>&a;</x>`
+	if _, err := ParseXML([]byte(doc)); err == nil {
+		t.Errorf("包含 DOCTYPE 的文档应被拒绝")
+	}
+}
@@ -0,0 +1,271 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// Element 保留原始前缀与命名空间声明的简易 DOM，用于签名校验时的规范化
+type Element struct {
+	Prefix   string
+	Local    string
+	Attrs    []xml.Attr // 原始属性，Name.Space 为前缀，包含 xmlns 声明
+	Children []any      // *Element、text 或 procInst
+	Parent   *Element
+}
+
+type text string
+
+type procInst struct {
+	Target string
+	Inst   string
+}
+
+// ParseXML 解析 XML 文档，拒绝 DOCTYPE 以避免实体扩展攻击
+func ParseXML(data []byte) (*Element, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var root, cur *Element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &Element{Prefix: t.Name.Space, Local: t.Name.Local, Attrs: append([]xml.Attr(nil), t.Attr...), Parent: cur}
+			if cur == nil {
+				if root != nil {
+					return nil, fmt.Errorf("XML 存在多个根元素")
+				}
+				root = el
+			} else {
+				cur.Children = append(cur.Children, el)
+			}
+			cur = el
+		case xml.EndElement:
+			if cur == nil || cur.Prefix != t.Name.Space || cur.Local != t.Name.Local {
+				return nil, fmt.Errorf("XML 结束标签 %s 不匹配", t.Name.Local)
+			}
+			cur = cur.Parent
+		case xml.CharData:
+			if cur != nil {
+				cur.Children = append(cur.Children, text(t))
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("XML 根元素外存在文本")
+			}
+		case xml.ProcInst:
+			if cur != nil {
+				cur.Children = append(cur.Children, procInst{Target: t.Target, Inst: string(t.Inst)})
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("不支持包含 DOCTYPE 的 XML")
+		}
+	}
+	if root == nil || cur != nil {
+		return nil, fmt.Errorf("XML 文档不完整")
+	}
+	return root, nil
+}
+
+// NamespaceURI 元素自身的命名空间
+func (e *Element) NamespaceURI() string {
+	uri, _ := e.lookupNamespace(e.Prefix)
+	return uri
+}
+
+// Is 判断元素的命名空间与名称
+func (e *Element) Is(ns, local string) bool {
+	return e.Local == local && e.NamespaceURI() == ns
+}
+
+// Attr 获取无前缀属性的值
+func (e *Element) Attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Child 获取第一个匹配的直接子元素
+func (e *Element) Child(ns, local string) *Element {
+	if list := e.ChildElements(ns, local); len(list) > 0 {
+		return list[0]
+	}
+	return nil
+}
+
+// ChildElements 获取所有匹配的直接子元素
+func (e *Element) ChildElements(ns, local string) []*Element {
+	var list []*Element
+	for _, c := range e.Children {
+		if el, ok := c.(*Element); ok && el.Is(ns, local) {
+			list = append(list, el)
+		}
+	}
+	return list
+}
+
+// Text 元素的文本内容（仅直接文本子节点）
+func (e *Element) Text() string {
+	var sb strings.Builder
+	for _, c := range e.Children {
+		if t, ok := c.(text); ok {
+			sb.WriteString(string(t))
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// walk 深度优先遍历全部元素
+func (e *Element) walk(fn func(*Element)) {
+	fn(e)
+	for _, c := range e.Children {
+		if el, ok := c.(*Element); ok {
+			el.walk(fn)
+		}
+	}
+}
+
+func (e *Element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for el := e; el != nil; el = el.Parent {
+		for _, a := range el.Attrs {
+			if prefix == "" && a.Name.Space == "" && a.Name.Local == "xmlns" {
+				return a.Value, true
+			}
+			if prefix != "" && a.Name.Space == "xmlns" && a.Name.Local == prefix {
+				return a.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// canonicalize 按 Exclusive XML Canonicalization 1.0（不含注释）输出元素。
+// exclude 为需要剔除的节点（enveloped-signature），inclusivePrefixes 为 InclusiveNamespaces PrefixList
+func canonicalize(e *Element, exclude *Element, inclusivePrefixes []string) []byte {
+	var buf bytes.Buffer
+	c14nElement(&buf, e, exclude, inclusivePrefixes, map[string]string{})
+	return buf.Bytes()
+}
+
+type c14nAttr struct {
+	ns, local, qname, value string
+}
+
+func c14nElement(buf *bytes.Buffer, e *Element, exclude *Element, inclusive []string, rendered map[string]string) {
+	// 需要输出的命名空间：元素及其属性实际使用的前缀，加上 PrefixList 中在作用域内的前缀
+	used := []string{e.Prefix}
+	var attrs []c14nAttr
+	for _, a := range e.Attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		qname := a.Name.Local
+		ns := ""
+		if a.Name.Space != "" {
+			qname = a.Name.Space + ":" + a.Name.Local
+			ns, _ = e.lookupNamespace(a.Name.Space)
+			if a.Name.Space != "xml" {
+				used = append(used, a.Name.Space)
+			}
+		}
+		attrs = append(attrs, c14nAttr{ns: ns, local: a.Name.Local, qname: qname, value: a.Value})
+	}
+	for _, p := range inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		if _, ok := e.lookupNamespace(p); ok {
+			used = append(used, p)
+		}
+	}
+
+	scope := make(map[string]string, len(rendered)+len(used))
+	for k, v := range rendered {
+		scope[k] = v
+	}
+	var nsDecls []c14nAttr
+	seen := map[string]bool{}
+	for _, p := range used {
+		if seen[p] || p == "xml" {
+			continue
+		}
+		seen[p] = true
+		uri, _ := e.lookupNamespace(p)
+		prev, ok := rendered[p]
+		if p == "" && uri == "" && (!ok || prev == "") {
+			continue
+		}
+		if ok && prev == uri {
+			continue
+		}
+		scope[p] = uri
+		name := "xmlns"
+		if p != "" {
+			name = "xmlns:" + p
+		}
+		nsDecls = append(nsDecls, c14nAttr{local: p, qname: name, value: uri})
+	}
+	sort.Slice(nsDecls, func(i, j int) bool { return nsDecls[i].local < nsDecls[j].local })
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].ns != attrs[j].ns {
+			return attrs[i].ns < attrs[j].ns
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	qname := e.Local
+	if e.Prefix != "" {
+		qname = e.Prefix + ":" + e.Local
+	}
+	buf.WriteString("<" + qname)
+	for _, a := range append(nsDecls, attrs...) {
+		buf.WriteString(" " + a.qname + `="`)
+		buf.WriteString(escapeAttr(a.value))
+		buf.WriteString(`"`)
+	}
+	buf.WriteString(">")
+	for _, c := range e.Children {
+		switch n := c.(type) {
+		case *Element:
+			if n != exclude {
+				c14nElement(buf, n, exclude, inclusive, scope)
+			}
+		case text:
+			buf.WriteString(escapeText(string(n)))
+		case procInst:
+			buf.WriteString("<?" + n.Target)
+			if n.Inst != "" {
+				buf.WriteString(" " + n.Inst)
+			}
+			buf.WriteString("?>")
+		}
+	}
+	buf.WriteString("</" + qname + ">")
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
@@ -0,0 +1,276 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxResults 单次查询返回的最大资源数
+const MaxResults = 1000
+
+// Filter 查询过滤条件，只支持 `属性 eq "值"` 形式，属性名已转为小写
+type Filter struct {
+	Attr  string
+	Value string
+}
+
+var filterRegexp = regexp.MustCompile(`(?i)^\s*([a-z][\w.:-]*)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// ParseFilter 解析查询过滤条件，为空时返回 nil
+func ParseFilter(s string) (*Filter, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	m := filterRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return nil, NewError(http.StatusBadRequest, ErrInvalidFilter, fmt.Sprintf("不支持的过滤条件: %s，仅支持 属性 eq \"值\"", s))
+	}
+	var value string
+	if err := json.Unmarshal([]byte(m[2]), &value); err != nil {
+		return nil, NewError(http.StatusBadRequest, ErrInvalidFilter, fmt.Sprintf("过滤条件格式错误: %s", s))
+	}
+	return &Filter{Attr: attrName(m[1]), Value: value}, nil
+}
+
+// Page 解析分页参数，startIndex 从 1 开始，count 默认 100，不超过 MaxResults
+func Page(startIndex, count string) (int, int) {
+	start, err := strconv.Atoi(startIndex)
+	if err != nil || start < 1 {
+		start = 1
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		n = 100
+	}
+	return start, min(max(n, 0), MaxResults)
+}
+
+// attrName 去掉属性名的 schema 前缀并转为小写
+func attrName(s string) string {
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		s = strings.TrimPrefix(s, schema+":")
+	}
+	return strings.ToLower(s)
+}
+
+// parsePath 解析 PATCH 路径，如 active、members、members[value eq "1"]
+func parsePath(path string) (string, *Filter, error) {
+	path = strings.TrimSpace(path)
+	i := strings.Index(path, "[")
+	if i < 0 {
+		return attrName(path), nil, nil
+	}
+	if !strings.HasSuffix(path, "]") {
+		return "", nil, NewError(http.StatusBadRequest, ErrInvalidPath, fmt.Sprintf("无效的路径: %s", path))
+	}
+	filter, err := ParseFilter(path[i+1 : len(path)-1])
+	if err != nil || filter == nil {
+		return "", nil, NewError(http.StatusBadRequest, ErrInvalidPath, fmt.Sprintf("无效的路径: %s", path))
+	}
+	return attrName(path[:i]), filter, nil
+}
+
+// decodeValue 解析操作的值，path 用于错误提示
+func decodeValue(raw json.RawMessage, v any, path string) error {
+	err := json.Unmarshal(raw, v)
+	if err == nil {
+		return nil
+	}
+	var scimErr *Error
+	if errors.As(err, &scimErr) {
+		return scimErr
+	}
+	return NewError(http.StatusBadRequest, ErrInvalidValue, fmt.Sprintf("路径 %s 的值格式错误: %v", path, err))
+}
+
+// decodeRefs 解析成员引用，兼容单个对象与数组
+func decodeRefs(op PatchOperation) ([]string, error) {
+	var refs []Ref
+	if len(op.Value) > 0 && op.Value[0] == '{' {
+		var ref Ref
+		if err := decodeValue(op.Value, &ref, op.Path); err != nil {
+			return nil, err
+		}
+		refs = []Ref{ref}
+	} else if err := decodeValue(op.Value, &refs, op.Path); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Value != "" {
+			ids = append(ids, ref.Value)
+		}
+	}
+	return ids, nil
+}
+
+func checkOp(op PatchOperation) (string, error) {
+	name := strings.ToLower(op.Op)
+	if name != "add" && name != "remove" && name != "replace" {
+		return "", NewError(http.StatusBadRequest, ErrInvalidSyntax, fmt.Sprintf("不支持的操作: %s", op.Op))
+	}
+	return name, nil
+}
+
+// UserPatch PATCH 请求对用户的修改，字段为空表示未修改
+type UserPatch struct {
+	UserName string
+	Active   *bool
+}
+
+// ApplyUserPatch 解析对用户的 PATCH 操作，k8m 不保存的属性忽略
+func ApplyUserPatch(ops []PatchOperation) (*UserPatch, error) {
+	result := &UserPatch{}
+	set := func(op PatchOperation, attr string, raw json.RawMessage) error {
+		switch attr {
+		case "active":
+			var active Bool
+			if err := decodeValue(raw, &active, op.Path); err != nil {
+				return err
+			}
+			v := bool(active)
+			result.Active = &v
+		case "username":
+			if err := decodeValue(raw, &result.UserName, op.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, op := range ops {
+		name, err := checkOp(op)
+		if err != nil {
+			return nil, err
+		}
+		attr, _, err := parsePath(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if name == "remove" {
+			if attr == "active" || attr == "username" {
+				return nil, NewError(http.StatusBadRequest, ErrMutability, fmt.Sprintf("属性 %s 不能删除", op.Path))
+			}
+			continue
+		}
+		if attr != "" {
+			if err := set(op, attr, op.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// 未指定路径时，值为包含多个属性的对象
+		var values map[string]json.RawMessage
+		if err := decodeValue(op.Value, &values, op.Path); err != nil {
+			return nil, err
+		}
+		for key, raw := range values {
+			if err := set(op, attrName(key), raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// GroupPatch PATCH 请求对用户组的修改
+type GroupPatch struct {
+	DisplayName string   // 为空表示未修改
+	Members     []string // 修改后的成员ID
+}
+
+// ApplyGroupPatch 按 PATCH 操作计算用户组修改后的名称与成员，members 为当前成员ID
+func ApplyGroupPatch(members []string, ops []PatchOperation) (*GroupPatch, error) {
+	result := &GroupPatch{Members: slices.Clone(members)}
+	add := func(ids []string) {
+		for _, id := range ids {
+			if !slices.Contains(result.Members, id) {
+				result.Members = append(result.Members, id)
+			}
+		}
+	}
+	remove := func(ids []string) {
+		result.Members = slices.DeleteFunc(result.Members, func(id string) bool {
+			return slices.Contains(ids, id)
+		})
+	}
+	for _, op := range ops {
+		name, err := checkOp(op)
+		if err != nil {
+			return nil, err
+		}
+		attr, filter, err := parsePath(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch attr {
+		case "":
+			if name == "remove" {
+				return nil, NewError(http.StatusBadRequest, ErrNoTarget, "remove 操作必须指定路径")
+			}
+			// 未指定路径时，值为包含多个属性的对象
+			var values struct {
+				DisplayName string `json:"displayName"`
+				Members     *[]Ref `json:"members"`
+			}
+			if err := decodeValue(op.Value, &values, op.Path); err != nil {
+				return nil, err
+			}
+			if values.DisplayName != "" {
+				result.DisplayName = values.DisplayName
+			}
+			if values.Members != nil {
+				var ids []string
+				for _, ref := range *values.Members {
+					ids = append(ids, ref.Value)
+				}
+				if name == "replace" {
+					result.Members = nil
+				}
+				add(ids)
+			}
+		case "displayname":
+			if name == "remove" {
+				return nil, NewError(http.StatusBadRequest, ErrMutability, "displayName 不能删除")
+			}
+			if err := decodeValue(op.Value, &result.DisplayName, op.Path); err != nil {
+				return nil, err
+			}
+		case "members":
+			if filter != nil {
+				if name != "remove" || filter.Attr != "value" {
+					return nil, NewError(http.StatusBadRequest, ErrInvalidPath, fmt.Sprintf("不支持的路径: %s", op.Path))
+				}
+				remove([]string{filter.Value})
+				continue
+			}
+			if name == "remove" && len(op.Value) == 0 {
+				result.Members = nil
+				continue
+			}
+			ids, err := decodeRefs(op)
+			if err != nil {
+				return nil, err
+			}
+			switch name {
+			case "add":
+				add(ids)
+			case "remove":
+				remove(ids)
+			case "replace":
+				result.Members = nil
+				add(ids)
+			}
+		case "externalid":
+			// 不保存外部ID
+		default:
+			return nil, NewError(http.StatusBadRequest, ErrInvalidPath, fmt.Sprintf("不支持的路径: %s", op.Path))
+		}
+	}
+	return result, nil
+}
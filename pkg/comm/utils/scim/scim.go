@@ -0,0 +1,181 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType SCIM 协议的响应类型
+const ContentType = "application/scim+json"
+
+// SCIM 2.0 资源与消息的 schema，见 RFC 7643、RFC 7644
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// 错误类型，见 RFC 7644 3.12
+const (
+	ErrInvalidFilter = "invalidFilter"
+	ErrInvalidPath   = "invalidPath"
+	ErrInvalidValue  = "invalidValue"
+	ErrInvalidSyntax = "invalidSyntax"
+	ErrMutability    = "mutability"
+	ErrUniqueness    = "uniqueness"
+	ErrNoTarget      = "noTarget"
+)
+
+// Meta 资源元数据
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// Ref 对其他资源的引用，用于用户所属的用户组与用户组的成员
+type Ref struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// User 用户资源。k8m 只保存 userName 与 active，其余属性接受但不保存
+type User struct {
+	Schemas    []string `json:"schemas"`
+	ID         string   `json:"id,omitempty"`
+	ExternalID string   `json:"externalId,omitempty"`
+	UserName   string   `json:"userName"`
+	Active     *Bool    `json:"active,omitempty"`
+	Groups     []Ref    `json:"groups,omitempty"`
+	Meta       *Meta    `json:"meta,omitempty"`
+}
+
+// Group 用户组资源
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Ref    `json:"members,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// ListResponse 查询结果
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    any      `json:"Resources"`
+}
+
+// NewListResponse 创建查询结果
+func NewListResponse(total int64, startIndex int, items any, n int) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: n,
+		Resources:    items,
+	}
+}
+
+// PatchRequest PATCH 请求
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation PATCH 操作，op 不区分大小写
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Bool 兼容部分身份提供方以字符串（如 "False"）传递的布尔值
+type Bool bool
+
+// UnmarshalJSON 解析 true、false 及其字符串形式，不区分大小写
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	v, err := strconv.ParseBool(strings.ToLower(s))
+	if err != nil {
+		return NewError(http.StatusBadRequest, ErrInvalidValue, fmt.Sprintf("无效的布尔值: %s", data))
+	}
+	*b = Bool(v)
+	return nil
+}
+
+// NewBool 创建布尔值指针
+func NewBool(v bool) *Bool {
+	b := Bool(v)
+	return &b
+}
+
+// Error SCIM 错误响应
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+	Code     int      `json:"-"`
+}
+
+// NewError 创建错误，scimType 可为空
+func NewError(code int, scimType, detail string) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(code),
+		ScimType: scimType,
+		Detail:   detail,
+		Code:     code,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// NotFound 资源不存在
+func NotFound(resource, id string) *Error {
+	return NewError(http.StatusNotFound, "", fmt.Sprintf("%s %s 不存在", resource, id))
+}
+
+// ServiceProviderConfig 描述服务端支持的 SCIM 功能
+func ServiceProviderConfig() map[string]any {
+	unsupported := map[string]any{"supported": false}
+	return map[string]any{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": MaxResults},
+		"changePassword": unsupported,
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "使用 k8m 中生成的 SCIM 令牌认证",
+			"primary":     true,
+		}},
+	}
+}
+
+// ResourceTypes 服务端支持的资源类型
+func ResourceTypes() []map[string]any {
+	return []map[string]any{
+		{"schemas": []string{SchemaResourceType}, "id": "User", "name": "User", "endpoint": "/Users", "schema": SchemaUser},
+		{"schemas": []string{SchemaResourceType}, "id": "Group", "name": "Group", "endpoint": "/Groups", "schema": SchemaGroup},
+	}
+}
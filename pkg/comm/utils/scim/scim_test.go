@@ -0,0 +1,84 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testOps(t *testing.T, s string) []PatchOperation {
+	t.Helper()
+	var req PatchRequest
+	if err := json.Unmarshal([]byte(s), &req); err != nil {
+		t.Fatalf("解析PATCH请求失败: %v", err)
+	}
+	return req.Operations
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(`userName eq "alice@example.com"`)
+	if err != nil || f.Attr != "username" || f.Value != "alice@example.com" {
+		t.Fatalf("解析结果错误: %+v %v", f, err)
+	}
+	f, err = ParseFilter(`urn:ietf:params:scim:schemas:core:2.0:Group:displayName EQ "a \"b\""`)
+	if err != nil || f.Attr != "displayname" || f.Value != `a "b"` {
+		t.Fatalf("带schema前缀与转义的过滤条件解析错误: %+v %v", f, err)
+	}
+	if f, err := ParseFilter(""); f != nil || err != nil {
+		t.Errorf("空过滤条件应返回nil")
+	}
+	var scimErr *Error
+	if _, err := ParseFilter(`userName sw "a"`); !errors.As(err, &scimErr) || scimErr.ScimType != ErrInvalidFilter {
+		t.Errorf("不支持的运算符应返回invalidFilter: %v", err)
+	}
+	if start, count := Page("0", "5000"); start != 1 || count != MaxResults {
+		t.Errorf("分页参数修正错误: %d %d", start, count)
+	}
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	// Azure AD 使用首字母大写的操作名，并以字符串传递布尔值
+	patch, err := ApplyUserPatch(testOps(t, `{"Operations":[
+		{"op":"Replace","path":"active","value":"False"},
+		{"op":"add","path":"displayName","value":"Alice"}]}`))
+	if err != nil || patch.Active == nil || *patch.Active || patch.UserName != "" {
+		t.Fatalf("停用用户解析错误: %+v %v", patch, err)
+	}
+	patch, err = ApplyUserPatch(testOps(t, `{"Operations":[{"op":"replace","value":{"active":true,"userName":"bob"}}]}`))
+	if err != nil || !*patch.Active || patch.UserName != "bob" {
+		t.Fatalf("无路径的替换操作解析错误: %+v %v", patch, err)
+	}
+	if _, err := ApplyUserPatch(testOps(t, `{"Operations":[{"op":"replace","path":"active","value":"maybe"}]}`)); err == nil {
+		t.Errorf("无效的布尔值应失败")
+	}
+	if _, err := ApplyUserPatch(testOps(t, `{"Operations":[{"op":"move","path":"active"}]}`)); err == nil {
+		t.Errorf("不支持的操作应失败")
+	}
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	patch, err := ApplyGroupPatch([]string{"1", "2"}, testOps(t, `{"Operations":[
+		{"op":"add","path":"members","value":[{"value":"3"},{"value":"1"}]},
+		{"op":"remove","path":"members[value eq \"2\"]"},
+		{"op":"replace","path":"displayName","value":"dev"}]}`))
+	if err != nil || strings.Join(patch.Members, ",") != "1,3" || patch.DisplayName != "dev" {
+		t.Fatalf("成员增删解析错误: %+v %v", patch, err)
+	}
+	patch, err = ApplyGroupPatch([]string{"1"}, testOps(t, `{"Operations":[
+		{"op":"Remove","path":"members","value":[{"value":"1"}]},
+		{"op":"replace","value":{"members":[{"value":"5"}]}}]}`))
+	if err != nil || strings.Join(patch.Members, ",") != "5" || patch.DisplayName != "" {
+		t.Fatalf("替换成员解析错误: %+v %v", patch, err)
+	}
+	patch, _ = ApplyGroupPatch([]string{"1", "2"}, testOps(t, `{"Operations":[{"op":"remove","path":"members"}]}`))
+	if len(patch.Members) != 0 {
+		t.Errorf("未指定值的remove应清空成员: %v", patch.Members)
+	}
+	var scimErr *Error
+	_, err = ApplyGroupPatch(nil, testOps(t, `{"Operations":[{"op":"replace","path":"members[value eq \"1\"]","value":{}}]}`))
+	if !errors.As(err, &scimErr) || scimErr.Code != http.StatusBadRequest || scimErr.ScimType != ErrInvalidPath {
+		t.Errorf("带过滤条件的替换应返回invalidPath: %v", err)
+	}
+}
@@ -136,3 +136,24 @@ var ansiEscapeRegex = regexp.MustCompile(`\x1B\[[0-9;]*[A-Za-z]`)
 func CleanANSISequences(input string) string {
 	return ansiEscapeRegex.ReplaceAllString(input, "")
 }
+
+// MatchWildcard 判断字符串是否匹配通配符模式，* 匹配任意字符（包括 /），区分大小写
+func MatchWildcard(pattern, s string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == s
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(s, part)
+		if idx < 0 {
+			return false
+		}
+		s = s[idx+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
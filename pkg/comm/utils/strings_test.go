@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		s       string
+		want    bool
+	}{
+		{name: "无通配完全相同", pattern: "prod", s: "prod", want: true},
+		{name: "无通配不同", pattern: "prod", s: "prod-1", want: false},
+		{name: "单个星号匹配全部", pattern: "*", s: "config/prod-1", want: true},
+		{name: "单个星号匹配空串", pattern: "*", s: "", want: true},
+		{name: "前缀通配", pattern: "prod-*", s: "prod-1", want: true},
+		{name: "前缀不符", pattern: "prod-*", s: "dev-1", want: false},
+		{name: "前缀通配不匹配集群ID", pattern: "prod-*", s: "config/prod-1", want: false},
+		{name: "后缀通配", pattern: "*-prod", s: "bj-prod", want: true},
+		{name: "星号匹配斜杠", pattern: "*/prod-*", s: "config/prod-1", want: true},
+		{name: "中间通配", pattern: "a*b*c", s: "a-x-b-y-c", want: true},
+		{name: "中间片段缺失", pattern: "a*b*c", s: "a-x-c", want: false},
+		{name: "片段不可重叠", pattern: "ab*ba", s: "aba", want: false},
+		{name: "区分大小写", pattern: "Prod-*", s: "prod-1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchWildcard(tt.pattern, tt.s); got != tt.want {
+				t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package webauthn 实现 WebAuthn 依赖方（RP）的注册与认证校验，用于通行密钥（Passkey）登录与二次验证。
+// 只请求 none 证明，不校验认证器的证明链，认证器的可信度由用户登录后自行注册保证
+package webauthn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// 默认超时时间（毫秒）
+const defaultTimeout = 120000
+
+// 注册时支持的公钥算法，见 COSE Algorithms
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// URLEncodedBase64 浏览器以 base64url 编码传递的二进制数据
+type URLEncodedBase64 []byte
+
+func (b URLEncodedBase64) String() string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := DecodeBase64URL(s)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// DecodeBase64URL 解码 base64url，兼容带填充的格式
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// RelyingParty 依赖方，ID 为访问域名，Origin 为浏览器地址栏中的来源
+type RelyingParty struct {
+	ID     string
+	Name   string
+	Origin string
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity 注册时的用户信息，ID 为不含个人信息的随机标识
+type UserEntity struct {
+	ID          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor 已注册凭据的描述，用于排除重复注册或限定登录可用的凭据
+type CredentialDescriptor struct {
+	Type       string           `json:"type"`
+	ID         URLEncodedBase64 `json:"id"`
+	Transports []string         `json:"transports,omitempty"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions navigator.credentials.create 的 publicKey 参数
+type CreationOptions struct {
+	Challenge              URLEncodedBase64       `json:"challenge"`
+	RP                     rpEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions navigator.credentials.get 的 publicKey 参数
+type RequestOptions struct {
+	Challenge        URLEncodedBase64       `json:"challenge"`
+	Timeout          int                    `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse 浏览器注册凭据后返回的结果
+type AttestationResponse struct {
+	ID       string           `json:"id"`
+	RawID    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AttestationObject URLEncodedBase64 `json:"attestationObject"`
+		Transports        []string         `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse 浏览器使用凭据签名后返回的结果
+type AssertionResponse struct {
+	ID       string           `json:"id"`
+	RawID    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+		Signature         URLEncodedBase64 `json:"signature"`
+		UserHandle        URLEncodedBase64 `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential 注册成功的凭据
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key 编码的公钥
+	SignCount      uint32
+	AAGUID         []byte
+	UserVerified   bool // 注册时是否完成了用户验证（PIN、生物识别）
+	BackupEligible bool // 是否为可同步的通行密钥
+}
+
+// NewChallenge 生成随机挑战值
+func NewChallenge() ([]byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// NewCreationOptions 生成注册参数。residentKey 为 true 时要求可发现凭据与用户验证，用于免密登录
+func (rp *RelyingParty) NewCreationOptions(challenge []byte, user UserEntity, exclude []CredentialDescriptor, residentKey bool) *CreationOptions {
+	selection := authenticatorSelection{ResidentKey: "discouraged", UserVerification: "preferred"}
+	if residentKey {
+		selection = authenticatorSelection{ResidentKey: "required", UserVerification: "required"}
+	}
+	return &CreationOptions{
+		Challenge: challenge,
+		RP:        rpEntity{ID: rp.ID, Name: rp.Name},
+		User:      user,
+		PubKeyCredParams: []credentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:                defaultTimeout,
+		ExcludeCredentials:     exclude,
+		AuthenticatorSelection: selection,
+		Attestation:            "none",
+	}
+}
+
+// NewRequestOptions 生成登录参数。allow 为空时由浏览器列出可发现凭据，用于免密登录
+func (rp *RelyingParty) NewRequestOptions(challenge []byte, allow []CredentialDescriptor, userVerification string) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          defaultTimeout,
+		RPID:             rp.ID,
+		AllowCredentials: allow,
+		UserVerification: userVerification,
+	}
+}
@@ -0,0 +1,255 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// authenticator data 中的标志位
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagBackupEligible   = 0x08
+	flagAttestedCredData = 0x40
+)
+
+// COSE 密钥类型与曲线
+const (
+	coseKtyOKP     = 1
+	coseKtyEC2     = 2
+	coseKtyRSA     = 3
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// coseKey COSE_Key，-1 在 EC2/OKP 中为曲线、在 RSA 中为模数，按密钥类型分别解析
+type coseKey struct {
+	Kty int             `cbor:"1,keyasint"`
+	Alg int             `cbor:"3,keyasint"`
+	P1  cbor.RawMessage `cbor:"-1,keyasint"`
+	P2  []byte          `cbor:"-2,keyasint"`
+	P3  []byte          `cbor:"-3,keyasint"`
+}
+
+// VerifyRegistration 校验注册结果并返回凭据。requireUV 为 true 时要求认证器完成用户验证
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, resp *AttestationResponse, requireUV bool) (*Credential, error) {
+	if resp == nil || resp.Type != "public-key" {
+		return nil, errors.New("凭据类型无效")
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+	var att attestationObject
+	if err := cbor.Unmarshal(resp.Response.AttestationObject, &att); err != nil {
+		return nil, fmt.Errorf("解析证明对象失败: %w", err)
+	}
+	ad, err := parseAuthenticatorData(att.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(ad, requireUV); err != nil {
+		return nil, err
+	}
+	if ad.Flags&flagAttestedCredData == 0 || len(ad.CredentialID) == 0 {
+		return nil, errors.New("注册结果缺少凭据数据")
+	}
+	if subtle.ConstantTimeCompare(ad.CredentialID, resp.RawID) != 1 {
+		return nil, errors.New("凭据ID不一致")
+	}
+	if _, err := parsePublicKey(ad.PublicKey); err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:             ad.CredentialID,
+		PublicKey:      ad.PublicKey,
+		SignCount:      ad.SignCount,
+		AAGUID:         ad.AAGUID,
+		UserVerified:   ad.Flags&flagUserVerified != 0,
+		BackupEligible: ad.Flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion 使用已注册的公钥校验登录签名，返回新的签名计数。
+// 认证器支持计数时，计数未递增说明凭据可能被克隆，校验失败
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, resp *AssertionResponse, publicKey []byte, storedSignCount uint32, requireUV bool) (uint32, error) {
+	if resp == nil || resp.Type != "public-key" {
+		return 0, errors.New("凭据类型无效")
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.verifyAuthenticatorData(ad, requireUV); err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte{}, resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := verifySignature(key, signed, resp.Response.Signature); err != nil {
+		return 0, err
+	}
+
+	if (ad.SignCount != 0 || storedSignCount != 0) && ad.SignCount <= storedSignCount {
+		return 0, errors.New("签名计数未递增，凭据可能被复制")
+	}
+	return ad.SignCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, typ string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("解析客户端数据失败: %w", err)
+	}
+	if cd.Type != typ {
+		return fmt.Errorf("客户端数据类型错误: %s", cd.Type)
+	}
+	got, err := DecodeBase64URL(cd.Challenge)
+	if err != nil || len(challenge) == 0 || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return errors.New("挑战值不匹配")
+	}
+	if cd.Origin != rp.Origin {
+		return fmt.Errorf("来源不匹配: %s", cd.Origin)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(ad *authenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(ad.RPIDHash, rpIDHash[:]) != 1 {
+		return errors.New("依赖方ID不匹配")
+	}
+	if ad.Flags&flagUserPresent == 0 {
+		return errors.New("认证器未确认用户在场")
+	}
+	if requireUV && ad.Flags&flagUserVerified == 0 {
+		return errors.New("认证器未完成用户验证")
+	}
+	return nil
+}
+
+// parseAuthenticatorData 解析 authenticator data：rpIdHash(32) flags(1) signCount(4) [attestedCredentialData] [extensions]
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("认证器数据长度不足")
+	}
+	ad := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.Flags&flagAttestedCredData == 0 {
+		return ad, nil
+	}
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("凭据数据长度不足")
+	}
+	ad.AAGUID = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return nil, errors.New("凭据ID长度不足")
+	}
+	ad.CredentialID = rest[:idLen]
+	rest = rest[idLen:]
+	// 公钥之后可能紧跟扩展数据，按第一个 CBOR 数据项截取
+	var raw cbor.RawMessage
+	if _, err := cbor.UnmarshalFirst(rest, &raw); err != nil {
+		return nil, fmt.Errorf("解析凭据公钥失败: %w", err)
+	}
+	ad.PublicKey = raw
+	return ad, nil
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	var key coseKey
+	if err := cbor.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("解析凭据公钥失败: %w", err)
+	}
+	switch {
+	case key.Kty == coseKtyEC2 && key.Alg == AlgES256:
+		var crv int
+		if err := cbor.Unmarshal(key.P1, &crv); err != nil || crv != coseCrvP256 || len(key.P2) != 32 || len(key.P3) != 32 {
+			return nil, errors.New("不支持的椭圆曲线公钥")
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(key.P2), Y: new(big.Int).SetBytes(key.P3)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("椭圆曲线公钥无效")
+		}
+		return pub, nil
+	case key.Kty == coseKtyOKP && key.Alg == AlgEdDSA:
+		var crv int
+		if err := cbor.Unmarshal(key.P1, &crv); err != nil || crv != coseCrvEd25519 || len(key.P2) != ed25519.PublicKeySize {
+			return nil, errors.New("不支持的 EdDSA 公钥")
+		}
+		return ed25519.PublicKey(key.P2), nil
+	case key.Kty == coseKtyRSA && key.Alg == AlgRS256:
+		var n []byte
+		if err := cbor.Unmarshal(key.P1, &n); err != nil || len(n) < 256 || len(key.P2) == 0 || len(key.P2) > 4 {
+			return nil, errors.New("不支持的 RSA 公钥")
+		}
+		e := 0
+		for _, b := range key.P2 {
+			e = e<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: e}, nil
+	}
+	return nil, fmt.Errorf("不支持的公钥类型: kty=%d alg=%d", key.Kty, key.Alg)
+}
+
+func verifySignature(key crypto.PublicKey, signed, sig []byte) error {
+	digest := sha256.Sum256(signed)
+	ok := false
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest[:], sig)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, signed, sig)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	}
+	if !ok {
+		return errors.New("签名校验失败")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var testRP = &RelyingParty{ID: "k8m.example.com", Name: "k8m", Origin: "https://k8m.example.com"}
+
+func testClientData(t *testing.T, typ string, challenge []byte, origin string) []byte {
+	data, err := json.Marshal(clientData{Type: typ, Challenge: URLEncodedBase64(challenge).String(), Origin: origin})
+	if err != nil {
+		t.Fatalf("生成客户端数据失败: %v", err)
+	}
+	return data
+}
+
+func testAuthData(rpID string, flags byte, signCount uint32, credID, publicKey []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if credID != nil {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(credID)))
+		data = append(data, credID...)
+		data = append(data, publicKey...)
+	}
+	return data
+}
+
+func testRegister(t *testing.T, key *ecdsa.PrivateKey, challenge []byte) (*AttestationResponse, []byte) {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.PublicKey.X.FillBytes(x)
+	key.PublicKey.Y.FillBytes(y)
+	publicKey, err := cbor.Marshal(map[int]any{1: coseKtyEC2, 3: AlgES256, -1: coseCrvP256, -2: x, -3: y})
+	if err != nil {
+		t.Fatalf("编码公钥失败: %v", err)
+	}
+	credID := []byte("credential-1")
+	att, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": testAuthData(testRP.ID, flagUserPresent|flagUserVerified|flagAttestedCredData, 0, credID, publicKey),
+	})
+	if err != nil {
+		t.Fatalf("编码证明对象失败: %v", err)
+	}
+	resp := &AttestationResponse{RawID: credID, Type: "public-key"}
+	resp.Response.ClientDataJSON = testClientData(t, "webauthn.create", challenge, testRP.Origin)
+	resp.Response.AttestationObject = att
+	return resp, publicKey
+}
+
+func testAssert(t *testing.T, key *ecdsa.PrivateKey, challenge []byte, signCount uint32, origin string) *AssertionResponse {
+	resp := &AssertionResponse{RawID: []byte("credential-1"), Type: "public-key"}
+	resp.Response.ClientDataJSON = testClientData(t, "webauthn.get", challenge, origin)
+	resp.Response.AuthenticatorData = testAuthData(testRP.ID, flagUserPresent|flagUserVerified, signCount, nil, nil)
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, resp.Response.AuthenticatorData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	resp.Response.Signature = sig
+	return resp
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	challenge, _ := NewChallenge()
+	reg, publicKey := testRegister(t, key, challenge)
+
+	cred, err := testRP.VerifyRegistration(challenge, reg, true)
+	if err != nil {
+		t.Fatalf("校验注册失败: %v", err)
+	}
+	if string(cred.ID) != "credential-1" || string(cred.PublicKey) != string(publicKey) || !cred.UserVerified {
+		t.Errorf("凭据解析错误: %+v", cred)
+	}
+	other, _ := NewChallenge()
+	if _, err := testRP.VerifyRegistration(other, reg, true); err == nil {
+		t.Errorf("挑战值不匹配时注册应失败")
+	}
+
+	challenge, _ = NewChallenge()
+	count, err := testRP.VerifyAssertion(challenge, testAssert(t, key, challenge, 5, testRP.Origin), cred.PublicKey, 0, true)
+	if err != nil || count != 5 {
+		t.Fatalf("校验登录签名失败: %v", err)
+	}
+	if _, err := testRP.VerifyAssertion(challenge, testAssert(t, key, challenge, 5, testRP.Origin), cred.PublicKey, 5, true); err == nil {
+		t.Errorf("签名计数未递增时应失败")
+	}
+	if _, err := testRP.VerifyAssertion(challenge, testAssert(t, key, challenge, 6, "https://evil.example.com"), cred.PublicKey, 5, true); err == nil {
+		t.Errorf("来源不匹配时应失败")
+	}
+	tampered := testAssert(t, key, challenge, 7, testRP.Origin)
+	tampered.Response.AuthenticatorData[32] &^= flagUserVerified
+	if _, err := testRP.VerifyAssertion(challenge, tampered, cred.PublicKey, 5, false); err == nil {
+		t.Errorf("篡改认证器数据后签名校验应失败")
+	}
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := testRP.VerifyAssertion(challenge, testAssert(t, otherKey, challenge, 8, testRP.Origin), cred.PublicKey, 5, true); err == nil {
+		t.Errorf("其他密钥的签名应失败")
+	}
+}
@@ -2,5 +2,16 @@ package constants
 
 const (
 	JwtUserName = "username"
+	// JwtSessionID JWT 及 context 中保存登录会话ID的键，API 令牌不携带
+	JwtSessionID = "sid"
+	// JwtKeyID MCP Key 令牌中保存密钥ID的键
+	JwtKeyID = "kid"
+	// JwtScope 令牌的使用范围，为 JwtScopeMCP 时仅可访问 /mcp/ 接口
+	JwtScope    = "scope"
+	JwtScopeMCP = "mcp"
 	ClusterID   = "clusterID"
+	// AccessScope context 中保存访问范围限制的键，见 comm.AccessScope
+	AccessScope = "accessScope"
+	// PermissionVerb context 中保存本次操作业务动词的键，如 scale、restart，见 comm.WithPermissionVerb
+	PermissionVerb = "permissionVerb"
 )
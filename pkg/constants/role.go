@@ -5,6 +5,7 @@ package constants
 // 平台管理员拥有所有权限
 // 普通用户需要赋予集群角色，
 // 集群角色三种，集群管理员、集群只读、集群Pod内执行命令
+// 另可授予自定义角色，按资源类型、操作动词细粒度授权
 const (
 	RolePlatformAdmin = "platform_admin" // 平台管理员
 	RoleGuest         = "guest"          // 普通用户，只能登录，约等于游客,无任何集群权限，也看不到集群列表
@@ -12,6 +13,7 @@ const (
 	RoleClusterAdmin    = "cluster_admin"    // 集群管理员
 	RoleClusterReadonly = "cluster_readonly" // 集群只读权限
 	RoleClusterPodExec  = "cluster_pod_exec" // 集群Pod内执行命令权限
+	RoleClusterCustom   = "cluster_custom"   // 集群自定义角色，具体权限见 ClusterUserRole.CustomRole
 )
 
 // ClusterAuthorizationType 集群授权类型
@@ -0,0 +1,274 @@
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/agent"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"k8s.io/klog/v2"
+)
+
+// 默认的 Agent 镜像，k8m 镜像中同时包含 k8m-agent
+const defaultAgentImage = "registry.cn-hangzhou.aliyuncs.com/minik8m/k8m"
+
+// defaultAgentClusterRole Agent 默认绑定的 ClusterRole。k8m 经隧道以 Agent 的身份管理集群，
+// 权限不足的操作由 API Server 拒绝，只读纳管可改为 view 或自定义的 ClusterRole
+const defaultAgentClusterRole = "cluster-admin"
+
+var clusterRoleNameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9.:-]*[a-z0-9])?$`)
+
+// RegisterAgentTunnelRoutes 注册 Agent 反向隧道路由，不经过登录校验，由注册 Token 认证
+func RegisterAgentTunnelRoutes(r chi.Router) {
+	ctrl := &Controller{}
+	r.Get(strings.TrimPrefix(agent.ConnectPath, "/agent"), response.Adapter(ctrl.AgentConnect))
+}
+
+// @Summary Agent集群列表
+// @Description 获取通过Agent反向隧道纳管的集群
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/cluster/agent/list [get]
+func (a *Controller) AgentList(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.ClusterAgent{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存Agent集群
+// @Description 新增或更新Agent集群，新增后需生成部署清单并在目标集群中部署Agent
+// @Security BearerAuth
+// @Param request body models.ClusterAgent true "Agent集群信息"
+// @Success 200 {object} string
+// @Router /admin/cluster/agent/save [post]
+func (a *Controller) AgentSave(c *response.Context) {
+	var req models.ClusterAgent
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	req.Name = strings.NewReplacer("/", "-", "\\", "-", " ", "-").Replace(strings.TrimSpace(req.Name))
+	if req.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("集群名称不能为空"))
+		return
+	}
+	if req.TokenTTLHours < 0 {
+		amis.WriteJsonError(c, fmt.Errorf("Token 有效期不能小于0"))
+		return
+	}
+
+	if req.ID == 0 {
+		item := &models.ClusterAgent{
+			Name:          req.Name,
+			Description:   req.Description,
+			TokenTTLHours: req.TokenTTLHours,
+			Status:        models.ClusterAgentStatusOffline,
+			CreatedBy:     amis.GetLoginUser(c),
+		}
+		if err := dao.DB().Create(item).Error; err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	} else {
+		// 名称作为集群ID的一部分，创建后不允许修改
+		err := dao.DB().Model(&models.ClusterAgent{}).Where("id = ?", req.ID).Updates(map[string]any{
+			"description":     req.Description,
+			"token_ttl_hours": req.TokenTTLHours,
+		}).Error
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
+	service.ClusterService().ScanClustersInAgent()
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 生成Agent部署清单
+// @Description 重新生成注册Token并返回部署清单，旧Token立即失效。Token仅返回这一次
+// @Security BearerAuth
+// @Param id path int true "Agent ID"
+// @Param request body object false "{server_url: k8m访问地址, image: Agent镜像, cluster_role: Agent绑定的ClusterRole}"
+// @Success 200 {object} string
+// @Router /admin/cluster/agent/{id}/token [post]
+func (a *Controller) AgentToken(c *response.Context) {
+	var req struct {
+		ServerURL   string `json:"server_url,omitempty"`
+		Image       string `json:"image,omitempty"`
+		ClusterRole string `json:"cluster_role,omitempty"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	clusterRole := strings.TrimSpace(req.ClusterRole)
+	if clusterRole == "" {
+		clusterRole = defaultAgentClusterRole
+	}
+	if !clusterRoleNameRegexp.MatchString(clusterRole) {
+		amis.WriteJsonError(c, fmt.Errorf("ClusterRole 名称格式错误: %s", clusterRole))
+		return
+	}
+
+	id := utils.ToUInt(c.Param("id"))
+	item, token, err := service.AgentService().GenerateToken(id)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	serverURL := strings.TrimRight(strings.TrimSpace(req.ServerURL), "/")
+	if serverURL == "" {
+		serverURL = flag.Init().RequestOrigin(c.Request)
+	}
+	image := strings.TrimSpace(req.Image)
+	if image == "" {
+		version := flag.Init().Version
+		if version == "" {
+			version = "latest"
+		}
+		image = defaultAgentImage + ":" + version
+	}
+	klog.V(4).Infof("用户[%s]重新生成 Agent[%s] 注册 Token", amis.GetLoginUser(c), item.Name)
+	amis.WriteJsonData(c, response.H{
+		"name":       item.Name,
+		"token":      token,
+		"expires_at": item.ExpiresAt,
+		"server_url": serverURL,
+		"manifest":   agentManifest(serverURL, image, token, clusterRole),
+	})
+}
+
+// @Summary 删除Agent集群
+// @Description 删除Agent集群并断开隧道，目标集群中的Agent需手动卸载
+// @Security BearerAuth
+// @Param ids path string true "Agent ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/cluster/agent/delete/{ids} [post]
+func (a *Controller) AgentDelete(c *response.Context) {
+	ids := strings.Split(c.Param("ids"), ",")
+	if err := dao.DB().Where("id in ?", ids).Delete(&models.ClusterAgent{}).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.ClusterService().ScanClustersInAgent()
+	amis.WriteJsonOK(c)
+}
+
+// @Summary Agent建立反向隧道
+// @Description Agent使用注册Token通过WebSocket连接k8m，k8m经由该隧道访问目标集群API Server
+// @Param Authorization header string true "Bearer 注册Token"
+// @Success 101 {object} string
+// @Router /agent/connect [get]
+func (a *Controller) AgentConnect(c *response.Context) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.Request.Header.Get("Authorization"), "Bearer "))
+	item, err := service.AgentService().Authenticate(token)
+	if err != nil {
+		klog.V(4).Infof("Agent 连接被拒绝 %s: %v", c.Request.RemoteAddr, err)
+		c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			// Agent 不是浏览器，不校验来源
+			return true
+		},
+	}
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		klog.Errorf("Agent[%s] WebSocket Upgrade Error:%v", item.Name, err)
+		return
+	}
+	remoteAddr := flag.Init().ClientIP(c.Request)
+	if err := service.AgentService().Attach(item, agent.NewConn(ws), remoteAddr, c.Request.Header.Get(agent.HeaderVersion)); err != nil {
+		klog.Errorf("Agent[%s] 建立隧道失败: %v", item.Name, err)
+		_ = ws.Close()
+	}
+}
+
+// agentManifest 生成在目标集群中部署 Agent 的清单，Agent 使用绑定了 clusterRole 的 ServiceAccount 访问 API Server
+func agentManifest(serverURL, image, token, clusterRole string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: k8m-agent
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: k8m-agent
+  namespace: k8m-agent
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: k8m-agent
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: %s
+subjects:
+  - kind: ServiceAccount
+    name: k8m-agent
+    namespace: k8m-agent
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: k8m-agent
+  namespace: k8m-agent
+type: Opaque
+stringData:
+  token: %q
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: k8m-agent
+  namespace: k8m-agent
+  labels:
+    app: k8m-agent
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: k8m-agent
+  template:
+    metadata:
+      labels:
+        app: k8m-agent
+    spec:
+      serviceAccountName: k8m-agent
+      containers:
+        - name: agent
+          image: %s
+          command: ["/app/k8m-agent"]
+          env:
+            - name: K8M_SERVER
+              value: %q
+            - name: K8M_AGENT_TOKEN
+              valueFrom:
+                secretKeyRef:
+                  name: k8m-agent
+                  key: token
+          resources:
+            requests:
+              cpu: 50m
+              memory: 64Mi
+            limits:
+              memory: 256Mi
+`, clusterRole, token, image, serverURL)
+}
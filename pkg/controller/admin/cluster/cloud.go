@@ -0,0 +1,149 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+// SaveGKECluster
+// @Summary 保存GKE集群配置
+// @Description 使用服务账号JSON密钥纳管GKE集群，凭据加密保存，访问令牌自动续期
+// @Security BearerAuth
+// @Param request body object true "GKE配置信息"
+// @Success 200 {object} string "保存成功"
+// @Router /admin/cluster/gke/save [post]
+func (a *Controller) SaveGKECluster(c *response.Context) {
+	var req struct {
+		service.GKEAuthConfig
+		DisplayName string `json:"displayName"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		klog.V(6).Infof("绑定GKE请求参数失败: %v", err)
+		amis.WriteJsonError(c, err)
+		return
+	}
+	config := &req.GKEAuthConfig
+	config.ProjectID = strings.TrimSpace(config.ProjectID)
+	config.Location = strings.TrimSpace(config.Location)
+	config.ClusterName = strings.TrimSpace(config.ClusterName)
+
+	content, err := service.ClusterService().GenerateGKEKubeconfig(config)
+	if err != nil {
+		klog.V(6).Infof("生成GKE集群kubeconfig配置失败: %v", err)
+		amis.WriteJsonError(c, err)
+		return
+	}
+	kc := &models.KubeConfig{
+		ClusterName:   config.ClusterName,
+		Region:        config.Location,
+		CloudProvider: service.CloudProviderGKE,
+	}
+	if err := saveCloudCluster(c, kc, content, config, req.DisplayName); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	klog.V(4).Infof("成功保存GKE集群配置: %s [%s/%s/%s]", kc.DisplayName, config.ProjectID, config.Location, config.ClusterName)
+	amis.WriteJsonOKMsg(c, "GKE集群纳管成功")
+}
+
+// SaveAKSCluster
+// @Summary 保存AKS集群配置
+// @Description 使用Azure AD服务主体纳管AKS集群，凭据加密保存，访问令牌自动续期
+// @Security BearerAuth
+// @Param request body object true "AKS配置信息"
+// @Success 200 {object} string "保存成功"
+// @Router /admin/cluster/aks/save [post]
+func (a *Controller) SaveAKSCluster(c *response.Context) {
+	var req struct {
+		service.AKSAuthConfig
+		DisplayName string `json:"displayName"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		klog.V(6).Infof("绑定AKS请求参数失败: %v", err)
+		amis.WriteJsonError(c, err)
+		return
+	}
+	config := &req.AKSAuthConfig
+	config.TenantID = strings.TrimSpace(config.TenantID)
+	config.ClientID = strings.TrimSpace(config.ClientID)
+	config.SubscriptionID = strings.TrimSpace(config.SubscriptionID)
+	config.ResourceGroup = strings.TrimSpace(config.ResourceGroup)
+	config.ClusterName = strings.TrimSpace(config.ClusterName)
+
+	content, err := service.ClusterService().GenerateAKSKubeconfig(config)
+	if err != nil {
+		klog.V(6).Infof("生成AKS集群kubeconfig配置失败: %v", err)
+		amis.WriteJsonError(c, err)
+		return
+	}
+	kc := &models.KubeConfig{
+		ClusterName:   config.ClusterName,
+		CloudProvider: service.CloudProviderAKS,
+	}
+	if err := saveCloudCluster(c, kc, content, config, req.DisplayName); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	klog.V(4).Infof("成功保存AKS集群配置: %s [%s/%s]", kc.DisplayName, config.ResourceGroup, config.ClusterName)
+	amis.WriteJsonOKMsg(c, "AKS集群纳管成功")
+}
+
+// saveCloudCluster 保存云厂商集群，同一集群重复纳管时覆盖原有记录，认证配置整体加密保存
+func saveCloudCluster(c *response.Context, kc *models.KubeConfig, content string, credential any, displayName string) error {
+	params := dao.BuildParams(c)
+
+	config, err := clientcmd.Load([]byte(content))
+	if err != nil {
+		return fmt.Errorf("解析集群kubeconfig配置失败: %w", err)
+	}
+	context := config.Contexts[config.CurrentContext]
+	if context == nil || config.Clusters[context.Cluster] == nil {
+		return fmt.Errorf("集群kubeconfig配置缺少当前上下文")
+	}
+	cred, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+
+	kc.Cluster = context.Cluster
+	kc.Server = config.Clusters[context.Cluster].Server
+	kc.User = context.AuthInfo
+	kc.Content = content
+	kc.CloudCredential = string(cred)
+	kc.DisplayName = strings.NewReplacer("/", "-", "\\", "-", " ", "-").Replace(strings.TrimSpace(displayName))
+	if kc.DisplayName == "" {
+		kc.DisplayName = kc.ClusterName
+	}
+
+	existing := &models.KubeConfig{}
+	if old, err := existing.GetOne(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("server = ? AND cluster = ? AND cloud_provider = ?", kc.Server, kc.Cluster, kc.CloudProvider)
+	}); err == nil && old != nil {
+		// 保留原有的 kom 注册配置项
+		kc.ID = old.ID
+		kc.ProxyURL, kc.Timeout, kc.QPS, kc.Burst = old.ProxyURL, old.Timeout, old.QPS, old.Burst
+		kc.Impersonate = old.Impersonate
+		kc.CreatedAt = old.CreatedAt
+	}
+
+	if err := kc.Save(params); err != nil {
+		klog.V(6).Infof("保存云厂商集群 [%s]失败: %v", kc.Server, err)
+		return err
+	}
+
+	// 执行一下扫描
+	service.ClusterService().ScanClustersInDB()
+	return nil
+}
@@ -26,9 +26,25 @@ func RegisterAdminClusterRoutes(r chi.Router) {
 	r.Post("/cluster/kubeconfig/remove", response.Adapter(ctrl.RemoveKubeConfig))
 	r.Post("/cluster/{cluster}/disconnect", response.Adapter(ctrl.Disconnect))
 	r.Post("/cluster/aws/save", response.Adapter(ctrl.SaveAWSEKSCluster))
+	r.Post("/cluster/gke/save", response.Adapter(ctrl.SaveGKECluster))
+	r.Post("/cluster/aks/save", response.Adapter(ctrl.SaveAKSCluster))
 	r.Post("/cluster/token/save", response.Adapter(ctrl.SaveTokenCluster))
 	r.Get("/cluster/config/{id}", response.Adapter(ctrl.GetClusterConfig))
 	r.Post("/cluster/config/save", response.Adapter(ctrl.SaveClusterConfig))
+	r.Get("/cluster/agent/list", response.Adapter(ctrl.AgentList))
+	r.Post("/cluster/agent/save", response.Adapter(ctrl.AgentSave))
+	r.Post("/cluster/agent/{id}/token", response.Adapter(ctrl.AgentToken))
+	r.Post("/cluster/agent/delete/{ids}", response.Adapter(ctrl.AgentDelete))
+	r.Post("/cluster/{cluster}/labels/save", response.Adapter(ctrl.SaveLabels))
+	r.Get("/cluster/group/list", response.Adapter(ctrl.GroupList))
+	r.Get("/cluster/group/option_list", response.Adapter(ctrl.GroupOptionList))
+	r.Post("/cluster/group/save", response.Adapter(ctrl.GroupSave))
+	r.Post("/cluster/group/delete/{ids}", response.Adapter(ctrl.GroupDelete))
+	r.Get("/cluster/group/{id}/clusters", response.Adapter(ctrl.GroupClusters))
+	r.Post("/cluster/fleet/match", response.Adapter(ctrl.FleetMatch))
+	r.Post("/cluster/fleet/apply", response.Adapter(ctrl.FleetApply))
+	r.Post("/cluster/fleet/restart", response.Adapter(ctrl.FleetRestart))
+	r.Post("/cluster/fleet/get", response.Adapter(ctrl.FleetGet))
 }
 
 // RegisterUserClusterRoutes 注册用户集群路由
@@ -147,11 +163,13 @@ func (a *Controller) GetClusterConfig(c *response.Context) {
 
 	// 只返回配置相关的字段
 	configData := map[string]any{
-		"id":       config.ID,
-		"proxyURL": config.ProxyURL,
-		"timeout":  config.Timeout,
-		"qps":      config.QPS,
-		"burst":    config.Burst,
+		"id":          config.ID,
+		"proxyURL":    config.ProxyURL,
+		"timeout":     config.Timeout,
+		"qps":         config.QPS,
+		"burst":       config.Burst,
+		"impersonate": config.Impersonate,
+		"is_aws_eks":  config.IsAWSEKS,
 	}
 
 	amis.WriteJsonData(c, configData)
@@ -169,11 +187,12 @@ func (a *Controller) GetClusterConfig(c *response.Context) {
 // @Router /admin/cluster/config/save [post]
 func (a *Controller) SaveClusterConfig(c *response.Context) {
 	var configData struct {
-		ID       uint    `json:"id" binding:"required"`
-		ProxyURL string  `json:"proxyURL"`
-		Timeout  int     `json:"timeout"`
-		QPS      float32 `json:"qps"`
-		Burst    int     `json:"burst"`
+		ID          uint    `json:"id" binding:"required"`
+		ProxyURL    string  `json:"proxyURL"`
+		Timeout     int     `json:"timeout"`
+		QPS         float32 `json:"qps"`
+		Burst       int     `json:"burst"`
+		Impersonate bool    `json:"impersonate"` // 用户模拟模式
 	}
 
 	if err := c.ShouldBindJSON(&configData); err != nil {
@@ -198,6 +217,11 @@ func (a *Controller) SaveClusterConfig(c *response.Context) {
 	config.Timeout = configData.Timeout
 	config.QPS = configData.QPS
 	config.Burst = configData.Burst
+	if configData.Impersonate && config.IsAWSEKS {
+		amis.WriteJsonError(c, errors.New("AWS EKS 集群暂不支持用户模拟模式"))
+		return
+	}
+	config.Impersonate = configData.Impersonate
 
 	// 保存更新
 	if err := config.Save(params); err != nil {
@@ -206,7 +230,7 @@ func (a *Controller) SaveClusterConfig(c *response.Context) {
 	}
 
 	// 更新已加载集群的配置参数
-	if err := service.ClusterService().UpdateClusterConfig(configData.ID, configData.ProxyURL, configData.Timeout, configData.QPS, configData.Burst); err != nil {
+	if err := service.ClusterService().UpdateClusterConfig(configData.ID, configData.ProxyURL, configData.Timeout, configData.QPS, configData.Burst, configData.Impersonate); err != nil {
 		// 记录错误但不影响保存操作的成功响应
 		// 因为数据库已经保存成功，只是内存中的集群配置更新失败
 		// 下次重新扫描时会自动同步
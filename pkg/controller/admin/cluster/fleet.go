@@ -0,0 +1,159 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"github.com/weibaohui/kom/kom"
+	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// fleetRequest 批量操作的公共参数
+type fleetRequest struct {
+	Selector  string `json:"selector"` // 集群标签选择器
+	Yaml      string `json:"yaml,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// @Summary 按标签选择器匹配集群
+// @Description 预览批量操作将作用的集群
+// @Security BearerAuth
+// @Param request body object true "{selector: 标签选择器}"
+// @Success 200 {object} string
+// @Router /admin/cluster/fleet/match [post]
+func (a *Controller) FleetMatch(c *response.Context) {
+	var req fleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	clusters, err := service.ClusterService().MatchClusters(req.Selector)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonList(c, clusterBriefs(clusters))
+}
+
+// @Summary 批量应用YAML
+// @Description 在标签选择器匹配的全部集群上应用YAML，按集群返回结果
+// @Security BearerAuth
+// @Param request body object true "{selector: 标签选择器, yaml: YAML内容}"
+// @Success 200 {object} string
+// @Router /admin/cluster/fleet/apply [post]
+func (a *Controller) FleetApply(c *response.Context) {
+	var req fleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if strings.TrimSpace(req.Yaml) == "" {
+		amis.WriteJsonError(c, fmt.Errorf("YAML 不能为空"))
+		return
+	}
+	ctx := amis.GetContextWithUser(c)
+	a.writeFleetResults(c, req.Selector, "apply", func(cluster string) (any, error) {
+		result := kom.Cluster(cluster).WithContext(ctx).Applier().Apply(req.Yaml)
+		for _, line := range result {
+			if applyFailed(line) {
+				return result, fmt.Errorf("%s", line)
+			}
+		}
+		return result, nil
+	})
+}
+
+// applyFailed 判断 Applier 返回的单条结果是否失败。结果格式为「类型/名称 消息」，
+// 只检查名称之后的消息，避免名称中包含 error 的资源（如 deployment/error-pages）被误判；YAML 解析失败时整行即为消息
+func applyFailed(line string) bool {
+	msg := line
+	if ref, rest, ok := strings.Cut(strings.TrimSpace(line), " "); ok && strings.Contains(ref, "/") {
+		msg = rest
+	}
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "error") || strings.Contains(msg, "失败")
+}
+
+// @Summary 批量重启Deployment
+// @Description 在标签选择器匹配的全部集群上重启指定名称的Deployment，按集群返回结果
+// @Security BearerAuth
+// @Param request body object true "{selector: 标签选择器, namespace: 命名空间, name: 名称}"
+// @Success 200 {object} string
+// @Router /admin/cluster/fleet/restart [post]
+func (a *Controller) FleetRestart(c *response.Context) {
+	var req fleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.Namespace == "" || req.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("命名空间与名称不能为空"))
+		return
+	}
+	ctx := amis.GetContextWithUser(c)
+	a.writeFleetResults(c, req.Selector, "restart", func(cluster string) (any, error) {
+		err := kom.Cluster(cluster).WithContext(ctx).Resource(&v1.Deployment{}).Namespace(req.Namespace).Name(req.Name).
+			Ctl().Rollout().Restart()
+		return nil, err
+	})
+}
+
+// @Summary 批量读取资源
+// @Description 在标签选择器匹配的全部集群上读取指定资源，按集群返回YAML
+// @Security BearerAuth
+// @Param request body object true "{selector: 标签选择器, group: 组, version: 版本, kind: 类型, namespace: 命名空间, name: 名称}"
+// @Success 200 {object} string
+// @Router /admin/cluster/fleet/get [post]
+func (a *Controller) FleetGet(c *response.Context) {
+	var req fleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.Version == "" || req.Kind == "" || req.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("版本、类型与名称不能为空"))
+		return
+	}
+	ctx := amis.GetContextWithUser(c)
+	a.writeFleetResults(c, req.Selector, "get", func(cluster string) (any, error) {
+		var obj *unstructured.Unstructured
+		err := kom.Cluster(cluster).WithContext(ctx).RemoveManagedFields().Name(req.Name).Namespace(req.Namespace).
+			CRD(req.Group, req.Version, req.Kind).Get(&obj).Error
+		if err != nil {
+			return nil, err
+		}
+		data, err := yaml.Marshal(obj.Object)
+		return string(data), err
+	})
+}
+
+// writeFleetResults 执行批量操作并按集群汇总输出
+func (a *Controller) writeFleetResults(c *response.Context, selector string, action string, fn func(cluster string) (any, error)) {
+	klog.V(4).Infof("用户[%s]执行批量操作[%s]，选择器 %s", amis.GetLoginUser(c), action, selector)
+	results, err := service.ClusterService().RunOnClusters(selector, fn)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	summary := map[string]int{}
+	for _, r := range results {
+		summary[r.Status]++
+	}
+	amis.WriteJsonData(c, response.H{
+		"rows":    results,
+		"total":   len(results),
+		"success": summary[service.FleetStatusSuccess],
+		"failed":  summary[service.FleetStatusFailed],
+		"skipped": summary[service.FleetStatusSkipped],
+	})
+}
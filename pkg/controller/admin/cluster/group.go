@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// @Summary 保存集群标签
+// @Description 设置集群标签，形如 env=prod,region=eu，为空时清除标签
+// @Security BearerAuth
+// @Param cluster path string true "集群标识（MD5）"
+// @Param request body object true "{labels: 标签}"
+// @Success 200 {object} string
+// @Router /admin/cluster/{cluster}/labels/save [post]
+func (a *Controller) SaveLabels(c *response.Context) {
+	var req struct {
+		Labels string `json:"labels"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	clusterID, err := service.ClusterService().ResolveClusterID(c.Param("cluster"))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	err = service.ClusterService().SaveClusterLabels(clusterID, req.Labels, amis.GetLoginUser(c))
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 集群分组列表
+// @Description 获取集群分组，分组通过标签选择器动态匹配集群
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/cluster/group/list [get]
+func (a *Controller) GroupList(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.ClusterGroup{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 集群分组选项列表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/cluster/group/option_list [get]
+func (a *Controller) GroupOptionList(c *response.Context) {
+	var list []*models.ClusterGroup
+	if err := dao.DB().Model(&models.ClusterGroup{}).Order("name asc").Find(&list).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	options := make([]map[string]string, 0, len(list))
+	for _, item := range list {
+		options = append(options, map[string]string{
+			"label": fmt.Sprintf("%s（%s）", item.Name, item.Selector),
+			"value": item.Name,
+		})
+	}
+	amis.WriteJsonData(c, response.H{
+		"options": options,
+	})
+}
+
+// @Summary 保存集群分组
+// @Description 新增或更新集群分组，分组名称创建后不允许修改
+// @Security BearerAuth
+// @Param request body models.ClusterGroup true "集群分组"
+// @Success 200 {object} string
+// @Router /admin/cluster/group/save [post]
+func (a *Controller) GroupSave(c *response.Context) {
+	var req models.ClusterGroup
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Selector = strings.TrimSpace(req.Selector)
+	if req.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("分组名称不能为空"))
+		return
+	}
+	if _, err := service.ParseClusterSelector(req.Selector); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	var err error
+	if req.ID == 0 {
+		req.CreatedBy = amis.GetLoginUser(c)
+		err = dao.DB().Create(&req).Error
+	} else {
+		// 名称被集群授权引用，创建后不允许修改
+		err = dao.DB().Model(&models.ClusterGroup{}).Where("id = ?", req.ID).Updates(map[string]any{
+			"selector":    req.Selector,
+			"description": req.Description,
+		}).Error
+	}
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除集群分组
+// @Description 删除集群分组，同时删除授权给该分组的集群权限
+// @Security BearerAuth
+// @Param ids path string true "分组ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/cluster/group/delete/{ids} [post]
+func (a *Controller) GroupDelete(c *response.Context) {
+	ids := strings.Split(c.Param("ids"), ",")
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		var names []string
+		if err := tx.Model(&models.ClusterGroup{}).Where("id in ?", ids).Pluck("name", &names).Error; err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			if err := tx.Where("cluster_group in ?", names).Delete(&models.ClusterUserRole{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id in ?", ids).Delete(&models.ClusterGroup{}).Error
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 集群分组匹配的集群
+// @Security BearerAuth
+// @Param id path int true "分组ID"
+// @Success 200 {object} string
+// @Router /admin/cluster/group/{id}/clusters [get]
+func (a *Controller) GroupClusters(c *response.Context) {
+	var group models.ClusterGroup
+	if err := dao.DB().First(&group, utils.ToUInt(c.Param("id"))).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	clusters, err := service.ClusterService().MatchClusters(group.Selector)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonList(c, clusterBriefs(clusters))
+}
+
+// clusterBriefs 集群概要信息，用于展示分组或选择器匹配结果
+func clusterBriefs(clusters []*service.ClusterConfig) []map[string]any {
+	all := service.ClusterService().ClusterLabels()
+	items := make([]map[string]any, 0, len(clusters))
+	for _, cc := range clusters {
+		id := cc.GetClusterID()
+		items = append(items, map[string]any{
+			"cluster":   id,
+			"labels":    all[id].String(),
+			"source":    cc.Source,
+			"connected": cc.ClusterConnectStatus == constants.ClusterConnectStatusConnected,
+		})
+	}
+	return items
+}
@@ -1,11 +1,14 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/k8m/pkg/service"
+	"k8s.io/klog/v2"
 )
 
 type Controller struct {
@@ -15,6 +18,7 @@ func RegisterConfigRoutes(r chi.Router) {
 	ctrl := &Controller{}
 	r.Get("/config/all", response.Adapter(ctrl.All))
 	r.Post("/config/update", response.Adapter(ctrl.Update))
+	r.Post("/config/secret/rotate", response.Adapter(ctrl.RotateSecretKey))
 }
 
 // @Summary 获取系统配置
@@ -27,6 +31,7 @@ func (cc *Controller) All(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	config.JwtTokenSecret = models.MaskSecret(config.JwtTokenSecret)
 	amis.WriteJsonData(c, config)
 }
 
@@ -42,9 +47,34 @@ func (cc *Controller) Update(c *response.Context) {
 		return
 	}
 
+	// 密钥不回显，未修改时沿用原值
+	if config.JwtTokenSecret == models.SecretMask {
+		old, err := service.ConfigService().GetConfig()
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		config.JwtTokenSecret = old.JwtTokenSecret
+	}
+
 	if err := service.ConfigService().UpdateConfig(&config); err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
 	amis.WriteJsonOK(c)
 }
+
+// @Summary 轮换数据加密密钥
+// @Description 生成新的数据密钥，并重新加密数据库中全部敏感字段
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/config/secret/rotate [post]
+func (cc *Controller) RotateSecretKey(c *response.Context) {
+	count, err := models.RotateDataKey()
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	klog.V(4).Infof("用户[%s]轮换数据加密密钥，重新加密 %d 个字段", amis.GetLoginUser(c), count)
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("轮换完成，重新加密 %d 个字段", count))
+}
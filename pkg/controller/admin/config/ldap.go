@@ -1,7 +1,6 @@
 package config
 
 import (
-	"encoding/base64"
 	"fmt"
 	"net/http"
 
@@ -12,6 +11,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
 	"gorm.io/gorm"
 	"k8s.io/klog/v2"
 )
@@ -30,6 +30,7 @@ func RegisterLdapConfigRoutes(r chi.Router) {
 	r.Post("/config/ldap/delete/{ids}", response.Adapter(ctrl.LDAPConfigDelete))
 	r.Post("/config/ldap/save/id/{id}/status/{enabled}", response.Adapter(ctrl.LDAPConfigQuickSave))
 	r.Post("/config/ldap/test_connect", response.Adapter(ctrl.LDAPConfigTestConnect))
+	r.Post("/config/ldap/sync/{id}", response.Adapter(ctrl.LDAPConfigSync))
 }
 
 // LDAP配置列表
@@ -63,6 +64,7 @@ func (lc *LdapConfigController) LDAPConfigDetail(c *response.Context) {
 		c.JSON(http.StatusNotFound, response.H{"status": 1, "msg": "未找到配置"})
 		return
 	}
+	conf.BindPassword = ""
 	c.JSON(http.StatusOK, response.H{"status": 0, "msg": "ok", "data": conf})
 }
 
@@ -76,39 +78,18 @@ func (lc *LdapConfigController) LDAPConfigSave(c *response.Context) {
 		return
 	}
 
-	// 判断是新建还是编辑
-	if m.ID > 0 {
-		// 编辑，若未填写密码则保留原密码
+	// 编辑，若未填写密码则保留原密码。密码由模型加密存储
+	if m.ID > 0 && m.BindPassword == "" {
 		var old models.LDAPConfig
 		if err := dao.DB().Where("id = ?", m.ID).First(&old).Error; err == nil {
-			if m.BindPassword == "" {
-				// 保留原加密密码
-				m.BindPassword = old.BindPassword
-			} else if m.BindPassword != old.BindPassword {
-				// 仅当填写新密码时才加密
-				encrypted, err := utils.AesEncrypt([]byte(m.BindPassword))
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, response.H{"status": 1, "msg": "密码加密失败"})
-					return
-				}
-				m.BindPassword = base64.StdEncoding.EncodeToString(encrypted)
-			}
-		}
-	} else {
-		// 新增配置时也要对密码进行加密
-		if m.BindPassword != "" {
-			encrypted, err := utils.AesEncrypt([]byte(m.BindPassword))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, response.H{"status": 1, "msg": "密码加密失败"})
-				return
-			}
-			m.BindPassword = base64.StdEncoding.EncodeToString(encrypted)
+			m.BindPassword = old.BindPassword
 		}
 	}
 
 	// 保存数据库，仅更新指定字段，避免覆盖其他字段
 	err = m.Save(params, func(db *gorm.DB) *gorm.DB {
-		return db.Select([]string{"name", "host", "port", "bind_dn", "bind_password", "base_dn", "user_filter", "login2_auth_close", "default_group", "enabled"})
+		return db.Select([]string{"name", "host", "port", "bind_dn", "bind_password", "base_dn", "user_filter", "login2_auth_close", "default_group", "enabled",
+			"group_attribute", "sync_enabled", "sync_interval", "sync_max_missing"})
 	})
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -177,6 +158,7 @@ func (lc *LdapConfigController) GetLdapConfig(c *response.Context) {
 // 测试LDAP连接
 func (lc *LdapConfigController) LDAPConfigTestConnect(c *response.Context) {
 	type Req struct {
+		ID           uint   `json:"id"`
 		Host         string `json:"host"`
 		Port         int    `json:"port"`
 		BindDN       string `json:"bind_dn"`
@@ -189,6 +171,13 @@ func (lc *LdapConfigController) LDAPConfigTestConnect(c *response.Context) {
 		c.JSON(http.StatusBadRequest, response.H{"status": 1, "msg": "参数错误"})
 		return
 	}
+	// 编辑已有配置时密码不回显，未填写则使用已保存的密码
+	if req.ID > 0 && req.BindPassword == "" {
+		var old models.LDAPConfig
+		if err := dao.DB().Where("id = ?", req.ID).First(&old).Error; err == nil {
+			req.BindPassword = old.BindPassword
+		}
+	}
 
 	addr := fmt.Sprintf("%s:%d", req.Host, req.Port)
 	conn, err := ldap.Dial("tcp", addr)
@@ -199,24 +188,26 @@ func (lc *LdapConfigController) LDAPConfigTestConnect(c *response.Context) {
 	}
 	defer conn.Close()
 
-	// 先尝试用明文密码绑定
 	if err := conn.Bind(req.BindDN, req.BindPassword); err == nil {
 		c.JSON(http.StatusOK, response.H{"status": 0, "msg": "连接成功"})
 		return
 	}
 
-	// 如果明文失败，再尝试解密（兼容编辑后密文场景）
-	decryptedPwd := req.BindPassword
-	if req.BindPassword != "" {
-		if plain, err := utils.AesDecrypt(req.BindPassword); err == nil {
-			decryptedPwd = string(plain)
-			if err := conn.Bind(req.BindDN, decryptedPwd); err == nil {
-				c.JSON(http.StatusOK, response.H{"status": 0, "msg": "连接成功"})
-				return
-			}
-		}
-	}
-
 	klog.Errorf("管理员账号或密码错误")
 	c.JSON(http.StatusOK, response.H{"status": 1, "msg": "管理员账号或密码错误"})
 }
+
+// LDAPConfigSync 立即同步LDAP用户：禁用目录中已删除的用户，并按用户组映射规则同步用户组与集群授权
+func (lc *LdapConfigController) LDAPConfigSync(c *response.Context) {
+	var config models.LDAPConfig
+	if err := dao.DB().Where("id = ?", c.Param("id")).First(&config).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	msg, err := service.GroupMappingService().SyncLdap(&config)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, msg)
+}
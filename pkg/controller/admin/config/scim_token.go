@@ -0,0 +1,102 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+type ScimTokenController struct {
+}
+
+// RegisterScimTokenRoutes 注册 SCIM 令牌管理路由
+func RegisterScimTokenRoutes(r chi.Router) {
+	ctrl := &ScimTokenController{}
+	r.Get("/config/scim_token/list", response.Adapter(ctrl.List))
+	r.Post("/config/scim_token/create", response.Adapter(ctrl.Create))
+	r.Post("/config/scim_token/delete/{ids}", response.Adapter(ctrl.Delete))
+	r.Post("/config/scim_token/save/id/{id}/status/{enabled}", response.Adapter(ctrl.QuickSave))
+}
+
+// @Summary 获取SCIM令牌列表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/config/scim_token/list [get]
+func (sc *ScimTokenController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.SCIMToken{}
+
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 创建SCIM令牌
+// @Description 令牌仅在创建时返回一次，请妥善保存
+// @Security BearerAuth
+// @Param request body models.SCIMToken true "令牌名称与描述"
+// @Success 200 {object} string
+// @Router /admin/config/scim_token/create [post]
+func (sc *ScimTokenController) Create(c *response.Context) {
+	var req models.SCIMToken
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	item, token, err := service.ScimService().GenerateToken(strings.TrimSpace(req.Name), req.Description, amis.GetLoginUser(c))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, response.H{
+		"id":       item.ID,
+		"token":    token,
+		"endpoint": flag.Init().RequestOrigin(c.Request) + "/scim/v2",
+	})
+}
+
+// @Summary 删除SCIM令牌
+// @Description 删除后使用该令牌的同步请求立即失效
+// @Security BearerAuth
+// @Param ids path string true "令牌ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/config/scim_token/delete/{ids} [post]
+func (sc *ScimTokenController) Delete(c *response.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	m := &models.SCIMToken{}
+
+	if err := m.Delete(params, ids); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 快速更新SCIM令牌状态
+// @Security BearerAuth
+// @Param id path int true "令牌ID"
+// @Param enabled path string true "状态，例如：true、false"
+// @Success 200 {object} string
+// @Router /admin/config/scim_token/save/id/{id}/status/{enabled} [post]
+func (sc *ScimTokenController) QuickSave(c *response.Context) {
+	var entity models.SCIMToken
+	entity.ID = utils.ToUInt(c.Param("id"))
+	entity.Enabled = c.Param("enabled") == "true"
+
+	if err := dao.DB().Model(&entity).Select("enabled").Updates(entity).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
@@ -1,10 +1,18 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/comm/utils/saml"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"gorm.io/gorm"
@@ -35,6 +43,10 @@ func (sc *SSOConfigController) List(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 客户端密钥不回显
+	for _, item := range items {
+		item.ClientSecret = models.MaskSecret(item.ClientSecret)
+	}
 	amis.WriteJsonListWithTotal(c, total, items)
 }
 
@@ -51,8 +63,26 @@ func (sc *SSOConfigController) Save(c *response.Context) {
 		return
 	}
 
+	// 编辑时未修改客户端密钥，沿用原值
+	if m.ID > 0 && m.ClientSecret == models.SecretMask {
+		var old models.SSOConfig
+		if err := dao.DB().Where("id = ?", m.ID).First(&old).Error; err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		m.ClientSecret = old.ClientSecret
+	}
+
+	if m.Type == "saml" {
+		if err := importSAMLMetadata(c.Request.Context(), &m); err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
+
 	err = m.Save(params, func(db *gorm.DB) *gorm.DB {
-		return db.Select([]string{"name", "type", "client_id", "client_secret", "issuer", "prefer_user_name_keys", "scopes"})
+		return db.Select([]string{"name", "type", "client_id", "client_secret", "issuer", "prefer_user_name_keys", "scopes",
+			"idp_metadata_url", "idp_metadata", "sp_entity_id", "groups_attribute"})
 	})
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -107,3 +137,37 @@ func (sc *SSOConfigController) QuickSave(c *response.Context) {
 	}
 	amis.WriteJsonErrorOrOK(c, err)
 }
+
+// importSAMLMetadata 配置了元数据地址时下载 IdP 元数据，校验后保存，认证服务器显示为 IdP 实体ID
+func importSAMLMetadata(ctx context.Context, m *models.SSOConfig) error {
+	if m.IdpMetadataURL != "" {
+		ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.IdpMetadataURL, nil)
+		if err != nil {
+			return fmt.Errorf("IdP 元数据地址错误: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("下载 IdP 元数据失败: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("下载 IdP 元数据失败: HTTP %d", resp.StatusCode)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+		if err != nil {
+			return fmt.Errorf("下载 IdP 元数据失败: %w", err)
+		}
+		m.IdpMetadata = string(data)
+	}
+	if strings.TrimSpace(m.IdpMetadata) == "" {
+		return fmt.Errorf("请填写 IdP 元数据地址或粘贴 IdP 元数据")
+	}
+	idp, err := saml.ParseIDPMetadata([]byte(m.IdpMetadata))
+	if err != nil {
+		return err
+	}
+	m.Issuer = idp.EntityID
+	return nil
+}
@@ -31,6 +31,8 @@ func RegisterClusterPermissionRoutes(r chi.Router) {
 	r.Get("/cluster_permissions/cluster/{cluster}/list", response.Adapter(ctrl.ListClusterPermissionsByClusterID))      // 列出指定集群下所有授权情况
 	r.Get("/cluster_permissions/cluster/{cluster}/ns/list", response.Adapter(ctrl.ListClusterNamespaceListByClusterID)) // 列出指定集群下所有授权情况
 	r.Post("/cluster_permissions/cluster/{cluster}/role/{role}/{authorization_type}/save", response.Adapter(ctrl.SaveClusterPermission))
+	r.Get("/cluster_permissions/group/{group}/list", response.Adapter(ctrl.ListClusterPermissionsByGroup))
+	r.Post("/cluster_permissions/group/{group}/role/{role}/{authorization_type}/save", response.Adapter(ctrl.SaveClusterGroupPermission))
 	r.Post("/cluster_permissions/delete/{ids}", response.Adapter(ctrl.DeleteClusterPermission))
 	r.Post("/cluster_permissions/update_namespaces/{id}", response.Adapter(ctrl.UpdateNamespaces))
 	r.Post("/cluster_permissions/update_blacklist_namespaces/{id}", response.Adapter(ctrl.UpdateBlacklistNamespaces))
@@ -162,13 +164,64 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	template := models.ClusterUserRole{
+		Cluster: cluster,
+		Role:    role,
+	}
+	a.saveClusterPermissions(c, template, authorizationType)
+}
+
+// @Summary 批量为集群分组添加用户角色权限
+// @Description 授权作用于分组通过标签选择器匹配的全部集群，集群标签变化后自动生效
+// @Security BearerAuth
+// @Param group path string true "集群分组名称"
+// @Param role path string true "角色"
+// @Param authorization_type path string true "授权类型"
+// @Success 200 {object} string
+// @Router /admin/cluster_permissions/group/{group}/role/{role}/{authorization_type}/save [post]
+func (a *AdminClusterPermission) SaveClusterGroupPermission(c *response.Context) {
+	group := c.Param("group")
+	if err := dao.DB().Where("name = ?", group).First(&models.ClusterGroup{}).Error; err != nil {
+		amis.WriteJsonError(c, fmt.Errorf("集群分组[%s]不存在", group))
+		return
+	}
+	template := models.ClusterUserRole{
+		ClusterGroup: group,
+		Role:         c.Param("role"),
+	}
+	a.saveClusterPermissions(c, template, c.Param("authorization_type"))
+}
+
+// @Summary 获取指定集群分组下所有用户的权限角色列表
+// @Security BearerAuth
+// @Param group path string true "集群分组名称"
+// @Success 200 {object} string
+// @Router /admin/cluster_permissions/group/{group}/list [get]
+func (a *AdminClusterPermission) ListClusterPermissionsByGroup(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.ClusterUserRole{}
+	m.ClusterGroup = c.Param("group")
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where(m).Order("authorization_type desc ,username asc")
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// saveClusterPermissions 按模板为请求中的用户（或用户组）逐个添加授权，已存在的条目跳过
+func (a *AdminClusterPermission) saveClusterPermissions(c *response.Context, template models.ClusterUserRole, authorizationType string) {
 	// {"users":"lisi,no2fa,test"}
+	// 自定义角色授权时需指定角色名称 {"users":"lisi","custom_role":"deploy-operator"}
 	type requestBody struct {
-		Users string `json:"users"`
+		Users      string `json:"users"`
+		CustomRole string `json:"custom_role"`
 	}
 	var userList requestBody
 
-	err = c.ShouldBindJSON(&userList)
+	err := c.ShouldBindJSON(&userList)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
@@ -178,6 +231,17 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *response.Context) {
 		amis.WriteJsonError(c, fmt.Errorf("用户列表不能为空"))
 		return
 	}
+	if template.Role == constants.RoleClusterCustom {
+		if userList.CustomRole == "" {
+			amis.WriteJsonError(c, fmt.Errorf("请选择自定义角色"))
+			return
+		}
+		if err := dao.DB().Where("name = ?", userList.CustomRole).First(&models.CustomRole{}).Error; err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("自定义角色[%s]不存在", userList.CustomRole))
+			return
+		}
+		template.CustomRole = userList.CustomRole
+	}
 
 	params := dao.BuildParams(c)
 
@@ -186,9 +250,7 @@ func (a *AdminClusterPermission) SaveClusterPermission(c *response.Context) {
 		authorizationType = "user"
 	}
 	for _, username := range strings.Split(userList.Users, ",") {
-		var m models.ClusterUserRole
-		m.Cluster = cluster
-		m.Role = role
+		m := template
 		m.Username = username
 		m.AuthorizationType = constants.ClusterAuthorizationType(authorizationType)
 		one, err := m.GetOne(params, func(db *gorm.DB) *gorm.DB {
@@ -0,0 +1,150 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+type AdminCustomRoleController struct{}
+
+// AdminCustomRoleController 用于自定义集群角色相关接口
+
+func RegisterCustomRoleRoutes(r chi.Router) {
+	ctrl := &AdminCustomRoleController{}
+	r.Get("/custom_role/list", response.Adapter(ctrl.List))
+	r.Get("/custom_role/option_list", response.Adapter(ctrl.OptionList))
+	r.Post("/custom_role/save", response.Adapter(ctrl.Save))
+	r.Post("/custom_role/delete/{ids}", response.Adapter(ctrl.Delete))
+}
+
+// @Summary 获取自定义角色列表
+// @Security BearerAuth
+// @Success 200 {object} []models.CustomRole
+// @Router /admin/custom_role/list [get]
+func (a *AdminCustomRoleController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.CustomRole{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 自定义角色选项列表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/custom_role/option_list [get]
+func (a *AdminCustomRoleController) OptionList(c *response.Context) {
+	var list []*models.CustomRole
+	if err := dao.DB().Model(&models.CustomRole{}).Order("name asc").Find(&list).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	options := make([]map[string]string, 0, len(list))
+	for _, item := range list {
+		label := item.Name
+		if item.Description != "" {
+			label = fmt.Sprintf("%s（%s）", item.Name, item.Description)
+		}
+		options = append(options, map[string]string{
+			"label": label,
+			"value": item.Name,
+		})
+	}
+	amis.WriteJsonData(c, response.H{
+		"options": options,
+	})
+}
+
+// @Summary 保存自定义角色
+// @Description 新增或更新自定义角色，角色名称被集群授权引用，创建后不允许修改
+// @Security BearerAuth
+// @Param request body models.CustomRole true "自定义角色"
+// @Success 200 {object} string
+// @Router /admin/custom_role/save [post]
+func (a *AdminCustomRoleController) Save(c *response.Context) {
+	var req models.CustomRole
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.ID == 0 && req.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("角色名称不能为空"))
+		return
+	}
+	if len(req.Rules) == 0 {
+		amis.WriteJsonError(c, fmt.Errorf("至少需要一条规则"))
+		return
+	}
+	for i := range req.Rules {
+		if err := req.Rules[i].Normalize(); err != nil {
+			amis.WriteJsonError(c, fmt.Errorf("第%d条规则: %w", i+1, err))
+			return
+		}
+	}
+
+	var err error
+	if req.ID == 0 {
+		req.CreatedBy = amis.GetLoginUser(c)
+		err = dao.DB().Create(&req).Error
+	} else {
+		err = dao.DB().Model(&models.CustomRole{ID: req.ID}).Select("description", "rules").Updates(&models.CustomRole{
+			Description: req.Description,
+			Rules:       req.Rules,
+		}).Error
+	}
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("custom_role")
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除自定义角色
+// @Description 已被集群授权引用的角色不允许删除
+// @Security BearerAuth
+// @Param ids path string true "角色ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/custom_role/delete/{ids} [post]
+func (a *AdminCustomRoleController) Delete(c *response.Context) {
+	ids := utils.ToInt64Slice(c.Param("ids"))
+
+	var names []string
+	if err := dao.DB().Model(&models.CustomRole{}).Where("id in ?", ids).Pluck("name", &names).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	var count int64
+	if len(names) > 0 {
+		if err := dao.DB().Model(&models.ClusterUserRole{}).
+			Where("role = ? AND custom_role in ?", constants.RoleClusterCustom, names).
+			Count(&count).Error; err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+	}
+	if count > 0 {
+		amis.WriteJsonError(c, fmt.Errorf("自定义角色[%s]仍有%d条集群授权，请先删除授权", strings.Join(names, ","), count))
+		return
+	}
+
+	if err := dao.DB().Where("id in ?", ids).Delete(&models.CustomRole{}).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("custom_role")
+	amis.WriteJsonOK(c)
+}
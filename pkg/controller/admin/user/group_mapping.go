@@ -0,0 +1,189 @@
+package user
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type AdminGroupMappingController struct{}
+
+// AdminGroupMappingController 用于外部用户组映射规则相关接口
+
+func RegisterGroupMappingRoutes(r chi.Router) {
+	ctrl := &AdminGroupMappingController{}
+	r.Get("/group_mapping/list", response.Adapter(ctrl.List))
+	r.Post("/group_mapping/save", response.Adapter(ctrl.Save))
+	r.Post("/group_mapping/delete/{ids}", response.Adapter(ctrl.Delete))
+	r.Post("/group_mapping/save/id/{id}/status/{enabled}", response.Adapter(ctrl.QuickSave))
+}
+
+// @Summary 获取用户组映射规则列表
+// @Security BearerAuth
+// @Success 200 {object} []models.GroupMapping
+// @Router /admin/group_mapping/list [get]
+func (a *AdminGroupMappingController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.GroupMapping{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存用户组映射规则
+// @Description 新增或更新映射规则，已生成的集群授权随规则同步更新，用户组成员在用户下次登录或LDAP同步时生效
+// @Security BearerAuth
+// @Param request body models.GroupMapping true "映射规则"
+// @Success 200 {object} string
+// @Router /admin/group_mapping/save [post]
+func (a *AdminGroupMappingController) Save(c *response.Context) {
+	var req models.GroupMapping
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err := validateGroupMapping(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	var err error
+	if req.ID == 0 {
+		req.CreatedBy = amis.GetLoginUser(c)
+		err = dao.DB().Create(&req).Error
+	} else {
+		err = dao.DB().Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.GroupMapping{ID: req.ID}).
+				Select("source", "source_name", "external_group", "target_type", "user_group", "cluster", "role", "custom_role", "namespaces", "description", "enabled").
+				Updates(&req).Error; err != nil {
+				return err
+			}
+			return syncMappingGrants(tx, &req)
+		})
+	}
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除用户组映射规则
+// @Description 同时删除规则生成的集群授权
+// @Security BearerAuth
+// @Param ids path string true "规则ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/group_mapping/delete/{ids} [post]
+func (a *AdminGroupMappingController) Delete(c *response.Context) {
+	ids := utils.ToInt64Slice(c.Param("ids"))
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("mapping_id in ?", ids).Delete(&models.ClusterUserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id in ?", ids).Delete(&models.GroupMapping{}).Error
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 快速启用或停用用户组映射规则
+// @Description 停用时删除规则生成的集群授权，重新启用后在用户下次登录或LDAP同步时恢复
+// @Security BearerAuth
+// @Param id path int true "规则ID"
+// @Param enabled path string true "状态，例如：true、false"
+// @Success 200 {object} string
+// @Router /admin/group_mapping/save/id/{id}/status/{enabled} [post]
+func (a *AdminGroupMappingController) QuickSave(c *response.Context) {
+	id := utils.ToUInt(c.Param("id"))
+	enabled := c.Param("enabled") == "true"
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.GroupMapping{ID: id}).Select("enabled").Updates(&models.GroupMapping{Enabled: enabled}).Error; err != nil {
+			return err
+		}
+		if enabled {
+			return nil
+		}
+		return tx.Where("mapping_id = ?", id).Delete(&models.ClusterUserRole{}).Error
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	service.UserService().ClearCacheByKey("cluster")
+	amis.WriteJsonOK(c)
+}
+
+// syncMappingGrants 规则变更后更新其已生成的集群授权，规则停用或不再授予集群角色时删除
+func syncMappingGrants(tx *gorm.DB, m *models.GroupMapping) error {
+	if !m.Enabled || m.TargetType != models.GroupMappingTargetClusterRole {
+		return tx.Where("mapping_id = ?", m.ID).Delete(&models.ClusterUserRole{}).Error
+	}
+	return tx.Model(&models.ClusterUserRole{}).Where("mapping_id = ?", m.ID).Updates(map[string]any{
+		"cluster":     m.Cluster,
+		"role":        m.Role,
+		"custom_role": m.CustomRole,
+		"namespaces":  m.Namespaces,
+	}).Error
+}
+
+// validateGroupMapping 校验规则并清理与目标类型无关的字段
+func validateGroupMapping(m *models.GroupMapping) error {
+	m.ExternalGroup = strings.TrimSpace(m.ExternalGroup)
+	if m.ExternalGroup == "" {
+		return fmt.Errorf("外部用户组不能为空")
+	}
+	switch m.Source {
+	case models.GroupMappingSourceSSO:
+	case models.GroupMappingSourceLDAP:
+		m.SourceName = ""
+	default:
+		return fmt.Errorf("不支持的用户组来源: %s", m.Source)
+	}
+
+	switch m.TargetType {
+	case models.GroupMappingTargetUserGroup:
+		if m.UserGroup == "" {
+			return fmt.Errorf("请选择用户组")
+		}
+		m.Cluster, m.Role, m.CustomRole, m.Namespaces = "", "", "", ""
+	case models.GroupMappingTargetClusterRole:
+		m.Cluster = strings.TrimSpace(m.Cluster)
+		if m.Cluster == "" {
+			return fmt.Errorf("请填写集群")
+		}
+		roles := []string{constants.RoleClusterReadonly, constants.RoleClusterPodExec, constants.RoleClusterAdmin, constants.RoleClusterCustom}
+		if !slices.Contains(roles, m.Role) {
+			return fmt.Errorf("不支持的集群角色: %s", m.Role)
+		}
+		if m.Role == constants.RoleClusterCustom {
+			if _, err := service.UserService().GetCustomRole(m.CustomRole); err != nil {
+				return err
+			}
+		} else {
+			m.CustomRole = ""
+		}
+		m.Namespaces = strings.Join(utils.SplitAndTrim(m.Namespaces, ","), ",")
+		m.UserGroup = ""
+	default:
+		return fmt.Errorf("不支持的授权目标: %s", m.TargetType)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type AdminLoginHistoryController struct{}
+
+// AdminLoginHistoryController 用于管理员查看登录记录、解除账户锁定
+
+func RegisterAdminLoginHistoryRoutes(r chi.Router) {
+	ctrl := &AdminLoginHistoryController{}
+	r.Get("/user/login_history/list", response.Adapter(ctrl.List))
+	r.Post("/user/login_history/unlock", response.Adapter(ctrl.Unlock))
+}
+
+// @Summary 获取登录记录列表
+// @Description 按时间倒序列出登录记录，可按用户名、来源IP、登录方式模糊查询，按结果过滤
+// @Security BearerAuth
+// @Param result query string false "结果，多个用逗号分隔，例如：failure,rejected,locked"
+// @Success 200 {object} []models.LoginHistory
+// @Router /admin/user/login_history/list [get]
+func (a *AdminLoginHistoryController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	result := c.Query("result")
+	delete(params.Queries, "result")
+	m := &models.LoginHistory{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		if result != "" {
+			db = db.Where("result in ?", strings.Split(result, ","))
+		}
+		return db
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// UnlockRequest 解除锁定请求
+type UnlockRequest struct {
+	Username string `json:"username"`
+}
+
+// @Summary 解除账户锁定
+// @Description 解除因连续登录失败导致的账户锁定，解锁后重新统计失败次数
+// @Security BearerAuth
+// @Param request body UnlockRequest true "用户名"
+// @Success 200 {object} string
+// @Router /admin/user/login_history/unlock [post]
+func (a *AdminLoginHistoryController) Unlock(c *response.Context) {
+	var req UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.Username == "" {
+		amis.WriteJsonError(c, fmt.Errorf("用户名不能为空"))
+		return
+	}
+	if err := service.LoginSecurityService().Unlock(req.Username, amis.GetLoginUser(c)); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("已解除用户[%s]的锁定", req.Username))
+}
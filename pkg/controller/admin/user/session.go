@@ -0,0 +1,105 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+type AdminSessionController struct{}
+
+// AdminSessionController 用于管理员查看、吊销用户登录会话
+
+func RegisterAdminSessionRoutes(r chi.Router) {
+	ctrl := &AdminSessionController{}
+	r.Get("/user/session/list", response.Adapter(ctrl.List))
+	r.Post("/user/session/revoke/{ids}", response.Adapter(ctrl.Revoke))
+	r.Post("/user/force_logout/{ids}", response.Adapter(ctrl.ForceLogout))
+}
+
+// @Summary 获取用户登录会话列表
+// @Description 列出未过期、未吊销的登录会话，可按用户名过滤
+// @Security BearerAuth
+// @Param username query string false "用户名"
+// @Success 200 {object} []models.UserSession
+// @Router /admin/user/session/list [get]
+func (a *AdminSessionController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	params.UserName = ""
+	// 用户名精确匹配，不使用通用查询的模糊匹配
+	username := c.Query("username")
+	delete(params.Queries, "username")
+	m := &models.UserSession{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		db = db.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+		if username != "" {
+			db = db.Where("username = ?", username)
+		}
+		return db
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 吊销登录会话
+// @Security BearerAuth
+// @Param ids path string true "会话ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/user/session/revoke/{ids} [post]
+func (a *AdminSessionController) Revoke(c *response.Context) {
+	_, err := service.SessionService().RevokeByIDs(utils.ToInt64Slice(c.Param("ids")), "", amis.GetLoginUser(c), "管理员吊销")
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 强制用户下线
+// @Description 吊销用户的全部登录会话，用户需重新登录
+// @Security BearerAuth
+// @Param ids path string true "用户ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/user/force_logout/{ids} [post]
+func (a *AdminSessionController) ForceLogout(c *response.Context) {
+	usernames, err := usernamesByIDs(c.Param("ids"))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	total := 0
+	for _, username := range usernames {
+		n, err := service.SessionService().RevokeUser(username, amis.GetLoginUser(c), "管理员强制下线")
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		total += n
+	}
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("已吊销%d个会话", total))
+}
+
+// usernamesByIDs 按用户ID查询用户名
+func usernamesByIDs(ids string) ([]string, error) {
+	var usernames []string
+	err := dao.DB().Model(&models.User{}).Where("id in ?", utils.ToInt64Slice(ids)).Pluck("username", &usernames).Error
+	return usernames, err
+}
+
+// revokeUserSessions 用户被禁用、删除或重置2FA后吊销其全部会话，失败只记录日志
+func revokeUserSessions(c *response.Context, usernames []string, reason string) {
+	for _, username := range usernames {
+		service.UserService().ClearCacheByKey(username)
+		if _, err := service.SessionService().RevokeUser(username, amis.GetLoginUser(c), reason); err != nil {
+			klog.Errorf("吊销用户[%s]会话失败: %v", username, err)
+		}
+	}
+}
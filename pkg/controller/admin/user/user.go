@@ -3,6 +3,7 @@ package user
 import (
 	"encoding/base64"
 	"fmt"
+	"time"
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/go-chi/chi/v5"
@@ -13,6 +14,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/k8m/pkg/service"
 	"gorm.io/gorm"
+	"k8s.io/klog/v2"
 )
 
 type AdminUserController struct {
@@ -45,7 +47,7 @@ func (a *AdminUserController) List(c *response.Context) {
 
 	queryFuncs := genQueryFuncs(c, params)
 	queryFuncs = append(queryFuncs, func(db *gorm.DB) *gorm.DB {
-		return db.Select([]string{"id", "group_names", "two_fa_enabled", "username", "two_fa_type", "two_fa_app_name", "source", "created_at", "updated_at", "disabled"})
+		return db.Select([]string{"id", "group_names", "two_fa_enabled", "webauthn_enabled", "username", "two_fa_type", "two_fa_app_name", "source", "created_at", "updated_at", "disabled"})
 	})
 	items, total, err := m.List(params, queryFuncs...)
 	if err != nil {
@@ -108,13 +110,24 @@ func (a *AdminUserController) Delete(c *response.Context) {
 
 	queryFuncs := genQueryFuncs(c, params)
 
-	err := m.Delete(params, ids, queryFuncs...)
+	usernames, err := usernamesByIDs(ids)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
-	// 清除用户的缓存
-	service.UserService().ClearCacheByKey(m.Username)
+	err = m.Delete(params, ids, queryFuncs...)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	// 删除用户的通行密钥，避免同名用户重建后沿用
+	for _, username := range usernames {
+		if err := service.WebAuthnService().ResetUser(username); err != nil {
+			klog.Errorf("删除用户[%s]的通行密钥失败: %v", username, err)
+		}
+	}
+	// 清除用户的缓存并吊销会话
+	revokeUserSessions(c, usernames, "用户已删除")
 	amis.WriteJsonOK(c)
 }
 
@@ -144,6 +157,10 @@ func (a *AdminUserController) UpdatePsw(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if err := service.LoginSecurityService().ValidatePassword(string(pswBytes)); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 	m.Salt = utils.RandNLengthString(8)
 	psw, err := utils.AesEncrypt([]byte(fmt.Sprintf("%s%s", string(pswBytes), m.Salt)))
 	if err != nil {
@@ -152,10 +169,12 @@ func (a *AdminUserController) UpdatePsw(c *response.Context) {
 	}
 
 	m.Password = base64.StdEncoding.EncodeToString(psw)
+	now := time.Now()
+	m.PasswordChangedAt = &now
 
 	queryFuncs := genQueryFuncs(c, params)
 	queryFuncs = append(queryFuncs, func(db *gorm.DB) *gorm.DB {
-		return db.Select([]string{"password", "salt"}).Updates(m)
+		return db.Select([]string{"password", "salt", "password_changed_at"}).Updates(m)
 	})
 	err = m.Save(params, queryFuncs...)
 	if err != nil {
@@ -208,7 +227,7 @@ func (a *AdminUserController) UserOptionList(c *response.Context) {
 
 // Disable2FA 禁用2FA
 // @Summary 禁用用户2FA
-// @Description 禁用指定用户的二步验证
+// @Description 禁用指定用户的二步验证，同时删除用户注册的全部通行密钥
 // @Security BearerAuth
 // @Param id path string true "用户ID"
 // @Success 200 {object} string
@@ -229,7 +248,7 @@ func (a *AdminUserController) Disable2FA(c *response.Context) {
 	}
 
 	// 检查是否已启用2FA
-	if !user.TwoFAEnabled {
+	if !user.TwoFAEnabled && !user.WebAuthnEnabled {
 		amis.WriteJsonError(c, fmt.Errorf("2FA未启用"))
 		return
 	}
@@ -249,6 +268,12 @@ func (a *AdminUserController) Disable2FA(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if err := service.WebAuthnService().ResetUser(user.Username); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	// 重置2FA后需重新登录
+	revokeUserSessions(c, []string{user.Username}, "管理员重置2FA")
 
 	amis.WriteJsonOK(c)
 }
@@ -278,5 +303,18 @@ func (a *AdminUserController) UserStatusQuickSave(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	usernames, err := usernamesByIDs(id)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if entity.Disabled {
+		// 禁用后已登录的会话立即失效
+		revokeUserSessions(c, usernames, "用户已禁用")
+	} else {
+		for _, username := range usernames {
+			service.UserService().ClearCacheByKey(username)
+		}
+	}
 	amis.WriteJsonErrorOrOK(c, err)
 }
@@ -1,10 +1,13 @@
 package cluster_status
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
 )
 
@@ -31,8 +34,9 @@ func (cc *ClusterController) ClusterResourceCount(c *response.Context) {
 	if cacheStr == "" {
 		cacheStr = "30"
 	}
-	cache := utils.ToInt(cacheStr)
-	sm, err := kom.Cluster(selectedCluster).Status().GetResourceCountSummary(cache)
+	ttl := service.ClusterService().CacheTTL(selectedCluster, time.Duration(utils.ToInt(cacheStr))*time.Second)
+	ctx := amis.GetContextWithUser(c)
+	sm, err := kom.Cluster(selectedCluster).WithContext(ctx).Status().GetResourceCountSummary(int(ttl / time.Second))
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
@@ -5,8 +5,10 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/apps/v1"
@@ -49,7 +51,7 @@ func RegisterActionRoutes(r chi.Router) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/deploy/batch/stop [post]
 func (nc *ActionController) BatchStop(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -92,7 +94,7 @@ func (nc *ActionController) BatchStop(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/deploy/batch/restore [post]
 func (nc *ActionController) BatchRestore(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -137,7 +139,7 @@ func (nc *ActionController) BatchRestore(c *response.Context) {
 func (nc *ActionController) Restart(c *response.Context) {
 	ns := c.Param("ns")
 	name := c.Param("name")
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -157,7 +159,7 @@ func (nc *ActionController) Restart(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/deploy/batch/restart [post]
 func (nc *ActionController) BatchRestart(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -328,7 +330,7 @@ func (nc *ActionController) Scale(c *response.Context) {
 	replica := c.Param("replica")
 	r := utils.ToInt32(replica)
 
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
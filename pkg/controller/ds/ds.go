@@ -2,8 +2,10 @@ package ds
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/apps/v1"
@@ -61,7 +63,7 @@ func (cc *Controller) History(c *response.Context) {
 func (cc *Controller) Restart(c *response.Context) {
 	ns := c.Param("ns")
 	name := c.Param("name")
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -80,7 +82,7 @@ func (cc *Controller) Restart(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/daemonset/batch/restart [post]
 func (cc *Controller) BatchRestart(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
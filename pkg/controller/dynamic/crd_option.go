@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -111,7 +112,7 @@ func getCrdList(ctx context.Context, selectedCluster string) ([]*unstructured.Un
 		"apiextensions.k8s.io",
 		"v1",
 		"CustomResourceDefinition").
-		WithCache(time.Second * 30).
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).
 		List(&list).Error
 	return list, err
 }
@@ -122,7 +123,7 @@ func getCrdKindListByGroup(ctx context.Context, selectedCluster string, group st
 		"v1",
 		"CustomResourceDefinition").
 		Where("`spec.group`=?", group).
-		WithCache(time.Second * 30).
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).
 		List(&list).Error
 	if err != nil {
 		return make([]string, 0)
@@ -8,8 +8,10 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm"
 	utils2 "github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
@@ -562,7 +564,7 @@ func (ac *ActionController) Scale(c *response.Context) {
 	group := c.Param("group")
 	version := c.Param("version")
 	r := utils2.ToInt32(replica)
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
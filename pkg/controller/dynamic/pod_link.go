@@ -39,7 +39,7 @@ func getPod(selectedCluster string, ctx context.Context, ns string, name string,
 		CRD(group, version, kind).
 		Namespace(ns).
 		Name(name).
-		WithCache(linkCacheTTL)
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, linkCacheTTL))
 	pod, err = kk.Ctl().CRD().ManagedPod()
 
 	if err == nil && pod != nil {
@@ -66,7 +66,7 @@ func getPods(selectedCluster string, ctx context.Context, ns string, name string
 		CRD(group, version, kind).
 		Namespace(ns).
 		Name(name).
-		WithCache(linkCacheTTL)
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, linkCacheTTL))
 	pods, err = kk.Ctl().CRD().ManagedPods()
 
 	if err == nil && len(pods) != 0 {
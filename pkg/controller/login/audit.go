@@ -0,0 +1,58 @@
+package login
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	chim "github.com/go-chi/chi/v5/middleware"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+// loginAudit 记录登录请求的响应，请求未成功时按响应中的提示记录登录失败。
+// 登录成功在创建会话时统一记录；要求补充二次验证或设置新密码的中间响应不计为失败
+type loginAudit struct {
+	ww   chim.WrapResponseWriter
+	body bytes.Buffer
+	err  error // 指定记录的错误，如账户锁定，未指定时取响应中的提示
+}
+
+func newLoginAudit(c *response.Context) *loginAudit {
+	a := &loginAudit{ww: chim.NewWrapResponseWriter(c.Writer, c.Request.ProtoMajor)}
+	a.ww.Tee(&a.body)
+	c.Writer = a.ww
+	return a
+}
+
+func (a *loginAudit) done(r *http.Request, username, method string) {
+	status := a.ww.Status()
+	if status == 0 || status == http.StatusOK {
+		return
+	}
+	if a.err != nil {
+		service.LoginSecurityService().Record(r, username, method, a.err)
+		return
+	}
+	var resp struct {
+		Message         string `json:"message"`
+		TwoFA           any    `json:"two_fa"`
+		PasswordExpired bool   `json:"password_expired"`
+	}
+	_ = json.Unmarshal(a.body.Bytes(), &resp)
+	if resp.TwoFA != nil || resp.PasswordExpired {
+		return
+	}
+	if resp.Message == "" {
+		resp.Message = http.StatusText(status)
+	}
+	service.LoginSecurityService().Record(r, username, method, errors.New(resp.Message))
+}
+
+func loginMethod(loginType int) string {
+	if loginType == 1 {
+		return "ldap"
+	}
+	return "password"
+}
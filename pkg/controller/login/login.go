@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/internal/dao"
@@ -16,6 +15,7 @@ import (
 
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/totp"
+	"github.com/weibaohui/k8m/pkg/comm/utils/webauthn"
 	"github.com/weibaohui/k8m/pkg/flag"
 	"github.com/weibaohui/k8m/pkg/service"
 	"k8s.io/klog/v2"
@@ -28,6 +28,10 @@ type Controller struct{}
 func RegisterLoginRoutes(r chi.Router) {
 	ctrl := &Controller{}
 	r.Post("/login", response.Adapter(ctrl.LoginByPassword))
+	r.Post("/refresh", response.Adapter(ctrl.Refresh))
+	r.Post("/logout", response.Adapter(ctrl.Logout))
+	r.Post("/webauthn/login/begin", response.Adapter(ctrl.WebAuthnLoginBegin))
+	r.Post("/webauthn/login/finish", response.Adapter(ctrl.WebAuthnLoginFinish))
 }
 
 // Request  用户结构体
@@ -36,22 +40,38 @@ type Request struct {
 	Password  string `json:"password" binding:"required"`
 	LoginType int    `json:"loginType"` // 0: 普通登录, 1: LDAP登录
 	Code      string `json:"code"`
+	// WebAuthn 通行密钥二次验证结果，与 Code 二选一
+	WebAuthn *WebAuthnRequest `json:"webauthn"`
+	// NewPassword 密码过期时设置的新密码（加密）
+	NewPassword string `json:"new_password"`
+}
+
+// WebAuthnRequest 通行密钥验证结果
+type WebAuthnRequest struct {
+	ChallengeToken string                      `json:"challenge_token"`
+	Credential     *webauthn.AssertionResponse `json:"credential"`
 }
 
 // 验证用户名和密码
 // 1、从cfg中获取用户名，先判断是不是admin，是进行密码比对.必须启用临时管理员配置才进行这一步
 // 2、从DB中获取用户名密码
 // @Summary 用户登录
-// @Description 用户通过用户名、密码和2FA验证码登录，支持普通和LDAP登录
+// @Description 用户通过用户名、密码和2FA登录，支持普通和LDAP登录。2FA可使用TOTP验证码或通行密钥，
+// @Description 均未提供时返回401及可用的验证方式，启用了通行密钥的用户同时返回通行密钥挑战。
+// @Description 连续失败达到上限时账户锁定；本地用户密码过期时返回401及 password_expired，需携带新密码重新登录
 // @Param username body string true "用户名"
 // @Param password body string true "密码（加密）"
 // @Param loginType body int false "登录类型 0:普通 1:LDAP"
 // @Param code body string false "2FA验证码"
+// @Param webauthn body WebAuthnRequest false "通行密钥验证结果"
+// @Param new_password body string false "密码过期时设置的新密码（加密）"
 // @Success 200 {object} string "登录成功，返回JWT Token"
 // @Failure 401 {object} string "登录失败"
 // @Router /auth/login [post]
 func (lc *Controller) LoginByPassword(c *response.Context) {
 	var req Request
+	la := newLoginAudit(c)
+	defer func() { la.done(c.Request, req.Username, loginMethod(req.LoginType)) }()
 	errorInfo := response.H{"message": "用户名密码错误或用户被禁用"}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		klog.Errorf("LoginByPassword %v", err.Error())
@@ -59,6 +79,13 @@ func (lc *Controller) LoginByPassword(c *response.Context) {
 		return
 	}
 
+	// 账户锁定期间直接拒绝，不再校验密码及2FA（含通行密钥）
+	if err := service.LoginSecurityService().CheckLocked(c.Request, req.Username); err != nil {
+		la.err = err
+		c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+		return
+	}
+
 	// 初始化配置
 	cfg := flag.Init()
 
@@ -70,11 +97,10 @@ func (lc *Controller) LoginByPassword(c *response.Context) {
 		return
 	}
 
-	// LDAP登录判断
+	// LDAP登录判断，失败时 handleLDAPLogin 已写入响应
 	if req.LoginType == 1 {
-		if err := handleLDAPLogin(c, req.Username, string(decrypt), req.Code, cfg); err != nil {
-			c.JSON(http.StatusUnauthorized, errorInfo)
-			return
+		if err := handleLDAPLogin(c, &req, string(decrypt), cfg); err != nil {
+			klog.V(6).Infof("LDAP用户[%s]登录未完成: %v", req.Username, err)
 		}
 		return
 	}
@@ -87,8 +113,7 @@ func (lc *Controller) LoginByPassword(c *response.Context) {
 			return
 		}
 		// Admin用户不需要2FA验证
-		token, _ := service.UserService().GenerateJWTTokenOnlyUserName(req.Username, time.Hour*24)
-		c.JSON(http.StatusOK, response.H{"token": token})
+		writeSession(c, req.Username, "password")
 		return
 	} else {
 		// DB 用户名密码
@@ -128,21 +153,16 @@ func (lc *Controller) LoginByPassword(c *response.Context) {
 				}
 
 				// 检查是否启用了2FA
-				if v.TwoFAEnabled {
-					// 如果启用了2FA但未提供验证码
-					if req.Code == "" {
-						c.JSON(http.StatusUnauthorized, response.H{"message": "请输入2FA验证码"})
-						return
-					}
-					// 验证2FA代码
-					if !totp.ValidateCode(v.TwoFASecret, req.Code) {
-						c.JSON(http.StatusUnauthorized, response.H{"message": "2FA验证码错误"})
-						return
-					}
+				if err := validateTwoFA(v, &req, c); err != nil {
+					return
+				}
+
+				// 密码过期时需设置新密码后才能登录
+				if err := checkPasswordExpired(c, v, &req, string(decrypt)); err != nil {
+					return
 				}
 
-				token, _ := service.UserService().GenerateJWTTokenOnlyUserName(v.Username, 24*time.Hour)
-				c.JSON(http.StatusOK, response.H{"token": token})
+				writeSession(c, v.Username, "password")
 				return
 			}
 		}
@@ -151,10 +171,11 @@ func (lc *Controller) LoginByPassword(c *response.Context) {
 	c.JSON(http.StatusUnauthorized, errorInfo)
 }
 
-// handleLDAPLogin 处理LDAP登录流程
-func handleLDAPLogin(c *response.Context, username, password, code string, cfg *flag.Config) error {
+// handleLDAPLogin 处理LDAP登录流程，成功与失败的响应均在此写入，返回的错误仅用于调用方判断
+func handleLDAPLogin(c *response.Context, req *Request, password string, cfg *flag.Config) error {
+	username := req.Username
 	// 1. LDAP认证
-	_, err := service.UserService().LoginWithLdap(username, password, cfg)
+	entry, err := service.UserService().LoginWithLdap(username, password, cfg)
 	if err != nil {
 		klog.Errorf("LDAP登录失败: %v", err)
 		c.JSON(http.StatusUnauthorized, response.H{"message": "LDAP登录验证失败"})
@@ -171,8 +192,10 @@ func handleLDAPLogin(c *response.Context, username, password, code string, cfg *
 
 	config, err := ldapConfig.GetOne(params, queryFunc)
 	var defaultGroup string
+	groupAttribute := "memberOf"
 	if err == nil && config != nil {
 		defaultGroup = config.DefaultGroup
+		groupAttribute = service.LdapGroupAttribute(config)
 	}
 
 	// 2. 检查用户是否已存在
@@ -200,6 +223,11 @@ func handleLDAPLogin(c *response.Context, username, password, code string, cfg *
 		return err
 	}
 
+	// 按用户组映射规则同步用户组与集群授权，失败不影响登录
+	if err := service.GroupMappingService().Apply(username, models.GroupMappingSourceLDAP, "", entry.GetAttributeValues(groupAttribute)); err != nil {
+		klog.Errorf("LDAP用户[%s]同步用户组映射失败: %v", username, err)
+	}
+
 	// 3. 获取用户信息
 	user, err := getUserInfo(username)
 	if err != nil {
@@ -209,13 +237,12 @@ func handleLDAPLogin(c *response.Context, username, password, code string, cfg *
 	}
 
 	// 4. 验证2FA
-	if err := validateTwoFA(user, code, c); err != nil {
+	if err := validateTwoFA(user, req, c); err != nil {
 		return err
 	}
 
-	// 5. 生成token
-	token, _ := service.UserService().GenerateJWTTokenOnlyUserName(username, 24*time.Hour)
-	c.JSON(http.StatusOK, response.H{"token": token})
+	// 5. 创建会话，生成token
+	writeSession(c, username, "ldap")
 	return nil
 }
 
@@ -229,18 +256,169 @@ func getUserInfo(username string) (*models.User, error) {
 	return user.GetOne(params, queryFunc)
 }
 
-// validateTwoFA 验证2FA
+// validateTwoFA 验证2FA，TOTP验证码与通行密钥任一通过即可。
+// 均未提供时返回401及可用的验证方式，启用了通行密钥时附带挑战，前端据此调起浏览器验证后重新提交
 
-func validateTwoFA(user *models.User, code string, c *response.Context) error {
-	if user != nil && user.TwoFAEnabled {
-		if code == "" {
-			c.JSON(http.StatusUnauthorized, response.H{"message": "请输入2FA验证码"})
-			return errors.New("2FA验证码未提供")
+func validateTwoFA(user *models.User, req *Request, c *response.Context) error {
+	if user == nil || (!user.TwoFAEnabled && !user.WebAuthnEnabled) {
+		return nil
+	}
+	if req.WebAuthn != nil && req.WebAuthn.ChallengeToken != "" && user.WebAuthnEnabled {
+		if err := service.WebAuthnService().FinishLogin(c.Request, user.Username, req.WebAuthn.ChallengeToken, req.WebAuthn.Credential); err != nil {
+			c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+			return err
 		}
-		if !totp.ValidateCode(user.TwoFASecret, code) {
+		return nil
+	}
+	if req.Code != "" && user.TwoFAEnabled {
+		if !totp.ValidateCode(user.TwoFASecret, req.Code) {
 			c.JSON(http.StatusUnauthorized, response.H{"message": "2FA验证码错误"})
 			return errors.New("2FA验证码错误")
 		}
+		return nil
 	}
+
+	message := "请输入2FA验证码"
+	twoFA := response.H{"totp": user.TwoFAEnabled}
+	if user.WebAuthnEnabled {
+		challenge, err := service.WebAuthnService().BeginLogin(c.Request, user.Username)
+		if err != nil {
+			klog.Errorf("生成用户[%s]通行密钥挑战失败: %v", user.Username, err)
+		} else if challenge != nil {
+			twoFA["webauthn"] = challenge
+			if !user.TwoFAEnabled {
+				message = "请使用通行密钥完成2FA验证"
+			}
+		}
+	}
+	c.JSON(http.StatusUnauthorized, response.H{"message": message, "two_fa": twoFA})
+	return errors.New("2FA验证信息未提供")
+}
+
+// checkPasswordExpired 本地用户密码过期时，未提供新密码则返回401要求设置新密码；
+// 提供了新密码则按密码策略校验后更新。带 password_expired 标记的响应不计为登录失败
+func checkPasswordExpired(c *response.Context, user *models.User, req *Request, oldPassword string) error {
+	if !service.LoginSecurityService().PasswordExpired(user) {
+		return nil
+	}
+	if req.NewPassword == "" {
+		c.JSON(http.StatusUnauthorized, response.H{"message": service.ErrPasswordExpired.Error(), "password_expired": true})
+		return service.ErrPasswordExpired
+	}
+	newPassword, err := utils.AesDecrypt(req.NewPassword)
+	if err != nil {
+		klog.Errorf("用户[%s]新密码解密失败: %v", user.Username, err)
+		c.JSON(http.StatusUnauthorized, response.H{"message": "新密码格式错误", "password_expired": true})
+		return err
+	}
+	if string(newPassword) == oldPassword {
+		c.JSON(http.StatusUnauthorized, response.H{"message": "新密码不能与原密码相同", "password_expired": true})
+		return errors.New("新密码不能与原密码相同")
+	}
+	if err := service.LoginSecurityService().SetPassword(user.Username, string(newPassword)); err != nil {
+		c.JSON(http.StatusUnauthorized, response.H{"message": err.Error(), "password_expired": true})
+		return err
+	}
+	klog.V(4).Infof("用户[%s]密码已过期，登录时设置了新密码", user.Username)
 	return nil
 }
+
+// @Summary 开始通行密钥免密登录
+// @Description 返回浏览器调用 navigator.credentials.get 所需的参数，由浏览器列出可用的通行密钥
+// @Success 200 {object} service.WebAuthnChallenge
+// @Router /auth/webauthn/login/begin [post]
+func (lc *Controller) WebAuthnLoginBegin(c *response.Context) {
+	challenge, err := service.WebAuthnService().BeginPasswordless(c.Request)
+	if err != nil {
+		klog.Errorf("生成通行密钥登录挑战失败: %v", err)
+		c.JSON(http.StatusInternalServerError, response.H{"message": "系统错误"})
+		return
+	}
+	c.JSON(http.StatusOK, challenge)
+}
+
+// @Summary 完成通行密钥免密登录
+// @Description 校验通行密钥签名，只接受注册为免密登录的通行密钥，通过后返回JWT Token
+// @Param request body WebAuthnRequest true "通行密钥验证结果"
+// @Success 200 {object} service.TokenPair
+// @Failure 401 {object} string "登录失败"
+// @Router /auth/webauthn/login/finish [post]
+func (lc *Controller) WebAuthnLoginFinish(c *response.Context) {
+	var req WebAuthnRequest
+	var username string
+	la := newLoginAudit(c)
+	defer func() { la.done(c.Request, username, "webauthn") }()
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnauthorized, response.H{"message": service.ErrWebAuthnFailed.Error()})
+		return
+	}
+	var err error
+	username, err = service.WebAuthnService().FinishPasswordless(c.Request, req.ChallengeToken, req.Credential)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+		return
+	}
+	// 通行密钥验证通过后才能确定用户，锁定期间同样拒绝登录
+	if err := service.LoginSecurityService().CheckLocked(c.Request, username); err != nil {
+		la.err = err
+		c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+		return
+	}
+	if service.UserService().IsUserDisabled(username) {
+		klog.Errorf("用户[%s]被禁用", username)
+		c.JSON(http.StatusUnauthorized, response.H{"message": "用户名密码错误或用户被禁用"})
+		return
+	}
+	writeSession(c, username, "webauthn")
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// @Summary 刷新访问令牌
+// @Description 使用刷新令牌换取新的访问令牌，会话被吊销、过期或用户被禁用时返回401
+// @Param refresh_token body string true "刷新令牌"
+// @Success 200 {object} service.TokenPair
+// @Failure 401 {object} string "会话已失效"
+// @Router /auth/refresh [post]
+func (lc *Controller) Refresh(c *response.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusUnauthorized, response.H{"message": service.ErrSessionInvalid.Error()})
+		return
+	}
+	pair, err := service.SessionService().Refresh(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// @Summary 退出登录
+// @Description 吊销刷新令牌所属的会话，访问令牌随之失效
+// @Param refresh_token body string true "刷新令牌"
+// @Success 200 {object} string
+// @Router /auth/logout [post]
+func (lc *Controller) Logout(c *response.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err == nil {
+		if err := service.SessionService().Logout(req.RefreshToken); err != nil {
+			klog.V(6).Infof("退出登录吊销会话失败: %v", err)
+		}
+	}
+	c.JSON(http.StatusOK, response.H{"message": "已退出登录"})
+}
+
+// writeSession 创建登录会话并返回访问令牌与刷新令牌
+func writeSession(c *response.Context, username, loginType string) {
+	pair, err := service.SessionService().Create(c.Request, username, loginType)
+	if err != nil {
+		klog.Errorf("创建用户[%s]登录会话失败: %v", username, err)
+		c.JSON(http.StatusInternalServerError, response.H{"message": "系统错误"})
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
@@ -37,7 +37,7 @@ func (nc *MetadataController) NameOptionList(c *response.Context) {
 
 	var list []*unstructured.Unstructured
 	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).
-		WithCache(time.Second * 30).
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).
 		List(&list).Error
 	if err != nil {
 		amis.WriteJsonData(c, response.H{
@@ -80,14 +80,14 @@ func (nc *MetadataController) AllLabelList(c *response.Context) {
 	// 先拿到所有的lable列表
 	// 通过lable的kv去匹配node，将node name放入到label 结构体中，方便选择时做出判断
 	labels, err := kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).
-		WithCache(time.Second * 30).Ctl().Node().AllNodeLabels()
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).Ctl().Node().AllNodeLabels()
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
 	var nodeList []*v1.Node
 	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).
-		WithCache(time.Second * 30).
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).
 		List(&nodeList).Error
 	if err != nil {
 		amis.WriteJsonError(c, err)
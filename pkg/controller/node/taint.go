@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/core/v1"
 )
@@ -38,7 +39,7 @@ func (tc *TaintController) List(c *response.Context) {
 
 	var nodeList []*v1.Node
 	err = kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Node{}).
-		WithCache(time.Second * 30).
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).
 		List(&nodeList).Error
 	if err != nil {
 		amis.WriteJsonError(c, err)
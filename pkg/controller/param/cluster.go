@@ -84,10 +84,11 @@ func (pc *Controller) ClusterTableList(c *response.Context) {
 	}
 	// 增加cluster.NotAfter
 	configs := service.ClusterService().ConnectedClusters() // 优化：移到循环外部
+	allLabels := service.ClusterService().ClusterLabels()
 	for _, cluster := range clusters {
-		cluster.GetClusterID()
-		// InCluster AWS
-		if !(cluster.IsInCluster || cluster.IsAWSEKS) && slice.ContainBy(configs, func(item *service.ClusterConfig) bool {
+		cluster.Labels = allLabels[cluster.GetClusterID()].String()
+		// InCluster AWS 云厂商集群均不使用客户端证书
+		if !(cluster.IsInCluster || cluster.IsAWSEKS || cluster.CloudProvider != "") && slice.ContainBy(configs, func(item *service.ClusterConfig) bool {
 			return item.ClusterID == cluster.ClusterID
 		}) {
 			cacheKey := fmt.Sprintf("%s/kubeconfig/not_after", cluster.ClusterID)
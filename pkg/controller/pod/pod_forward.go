@@ -7,8 +7,10 @@ import (
 	"sync"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/core/v1"
@@ -217,6 +219,12 @@ func StartPortForwardByPod(ctx context.Context, selectedCluster, ns, podName, co
 		return "", fmt.Errorf("无效的本地端口号: %s", localPort)
 	}
 
+	// 端口转发在后台长期运行，启动前按 port-forward 动词校验权限
+	ctx = comm.WithPermissionVerb(ctx, models.VerbPortForward)
+	if err := comm.CheckResourcePermission(ctx, selectedCluster, []string{ns}, ns, podName, models.VerbPortForward, "", "Pod"); err != nil {
+		return "", err
+	}
+
 	stopCh := make(chan struct{})
 	key := getMapKey(selectedCluster, ns, podName, containerName, podPort)
 
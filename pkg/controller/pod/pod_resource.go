@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/core/v1"
 )
@@ -67,7 +68,7 @@ func (rc *ResourceController) TopList(c *response.Context) {
 
 	podMetrics, err := kom.Cluster(selectedCluster).WithContext(ctx).Resource(&v1.Pod{}).
 		Namespace(strings.Split(ns, ",")...).
-		WithCache(time.Second * 30).
+		WithCache(service.ClusterService().CacheTTL(selectedCluster, time.Second*30)).
 		Ctl().Pod().Top()
 	if err != nil {
 		amis.WriteJsonError(c, err)
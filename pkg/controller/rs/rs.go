@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/apps/v1"
@@ -38,7 +40,7 @@ func RegisterRoutes(r chi.Router) {
 func (cc *Controller) Restart(c *response.Context) {
 	ns := c.Param("ns")
 	name := c.Param("name")
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -58,7 +60,7 @@ func (cc *Controller) Restart(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/replicaset/batch/restart [post]
 func (cc *Controller) BatchRestart(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -101,7 +103,7 @@ func (cc *Controller) BatchRestart(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/replicaset/batch/stop [post]
 func (cc *Controller) BatchStop(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -144,7 +146,7 @@ func (cc *Controller) BatchStop(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/replicaset/batch/restore [post]
 func (cc *Controller) BatchRestore(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -0,0 +1,336 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm/utils/scim"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"k8s.io/klog/v2"
+)
+
+type Controller struct{}
+
+// RegisterScimRoutes 注册 SCIM 2.0 用户与用户组同步路由，不经过登录校验，由 SCIM 令牌认证
+func RegisterScimRoutes(r chi.Router) {
+	ctrl := &Controller{}
+	r.Use(tokenAuth)
+	r.Get("/ServiceProviderConfig", response.Adapter(ctrl.ServiceProviderConfig))
+	r.Get("/ResourceTypes", response.Adapter(ctrl.ResourceTypes))
+
+	r.Get("/Users", response.Adapter(ctrl.ListUsers))
+	r.Post("/Users", response.Adapter(ctrl.CreateUser))
+	r.Get("/Users/{id}", response.Adapter(ctrl.GetUser))
+	r.Put("/Users/{id}", response.Adapter(ctrl.ReplaceUser))
+	r.Patch("/Users/{id}", response.Adapter(ctrl.PatchUser))
+	r.Delete("/Users/{id}", response.Adapter(ctrl.DeleteUser))
+
+	r.Get("/Groups", response.Adapter(ctrl.ListGroups))
+	r.Post("/Groups", response.Adapter(ctrl.CreateGroup))
+	r.Get("/Groups/{id}", response.Adapter(ctrl.GetGroup))
+	r.Put("/Groups/{id}", response.Adapter(ctrl.ReplaceGroup))
+	r.Patch("/Groups/{id}", response.Adapter(ctrl.PatchGroup))
+	r.Delete("/Groups/{id}", response.Adapter(ctrl.DeleteGroup))
+}
+
+// tokenAuth 校验请求头中的 SCIM 令牌
+func tokenAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if _, err := service.ScimService().Authenticate(token); err != nil {
+			klog.V(4).Infof("SCIM 请求被拒绝 %s %s: %v", r.RemoteAddr, r.URL.Path, err)
+			write(response.New(w, r), http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// @Summary SCIM 服务端配置
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (s *Controller) ServiceProviderConfig(c *response.Context) {
+	write(c, http.StatusOK, scim.ServiceProviderConfig())
+}
+
+// @Summary SCIM 资源类型
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /scim/v2/ResourceTypes [get]
+func (s *Controller) ResourceTypes(c *response.Context) {
+	types := scim.ResourceTypes()
+	write(c, http.StatusOK, scim.NewListResponse(int64(len(types)), 1, types, len(types)))
+}
+
+// @Summary 查询用户
+// @Description 支持按 userName、id 过滤，仅支持 eq
+// @Security BearerAuth
+// @Param filter query string false "过滤条件，例如：userName eq \"zhangsan\""
+// @Param startIndex query int false "起始序号，从1开始"
+// @Param count query int false "每页数量"
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/Users [get]
+func (s *Controller) ListUsers(c *response.Context) {
+	filter, err := scim.ParseFilter(c.Query("filter"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	start, count := scim.Page(c.Query("startIndex"), c.Query("count"))
+	list, err := service.ScimService().ListUsers(filter, start, count)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if items, ok := list.Resources.([]*scim.User); ok {
+		for _, item := range items {
+			setUserLocation(c, item)
+		}
+	}
+	write(c, http.StatusOK, list)
+}
+
+// @Summary 获取用户
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} scim.User
+// @Router /scim/v2/Users/{id} [get]
+func (s *Controller) GetUser(c *response.Context) {
+	item, err := service.ScimService().GetUser(c.Param("id"))
+	writeUser(c, http.StatusOK, item, err)
+}
+
+// @Summary 创建用户
+// @Description 创建的用户来源为 scim，没有密码，需通过单点登录或 LDAP 登录
+// @Security BearerAuth
+// @Param request body scim.User true "用户"
+// @Success 201 {object} scim.User
+// @Router /scim/v2/Users [post]
+func (s *Controller) CreateUser(c *response.Context) {
+	var req scim.User
+	if err := bind(c, &req); err != nil {
+		writeError(c, err)
+		return
+	}
+	item, err := service.ScimService().CreateUser(&req)
+	writeUser(c, http.StatusCreated, item, err)
+}
+
+// @Summary 替换用户
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param request body scim.User true "用户"
+// @Success 200 {object} scim.User
+// @Router /scim/v2/Users/{id} [put]
+func (s *Controller) ReplaceUser(c *response.Context) {
+	var req scim.User
+	if err := bind(c, &req); err != nil {
+		writeError(c, err)
+		return
+	}
+	item, err := service.ScimService().ReplaceUser(c.Param("id"), &req)
+	writeUser(c, http.StatusOK, item, err)
+}
+
+// @Summary 修改用户
+// @Description active 改为 false 时停用用户，并吊销其全部登录会话
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param request body scim.PatchRequest true "PATCH 操作"
+// @Success 200 {object} scim.User
+// @Router /scim/v2/Users/{id} [patch]
+func (s *Controller) PatchUser(c *response.Context) {
+	var req scim.PatchRequest
+	if err := bind(c, &req); err != nil {
+		writeError(c, err)
+		return
+	}
+	item, err := service.ScimService().PatchUser(c.Param("id"), &req)
+	writeUser(c, http.StatusOK, item, err)
+}
+
+// @Summary 删除用户
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Success 204
+// @Router /scim/v2/Users/{id} [delete]
+func (s *Controller) DeleteUser(c *response.Context) {
+	if err := service.ScimService().DeleteUser(c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary 查询用户组
+// @Description 支持按 displayName、id 过滤，仅支持 eq
+// @Security BearerAuth
+// @Param filter query string false "过滤条件，例如：displayName eq \"dev\""
+// @Param excludedAttributes query string false "为 members 时不返回成员"
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/Groups [get]
+func (s *Controller) ListGroups(c *response.Context) {
+	filter, err := scim.ParseFilter(c.Query("filter"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	start, count := scim.Page(c.Query("startIndex"), c.Query("count"))
+	list, err := service.ScimService().ListGroups(filter, start, count, withMembers(c))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if items, ok := list.Resources.([]*scim.Group); ok {
+		for _, item := range items {
+			setGroupLocation(c, item)
+		}
+	}
+	write(c, http.StatusOK, list)
+}
+
+// @Summary 获取用户组
+// @Security BearerAuth
+// @Param id path string true "用户组ID"
+// @Success 200 {object} scim.Group
+// @Router /scim/v2/Groups/{id} [get]
+func (s *Controller) GetGroup(c *response.Context) {
+	item, err := service.ScimService().GetGroup(c.Param("id"), withMembers(c))
+	writeGroup(c, http.StatusOK, item, err)
+}
+
+// @Summary 创建用户组
+// @Description 新用户组的角色为普通用户，集群授权需在 k8m 中配置
+// @Security BearerAuth
+// @Param request body scim.Group true "用户组"
+// @Success 201 {object} scim.Group
+// @Router /scim/v2/Groups [post]
+func (s *Controller) CreateGroup(c *response.Context) {
+	var req scim.Group
+	if err := bind(c, &req); err != nil {
+		writeError(c, err)
+		return
+	}
+	item, err := service.ScimService().CreateGroup(&req)
+	writeGroup(c, http.StatusCreated, item, err)
+}
+
+// @Summary 替换用户组
+// @Security BearerAuth
+// @Param id path string true "用户组ID"
+// @Param request body scim.Group true "用户组"
+// @Success 200 {object} scim.Group
+// @Router /scim/v2/Groups/{id} [put]
+func (s *Controller) ReplaceGroup(c *response.Context) {
+	var req scim.Group
+	if err := bind(c, &req); err != nil {
+		writeError(c, err)
+		return
+	}
+	item, err := service.ScimService().ReplaceGroup(c.Param("id"), &req)
+	writeGroup(c, http.StatusOK, item, err)
+}
+
+// @Summary 修改用户组
+// @Description 支持修改名称、添加与移除成员，修改名称时同步修改按用户组的集群授权
+// @Security BearerAuth
+// @Param id path string true "用户组ID"
+// @Param request body scim.PatchRequest true "PATCH 操作"
+// @Success 200 {object} scim.Group
+// @Router /scim/v2/Groups/{id} [patch]
+func (s *Controller) PatchGroup(c *response.Context) {
+	var req scim.PatchRequest
+	if err := bind(c, &req); err != nil {
+		writeError(c, err)
+		return
+	}
+	item, err := service.ScimService().PatchGroup(c.Param("id"), &req)
+	writeGroup(c, http.StatusOK, item, err)
+}
+
+// @Summary 删除用户组
+// @Security BearerAuth
+// @Param id path string true "用户组ID"
+// @Success 204
+// @Router /scim/v2/Groups/{id} [delete]
+func (s *Controller) DeleteGroup(c *response.Context) {
+	if err := service.ScimService().DeleteGroup(c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Writer.WriteHeader(http.StatusNoContent)
+}
+
+// withMembers 请求未排除 members 属性时返回用户组成员
+func withMembers(c *response.Context) bool {
+	return !strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members")
+}
+
+func bind(c *response.Context, obj any) error {
+	err := json.NewDecoder(c.Request.Body).Decode(obj)
+	if err == nil {
+		return nil
+	}
+	var scimErr *scim.Error
+	if errors.As(err, &scimErr) {
+		return scimErr
+	}
+	return scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, fmt.Sprintf("请求格式错误: %v", err))
+}
+
+func writeUser(c *response.Context, status int, item *scim.User, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	setUserLocation(c, item)
+	write(c, status, item)
+}
+
+func writeGroup(c *response.Context, status int, item *scim.Group, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	setGroupLocation(c, item)
+	write(c, status, item)
+}
+
+func setUserLocation(c *response.Context, item *scim.User) {
+	origin := flag.Init().RequestOrigin(c.Request)
+	item.Meta.Location = fmt.Sprintf("%s/scim/v2/Users/%s", origin, item.ID)
+	for i := range item.Groups {
+		item.Groups[i].Ref = fmt.Sprintf("%s/scim/v2/Groups/%s", origin, item.Groups[i].Value)
+	}
+}
+
+func setGroupLocation(c *response.Context, item *scim.Group) {
+	origin := flag.Init().RequestOrigin(c.Request)
+	item.Meta.Location = fmt.Sprintf("%s/scim/v2/Groups/%s", origin, item.ID)
+	for i := range item.Members {
+		item.Members[i].Ref = fmt.Sprintf("%s/scim/v2/Users/%s", origin, item.Members[i].Value)
+	}
+}
+
+// writeError 输出 SCIM 错误响应，非 SCIM 错误按服务端错误处理
+func writeError(c *response.Context, err error) {
+	var scimErr *scim.Error
+	if !errors.As(err, &scimErr) {
+		klog.Errorf("SCIM 请求 %s %s 处理失败: %v", c.Request.Method, c.Request.URL.Path, err)
+		scimErr = scim.NewError(http.StatusInternalServerError, "", err.Error())
+	}
+	write(c, scimErr.Code, scimErr)
+}
+
+func write(c *response.Context, status int, obj any) {
+	c.Header("Content-Type", scim.ContentType)
+	c.Writer.WriteHeader(status)
+	_ = json.NewEncoder(c.Writer).Encode(obj)
+}
@@ -14,5 +14,8 @@ func RegisterAuthRoutes(auth chi.Router) {
 	auth.Get("/sso/config", response.Adapter(ctrl.GetSSOConfig))
 	auth.Get("/oidc/{name}/sso", response.Adapter(ctrl.GetAuthCodeURL))
 	auth.Get("/oidc/{name}/callback", response.Adapter(ctrl.HandleCallback))
+	auth.Get("/saml/{name}/sso", response.Adapter(ctrl.GetSAMLAuthRequest))
+	auth.Post("/saml/{name}/callback", response.Adapter(ctrl.HandleSAMLCallback))
+	auth.Get("/saml/{name}/metadata", response.Adapter(ctrl.GetSAMLMetadata))
 	auth.Get("/ldap/config", response.Adapter(ldap.GetLdapConfig))
 }
@@ -0,0 +1,145 @@
+package sso
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/saml"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+)
+
+// SAML 登录请求的有效期，超时后需重新发起登录
+const samlRequestTTL = 10 * time.Minute
+
+// 未配置用户组属性时，按顺序查找的常见属性名称
+var defaultSAMLGroupsAttributes = []string{
+	"groups",
+	"Group",
+	"memberOf",
+	"http://schemas.microsoft.com/ws/2008/06/identity/claims/groups",
+	"http://schemas.xmlsoap.org/claims/Group",
+}
+
+// NewSAMLServiceProvider 根据 SSO 配置构建 SAML SP，回调地址与元数据地址按当前请求动态生成
+func NewSAMLServiceProvider(c *response.Context, cfg *models.SSOConfig) (*saml.ServiceProvider, error) {
+	if cfg.IdpMetadata == "" {
+		return nil, fmt.Errorf("SAML配置[%s]未导入IdP元数据", cfg.Name)
+	}
+	idp, err := saml.ParseIDPMetadata([]byte(cfg.IdpMetadata))
+	if err != nil {
+		return nil, err
+	}
+	entityID, acsURL := samlEndpoints(c, cfg)
+	return &saml.ServiceProvider{
+		EntityID: entityID,
+		ACSURL:   acsURL,
+		IDP:      idp,
+	}, nil
+}
+
+// samlEndpoints SP 实体ID与断言消费地址，实体ID未配置时使用 SP 元数据地址。
+// SAML 校验回调地址时要求完全一致，访问地址由 --external-url 或受信任代理的转发头确定
+func samlEndpoints(c *response.Context, cfg *models.SSOConfig) (entityID, acsURL string) {
+	baseURL := fmt.Sprintf("%s/auth/%s/%s", flag.Init().RequestOrigin(c.Request), cfg.Type, cfg.Name)
+	entityID = cfg.SPEntityID
+	if entityID == "" {
+		entityID = baseURL + "/metadata"
+	}
+	return entityID, baseURL + "/callback"
+}
+
+// getSAMLConfig 获取 SAML 配置，登录与回调要求配置已启用，SP 元数据在启用前即可获取
+func getSAMLConfig(name string, requireEnabled bool) (*models.SSOConfig, error) {
+	var cfg models.SSOConfig
+	err := dao.DB().Where("name = ? AND type = ?", name, "saml").First(&cfg).Error
+	if err != nil {
+		return nil, fmt.Errorf("SAML配置[%s]不存在", name)
+	}
+	if requireEnabled && !cfg.Enabled {
+		return nil, fmt.Errorf("SAML配置[%s]未启用", name)
+	}
+	return &cfg, nil
+}
+
+// samlUsername 按配置的属性顺序获取用户名，均未命中时使用 NameID
+func samlUsername(a *saml.Assertion, preferKeys []string) string {
+	for _, key := range preferKeys {
+		if values := a.Attributes[strings.TrimSpace(key)]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return a.NameID
+}
+
+// samlGroups 获取用户组，多个值以逗号拼接，与 OIDC 的 groups 处理一致
+func samlGroups(a *saml.Assertion, groupsAttribute string) string {
+	keys := defaultSAMLGroupsAttributes
+	if groupsAttribute != "" {
+		keys = strings.Split(groupsAttribute, ",")
+	}
+	for _, key := range keys {
+		if values := a.Attributes[strings.TrimSpace(key)]; len(values) > 0 {
+			return strings.Join(values, ",")
+		}
+	}
+	return ""
+}
+
+// signRelayState 将登录请求ID签名后放入 RelayState，回调时校验 InResponseTo，多实例部署无需共享会话。
+// SAML 规范要求 RelayState 不超过 80 字节
+func signRelayState(name, requestID string, expiresAt time.Time) string {
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	return requestID + "." + exp + "." + relayStateMAC(name, requestID, exp)
+}
+
+// verifyRelayState 校验 RelayState 并返回登录请求ID
+func verifyRelayState(name, relayState string, now time.Time) (string, error) {
+	parts := strings.Split(relayState, ".")
+	if len(parts) != 3 || !hmac.Equal([]byte(parts[2]), []byte(relayStateMAC(name, parts[0], parts[1]))) {
+		return "", fmt.Errorf("SAML RelayState 无效，请重新登录")
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > exp {
+		return "", fmt.Errorf("SAML 登录请求已过期，请重新登录")
+	}
+	return parts[0], nil
+}
+
+func relayStateMAC(name, requestID, exp string) string {
+	mac := hmac.New(sha256.New, []byte(flag.Init().JwtTokenSecret))
+	mac.Write([]byte(name + "|" + requestID + "|" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// markSAMLConsumed 记录已使用的登录请求与断言，在有效期内拒绝重放。
+// 记录保存在本实例缓存中，多实例部署时由 RelayState 的有效期兜底
+func markSAMLConsumed(name string, a *saml.Assertion, requestID string, now time.Time) error {
+	cache := service.CacheService().CacheInstance()
+	keys := []string{
+		fmt.Sprintf("saml:request:%s:%s", name, requestID),
+		fmt.Sprintf("saml:assertion:%s:%s", name, a.ID),
+	}
+	for _, key := range keys {
+		if _, found := cache.Get(key); found {
+			return fmt.Errorf("SAML 断言已被使用，请重新登录")
+		}
+	}
+	ttl := a.NotOnOrAfter.Sub(now) + saml.MaxClockSkew
+	if ttl < samlRequestTTL {
+		ttl = samlRequestTTL
+	}
+	for _, key := range keys {
+		cache.SetWithTTL(key, true, 1, ttl)
+	}
+	cache.Wait()
+	return nil
+}
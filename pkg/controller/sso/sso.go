@@ -12,6 +12,7 @@ import (
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/comm/utils/saml"
 	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/service"
 	"k8s.io/klog/v2"
@@ -70,31 +71,31 @@ func (au *AuthController) HandleCallback(c *response.Context) {
 	ctx := c.Request.Context()
 	client, err := getDefaultOIDCClient(c, name)
 	if err != nil {
-		amis.WriteJsonError(c, err)
+		loginFailed(c, name, err)
 		return
 	}
 	code := c.Query("code")
 	oauth2Token, err := client.OAuth2Config.Exchange(ctx, code)
 	if err != nil {
-		amis.WriteJsonError(c, err)
+		loginFailed(c, name, err)
 		return
 	}
 
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
 	if !ok {
-		amis.WriteJsonError(c, fmt.Errorf("No id_token in token response"))
+		loginFailed(c, name, fmt.Errorf("No id_token in token response"))
 		return
 	}
 
 	idToken, err := client.Verifier.Verify(ctx, rawIDToken)
 	if err != nil {
-		amis.WriteJsonError(c, err)
+		loginFailed(c, name, err)
 		return
 	}
 
 	var claims map[string]any
 	if err = idToken.Claims(&claims); err != nil {
-		amis.WriteJsonError(c, err)
+		loginFailed(c, name, err)
 		return
 	}
 
@@ -103,8 +104,23 @@ func (au *AuthController) HandleCallback(c *response.Context) {
 
 	username := GetUsername(claims, strings.Split(client.DBConfig.PreferUserNameKeys, ","))
 	groups := GetUserGroups(claims)
-	_ = service.UserService().CheckAndCreateUser(username, name, groups)
-	userLoginToken, err := service.UserService().GenerateJWTTokenOnlyUserName(username, 24*time.Hour)
+	writeLoginSuccess(c, username, name, groups)
+}
+
+// writeLoginSuccess 创建或更新 SSO 用户、同步用户组映射并签发登录 Token，OIDC 与 SAML 共用
+func writeLoginSuccess(c *response.Context, username, source, groups string) {
+	_ = service.UserService().CheckAndCreateUser(username, source, groups)
+	// 用户可能已被管理员或 SCIM 同步停用，IdP 认证通过也不能登录
+	if service.UserService().IsUserDisabled(username) {
+		service.LoginSecurityService().Record(c.Request, username, source, service.ErrUserDisabled)
+		amis.WriteJsonError(c, service.ErrUserDisabled)
+		return
+	}
+	// 按用户组映射规则同步用户组与集群授权，失败不影响登录
+	if err := service.GroupMappingService().Apply(username, models.GroupMappingSourceSSO, source, utils.SplitAndTrim(groups, ",")); err != nil {
+		klog.Errorf("SSO用户[%s]同步用户组映射失败: %v", username, err)
+	}
+	pair, err := service.SessionService().Create(c.Request, username, source)
 	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
@@ -118,17 +134,117 @@ func (au *AuthController) HandleCallback(c *response.Context) {
   <body>
     <script>
       localStorage.setItem("token", %q);
+      localStorage.setItem("refresh_token", %q);
       // 自动跳转回首页或 dashboard
       window.location.href = "/#/";
     </script>
     <p>登录成功，正在跳转...</p>
   </body>
 </html>
-`, userLoginToken)
+`, pair.Token, pair.RefreshToken)
 
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
 }
 
+// loginFailed 记录 SSO 登录失败并返回错误，source 为 SSO 配置名称
+func loginFailed(c *response.Context, source string, err error) {
+	service.LoginSecurityService().Record(c.Request, "", source, err)
+	amis.WriteJsonError(c, err)
+}
+
+// @Summary 发起SAML登录
+// @Description 生成SAML AuthnRequest并跳转到IdP登录页面
+// @Param name path string true "SSO名称"
+// @Success 302 {string} string
+// @Router /auth/saml/{name}/sso [get]
+func (au *AuthController) GetSAMLAuthRequest(c *response.Context) {
+	name := c.Param("name")
+	cfg, err := getSAMLConfig(name, true)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	sp, err := NewSAMLServiceProvider(c, cfg)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	now := time.Now()
+	requestID := saml.NewRequestID()
+	relayState := signRelayState(name, requestID, now.Add(samlRequestTTL))
+	redirectURL, postForm, err := sp.AuthnRequest(requestID, relayState, now)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	klog.V(6).Infof("saml [%s] authn request %s", name, requestID)
+	if postForm != nil {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", postForm)
+		return
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// @Summary 处理SAML回调
+// @Description 校验IdP以HTTP-POST回传的SAMLResponse签名与断言，完成用户登录
+// @Param name path string true "SSO名称"
+// @Param SAMLResponse formData string true "SAML响应"
+// @Param RelayState formData string true "登录请求状态"
+// @Success 200 {string} string
+// @Router /auth/saml/{name}/callback [post]
+func (au *AuthController) HandleSAMLCallback(c *response.Context) {
+	name := c.Param("name")
+	cfg, err := getSAMLConfig(name, true)
+	if err != nil {
+		loginFailed(c, name, err)
+		return
+	}
+	sp, err := NewSAMLServiceProvider(c, cfg)
+	if err != nil {
+		loginFailed(c, name, err)
+		return
+	}
+	now := time.Now()
+	requestID, err := verifyRelayState(name, c.PostForm("RelayState"), now)
+	if err != nil {
+		loginFailed(c, name, err)
+		return
+	}
+	assertion, err := sp.ParseResponse(c.PostForm("SAMLResponse"), requestID, now)
+	if err != nil {
+		klog.V(6).Infof("saml [%s] response rejected: %v", name, err)
+		loginFailed(c, name, err)
+		return
+	}
+	if err = markSAMLConsumed(name, assertion, requestID, now); err != nil {
+		loginFailed(c, name, err)
+		return
+	}
+
+	username := samlUsername(assertion, strings.Split(cfg.PreferUserNameKeys, ","))
+	if username == "" {
+		loginFailed(c, name, fmt.Errorf("SAML断言中未找到用户名"))
+		return
+	}
+	groups := samlGroups(assertion, cfg.GroupsAttribute)
+	writeLoginSuccess(c, username, name, groups)
+}
+
+// @Summary 获取SAML SP元数据
+// @Description 获取k8m作为服务提供方的SAML元数据，用于在IdP中注册应用
+// @Param name path string true "SSO名称"
+// @Success 200 {string} string
+// @Router /auth/saml/{name}/metadata [get]
+func (au *AuthController) GetSAMLMetadata(c *response.Context) {
+	cfg, err := getSAMLConfig(c.Param("name"), false)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	entityID, acsURL := samlEndpoints(c, cfg)
+	c.Data(http.StatusOK, "application/samlmetadata+xml", saml.SPMetadata(entityID, acsURL))
+}
+
 // 获取默认OIDC客户端配置
 func getDefaultOIDCClient(c *response.Context, name string) (*Client, error) {
 	// 通过name 获取配置
@@ -2,8 +2,10 @@ package sts
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/kom/kom"
 	v1 "k8s.io/api/apps/v1"
@@ -64,7 +66,7 @@ func (cc *Controller) History(c *response.Context) {
 func (cc *Controller) Restart(c *response.Context) {
 	ns := c.Param("ns")
 	name := c.Param("name")
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -84,7 +86,7 @@ func (cc *Controller) Restart(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/statefulset/batch/restart [post]
 func (cc *Controller) BatchRestart(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbRestart)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -127,7 +129,7 @@ func (cc *Controller) BatchRestart(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/statefulset/batch/stop [post]
 func (cc *Controller) BatchStop(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -170,7 +172,7 @@ func (cc *Controller) BatchStop(c *response.Context) {
 // @Success 200 {object} string
 // @Router /k8s/cluster/{cluster}/statefulset/batch/restore [post]
 func (cc *Controller) BatchRestore(c *response.Context) {
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
 		amis.WriteJsonError(c, err)
@@ -224,7 +226,7 @@ func (cc *Controller) Scale(c *response.Context) {
 		return
 	}
 
-	ctx := amis.GetContextWithUser(c)
+	ctx := comm.WithPermissionVerb(amis.GetContextWithUser(c), models.VerbScale)
 	err = kom.Cluster(selectedCluster).WithContext(ctx).
 		Resource(&v1.StatefulSet{}).
 		Namespace(ns).Name(name).
@@ -3,6 +3,7 @@ package profile
 import (
 	"encoding/base64"
 	"fmt"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/internal/dao"
@@ -24,6 +25,14 @@ func RegisterProfileRoutes(mgm chi.Router) {
 	mgm.Post("/user/profile/2fa/generate", response.Adapter(ctrl.Generate2FASecret))
 	mgm.Post("/user/profile/2fa/disable", response.Adapter(ctrl.Disable2FA))
 	mgm.Post("/user/profile/2fa/enable", response.Adapter(ctrl.Enable2FA))
+	mgm.Get("/user/profile/sessions/list", response.Adapter(ctrl.ListSessions))
+	mgm.Post("/user/profile/sessions/revoke/{ids}", response.Adapter(ctrl.RevokeSessions))
+	mgm.Post("/user/profile/sessions/revoke_others", response.Adapter(ctrl.RevokeOtherSessions))
+	mgm.Get("/user/profile/webauthn/list", response.Adapter(ctrl.ListWebAuthn))
+	mgm.Post("/user/profile/webauthn/register/begin", response.Adapter(ctrl.BeginRegisterWebAuthn))
+	mgm.Post("/user/profile/webauthn/register/finish", response.Adapter(ctrl.FinishRegisterWebAuthn))
+	mgm.Post("/user/profile/webauthn/save", response.Adapter(ctrl.SaveWebAuthn))
+	mgm.Post("/user/profile/webauthn/delete/{ids}", response.Adapter(ctrl.DeleteWebAuthn))
 }
 
 // @Summary 获取用户信息
@@ -40,7 +49,7 @@ func (uc *Controller) Profile(c *response.Context) {
 
 	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
 		return db.
-			Select([]string{"id", "group_names", "two_fa_enabled", "username", "two_fa_type", "two_fa_app_name", "source", "created_at", "updated_at"}).
+			Select([]string{"id", "group_names", "two_fa_enabled", "webauthn_enabled", "username", "two_fa_type", "two_fa_app_name", "source", "created_at", "updated_at"}).
 			Where(m)
 	})
 	if err != nil {
@@ -132,6 +141,10 @@ func (uc *Controller) UpdatePsw(c *response.Context) {
 		amis.WriteJsonError(c, fmt.Errorf("两次输入的密码不一致"))
 		return
 	}
+	if err := service.LoginSecurityService().ValidatePassword(string(pswBytes)); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	// 密码 + 盐重新计算
 	m := models.User{}
@@ -143,10 +156,12 @@ func (uc *Controller) UpdatePsw(c *response.Context) {
 	}
 
 	m.Password = base64.StdEncoding.EncodeToString(psw)
+	now := time.Now()
+	m.PasswordChangedAt = &now
 	m.Username = params.UserName // 用户名是从token中获取的，不能使用用户前端传递过来的用户名
 	params.UserName = ""         // 避免增加CreatedBy字段,因为查询用户集群权限，是管理员授权的，所以不需要CreatedBy字段
 
-	err = dao.DB().Select([]string{"password", "salt"}).Where("username=?", m.Username).Updates(m).Error
+	err = dao.DB().Select([]string{"password", "salt", "password_changed_at"}).Where("username=?", m.Username).Updates(m).Error
 
 	if err != nil {
 		amis.WriteJsonError(c, err)
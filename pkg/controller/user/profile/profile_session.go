@@ -0,0 +1,74 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// sessionItem 会话列表项，标记是否为当前会话
+type sessionItem struct {
+	*models.UserSession
+	Current bool `json:"current"`
+}
+
+// ListSessions 列出当前用户的有效会话
+// @Summary 获取我的登录会话
+// @Description 列出当前用户未过期、未吊销的登录会话
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /mgm/user/profile/sessions/list [get]
+func (uc *Controller) ListSessions(c *response.Context) {
+	params := dao.BuildParams(c)
+	username := params.UserName
+	params.UserName = ""
+
+	m := &models.UserSession{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("username = ? AND revoked_at IS NULL AND expires_at > ?", username, time.Now())
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	sid := amis.GetLoginSessionID(c)
+	result := make([]*sessionItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, &sessionItem{UserSession: item, Current: item.SessionID == sid})
+	}
+	amis.WriteJsonListWithTotal(c, total, result)
+}
+
+// RevokeSessions 吊销当前用户的会话
+// @Summary 吊销我的登录会话
+// @Description 吊销后该会话需重新登录，吊销当前会话等同于退出登录
+// @Security BearerAuth
+// @Param ids path string true "会话ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /mgm/user/profile/sessions/revoke/{ids} [post]
+func (uc *Controller) RevokeSessions(c *response.Context) {
+	username := amis.GetLoginUser(c)
+	_, err := service.SessionService().RevokeByIDs(utils.ToInt64Slice(c.Param("ids")), username, username, "用户手动吊销")
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// RevokeOtherSessions 吊销当前用户除本会话外的全部会话
+// @Summary 退出其他设备的登录
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /mgm/user/profile/sessions/revoke_others [post]
+func (uc *Controller) RevokeOtherSessions(c *response.Context) {
+	sid := amis.GetLoginSessionID(c)
+	if sid == "" {
+		amis.WriteJsonError(c, service.ErrSessionInvalid)
+		return
+	}
+	_, err := service.SessionService().RevokeOthers(amis.GetLoginUser(c), sid)
+	amis.WriteJsonErrorOrOK(c, err)
+}
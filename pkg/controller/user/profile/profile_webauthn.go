@@ -0,0 +1,200 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/comm/utils/totp"
+	"github.com/weibaohui/k8m/pkg/comm/utils/webauthn"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+// WebAuthnRegisterRequest 注册通行密钥请求
+type WebAuthnRegisterRequest struct {
+	Name           string                        `json:"name"`
+	Passwordless   bool                          `json:"passwordless"` // 是否可用于免密登录
+	ChallengeToken string                        `json:"challenge_token"`
+	Credential     *webauthn.AttestationResponse `json:"credential"`
+
+	// 以下用于注册前确认身份：当前密码（加密，启用2FA时需同时提供验证码），或已注册的通行密钥
+	Password    string                      `json:"password"`
+	Code        string                      `json:"code"`
+	StepUpToken string                      `json:"step_up_token"`
+	Assertion   *webauthn.AssertionResponse `json:"assertion"`
+}
+
+// errStepUpRequired 未提供身份确认信息
+var errStepUpRequired = errors.New("请先确认身份")
+
+// stepUp 注册通行密钥前确认身份，防止令牌泄露后被用于注册攻击者的通行密钥。
+// 未提供确认信息时返回 errStepUpRequired，并在用户已注册通行密钥时返回用于确认身份的挑战
+func stepUp(c *response.Context, username string, req *WebAuthnRegisterRequest) (*service.WebAuthnChallenge, error) {
+	user := &models.User{}
+	if err := dao.DB().Where("username = ?", username).First(user).Error; err != nil {
+		return nil, fmt.Errorf("用户[%s]不存在，无法注册通行密钥", username)
+	}
+
+	if req.StepUpToken != "" && user.WebAuthnEnabled {
+		return nil, service.WebAuthnService().FinishLogin(c.Request, username, req.StepUpToken, req.Assertion)
+	}
+	if req.Password != "" {
+		if err := checkPassword(user, req.Password); err != nil {
+			return nil, err
+		}
+		if user.TwoFAEnabled && !totp.ValidateCode(user.TwoFASecret, req.Code) {
+			return nil, fmt.Errorf("2FA验证码错误")
+		}
+		return nil, nil
+	}
+
+	if !user.WebAuthnEnabled {
+		return nil, errStepUpRequired
+	}
+	challenge, err := service.WebAuthnService().BeginLogin(c.Request, username)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, errStepUpRequired
+}
+
+// checkPassword 校验当前密码，LDAP 用户到目录中校验
+func checkPassword(user *models.User, encrypted string) error {
+	plain, err := utils.AesDecrypt(encrypted)
+	if err != nil {
+		return err
+	}
+	if user.Source == "ldap_config" {
+		if _, err := service.UserService().LoginWithLdap(user.Username, string(plain), flag.Init()); err != nil {
+			return fmt.Errorf("密码不正确")
+		}
+		return nil
+	}
+	if user.Password == "" {
+		return fmt.Errorf("当前账户未设置密码，请使用已注册的通行密钥确认身份")
+	}
+	psw, err := utils.AesEncrypt([]byte(fmt.Sprintf("%s%s", string(plain), user.Salt)))
+	if err != nil {
+		return err
+	}
+	dbPsw, err := base64.StdEncoding.DecodeString(user.Password)
+	if err != nil || !bytes.Equal(dbPsw, psw) {
+		return fmt.Errorf("密码不正确")
+	}
+	return nil
+}
+
+// ListWebAuthn 列出当前用户的通行密钥
+// @Summary 获取我的通行密钥
+// @Security BearerAuth
+// @Success 200 {object} []models.WebAuthnCredential
+// @Router /mgm/user/profile/webauthn/list [get]
+func (uc *Controller) ListWebAuthn(c *response.Context) {
+	params := dao.BuildParams(c)
+	username := params.UserName
+	params.UserName = ""
+
+	m := &models.WebAuthnCredential{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("username = ?", username)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// BeginRegisterWebAuthn 开始注册通行密钥
+// @Summary 开始注册通行密钥
+// @Description 确认身份后返回浏览器调用 navigator.credentials.create 所需的参数。
+// @Description 需提供当前密码（启用2FA时同时提供验证码），或使用已注册的通行密钥确认；均未提供时返回 step_up 说明可用的确认方式
+// @Security BearerAuth
+// @Param request body WebAuthnRegisterRequest true "passwordless 为 true 时注册可免密登录的通行密钥"
+// @Success 200 {object} service.WebAuthnChallenge
+// @Router /mgm/user/profile/webauthn/register/begin [post]
+func (uc *Controller) BeginRegisterWebAuthn(c *response.Context) {
+	var req WebAuthnRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	username := amis.GetLoginUser(c)
+	stepUpChallenge, err := stepUp(c, username, &req)
+	if errors.Is(err, errStepUpRequired) {
+		amis.WriteJsonData(c, response.H{
+			"step_up": response.H{"webauthn": stepUpChallenge},
+		})
+		return
+	}
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	challenge, err := service.WebAuthnService().BeginRegistration(c.Request, username, req.Passwordless)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, challenge)
+}
+
+// FinishRegisterWebAuthn 完成注册通行密钥
+// @Summary 完成注册通行密钥
+// @Description 校验浏览器返回的注册结果并保存，注册后登录时可使用通行密钥代替TOTP验证码
+// @Security BearerAuth
+// @Param request body WebAuthnRegisterRequest true "注册结果"
+// @Success 200 {object} string
+// @Router /mgm/user/profile/webauthn/register/finish [post]
+func (uc *Controller) FinishRegisterWebAuthn(c *response.Context) {
+	var req WebAuthnRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	_, err := service.WebAuthnService().FinishRegistration(c.Request, amis.GetLoginUser(c), req.Name, req.ChallengeToken, req.Credential)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// SaveWebAuthn 重命名通行密钥
+// @Summary 重命名通行密钥
+// @Security BearerAuth
+// @Param request body models.WebAuthnCredential true "通行密钥ID与名称"
+// @Success 200 {object} string
+// @Router /mgm/user/profile/webauthn/save [post]
+func (uc *Controller) SaveWebAuthn(c *response.Context) {
+	var req models.WebAuthnCredential
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		amis.WriteJsonError(c, fmt.Errorf("名称不能为空"))
+		return
+	}
+	err := dao.DB().Model(&models.WebAuthnCredential{}).
+		Where("id = ? AND username = ?", req.ID, amis.GetLoginUser(c)).
+		Update("name", req.Name).Error
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// DeleteWebAuthn 删除当前用户的通行密钥
+// @Summary 删除通行密钥
+// @Security BearerAuth
+// @Param ids path string true "通行密钥ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /mgm/user/profile/webauthn/delete/{ids} [post]
+func (uc *Controller) DeleteWebAuthn(c *response.Context) {
+	err := service.WebAuthnService().Delete(amis.GetLoginUser(c), utils.ToInt64Slice(c.Param("ids")))
+	amis.WriteJsonErrorOrOK(c, err)
+}
@@ -19,9 +19,11 @@ var config *Config
 var once sync.Once
 
 type Config struct {
-	Port       int    // chi 监听端口
-	Host       string // chi 监听地址
-	KubeConfig string // KUBECONFIG文件路径
+	Port         int    // chi 监听端口
+	Host         string // chi 监听地址
+	ExternalURL  string // k8m 对外访问地址，用于生成 Agent 部署清单、SCIM 资源地址等，为空时按请求推断
+	TrustedProxy bool   // 是否部署在可信的反向代理之后，开启后才使用 X-Forwarded-* 请求头
+	KubeConfig   string // KUBECONFIG文件路径
 
 	Debug             bool   // 调试模式，同步修改所有的debug模式
 	LogV              int    // klog的日志级别klog.V(this)
@@ -31,6 +33,9 @@ type Config struct {
 	AdminUserName     string // 管理员用户名，启用临时管理员账户配置后生效
 	AdminPassword     string // 管理员密码，启用临时管理员账户配置后生效
 	JwtTokenSecret    string // JWT token secret
+	MasterKey         string `json:"-"` // 敏感字段加密主密钥
+	MasterKeyFile     string // 主密钥文件路径，未设置 MasterKey 时从文件读取
+	MasterKeyPrevious string `json:"-"` // 轮换前的主密钥，多个用逗号分隔
 	NodeShellImage    string // nodeShell 镜像
 	KubectlShellImage string // kubectlShell 镜像
 	ImagePullTimeout  int    // 镜像拉取超时时间（秒）
@@ -207,12 +212,19 @@ func (c *Config) InitFlags() {
 	pflag.BoolVarP(&c.Debug, "debug", "d", defaultDebug, "调试模式")
 	pflag.IntVarP(&c.Port, "port", "p", defaultPort, "监听端口,默认3618")
 	pflag.StringVarP(&c.Host, "host", "h", defaultHost, "监听地址,默认0.0.0.0")
+	pflag.StringVar(&c.ExternalURL, "external-url", getEnv("EXTERNAL_URL", ""), "k8m对外访问地址，例如：https://k8m.example.com，用于生成Agent部署清单等，为空时按请求推断")
+	pflag.BoolVar(&c.TrustedProxy, "trusted-proxy", getEnvAsBool("TRUSTED_PROXY", false), "是否部署在可信的反向代理之后，开启后才使用X-Forwarded-For、X-Forwarded-Host、X-Forwarded-Proto请求头，默认关闭")
 
 	pflag.StringVar(&c.ProductName, "product-name", defaultProductName, "产品名称，默认为K8M")
 
 	pflag.StringVar(&c.LoginType, "login-type", defaultLoginType, "登录方式，password, oauth, token等,default is password")
 	pflag.StringVar(&c.JwtTokenSecret, "jwt-token-secret", defaultJwtTokenSecret, "登录后生成JWT token 使用的Secret")
 
+	// 敏感字段加密主密钥
+	pflag.StringVar(&c.MasterKey, "master-key", getEnv("MASTER_KEY", ""), "敏感字段加密主密钥，与 --master-key-file 均未设置时自动生成主密钥文件（sqlite数据库所在目录的 master.key），生产环境请务必设置")
+	pflag.StringVar(&c.MasterKeyFile, "master-key-file", getEnv("MASTER_KEY_FILE", ""), "主密钥文件路径，未设置 --master-key 时从该文件读取，可挂载 Kubernetes Secret")
+	pflag.StringVar(&c.MasterKeyPrevious, "master-key-previous", getEnv("MASTER_KEY_PREVIOUS", ""), "轮换前的主密钥，多个用逗号分隔。启动时使用新主密钥重新加密数据密钥")
+
 	// 临时管理员账户配置
 	pflag.BoolVar(&c.EnableTempAdmin, "enable-temp-admin", defaultEnableTempAdmin, "是否启用临时管理员账户配置，默认关闭")
 	pflag.StringVar(&c.AdminUserName, "admin-username", defaultAdminUserName, "管理员用户名，启用临时管理员账户配置后生效")
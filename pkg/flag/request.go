@@ -0,0 +1,47 @@
+package flag
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RequestOrigin k8m 的访问地址，如 https://k8m.example.com。
+// 优先使用 --external-url；未配置时按请求推断，开启 --trusted-proxy 后才使用客户端可伪造的 X-Forwarded-Proto、X-Forwarded-Host
+func (c *Config) RequestOrigin(r *http.Request) string {
+	if c.ExternalURL != "" {
+		return strings.TrimRight(c.ExternalURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	if c.TrustedProxy {
+		if proto := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0]); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if fh := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Host"), ",")[0]); fh != "" {
+			host = fh
+		}
+	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+// ClientIP 请求来源IP。默认使用连接地址；开启 --trusted-proxy 后取 X-Forwarded-For 的最后一个地址，即可信代理追加的客户端地址
+func (c *Config) ClientIP(r *http.Request) string {
+	if c.TrustedProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
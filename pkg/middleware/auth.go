@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/audit"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/flag"
 	"github.com/weibaohui/k8m/pkg/response"
@@ -31,6 +32,8 @@ func AuthMiddleware() func(http.Handler) http.Handler {
 				strings.HasPrefix(path, "/health/") ||
 				strings.HasPrefix(path, "/mcp/") ||
 				strings.HasPrefix(path, "/auth/") ||
+				strings.HasPrefix(path, "/agent/") ||
+				strings.HasPrefix(path, "/scim/") ||
 				strings.HasPrefix(path, "/assets/") ||
 				strings.HasPrefix(path, "/public/") {
 				next.ServeHTTP(w, r)
@@ -44,9 +47,22 @@ func AuthMiddleware() func(http.Handler) http.Handler {
 				c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
 				return
 			}
+			// MCP Key 令牌仅可用于 /mcp/ 接口
+			if scope, _ := claims[constants.JwtScope].(string); scope == constants.JwtScopeMCP {
+				c.JSON(http.StatusUnauthorized, response.H{"message": "MCP Key 令牌仅可用于MCP接口"})
+				return
+			}
+			// 会话被吊销或用户被禁用后，未过期的令牌同样失效
+			username, _ := claims[constants.JwtUserName].(string)
+			sid, _ := claims[constants.JwtSessionID].(string)
+			if err := service.SessionService().Validate(username, sid); err != nil {
+				c.JSON(http.StatusUnauthorized, response.H{"message": err.Error()})
+				return
+			}
 
 			// 设置信息传递，后面才能从ctx中获取到用户信息
 			ctx := context.WithValue(r.Context(), constants.JwtUserName, claims[constants.JwtUserName])
+			ctx = context.WithValue(ctx, constants.JwtSessionID, sid)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -64,6 +80,10 @@ func PlatformAuthMiddleware() func(http.Handler) http.Handler {
 				return
 			}
 
+			if scope, _ := claims[constants.JwtScope].(string); scope == constants.JwtScopeMCP {
+				c.JSON(http.StatusUnauthorized, response.H{"error": "MCP Key 令牌仅可用于MCP接口"})
+				return
+			}
 			username, ok := claims[constants.JwtUserName].(string)
 			if !ok || username == "" {
 				c.JSON(http.StatusUnauthorized, response.H{"error": "无效的用户名"})
@@ -76,6 +96,15 @@ func PlatformAuthMiddleware() func(http.Handler) http.Handler {
 			}
 			// 权限检查
 			if !slices.Contains(roles, constants.RolePlatformAdmin) {
+				service.AuditService().Emit(&audit.Event{
+					Type:    audit.EventPermissionDenied,
+					Action:  r.Method,
+					User:    username,
+					Role:    strings.Join(roles, ","),
+					Name:    r.URL.Path,
+					Result:  audit.ResultDenied,
+					Message: "平台管理员权限校验失败",
+				})
 				c.JSON(http.StatusForbidden, response.H{"error": "平台管理员权限校验失败"})
 				return
 			}
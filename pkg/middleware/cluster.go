@@ -48,6 +48,7 @@ func EnsureSelectedClusterMiddleware() func(http.Handler) http.Handler {
 				strings.HasPrefix(path, "/debug/") ||
 				strings.HasPrefix(path, "/mcp/") ||
 				strings.HasPrefix(path, "/auth/") ||
+				strings.HasPrefix(path, "/agent/") ||
 				strings.HasPrefix(path, "/assets/") ||
 				strings.HasPrefix(path, "/ai/") || // ai 聊天不带cluster
 				strings.HasPrefix(path, "/params/") || // 配置参数
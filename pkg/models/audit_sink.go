@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// AuditSink 审计日志转发目标
+type AuditSink struct {
+	ID                 uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name               string    `gorm:"size:100;uniqueIndex:idx_audit_sink_name" json:"name,omitempty"`
+	Type               string    `gorm:"size:20" json:"type,omitempty"` // syslog/file/otlp
+	Enabled            bool      `gorm:"default:false" json:"enabled,omitempty"`
+	EventTypes         string    `gorm:"type:text" json:"event_types,omitempty"` // 事件类型，逗号分隔，为空表示全部
+	Actions            string    `gorm:"type:text" json:"actions,omitempty"`     // 操作类型，逗号分隔，为空表示全部
+	Address            string    `gorm:"size:255" json:"address,omitempty"`      // syslog 地址 host:port
+	Protocol           string    `gorm:"size:10" json:"protocol,omitempty"`      // syslog 协议 udp/tcp/tls
+	Facility           int       `json:"facility,omitempty"`                     // syslog facility，默认 13（log audit）
+	AppName            string    `gorm:"size:48" json:"app_name,omitempty"`      // syslog APP-NAME，默认 k8m
+	FilePath           string    `gorm:"type:text" json:"file_path,omitempty"`   // JSON Lines 文件路径
+	MaxSizeMB          int       `json:"max_size_mb,omitempty"`                  // 单个文件大小上限
+	MaxBackups         int       `json:"max_backups,omitempty"`                  // 保留的历史文件数
+	Endpoint           string    `gorm:"type:text" json:"endpoint,omitempty"`    // OTLP/HTTP 地址
+	Headers            string    `gorm:"type:text" json:"headers,omitempty"`     // OTLP 请求头，每行一个 Key: Value，加密存储
+	CACert             string    `gorm:"type:text" json:"ca_cert,omitempty"`     // TLS 自定义 CA 证书
+	InsecureSkipVerify bool      `json:"insecure_skip_verify,omitempty"`
+	SpoolMaxMB         int       `json:"spool_max_mb,omitempty"` // 本地队列容量上限，默认 256MB
+	Description        string    `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt          time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt          time.Time `json:"updated_at,omitempty"`
+}
+
+// List 列出所有记录
+func (s *AuditSink) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AuditSink, int64, error) {
+	return dao.GenericQuery(params, s, queryFuncs...)
+}
+
+// Save 保存记录
+func (s *AuditSink) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, s, queryFuncs...)
+}
+
+// Delete 删除记录
+func (s *AuditSink) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, s, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+// GetOne 获取单条记录
+func (s *AuditSink) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AuditSink, error) {
+	return dao.GenericGetOne(params, s, queryFuncs...)
+}
+
+// BeforeSave 在保存前加密敏感字段
+func (s *AuditSink) BeforeSave(tx *gorm.DB) error {
+	return EncryptSecretFields(s)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (s *AuditSink) AfterSave(tx *gorm.DB) error {
+	return DecryptSecretFields(s)
+}
+
+// AfterFind 在查询后解密敏感字段
+func (s *AuditSink) AfterFind(tx *gorm.DB) error {
+	return DecryptSecretFields(s)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	ClusterAgentStatusOnline  = "online"
+	ClusterAgentStatusOffline = "offline"
+)
+
+// ClusterAgent 通过 Agent 反向隧道纳管的集群
+// 注册 Token 仅在生成时返回一次，数据库中只保存其 SHA256 摘要。
+// Token 需在有效期内完成首次连接，连接成功后 Agent 可持续使用该 Token 重连
+type ClusterAgent struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name            string     `gorm:"size:100;uniqueIndex:idx_cluster_agent_name;not null" json:"name,omitempty"` // 集群名称，作为集群ID的一部分
+	Description     string     `gorm:"type:text" json:"description,omitempty"`
+	TokenHash       string     `gorm:"size:64;index:idx_cluster_agent_token_hash" json:"-"`
+	TokenPrefix     string     `gorm:"size:16" json:"token_prefix,omitempty"` // Token 前缀，用于识别
+	TokenTTLHours   int        `json:"token_ttl_hours"`                       // Token 有效期，单位小时，0 表示永不过期
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`                  // Token 首次连接的截止时间
+	Status          string     `gorm:"size:20" json:"status,omitempty"`
+	AgentVersion    string     `gorm:"size:64" json:"agent_version,omitempty"`
+	KubeVersion     string     `gorm:"size:64" json:"kube_version,omitempty"`
+	RemoteAddr      string     `gorm:"size:128" json:"remote_addr,omitempty"`
+	ConnectedAt     *time.Time `json:"connected_at,omitempty"`
+	LastHeartbeatAt *time.Time `json:"last_heartbeat_at,omitempty"`
+	CreatedBy       string     `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt       time.Time  `json:"updated_at,omitempty"`
+}
+
+func (c *ClusterAgent) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*ClusterAgent, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *ClusterAgent) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *ClusterAgent) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *ClusterAgent) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*ClusterAgent, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// Expired 判断注册 Token 是否已过首次连接的截止时间
+func (c *ClusterAgent) Expired() bool {
+	return c.ConnectedAt == nil && c.ExpiresAt != nil && time.Now().After(*c.ExpiresAt)
+}
@@ -12,6 +12,10 @@ import (
 // ClusterUserRole 集群用户权限表
 // AuthorizationType有两种类型（user、user_group），如果是用户，那么代表这个人有哪些权限
 // 如果是Group，那么代表这个组有哪些权限，这个组可能会有多个用户，那么这多个用户都有相关的权限
+// ClusterGroup 非空时，授权对象为集群分组，分组内的集群随标签变化动态展开
+// Role 为 cluster_custom 时，权限由 CustomRole 指定的自定义角色规则决定
+// ExpiresAt 非空时为临时授权，到期后不再生效，并由定时任务删除
+// MappingID 非空时为用户组映射规则生成的授权，Cluster 可以包含 * 通配，随用户登录或 LDAP 同步自动增删
 type ClusterUserRole struct {
 	ID                  uint                               `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
 	Cluster             string                             `gorm:"size:100;index:idx_cluster_user_role_cluster" json:"cluster,omitempty"`           // 集群名称
@@ -20,6 +24,10 @@ type ClusterUserRole struct {
 	Namespaces          string                             `gorm:"type:text" json:"namespaces,omitempty"`                         // Namespaces列表，逗号分割 ，该用户可以访问的Ns
 	BlacklistNamespaces string                             `gorm:"type:text" json:"blacklist_namespaces,omitempty"`               // 黑名单Namespaces列表，逗号分割，禁止访问的Ns
 	AuthorizationType   constants.ClusterAuthorizationType `gorm:"size:20" json:"authorization_type,omitempty"`                   // 用户类型。User\Group两种，默认为User，空为User。Group指用户组
+	ClusterGroup        string                             `gorm:"size:100;index:idx_cluster_user_role_cluster_group" json:"cluster_group,omitempty"` // 集群分组名称，非空时授权作用于分组匹配的全部集群，Cluster为空
+	CustomRole          string                             `gorm:"size:50;index:idx_cluster_user_role_custom_role" json:"custom_role,omitempty"` // 自定义角色名称，Role为cluster_custom时有效
+	ExpiresAt           *time.Time                         `gorm:"index:idx_cluster_user_role_expires_at" json:"expires_at,omitempty"`             // 到期时间，为空表示长期有效，临时授权到期后自动回收
+	MappingID           uint                               `gorm:"index:idx_cluster_user_role_mapping_id" json:"mapping_id,omitempty"`             // 生成该授权的用户组映射规则ID，为0表示手工授权
 	CreatedAt           time.Time                          `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt           time.Time                          `json:"updated_at,omitempty"`
 }
@@ -9,17 +9,26 @@ import (
 )
 
 type Config struct {
-	ID                   uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
-	ProductName          string    `gorm:"size:100" json:"product_name,omitempty"`             // 产品名称
-	LoginType            string    `gorm:"size:50" json:"login_type,omitempty"`
-	JwtTokenSecret       string    `gorm:"size:255" json:"jwt_token_secret,omitempty"`
-	NodeShellImage       string    `gorm:"size:255" json:"node_shell_image,omitempty"`
-	KubectlShellImage    string    `gorm:"size:255" json:"kubectl_shell_image,omitempty"`
-	ImagePullTimeout     int       `gorm:"default:30" json:"image_pull_timeout,omitempty"` // 镜像拉取超时时间（秒）
-	PrintConfig          bool      `json:"print_config"`
-	ResourceCacheTimeout int       `gorm:"default:60" json:"resource_cache_timeout,omitempty"` // 资源缓存时间（秒）
-	CreatedAt            time.Time `json:"created_at,omitempty" gorm:"<-:create"`
-	UpdatedAt            time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
+	ID                      uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	ProductName             string    `gorm:"size:100" json:"product_name,omitempty"` // 产品名称
+	LoginType               string    `gorm:"size:50" json:"login_type,omitempty"`
+	JwtTokenSecret          string    `gorm:"type:text" json:"jwt_token_secret,omitempty"` // 加密存储
+	NodeShellImage          string    `gorm:"size:255" json:"node_shell_image,omitempty"`
+	KubectlShellImage       string    `gorm:"size:255" json:"kubectl_shell_image,omitempty"`
+	ImagePullTimeout        int       `gorm:"default:30" json:"image_pull_timeout,omitempty"` // 镜像拉取超时时间（秒）
+	PrintConfig             bool      `json:"print_config"`
+	ResourceCacheTimeout    int       `gorm:"default:60" json:"resource_cache_timeout,omitempty"` // 资源缓存时间（秒）
+	LoginMaxFailures        int       `gorm:"default:5" json:"login_max_failures"`                // 连续登录失败多少次后锁定账户，0 表示不锁定
+	LoginLockMinutes        int       `gorm:"default:15" json:"login_lock_minutes"`               // 账户锁定时长（分钟），同时作为统计失败次数的时间窗口
+	LoginAccountMaxFailures int       `gorm:"default:20" json:"login_account_max_failures"`       // 所有来源IP累计失败多少次后锁定账户，0 表示不限制
+	PasswordMinLength       int       `gorm:"default:6" json:"password_min_length"`               // 本地用户密码最小长度
+	PasswordRequireUpper    bool      `json:"password_require_upper"`                             // 密码必须包含大写字母
+	PasswordRequireLower    bool      `json:"password_require_lower"`                             // 密码必须包含小写字母
+	PasswordRequireDigit    bool      `json:"password_require_digit"`                             // 密码必须包含数字
+	PasswordRequireSpecial  bool      `json:"password_require_special"`                           // 密码必须包含特殊字符
+	PasswordExpireDays      int       `json:"password_expire_days"`                               // 本地用户密码有效期（天），0 表示永不过期
+	CreatedAt               time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt               time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
 }
 
 func (c *Config) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*Config, int64, error) {
@@ -37,3 +46,18 @@ func (c *Config) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm
 func (c *Config) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*Config, error) {
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
+
+// BeforeSave 在保存前加密敏感字段
+func (c *Config) BeforeSave(tx *gorm.DB) error {
+	return EncryptSecretFields(c)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (c *Config) AfterSave(tx *gorm.DB) error {
+	return DecryptSecretFields(c)
+}
+
+// AfterFind 在查询后解密敏感字段
+func (c *Config) AfterFind(tx *gorm.DB) error {
+	return DecryptSecretFields(c)
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 自定义角色规则中可用的操作动词
+// 与 kom 回调中的操作对应，scale、restart、port-forward 由对应的功能入口单独声明
+const (
+	VerbGet         = "get"
+	VerbList        = "list"
+	VerbCreate      = "create"
+	VerbUpdate      = "update"
+	VerbPatch       = "patch"
+	VerbDelete      = "delete"
+	VerbExec        = "exec"
+	VerbLogs        = "logs"
+	VerbPortForward = "port-forward"
+	VerbScale       = "scale"
+	VerbRestart     = "restart"
+)
+
+// CustomRoleVerbs 自定义角色支持的全部动词
+var CustomRoleVerbs = []string{
+	VerbGet, VerbList, VerbCreate, VerbUpdate, VerbPatch, VerbDelete,
+	VerbExec, VerbLogs, VerbPortForward, VerbScale, VerbRestart,
+}
+
+// 规则效果，deny 优先于 allow
+const (
+	RuleEffectAllow = "allow"
+	RuleEffectDeny  = "deny"
+)
+
+// CoreAPIGroup 规则中代表核心API组（如 Pod、Secret 所在的组）的名称
+const CoreAPIGroup = "core"
+
+// CustomRoleRule 自定义角色的一条规则，各字段中的 * 表示全部
+type CustomRoleRule struct {
+	Effect        string   `json:"effect,omitempty"`         // allow/deny，为空时为 allow
+	APIGroups     []string `json:"api_groups,omitempty"`     // API组，如 apps、batch，core 表示核心组，为空表示不限制
+	Kinds         []string `json:"kinds,omitempty"`          // 资源类型，如 Deployment、Secret
+	Verbs         []string `json:"verbs,omitempty"`          // 操作动词，见 CustomRoleVerbs
+	ResourceNames []string `json:"resource_names,omitempty"` // 资源名称，为空表示不限制
+}
+
+// CustomRole 自定义集群角色，由管理员定义按资源类型、操作动词划分的细粒度权限，
+// 通过 ClusterUserRole 授权给用户或用户组
+type CustomRole struct {
+	ID          uint             `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name        string           `gorm:"size:50;uniqueIndex:idx_custom_role_name;not null" json:"name,omitempty"` // 角色名称，授权时引用
+	Description string           `gorm:"type:text" json:"description,omitempty"`
+	Rules       []CustomRoleRule `gorm:"type:text;serializer:json" json:"rules,omitempty"`
+	CreatedBy   string           `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedAt   time.Time        `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time        `json:"updated_at,omitempty"`
+}
+
+func (c *CustomRole) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*CustomRole, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *CustomRole) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, c, queryFuncs...)
+}
+
+func (c *CustomRole) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (c *CustomRole) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*CustomRole, error) {
+	return dao.GenericGetOne(params, c, queryFuncs...)
+}
+
+// Normalize 清理规则中的空白与空项，并校验效果与动词是否合法
+func (r *CustomRoleRule) Normalize() error {
+	r.Effect = strings.ToLower(strings.TrimSpace(r.Effect))
+	if r.Effect == "" {
+		r.Effect = RuleEffectAllow
+	}
+	if r.Effect != RuleEffectAllow && r.Effect != RuleEffectDeny {
+		return fmt.Errorf("不支持的规则效果: %s", r.Effect)
+	}
+	r.APIGroups = compactStrings(r.APIGroups)
+	r.Kinds = compactStrings(r.Kinds)
+	r.Verbs = compactStrings(r.Verbs)
+	r.ResourceNames = compactStrings(r.ResourceNames)
+	if len(r.Kinds) == 0 {
+		return fmt.Errorf("规则必须指定资源类型")
+	}
+	if len(r.Verbs) == 0 {
+		return fmt.Errorf("规则必须指定操作动词")
+	}
+	for _, verb := range r.Verbs {
+		if verb != "*" && !slices.Contains(CustomRoleVerbs, verb) {
+			return fmt.Errorf("不支持的操作动词: %s", verb)
+		}
+	}
+	return nil
+}
+
+// Match 判断规则是否命中指定的操作。
+// APIGroups 为空时不限制API组；指定了资源名称时，只有带名称的操作才能命中
+func (r *CustomRoleRule) Match(verb, group, kind, name string) bool {
+	if !matchAny(r.Verbs, verb) {
+		return false
+	}
+	if group == "" {
+		group = CoreAPIGroup
+	}
+	if len(r.APIGroups) > 0 && !matchAny(r.APIGroups, group) {
+		return false
+	}
+	if !slices.ContainsFunc(r.Kinds, func(k string) bool {
+		return k == "*" || strings.EqualFold(k, kind)
+	}) {
+		return false
+	}
+	if len(r.ResourceNames) > 0 && (name == "" || !matchAny(r.ResourceNames, name)) {
+		return false
+	}
+	return true
+}
+
+// IsDeny 是否为拒绝规则
+func (r *CustomRoleRule) IsDeny() bool {
+	return r.Effect == RuleEffectDeny
+}
+
+func matchAny(list []string, value string) bool {
+	return slices.Contains(list, "*") || slices.Contains(list, value)
+}
+
+func compactStrings(list []string) []string {
+	result := make([]string, 0, len(list))
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" && !slices.Contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestCustomRoleRuleMatch(t *testing.T) {
+	deploy := CustomRoleRule{APIGroups: []string{"apps"}, Kinds: []string{"deployment"}, Verbs: []string{"scale", "restart", "get"}}
+	if err := deploy.Normalize(); err != nil {
+		t.Fatalf("规则校验失败: %v", err)
+	}
+	if !deploy.Match("scale", "apps", "Deployment", "web") {
+		t.Errorf("资源类型应忽略大小写匹配")
+	}
+	if deploy.Match("delete", "apps", "Deployment", "web") {
+		t.Errorf("未授权的动词不应命中")
+	}
+	if deploy.Match("get", "", "Pod", "web") {
+		t.Errorf("不同资源类型不应命中")
+	}
+
+	secret := CustomRoleRule{Effect: "Deny", APIGroups: []string{"core"}, Kinds: []string{"Secret"}, Verbs: []string{"*"}}
+	if err := secret.Normalize(); err != nil || !secret.IsDeny() {
+		t.Fatalf("拒绝规则校验失败: %v", err)
+	}
+	if !secret.Match("list", "", "Secret", "") {
+		t.Errorf("core 应匹配核心API组")
+	}
+
+	named := CustomRoleRule{Kinds: []string{"ConfigMap"}, Verbs: []string{"get", "list"}, ResourceNames: []string{"app-config"}}
+	if !named.Match("get", "", "ConfigMap", "app-config") || named.Match("list", "", "ConfigMap", "") {
+		t.Errorf("限定资源名称时只匹配带名称的操作")
+	}
+
+	invalid := []CustomRoleRule{
+		{Kinds: []string{"Pod"}, Verbs: []string{"escalate"}},
+		{Kinds: []string{" "}, Verbs: []string{"get"}},
+		{Effect: "audit", Kinds: []string{"Pod"}, Verbs: []string{"get"}},
+	}
+	for i := range invalid {
+		if err := invalid[i].Normalize(); err == nil {
+			t.Errorf("第%d条非法规则应校验失败", i+1)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 映射规则的用户组来源
+const (
+	GroupMappingSourceSSO  = "sso"  // OIDC、SAML 登录时 IdP 返回的用户组
+	GroupMappingSourceLDAP = "ldap" // LDAP 用户的 memberOf 等组属性
+)
+
+// 映射规则的授权目标
+const (
+	GroupMappingTargetUserGroup   = "user_group"   // 加入 k8m 用户组
+	GroupMappingTargetClusterRole = "cluster_role" // 授予集群角色
+)
+
+// GroupMapping 外部用户组到 k8m 用户组、集群角色的映射规则。
+// 用户登录及 LDAP 定时同步时按规则增删用户组与集群授权，由规则生成的授权在 ClusterUserRole.MappingID 中记录来源
+type GroupMapping struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Source        string    `gorm:"size:20;index:idx_group_mapping_source" json:"source,omitempty"` // sso/ldap
+	SourceName    string    `gorm:"size:100" json:"source_name,omitempty"`                          // SSO 配置名称，为空表示全部 SSO 配置，LDAP 忽略
+	ExternalGroup string    `gorm:"size:255" json:"external_group,omitempty"`                       // 外部用户组，支持 * 通配，LDAP 可填写完整DN或CN
+	TargetType    string    `gorm:"size:20" json:"target_type,omitempty"`                           // user_group/cluster_role
+	UserGroup     string    `gorm:"size:100" json:"user_group,omitempty"`                           // 目标 k8m 用户组
+	Cluster       string    `gorm:"size:255" json:"cluster,omitempty"`                              // 目标集群，支持 * 通配：不含 / 时按 context 名称匹配，如 prod-*；含 / 时按集群ID匹配
+	Role          string    `gorm:"size:50" json:"role,omitempty"`                                  // 集群角色
+	CustomRole    string    `gorm:"size:50" json:"custom_role,omitempty"`                           // Role为cluster_custom时的自定义角色名称
+	Namespaces    string    `gorm:"type:text" json:"namespaces,omitempty"`                          // 限定的命名空间，逗号分隔
+	Description   string    `gorm:"type:text" json:"description,omitempty"`
+	Enabled       bool      `gorm:"default:true" json:"enabled,omitempty"`
+	CreatedBy     string    `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+}
+
+func (c *GroupMapping) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*GroupMapping, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *GroupMapping) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+// Matches 判断外部用户组是否命中规则，忽略大小写。
+// LDAP 组为DN时，同时按第一段RDN（CN=ops）及其值（ops）匹配
+func (c *GroupMapping) Matches(group string) bool {
+	pattern := strings.ToLower(strings.TrimSpace(c.ExternalGroup))
+	if pattern == "" {
+		return false
+	}
+	group = strings.ToLower(strings.TrimSpace(group))
+	if group == "" {
+		return false
+	}
+	candidates := []string{group}
+	if strings.Contains(group, "=") {
+		rdn, _, _ := strings.Cut(group, ",")
+		_, value, _ := strings.Cut(rdn, "=")
+		candidates = append(candidates, rdn, strings.TrimSpace(value))
+	}
+	for _, candidate := range candidates {
+		if utils.MatchWildcard(pattern, candidate) {
+			return true
+		}
+	}
+	return false
+}
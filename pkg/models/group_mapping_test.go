@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestGroupMappingMatches(t *testing.T) {
+	cases := []struct {
+		pattern string
+		group   string
+		want    bool
+	}{
+		{"k8s-admins", "K8S-Admins", true},
+		{"prod-*", "prod-ops", true},
+		{"prod-*", "dev-ops", false},
+		{"k8s-admins", "CN=k8s-admins,OU=Groups,DC=example,DC=com", true},
+		{"cn=k8s-*", "CN=k8s-admins,OU=Groups,DC=example,DC=com", true},
+		{"CN=k8s-admins,OU=Groups,DC=example,DC=com", "cn=k8s-admins,ou=groups,dc=example,dc=com", true},
+		{"Groups", "CN=k8s-admins,OU=Groups,DC=example,DC=com", false},
+		{"*", "", false},
+		{"", "ops", false},
+	}
+	for _, c := range cases {
+		m := &GroupMapping{ExternalGroup: c.pattern}
+		if got := m.Matches(c.group); got != c.want {
+			t.Errorf("规则[%s]匹配[%s]应为%v，实际为%v", c.pattern, c.group, c.want, got)
+		}
+	}
+}
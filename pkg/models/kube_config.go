@@ -1,7 +1,6 @@
 package models
 
 import (
-	"encoding/base64"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
@@ -12,7 +11,7 @@ import (
 // KubeConfig 用户导入kubeconfig
 type KubeConfig struct {
 	ID          uint   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"` // 模板 ID，主键，自增
-	Content     string `gorm:"type:text" json:"content,omitempty"`           // 模板内容，支持大文本存储
+	Content     string `gorm:"type:text" json:"content,omitempty"`           // kubeconfig 内容，加密存储
 	Server      string `gorm:"size:255;index:idx_kube_config_server" json:"server,omitempty"`
 	User        string `gorm:"size:255;index:idx_kube_config_user" json:"user,omitempty"`
 	Cluster     string `gorm:"size:100;index:idx_kube_config_cluster" json:"cluster,omitempty"` // 类型，最大长度 100
@@ -24,9 +23,12 @@ type KubeConfig struct {
 	ClusterName     string `gorm:"size:100;index:idx_kube_config_cluster_name" json:"cluster_name"` // AWS EKS 集群名称
 	Region          string `gorm:"size:50;index:idx_kube_config_region" json:"region"` // AWS 区域
 	IsAWSEKS        bool   `json:"is_aws_eks,omitempty"`                   // 标识是否为AWS EKS集群
+	// 云厂商集群相关（GKE/AKS）
+	CloudProvider   string `gorm:"size:20;index:idx_kube_config_cloud_provider" json:"cloud_provider,omitempty"` // 云厂商，gke 或 aks
+	CloudCredential string `gorm:"type:text" json:"-"`                                                          // 云厂商认证配置 JSON，加密存储
 	// token 纳管相关 server\token\cadata
-	Token  string `gorm:"type:text" json:"token,omitempty"`   // token 内容，支持大文本存储
-	CACert string `gorm:"type:text" json:"ca_data,omitempty"` // ca 证书内容，支持大文本存储
+	Token  string `gorm:"type:text" json:"-"` // token 内容，加密存储
+	CACert string `gorm:"type:text" json:"-"` // ca 证书内容，加密存储
 
 	// kom 集群注册配置项
 	// ProxyURL 设置 HTTP 代理，例如 http://127.0.0.1:7890
@@ -37,6 +39,8 @@ type KubeConfig struct {
 	QPS float32 `gorm:"default:200" json:"qps,omitempty"`
 	// Burst 设置突发请求数限制，默认为 2000
 	Burst int `gorm:"default:2000" json:"burst,omitempty"`
+	// Impersonate 用户模拟模式，开启后以实际操作人的身份访问集群，由 Kubernetes RBAC 鉴权
+	Impersonate bool `gorm:"default:false" json:"impersonate,omitempty"`
 
 	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
@@ -60,62 +64,15 @@ func (c *KubeConfig) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *go
 
 // BeforeSave 在保存前加密敏感字段
 func (c *KubeConfig) BeforeSave(tx *gorm.DB) error {
-	if c.AccessKey != "" {
-		encrypted, err := encryptField(c.AccessKey)
-		if err != nil {
-			return err
-		}
-		c.AccessKey = encrypted
-	}
-	if c.SecretAccessKey != "" {
-		encrypted, err := encryptField(c.SecretAccessKey)
-		if err != nil {
-			return err
-		}
-		c.SecretAccessKey = encrypted
-	}
-	return nil
+	return EncryptSecretFields(c)
 }
 
-// AfterFind 在查询后解密敏感字段
-func (c *KubeConfig) AfterFind(tx *gorm.DB) error {
-	if c.AccessKey != "" {
-		decrypted, err := decryptField(c.AccessKey)
-		if err != nil {
-			return err
-		}
-		c.AccessKey = decrypted
-	}
-	if c.SecretAccessKey != "" {
-		decrypted, err := decryptField(c.SecretAccessKey)
-		if err != nil {
-			return err
-		}
-		c.SecretAccessKey = decrypted
-	}
-	return nil
-}
-
-// encryptField 加密字段
-func encryptField(plaintext string) (string, error) {
-	if plaintext == "" {
-		return "", nil
-	}
-	encrypted, err := utils.AesEncrypt([]byte(plaintext))
-	if err != nil {
-		return "", err
-	}
-	return base64.StdEncoding.EncodeToString(encrypted), nil
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (c *KubeConfig) AfterSave(tx *gorm.DB) error {
+	return DecryptSecretFields(c)
 }
 
-// decryptField 解密字段
-func decryptField(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-	decrypted, err := utils.AesDecrypt(ciphertext)
-	if err != nil {
-		return "", err
-	}
-	return string(decrypted), nil
+// AfterFind 在查询后解密敏感字段
+func (c *KubeConfig) AfterFind(tx *gorm.DB) error {
+	return DecryptSecretFields(c)
 }
@@ -23,6 +23,12 @@ type LDAPConfig struct {
 	LOGIN2AUTHCLOSE bool      `gorm:"default:true" json:"login2_auth_close"`              // 登录后开启认证
 	DefaultGroup    string    `gorm:"size:50" json:"default_group"`                       // 默认用户组
 	Enabled         bool      `gorm:"default:true" json:"enabled"`                        // 启用状态
+	GroupAttribute  string    `gorm:"size:100" json:"group_attribute"`                    // 用户组属性，默认memberOf，用于用户组映射
+	SyncEnabled     bool      `gorm:"default:false" json:"sync_enabled"`                  // 是否定时同步，禁用目录中已删除的用户
+	SyncInterval    int       `gorm:"default:60" json:"sync_interval"`                    // 同步间隔（分钟）
+	SyncMaxMissing  int       `gorm:"default:20" json:"sync_max_missing"`                 // 目录中缺失用户占比（%）超过该值时中止同步，不禁用任何用户
+	LastSyncAt      *time.Time `json:"last_sync_at,omitempty"`                            // 上次同步时间
+	LastSyncResult  string    `gorm:"type:text" json:"last_sync_result,omitempty"`        // 上次同步结果
 	CreatedAt       time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
@@ -46,3 +52,18 @@ func (l *LDAPConfig) Delete(params *dao.Params, ids string, queryFuncs ...func(*
 func (l *LDAPConfig) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*LDAPConfig, error) {
 	return dao.GenericGetOne(params, l, queryFuncs...)
 }
+
+// BeforeSave 在保存前加密敏感字段
+func (l *LDAPConfig) BeforeSave(tx *gorm.DB) error {
+	return EncryptSecretFields(l)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (l *LDAPConfig) AfterSave(tx *gorm.DB) error {
+	return DecryptSecretFields(l)
+}
+
+// AfterFind 在查询后解密敏感字段
+func (l *LDAPConfig) AfterFind(tx *gorm.DB) error {
+	return DecryptSecretFields(l)
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginWindow(t *testing.T) {
+	now := time.Now()
+	at := func(result string, minutes int) *LoginHistory {
+		return &LoginHistory{Result: result, CreatedAt: now.Add(time.Duration(minutes) * time.Minute)}
+	}
+	w := LoginWindow{
+		at(LoginResultFailure, -10),
+		at(LoginResultSuccess, -9),
+		at(LoginResultFailure, -8),
+		at(LoginResultRejected, -7),
+		at(LoginResultFailure, -6),
+	}
+	if n := w.Failures(); n != 2 {
+		t.Errorf("应只统计上次登录成功之后的失败次数，实际为%d", n)
+	}
+	if _, locked := w.LockedUntil(15 * time.Minute); locked {
+		t.Errorf("未达到上限时不应锁定")
+	}
+
+	w = append(w, at(LoginResultLocked, -5))
+	until, locked := w.LockedUntil(15 * time.Minute)
+	if !locked || !until.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("锁定截止时间错误: %v %v", locked, until)
+	}
+	if n := w.Failures(); n != 0 {
+		t.Errorf("锁定后应重新统计失败次数，实际为%d", n)
+	}
+
+	w = append(w, at(LoginResultRejected, -4), at(LoginResultUnlocked, -3), at(LoginResultFailure, -2))
+	if _, locked := w.LockedUntil(15 * time.Minute); locked {
+		t.Errorf("管理员解锁后不应处于锁定状态")
+	}
+	if n := w.Failures(); n != 1 {
+		t.Errorf("解锁后应重新统计失败次数，实际为%d", n)
+	}
+	if n := (LoginWindow{}).Failures(); n != 0 {
+		t.Errorf("无登录记录时失败次数应为0，实际为%d", n)
+	}
+}
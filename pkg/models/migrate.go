@@ -17,6 +17,11 @@ func init() {
 	}
 	klog.V(4).Info("数据库自动迁移完成")
 
+	registerCoreSecretFields()
+	if err := MigrateSecrets(); err != nil {
+		klog.Fatalf("敏感字段加密迁移失败，程序无法启动: %v", err)
+	}
+
 	_ = FixClusterName()
 	_ = FixRoleName()
 	_ = InitConfigTable()
@@ -75,6 +80,39 @@ func AutoMigrate() error {
 	if err := dao.DB().AutoMigrate(&Menu{}); err != nil {
 		errs = append(errs, err)
 	}
+	if err := dao.DB().AutoMigrate(&ClusterAgent{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ClusterLabel{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&ClusterGroup{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&SecretKey{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&CustomRole{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&GroupMapping{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&UserSession{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&WebAuthnCredential{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&AuditSink{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&LoginHistory{}); err != nil {
+		errs = append(errs, err)
+	}
+	if err := dao.DB().AutoMigrate(&SCIMToken{}); err != nil {
+		errs = append(errs, err)
+	}
 
 	// 插件配置表
 	if err := dao.DB().AutoMigrate(&PluginConfig{}); err != nil {
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// SCIMToken SCIM 接口的访问令牌，供身份提供方（IdP）同步用户与用户组。
+// 令牌仅在生成时返回一次，数据库中只保存其 SHA256 摘要
+type SCIMToken struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name        string     `gorm:"size:100;uniqueIndex:idx_scim_token_name" json:"name,omitempty"`
+	Description string     `gorm:"type:text" json:"description,omitempty"`
+	TokenHash   string     `gorm:"size:64;index:idx_scim_token_hash" json:"-"`
+	TokenPrefix string     `gorm:"size:16" json:"token_prefix,omitempty"` // 令牌前缀，用于识别
+	Enabled     bool       `gorm:"default:true" json:"enabled"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy   string     `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+}
+
+func (c *SCIMToken) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*SCIMToken, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *SCIMToken) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
@@ -0,0 +1,624 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// 敏感字段采用信封加密：字段值由数据密钥（DEK）使用 AES-GCM 加密，
+// 数据密钥由主密钥（KEK）加密后保存在 secret_keys 表。
+// 主密钥来自 --master-key / MASTER_KEY 或 --master-key-file / MASTER_KEY_FILE，
+// 均未配置时自动生成主密钥文件，主密钥不保存在数据库中。
+// 加密后的字段值形如 enc:v1:<数据密钥ID>:<base64(nonce||密文)>
+
+const (
+	secretPrefix = "enc:v1:"
+
+	SecretKeyKindData   = "data"   // 数据密钥
+	SecretKeyKindMaster = "master" // 历史版本未配置主密钥时保存在数据库中的主密钥，加载时迁移到主密钥文件
+)
+
+// SecretMask 密钥类字段在接口中的回显值，表示已设置但不返回明文。保存时提交该值表示不修改
+const SecretMask = "******"
+
+// SecretKey 加密密钥。数据密钥的 Material 为主密钥加密后的密文；
+// kind 为 master 的记录仅来自历史版本，加载时迁移到主密钥文件后删除
+type SecretKey struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Kind        string    `gorm:"size:20;index:idx_secret_key_kind" json:"kind,omitempty"`
+	MasterKeyID string    `gorm:"size:32" json:"master_key_id,omitempty"` // 加密本密钥的主密钥指纹
+	Material    string    `gorm:"type:text" json:"-"`
+	Active      bool      `json:"active"` // 当前用于加密的数据密钥
+	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// secretFieldSet 模型中需要加密存储的字段
+type secretFieldSet struct {
+	model  any
+	fields []string        // Go 字段名
+	legacy map[string]bool // 历史数据使用 utils.AesEncrypt 加密的字段
+}
+
+var (
+	secretFieldsMu sync.RWMutex
+	secretFields   = map[reflect.Type]*secretFieldSet{}
+)
+
+// RegisterSecretFields 注册模型中需要加密存储的字段（Go 字段名）。
+// 模型需在 BeforeSave 中调用 EncryptSecretFields，在 AfterSave、AfterFind 中调用 DecryptSecretFields
+func RegisterSecretFields(model any, fields ...string) {
+	registerSecretFields(model, false, fields...)
+}
+
+// RegisterLegacySecretFields 注册历史上已使用 utils.AesEncrypt 加密的字段，迁移时先按旧方式解密
+func RegisterLegacySecretFields(model any, fields ...string) {
+	registerSecretFields(model, true, fields...)
+}
+
+func registerSecretFields(model any, legacy bool, fields ...string) {
+	secretFieldsMu.Lock()
+	defer secretFieldsMu.Unlock()
+	t := reflect.TypeOf(model)
+	set, ok := secretFields[t]
+	if !ok {
+		set = &secretFieldSet{model: model, legacy: map[string]bool{}}
+		secretFields[t] = set
+	}
+	for _, f := range fields {
+		set.fields = append(set.fields, f)
+		set.legacy[f] = legacy
+	}
+}
+
+// registerCoreSecretFields 平台自身的敏感字段，插件在各自的 models 包中注册
+func registerCoreSecretFields() {
+	RegisterSecretFields(&KubeConfig{}, "Content", "Token", "CACert", "CloudCredential")
+	RegisterLegacySecretFields(&KubeConfig{}, "AccessKey", "SecretAccessKey")
+	RegisterSecretFields(&Config{}, "JwtTokenSecret")
+	RegisterSecretFields(&SSOConfig{}, "ClientSecret")
+	RegisterLegacySecretFields(&LDAPConfig{}, "BindPassword")
+	RegisterSecretFields(&User{}, "TwoFASecret")
+	RegisterSecretFields(&AuditSink{}, "Headers")
+}
+
+func lookupSecretFields(model any) *secretFieldSet {
+	secretFieldsMu.RLock()
+	defer secretFieldsMu.RUnlock()
+	return secretFields[reflect.TypeOf(model)]
+}
+
+// EncryptSecretFields 加密模型中已注册的敏感字段，已加密的字段不重复加密
+func EncryptSecretFields(model any) error {
+	set := lookupSecretFields(model)
+	if set == nil {
+		return nil
+	}
+	v := reflect.ValueOf(model).Elem()
+	for _, f := range set.fields {
+		fv := v.FieldByName(f)
+		encrypted, err := EncryptSecret(fv.String())
+		if err != nil {
+			return fmt.Errorf("加密字段 %s 失败: %w", f, err)
+		}
+		fv.SetString(encrypted)
+	}
+	return nil
+}
+
+// DecryptSecretFields 解密模型中已注册的敏感字段，兼容尚未迁移的明文及旧方式加密的数据
+func DecryptSecretFields(model any) error {
+	set := lookupSecretFields(model)
+	if set == nil {
+		return nil
+	}
+	v := reflect.ValueOf(model).Elem()
+	for _, f := range set.fields {
+		fv := v.FieldByName(f)
+		plaintext, err := decryptSecretValue(fv.String(), set.legacy[f])
+		if err != nil {
+			return fmt.Errorf("解密字段 %s 失败: %w", f, err)
+		}
+		fv.SetString(plaintext)
+	}
+	return nil
+}
+
+// MaskSecret 已设置的密钥替换为 SecretMask，未设置的保持为空
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return SecretMask
+}
+
+// IsEncryptedSecret 是否为加密后的字段值
+func IsEncryptedSecret(s string) bool {
+	return strings.HasPrefix(s, secretPrefix)
+}
+
+// EncryptSecret 使用当前数据密钥加密，空值及已加密的值原样返回
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" || IsEncryptedSecret(plaintext) {
+		return plaintext, nil
+	}
+	id, dek, err := keyring.activeKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := utils.GCMEncrypt(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d:%s", secretPrefix, id, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 的结果，未加密的值原样返回
+func DecryptSecret(s string) (string, error) {
+	if !IsEncryptedSecret(s) {
+		return s, nil
+	}
+	id, data, err := parseSecret(s)
+	if err != nil {
+		return "", err
+	}
+	dek, err := keyring.dataKey(id)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := utils.GCMDecrypt(dek, data)
+	if err != nil {
+		return "", fmt.Errorf("数据密钥[%d]解密失败: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+func parseSecret(s string) (uint, []byte, error) {
+	idStr, payload, ok := strings.Cut(strings.TrimPrefix(s, secretPrefix), ":")
+	if !ok {
+		return 0, nil, errors.New("加密字段格式错误")
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("加密字段格式错误: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("加密字段格式错误: %w", err)
+	}
+	return uint(id), data, nil
+}
+
+func decryptSecretValue(s string, legacy bool) (string, error) {
+	if s == "" || IsEncryptedSecret(s) || !legacy {
+		return DecryptSecret(s)
+	}
+	return legacyDecrypt(s)
+}
+
+// legacyDecrypt 解密 utils.AesEncrypt 加密并 base64 编码的旧数据
+func legacyDecrypt(s string) (plaintext string, err error) {
+	// 非法长度的密文会导致 CBC 解密 panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("旧密文格式错误: %v", r)
+		}
+	}()
+	raw, err := utils.AesDecrypt(s)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// MigrateSecrets 加密全部已注册模型中尚未加密的字段
+func MigrateSecrets() error {
+	if err := keyring.load(); err != nil {
+		return err
+	}
+	for _, set := range allSecretFieldSets() {
+		if _, err := reencryptSecretFields(set, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateSecretFields 加密指定模型中尚未加密的字段，供插件安装、升级时调用
+func MigrateSecretFields(model any) error {
+	set := lookupSecretFields(model)
+	if set == nil {
+		return fmt.Errorf("模型 %T 未注册加密字段", model)
+	}
+	_, err := reencryptSecretFields(set, false)
+	return err
+}
+
+// RotateDataKey 生成新的数据密钥，并使用新密钥重新加密全部已注册模型的字段，返回重新加密的字段数量。
+// 旧数据密钥保留，用于解密尚未安装插件中残留的数据
+func RotateDataKey() (int, error) {
+	if err := keyring.rotate(); err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, set := range allSecretFieldSets() {
+		n, err := reencryptSecretFields(set, true)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	klog.V(4).Infof("数据密钥轮换完成，重新加密 %d 个字段", total)
+	return total, nil
+}
+
+func allSecretFieldSets() []*secretFieldSet {
+	secretFieldsMu.RLock()
+	defer secretFieldsMu.RUnlock()
+	sets := make([]*secretFieldSet, 0, len(secretFields))
+	for _, set := range secretFields {
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// reencryptSecretFields 直接读写表中的字段，绕过模型钩子。
+// 未加密的值加密；rotate 为 true 时，非当前数据密钥加密的值也重新加密
+func reencryptSecretFields(set *secretFieldSet, rotate bool) (int, error) {
+	db := dao.DB()
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(set.model); err != nil {
+		return 0, err
+	}
+	table := stmt.Schema.Table
+	if !db.Migrator().HasTable(table) {
+		return 0, nil
+	}
+	columns := make([]string, 0, len(set.fields))
+	legacy := make([]bool, 0, len(set.fields))
+	for _, f := range set.fields {
+		field := stmt.Schema.LookUpField(f)
+		if field == nil {
+			return 0, fmt.Errorf("表 %s 不存在字段 %s", table, f)
+		}
+		columns = append(columns, field.DBName)
+		legacy = append(legacy, set.legacy[f])
+	}
+	activeID, _, err := keyring.activeKey()
+	if err != nil {
+		return 0, err
+	}
+
+	// 先读取全部行再处理，避免 sqlite 单连接下读写互相等待
+	type secretRow struct {
+		id     uint
+		values []sql.NullString
+	}
+	var list []secretRow
+	rows, err := db.Table(table).Select(append([]string{"id"}, columns...)).Rows()
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		r := secretRow{values: make([]sql.NullString, len(columns))}
+		dest := []any{&r.id}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		list = append(list, r)
+	}
+	_ = rows.Close()
+
+	updates := map[uint]map[string]any{}
+	for _, r := range list {
+		for i, v := range r.values {
+			if v.String == "" || !needReencrypt(v.String, rotate, activeID) {
+				continue
+			}
+			plaintext, err := decryptSecretValue(v.String, legacy[i])
+			if err != nil {
+				// 无法解密的值保持原样，不影响其他数据
+				klog.Errorf("表 %s 记录[%d]字段 %s 解密失败，跳过: %v", table, r.id, columns[i], err)
+				continue
+			}
+			encrypted, err := EncryptSecret(plaintext)
+			if err != nil {
+				return 0, err
+			}
+			if updates[r.id] == nil {
+				updates[r.id] = map[string]any{}
+			}
+			updates[r.id][columns[i]] = encrypted
+		}
+	}
+
+	count := 0
+	for id, values := range updates {
+		if err := db.Table(table).Where("id = ?", id).UpdateColumns(values).Error; err != nil {
+			return count, err
+		}
+		count += len(values)
+	}
+	if count > 0 {
+		klog.V(4).Infof("表 %s 加密敏感字段 %d 个", table, count)
+	}
+	return count, nil
+}
+
+func needReencrypt(s string, rotate bool, activeID uint) bool {
+	if !IsEncryptedSecret(s) {
+		return true
+	}
+	if !rotate {
+		return false
+	}
+	id, _, err := parseSecret(s)
+	return err == nil && id != activeID
+}
+
+// secretKeyring 已解密的数据密钥，首次使用时从数据库加载
+type secretKeyring struct {
+	mu       sync.RWMutex
+	loaded   bool
+	master   []byte
+	masterID string
+	keys     map[uint][]byte
+	active   uint
+}
+
+var keyring = &secretKeyring{}
+
+func (k *secretKeyring) activeKey() (uint, []byte, error) {
+	if err := k.ensureLoaded(); err != nil {
+		return 0, nil, err
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active, k.keys[k.active], nil
+}
+
+func (k *secretKeyring) dataKey(id uint) ([]byte, error) {
+	if err := k.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	dek, ok := k.keys[id]
+	k.mu.RUnlock()
+	if ok {
+		return dek, nil
+	}
+	// 可能是其他实例轮换后新建的数据密钥，重新加载一次
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if dek, ok = k.keys[id]; !ok {
+		return nil, fmt.Errorf("数据密钥[%d]不存在", id)
+	}
+	return dek, nil
+}
+
+func (k *secretKeyring) ensureLoaded() error {
+	k.mu.RLock()
+	loaded := k.loaded
+	k.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+	return k.load()
+}
+
+// load 加载主密钥与全部数据密钥。由旧主密钥加密的数据密钥使用当前主密钥重新加密，
+// 不存在数据密钥时生成一个
+func (k *secretKeyring) load() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	db := dao.DB()
+
+	var stored *SecretKey
+	var masterRows []*SecretKey
+	if err := db.Where("kind = ?", SecretKeyKindMaster).Order("id asc").Find(&masterRows).Error; err != nil {
+		return err
+	}
+	if len(masterRows) > 0 {
+		stored = masterRows[0]
+	}
+
+	master, err := configuredMasterKey()
+	if err != nil {
+		return err
+	}
+	if master == nil {
+		if master, err = k.generateMasterKeyFile(db, stored); err != nil {
+			return err
+		}
+	}
+	masterID := utils.SecretKeyID(master)
+
+	// 可用于解密数据密钥的全部主密钥
+	masters := map[string][]byte{masterID: master}
+	for _, m := range strings.Split(flag.Init().MasterKeyPrevious, ",") {
+		if strings.TrimSpace(m) != "" {
+			key := utils.DeriveSecretKey(m)
+			masters[utils.SecretKeyID(key)] = key
+		}
+	}
+	if stored != nil {
+		key := utils.DeriveSecretKey(stored.Material)
+		masters[utils.SecretKeyID(key)] = key
+	}
+
+	var rows []*SecretKey
+	if err := db.Where("kind = ?", SecretKeyKindData).Order("id asc").Find(&rows).Error; err != nil {
+		return err
+	}
+	keys := make(map[uint][]byte, len(rows))
+	var active uint
+	for _, row := range rows {
+		kek, ok := masters[row.MasterKeyID]
+		if !ok {
+			return fmt.Errorf("数据密钥[%d]由主密钥[%s]加密，当前主密钥无法解密，请通过 MASTER_KEY_PREVIOUS 提供原主密钥", row.ID, row.MasterKeyID)
+		}
+		raw, err := base64.StdEncoding.DecodeString(row.Material)
+		if err != nil {
+			return fmt.Errorf("数据密钥[%d]格式错误: %w", row.ID, err)
+		}
+		dek, err := utils.GCMDecrypt(kek, raw)
+		if err != nil {
+			return fmt.Errorf("数据密钥[%d]解密失败: %w", row.ID, err)
+		}
+		if row.MasterKeyID != masterID {
+			if err := k.wrapAndSave(db, row, master, masterID, dek); err != nil {
+				return err
+			}
+			klog.V(4).Infof("数据密钥[%d]已使用新的主密钥重新加密", row.ID)
+		}
+		keys[row.ID] = dek
+		if row.Active {
+			active = row.ID
+		}
+	}
+
+	k.master, k.masterID, k.keys = master, masterID, keys
+	if active == 0 {
+		if err := k.createDataKey(db); err != nil {
+			return err
+		}
+	} else {
+		k.active = active
+	}
+
+	// 数据密钥均已使用当前主密钥重新加密，历史版本保存在数据库中的主密钥不再需要
+	if len(masterRows) > 0 {
+		if err := db.Where("kind = ?", SecretKeyKindMaster).Delete(&SecretKey{}).Error; err != nil {
+			return err
+		}
+		klog.V(4).Infof("删除数据库中保存的主密钥")
+	}
+	k.loaded = true
+	return nil
+}
+
+// rotate 生成新的数据密钥并设为当前密钥
+func (k *secretKeyring) rotate() error {
+	if err := k.ensureLoaded(); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.createDataKey(dao.DB())
+}
+
+// createDataKey 生成数据密钥并设为当前密钥，调用方需持有写锁
+func (k *secretKeyring) createDataKey(db *gorm.DB) error {
+	dek, err := utils.NewSecretKey()
+	if err != nil {
+		return err
+	}
+	row := &SecretKey{Kind: SecretKeyKindData, Active: true}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&SecretKey{}).Where("kind = ? and active = ?", SecretKeyKindData, true).Update("active", false).Error; err != nil {
+			return err
+		}
+		return k.wrapAndSave(tx, row, k.master, k.masterID, dek)
+	})
+	if err != nil {
+		return err
+	}
+	k.keys[row.ID] = dek
+	k.active = row.ID
+	klog.V(4).Infof("生成数据密钥[%d]", row.ID)
+	return nil
+}
+
+// wrapAndSave 使用主密钥加密数据密钥后保存
+func (k *secretKeyring) wrapAndSave(db *gorm.DB, row *SecretKey, master []byte, masterID string, dek []byte) error {
+	wrapped, err := utils.GCMEncrypt(master, dek)
+	if err != nil {
+		return err
+	}
+	row.Material = base64.StdEncoding.EncodeToString(wrapped)
+	row.MasterKeyID = masterID
+	return db.Save(row).Error
+}
+
+// defaultMasterKeyFile 未配置主密钥时自动生成的主密钥文件，与 sqlite 数据库位于同一目录
+func defaultMasterKeyFile() string {
+	return filepath.Join(filepath.Dir(flag.Init().SqlitePath), "master.key")
+}
+
+// configuredMasterKey 读取主密钥，依次使用 MASTER_KEY、MASTER_KEY_FILE 与自动生成的主密钥文件，均不存在时返回 nil
+func configuredMasterKey() ([]byte, error) {
+	cfg := flag.Init()
+	material := cfg.MasterKey
+	if material == "" {
+		path := cfg.MasterKeyFile
+		if path == "" {
+			path = defaultMasterKeyFile()
+		}
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) && cfg.MasterKeyFile == "" {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取主密钥文件失败: %w", err)
+		}
+		material = string(content)
+	}
+	if strings.TrimSpace(material) == "" {
+		return nil, fmt.Errorf("主密钥为空")
+	}
+	return utils.DeriveSecretKey(material), nil
+}
+
+// generateMasterKeyFile 未配置主密钥时生成主密钥文件（权限 0600），主密钥不保存在数据库中。
+// 历史版本保存在数据库中的主密钥迁移到文件；数据库中已有数据密钥但找不到主密钥时拒绝生成，
+// 避免多实例部署时各实例生成不同的主密钥
+func (k *secretKeyring) generateMasterKeyFile(db *gorm.DB, stored *SecretKey) ([]byte, error) {
+	path := defaultMasterKeyFile()
+	var material string
+	if stored != nil {
+		material = stored.Material
+	} else {
+		var count int64
+		if err := db.Model(&SecretKey{}).Where("kind = ?", SecretKeyKindData).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("数据库中已有数据密钥，但未配置主密钥且主密钥文件 %s 不存在，多实例部署时请为所有实例配置相同的 MASTER_KEY 或 MASTER_KEY_FILE", path)
+		}
+		key, err := utils.NewSecretKey()
+		if err != nil {
+			return nil, err
+		}
+		material = base64.StdEncoding.EncodeToString(key)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("创建主密钥文件目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(material), 0o600); err != nil {
+		return nil, fmt.Errorf("写入主密钥文件失败: %w", err)
+	}
+	klog.Warningf("未配置主密钥（MASTER_KEY / MASTER_KEY_FILE），已生成主密钥文件 %s。请单独备份该文件，丢失后敏感字段无法解密；生产环境建议通过 MASTER_KEY_FILE 挂载密钥", path)
+	return configuredMasterKey()
+}
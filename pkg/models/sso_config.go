@@ -14,11 +14,15 @@ type SSOConfig struct {
 	Name               string    `gorm:"size:100;uniqueIndex:idx_sso_config_name" json:"name,omitempty"` // 配置名称
 	Type               string    `gorm:"size:20;default:oidc" json:"type,omitempty"`          // 配置类型
 	ClientID           string    `gorm:"type:text" json:"client_id,omitempty"`                // OAuth2客户端ID
-	ClientSecret       string    `gorm:"type:text" json:"client_secret,omitempty"`            // OAuth2客户端密钥
+	ClientSecret       string    `gorm:"type:text" json:"client_secret,omitempty"`            // OAuth2客户端密钥，加密存储
 	Issuer             string    `gorm:"type:text" json:"issuer,omitempty"`                   // 认证服务器地址
 	Enabled            bool      `gorm:"default:false" json:"enabled,omitempty"`              // 是否启用SSO
 	PreferUserNameKeys string    `gorm:"type:text" json:"prefer_user_name_keys,omitempty"`    // 用户自定义获取用户名的字段顺序，适用于如果用户名字段不在默认字段中情况
 	Scopes             string    `gorm:"type:text" json:"scopes,omitempty"`                   // 授权范围
+	IdpMetadataURL     string    `gorm:"type:text" json:"idp_metadata_url,omitempty"`         // SAML IdP 元数据地址，保存时自动导入
+	IdpMetadata        string    `gorm:"type:text" json:"idp_metadata,omitempty"`             // SAML IdP 元数据XML
+	SPEntityID         string    `gorm:"type:text" json:"sp_entity_id,omitempty"`             // SAML SP 实体ID，为空时使用SP元数据地址
+	GroupsAttribute    string    `gorm:"type:text" json:"groups_attribute,omitempty"`         // SAML 用户组属性名称，逗号分隔，按顺序查找
 	CreatedAt          time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt          time.Time `json:"updated_at,omitempty"` // 更新时间
 }
@@ -42,3 +46,18 @@ func (s *SSOConfig) Delete(params *dao.Params, ids string, queryFuncs ...func(*g
 func (s *SSOConfig) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*SSOConfig, error) {
 	return dao.GenericGetOne(params, s, queryFuncs...)
 }
+
+// BeforeSave 在保存前加密敏感字段
+func (s *SSOConfig) BeforeSave(tx *gorm.DB) error {
+	return EncryptSecretFields(s)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (s *SSOConfig) AfterSave(tx *gorm.DB) error {
+	return DecryptSecretFields(s)
+}
+
+// AfterFind 在查询后解密敏感字段
+func (s *SSOConfig) AfterFind(tx *gorm.DB) error {
+	return DecryptSecretFields(s)
+}
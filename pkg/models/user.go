@@ -20,10 +20,13 @@ type User struct {
 	UpdatedAt        time.Time `json:"updated_at,omitempty"`                                      // Automatically managed by GORM for update time
 	TwoFAEnabled     bool      `gorm:"default:false" json:"two_fa_enabled,omitempty"`             // 是否启用2FA
 	TwoFAType        string    `gorm:"size:20" json:"two_fa_type,omitempty"`                      // 2FA类型：如 'totp', 'sms', 'email'
-	TwoFASecret      string    `gorm:"size:100" json:"two_fa_secret,omitempty"`                   // 2FA密钥
+	TwoFASecret      string    `gorm:"size:255" json:"two_fa_secret,omitempty"`                   // 2FA密钥，加密存储
 	TwoFABackupCodes string    `gorm:"size:500" json:"two_fa_backup_codes,omitempty"`             // 备用恢复码，逗号分隔
 	TwoFAAppName     string    `gorm:"size:100" json:"two_fa_app_name,omitempty"`                 // 2FA应用名称，用于提醒用户使用的是哪个软件
+	WebAuthnEnabled  bool      `gorm:"default:false" json:"webauthn_enabled,omitempty"`           // 是否注册了通行密钥，可代替TOTP作为2FA
 	Disabled         bool      `gorm:"default:false" json:"disabled,omitempty"`                   // 是否启用
+	MappedGroupNames string    `gorm:"type:text" json:"mapped_group_names,omitempty"`            // 由用户组映射规则加入的用户组，不再命中规则时自动移出
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`                          // 最近一次设置密码的时间，用于判断密码是否过期
 }
 
 func (c *User) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*User, int64, error) {
@@ -55,3 +58,18 @@ func (c *User) IsDisabled(username string) (bool, error) {
 
 	return user.Disabled, nil
 }
+
+// BeforeSave 在保存前加密敏感字段
+func (c *User) BeforeSave(tx *gorm.DB) error {
+	return EncryptSecretFields(c)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (c *User) AfterSave(tx *gorm.DB) error {
+	return DecryptSecretFields(c)
+}
+
+// AfterFind 在查询后解密敏感字段
+func (c *User) AfterFind(tx *gorm.DB) error {
+	return DecryptSecretFields(c)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"gorm.io/gorm"
+)
+
+// UserSession 用户登录会话。每次登录生成一个会话，访问令牌（JWT）中携带会话ID，
+// 刷新令牌只保存哈希值，每次刷新都会轮换。会话被吊销后，其访问令牌与刷新令牌立即失效
+type UserSession struct {
+	ID               uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	SessionID        string     `gorm:"size:64;uniqueIndex:idx_user_session_sid" json:"session_id,omitempty"`
+	Username         string     `gorm:"size:100;index:idx_user_session_username" json:"username,omitempty"`
+	RefreshTokenHash string     `gorm:"size:64;index:idx_user_session_refresh" json:"-"`
+	PrevTokenHash    string     `gorm:"size:64;index:idx_user_session_prev_refresh" json:"-"` // 上一个刷新令牌的哈希，再次出现说明令牌已泄露
+	LoginType        string     `gorm:"size:100" json:"login_type,omitempty"`                 // 登录方式：password/ldap/SSO配置名称
+	ClientIP         string     `gorm:"size:64" json:"client_ip,omitempty"`
+	UserAgent        string     `gorm:"size:512" json:"user_agent,omitempty"`
+	LastActiveAt     time.Time  `json:"last_active_at,omitempty"`                                      // 登录或最近一次刷新令牌的时间
+	ExpiresAt        time.Time  `gorm:"index:idx_user_session_expires_at" json:"expires_at,omitempty"` // 刷新令牌过期时间，刷新时顺延
+	RevokedAt        *time.Time `gorm:"index:idx_user_session_revoked_at" json:"revoked_at,omitempty"`
+	RevokedBy        string     `gorm:"size:100" json:"revoked_by,omitempty"`
+	RevokeReason     string     `gorm:"size:255" json:"revoke_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt        time.Time  `json:"updated_at,omitempty"`
+}
+
+func (c *UserSession) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*UserSession, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+// Active 会话未吊销且未过期
+func (c *UserSession) Active(now time.Time) bool {
+	return c.RevokedAt == nil && now.Before(c.ExpiresAt)
+}
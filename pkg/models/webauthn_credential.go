@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential 用户注册的通行密钥（Passkey）或安全密钥，一个用户可注册多个
+type WebAuthnCredential struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Username       string     `gorm:"size:100;index:idx_webauthn_credential_username" json:"username,omitempty"`
+	Name           string     `gorm:"size:100" json:"name,omitempty"`                                                 // 用户自定义的名称，便于区分多个密钥
+	CredentialID   string     `gorm:"size:512;uniqueIndex:idx_webauthn_credential_id" json:"credential_id,omitempty"` // 凭据ID，base64url 编码
+	PublicKey      string     `gorm:"type:text" json:"-"`                                                             // COSE_Key 编码的公钥，base64url 编码
+	SignCount      uint32     `json:"sign_count"`                                                                     // 签名计数，用于发现被复制的凭据
+	AAGUID         string     `gorm:"size:64" json:"aaguid,omitempty"`                                                // 认证器型号标识
+	Transports     string     `gorm:"size:100" json:"transports,omitempty"`                                           // 认证器支持的传输方式，逗号分隔
+	Passwordless   bool       `gorm:"default:false" json:"passwordless"`                                              // 是否可用于免密登录
+	BackupEligible bool       `gorm:"default:false" json:"backup_eligible"`                                           // 是否为可跨设备同步的通行密钥
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt      time.Time  `json:"updated_at,omitempty"`
+}
+
+func (c *WebAuthnCredential) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*WebAuthnCredential, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
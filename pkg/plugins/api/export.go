@@ -3,6 +3,8 @@ package api
 func InitNoopService() {
 	initAINoop()
 	initWebhookNoop()
+	initAIKnowledgeNoop()
+	initPodDiagnosisNoop()
 }
 
 // AIChatService 返回当前生效的 AIChat 实现，始终非 nil。
@@ -19,3 +21,13 @@ func AIConfigService() AIConfig {
 func WebhookService() Webhook {
 	return webhookVal.Load().(*webhookHolder).svc
 }
+
+// AIKnowledgeService 返回当前生效的 AIKnowledge 实现，始终非 nil。
+func AIKnowledgeService() AIKnowledge {
+	return aiKnowledgeVal.Load().(*aiKnowledgeHolder).kb
+}
+
+// PodDiagnosisService 返回当前生效的 PodDiagnosis 实现，始终非 nil。
+func PodDiagnosisService() PodDiagnosis {
+	return podDiagnosisVal.Load().(*podDiagnosisHolder).svc
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"k8s.io/klog/v2"
+)
+
+// KnowledgePassage 知识库检索命中的片段，Source 用于在回答中标注引用来源。
+type KnowledgePassage struct {
+	Title   string  `json:"title"`
+	Source  string  `json:"source"`
+	Content string  `json:"content"`
+	Score   float32 `json:"score"`
+}
+
+// AIKnowledge 抽象 AI 知识库检索能力，对调用方隐藏向量化与存储实现。
+type AIKnowledge interface {
+	// Retrieve 检索与问题最相关的 topK 个知识片段。
+	Retrieve(ctx context.Context, query string, topK int) ([]KnowledgePassage, error)
+	// AugmentPrompt 将检索到的知识片段及引用要求追加到提示词中，未命中时原样返回。
+	AugmentPrompt(ctx context.Context, query string, prompt string) string
+}
+
+// AugmentWithPassages 将知识片段及引用要求追加到提示词中，passages 为空时原样返回。
+func AugmentWithPassages(prompt string, passages []KnowledgePassage) string {
+	if len(passages) == 0 {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n以下是从知识库中检索到的参考资料，仅在与问题相关时使用：\n")
+	for i, p := range passages {
+		b.WriteString(fmt.Sprintf("\n[%d] %s（来源：%s）\n%s\n", i+1, p.Title, p.Source, p.Content))
+	}
+	b.WriteString("\n如果回答中使用了上述参考资料，请在相应位置以 [编号] 标注引用，并在回答末尾列出引用的标题与来源。")
+	return b.String()
+}
+
+// noopAIKnowledge 为默认的空实现，未注册时不做任何增强。
+type noopAIKnowledge struct{}
+
+func (noopAIKnowledge) Retrieve(ctx context.Context, query string, topK int) ([]KnowledgePassage, error) {
+	klog.V(4).Infof("AI 插件未开启,Retrieve 方法未执行 ")
+	return nil, nil
+}
+
+func (noopAIKnowledge) AugmentPrompt(ctx context.Context, query string, prompt string) string {
+	return prompt
+}
+
+var aiKnowledgeVal atomic.Value // 保存 AIKnowledge 实现，始终为非 nil
+
+type aiKnowledgeHolder struct {
+	kb AIKnowledge
+}
+
+func initAIKnowledgeNoop() {
+	aiKnowledgeVal.Store(&aiKnowledgeHolder{kb: noopAIKnowledge{}})
+}
+
+// RegisterAIKnowledge 在运行期注册或切换知识库能力实现。
+func RegisterAIKnowledge(kb AIKnowledge) {
+	if kb == nil {
+		kb = noopAIKnowledge{}
+	}
+	aiKnowledgeVal.Store(&aiKnowledgeHolder{kb: kb})
+}
+
+// UnregisterAIKnowledge 在运行期取消注册知识库能力，实现回退为 noop。
+func UnregisterAIKnowledge() {
+	aiKnowledgeVal.Store(&aiKnowledgeHolder{kb: noopAIKnowledge{}})
+}
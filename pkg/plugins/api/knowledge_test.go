@@ -0,0 +1,24 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAugmentWithPassages(t *testing.T) {
+	if got := AugmentWithPassages("问题", nil); got != "问题" {
+		t.Errorf("未命中知识片段时应原样返回: %q", got)
+	}
+	got := AugmentWithPassages("问题", []KnowledgePassage{
+		{Title: "镜像拉取", Source: "inspection:pod", Content: "检查镜像地址"},
+		{Title: "节点压力", Source: "manual:1", Content: "清理磁盘"},
+	})
+	if !strings.HasPrefix(got, "问题\n\n") {
+		t.Errorf("参考资料应追加在提示词之后: %q", got)
+	}
+	for _, want := range []string{"[1] 镜像拉取（来源：inspection:pod）\n检查镜像地址", "[2] 节点压力（来源：manual:1）", "[编号] 标注引用"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("缺少 %q: %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodDiagnosis 抽象 Pod 故障诊断能力，Pod 监听器只负责上报状态变化，
+// 是否判定为故障、是否需要分析由具体插件决定。
+type PodDiagnosis interface {
+	// ObservePod 上报 Pod 的最新状态，实现方不得阻塞调用方，也不得持有传入的 pod 指针。
+	ObservePod(selectedCluster string, pod *corev1.Pod)
+}
+
+// noopPodDiagnosis 为默认的空实现，未注册时忽略所有上报。
+type noopPodDiagnosis struct{}
+
+func (noopPodDiagnosis) ObservePod(selectedCluster string, pod *corev1.Pod) {}
+
+var podDiagnosisVal atomic.Value // 保存 PodDiagnosis 实现，始终为非 nil
+
+type podDiagnosisHolder struct {
+	svc PodDiagnosis
+}
+
+func initPodDiagnosisNoop() {
+	podDiagnosisVal.Store(&podDiagnosisHolder{svc: noopPodDiagnosis{}})
+}
+
+// RegisterPodDiagnosis 在运行期注册或切换 Pod 故障诊断能力实现。
+func RegisterPodDiagnosis(svc PodDiagnosis) {
+	if svc == nil {
+		svc = noopPodDiagnosis{}
+	}
+	podDiagnosisVal.Store(&podDiagnosisHolder{svc: svc})
+}
+
+// UnregisterPodDiagnosis 在运行期取消注册 Pod 故障诊断能力，实现回退为 noop。
+func UnregisterPodDiagnosis() {
+	podDiagnosisVal.Store(&podDiagnosisHolder{svc: noopPodDiagnosis{}})
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/models"
+	"github.com/weibaohui/k8m/pkg/response"
+)
+
+type SettingController struct{}
+
+// @Summary 获取临时提权配置
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/access_request/setting/get [get]
+func (s *SettingController) GetSetting(c *response.Context) {
+	setting, err := models.GetOrCreateAccessRequestSetting()
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, setting)
+}
+
+// @Summary 更新临时提权配置
+// @Security BearerAuth
+// @Param request body models.AccessRequestSetting true "临时提权配置"
+// @Success 200 {object} string
+// @Router /admin/plugins/access_request/setting/update [post]
+func (s *SettingController) UpdateSetting(c *response.Context) {
+	var in models.AccessRequestSetting
+	if err := c.ShouldBindJSON(&in); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	if _, err := models.UpdateAccessRequestSetting(&in); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
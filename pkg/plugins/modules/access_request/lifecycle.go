@@ -0,0 +1,74 @@
+package access_request
+
+import (
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/models"
+	accessrequest "github.com/weibaohui/k8m/pkg/plugins/modules/access_request/service"
+	"k8s.io/klog/v2"
+)
+
+// AccessRequestLifecycle 临时提权插件生命周期实现
+type AccessRequestLifecycle struct{}
+
+// Install 安装临时提权插件
+func (l *AccessRequestLifecycle) Install(ctx plugins.InstallContext) error {
+	if err := models.InitDB(); err != nil {
+		klog.V(6).Infof("安装临时提权插件失败: %v", err)
+		return err
+	}
+	klog.V(6).Infof("安装临时提权插件成功")
+	return nil
+}
+
+// Upgrade 升级临时提权插件
+func (l *AccessRequestLifecycle) Upgrade(ctx plugins.UpgradeContext) error {
+	klog.V(6).Infof("升级临时提权插件：从版本 %s 到版本 %s", ctx.FromVersion(), ctx.ToVersion())
+	if err := models.UpgradeDB(ctx.FromVersion(), ctx.ToVersion()); err != nil {
+		klog.V(6).Infof("升级临时提权插件失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Enable 启用临时提权插件
+func (l *AccessRequestLifecycle) Enable(ctx plugins.EnableContext) error {
+	klog.V(6).Infof("启用临时提权插件")
+	return nil
+}
+
+// Disable 禁用临时提权插件
+func (l *AccessRequestLifecycle) Disable(ctx plugins.BaseContext) error {
+	klog.V(6).Infof("禁用临时提权插件")
+	return nil
+}
+
+// Uninstall 卸载临时提权插件，已生成的临时授权仍按到期时间失效
+func (l *AccessRequestLifecycle) Uninstall(ctx plugins.UninstallContext) error {
+	// 根据keepData参数决定是否删除数据库
+	if !ctx.KeepData() {
+		if err := models.DropDB(); err != nil {
+			klog.V(6).Infof("卸载临时提权插件失败: %v", err)
+			return err
+		}
+	}
+	klog.V(6).Infof("卸载临时提权插件成功")
+	return nil
+}
+
+// Start 启动临时提权插件，到期回收由插件定时任务执行
+func (l *AccessRequestLifecycle) Start(ctx plugins.BaseContext) error {
+	klog.V(6).Infof("启动临时提权插件")
+	return nil
+}
+
+// StartCron 回收到期的临时授权，启用 Leader 插件时仅在主实例上执行
+func (l *AccessRequestLifecycle) StartCron(ctx plugins.BaseContext, spec string) error {
+	accessrequest.ExpireDueRequests()
+	return nil
+}
+
+// Stop 停止临时提权插件
+func (l *AccessRequestLifecycle) Stop(ctx plugins.BaseContext) error {
+	klog.V(6).Infof("停止临时提权插件")
+	return nil
+}
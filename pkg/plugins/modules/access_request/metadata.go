@@ -0,0 +1,64 @@
+package access_request
+
+import (
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/route"
+)
+
+var Metadata = plugins.Module{
+	Meta: plugins.Meta{
+		Name:        modules.PluginNameAccessRequest,
+		Title:       "临时提权审批插件",
+		Version:     "1.0.0",
+		Description: "用户按集群、命名空间申请限时的集群角色，经平台管理员或指定用户组审批后生成临时授权，到期自动回收，全程记录操作日志并推送 webhook 通知。",
+	},
+	Tables: []string{
+		"access_request_settings",
+		"access_requests",
+	},
+	Crons: []string{
+		// 每分钟回收到期的临时授权
+		"* * * * *",
+	},
+	Menus: []plugins.Menu{
+		{
+			Key:   "plugin_access_request_index",
+			Title: "临时提权",
+			Icon:  "fa-solid fa-user-clock",
+			Order: 56,
+			Children: []plugins.Menu{
+				{
+					Key:         "plugin_access_request_my",
+					Title:       "我的申请",
+					Icon:        "fa-solid fa-paper-plane",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/access_request/my")`,
+					Order:       10,
+				},
+				{
+					Key:         "plugin_access_request_approval",
+					Title:       "申请审批",
+					Icon:        "fa-solid fa-stamp",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/access_request/approval")`,
+					Order:       20,
+				},
+				{
+					Key:         "plugin_access_request_setting",
+					Title:       "审批设置",
+					Icon:        "fa-solid fa-gear",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/access_request/setting")`,
+					Order:       30,
+				},
+			},
+		},
+	},
+	Dependencies:      []string{},
+	RunAfter:          []string{modules.PluginNameLeader, modules.PluginNameWebhook},
+	Lifecycle:         &AccessRequestLifecycle{},
+	ManagementRouter:  route.RegisterManagementRoutes,
+	PluginAdminRouter: route.RegisterPluginAdminRoutes,
+}
@@ -0,0 +1,180 @@
+package mgm
+
+import (
+	"fmt"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	k8mmodels "github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/models"
+	accessrequest "github.com/weibaohui/k8m/pkg/plugins/modules/access_request/service"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+)
+
+type RequestController struct{}
+
+// @Summary 提交临时提权申请
+// @Security BearerAuth
+// @Param request body models.AccessRequest true "申请内容：cluster、role、custom_role、namespaces、hours、reason"
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/request/submit [post]
+func (r *RequestController) Submit(c *response.Context) {
+	var in models.AccessRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if _, err := accessrequest.SubmitRequest(amis.GetLoginUser(c), &in); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, "申请已提交，等待审批")
+}
+
+// @Summary 我的临时提权申请
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/request/my [get]
+func (r *RequestController) MyList(c *response.Context) {
+	params := dao.BuildParams(c)
+	username := amis.GetLoginUser(c)
+	m := &models.AccessRequest{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("username = ?", username)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 撤回临时提权申请
+// @Security BearerAuth
+// @Param id path int true "申请ID"
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/request/{id}/cancel [post]
+func (r *RequestController) Cancel(c *response.Context) {
+	err := accessrequest.CancelRequest(utils.ToUInt(c.Param("id")), amis.GetLoginUser(c))
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 可申请的集群选项
+// @Description 申请人可能尚无任何集群权限，因此列出全部集群
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/cluster/option_list [get]
+func (r *RequestController) ClusterOptionList(c *response.Context) {
+	clusters := service.ClusterService().AllClusters()
+	options := make([]map[string]string, 0, len(clusters))
+	for _, cc := range clusters {
+		options = append(options, map[string]string{
+			"label": cc.GetClusterID(),
+			"value": cc.GetClusterID(),
+		})
+	}
+	amis.WriteJsonData(c, response.H{
+		"options": options,
+	})
+}
+
+// @Summary 自定义角色选项
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/custom_role/option_list [get]
+func (r *RequestController) CustomRoleOptionList(c *response.Context) {
+	var names []string
+	if err := dao.DB().Model(&k8mmodels.CustomRole{}).Order("name asc").Pluck("name", &names).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	options := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		options = append(options, map[string]string{
+			"label": name,
+			"value": name,
+		})
+	}
+	amis.WriteJsonData(c, response.H{
+		"options": options,
+	})
+}
+
+// @Summary 审批列表
+// @Description 审批人查看全部申请，默认按状态筛选
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/approval/list [get]
+func (r *RequestController) ApprovalList(c *response.Context) {
+	if !accessrequest.CanApprove(amis.GetLoginUser(c)) {
+		amis.WriteJsonError(c, fmt.Errorf("没有审批权限"))
+		return
+	}
+	params := dao.BuildParams(c)
+	m := &models.AccessRequest{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 批准临时提权申请
+// @Security BearerAuth
+// @Param id path int true "申请ID"
+// @Param request body object true "{comment: 审批意见}"
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/approval/{id}/approve [post]
+func (r *RequestController) Approve(c *response.Context) {
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	err := accessrequest.ApproveRequest(utils.ToUInt(c.Param("id")), amis.GetLoginUser(c), comment)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 拒绝临时提权申请
+// @Security BearerAuth
+// @Param id path int true "申请ID"
+// @Param request body object true "{comment: 审批意见}"
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/approval/{id}/deny [post]
+func (r *RequestController) Deny(c *response.Context) {
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	err := accessrequest.DenyRequest(utils.ToUInt(c.Param("id")), amis.GetLoginUser(c), comment)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 提前回收临时授权
+// @Security BearerAuth
+// @Param id path int true "申请ID"
+// @Param request body object true "{comment: 回收原因}"
+// @Success 200 {object} string
+// @Router /mgm/plugins/access_request/approval/{id}/revoke [post]
+func (r *RequestController) Revoke(c *response.Context) {
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	err := accessrequest.RevokeRequest(utils.ToUInt(c.Param("id")), amis.GetLoginUser(c), comment)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+func bindComment(c *response.Context) (string, bool) {
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return "", false
+	}
+	return req.Comment, true
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// 申请状态
+const (
+	StatusPending   = "pending"   // 待审批
+	StatusApproved  = "approved"  // 已批准，临时授权生效中
+	StatusDenied    = "denied"    // 已拒绝
+	StatusCancelled = "cancelled" // 申请人撤回
+	StatusExpired   = "expired"   // 到期自动回收
+	StatusRevoked   = "revoked"   // 到期前被提前回收
+)
+
+// AccessRequest 临时提权申请，审批通过后生成带到期时间的集群授权
+type AccessRequest struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Username   string     `gorm:"size:255;index:idx_access_request_username" json:"username,omitempty"` // 申请人
+	Cluster    string     `gorm:"size:255;index:idx_access_request_cluster" json:"cluster,omitempty"`   // 集群ID
+	Role       string     `gorm:"size:50" json:"role,omitempty"`                                        // 申请的集群角色
+	CustomRole string     `gorm:"size:50" json:"custom_role,omitempty"`                                 // Role为cluster_custom时的自定义角色名称
+	Namespaces string     `gorm:"type:text" json:"namespaces,omitempty"`                                // 限定的命名空间，逗号分隔，为空表示整个集群
+	Hours      int        `json:"hours,omitempty"`                                                      // 申请时长（小时）
+	Reason     string     `gorm:"type:text" json:"reason,omitempty"`                                    // 申请理由
+	Status     string     `gorm:"size:20;index:idx_access_request_status" json:"status,omitempty"`
+	Approver   string     `gorm:"size:255" json:"approver,omitempty"` // 审批人，回收时为回收人
+	Comment    string     `gorm:"type:text" json:"comment,omitempty"` // 审批意见
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`              // 审批通过时间
+	ExpiresAt  *time.Time `gorm:"index:idx_access_request_expires_at" json:"expires_at,omitempty"`
+	GrantID    uint       `json:"grant_id,omitempty"` // 审批通过后生成的集群授权ID
+	CreatedAt  time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+func (AccessRequest) TableName() string {
+	return "access_requests"
+}
+
+func (c *AccessRequest) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AccessRequest, int64, error) {
+	return dao.GenericQuery(params, c, queryFuncs...)
+}
+
+func (c *AccessRequest) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, c, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+// GetAccessRequest 按ID获取申请
+func GetAccessRequest(id uint) (*AccessRequest, error) {
+	var req AccessRequest
+	if err := dao.DB().First(&req, id).Error; err != nil {
+		return nil, fmt.Errorf("申请[%d]不存在: %w", id, err)
+	}
+	return &req, nil
+}
+
+// TransitionStatus 仅当申请仍处于 from 状态时更新，避免多实例或重复点击导致重复审批
+func TransitionStatus(tx *gorm.DB, id uint, from string, updates map[string]any) error {
+	result := tx.Model(&AccessRequest{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("申请[%d]状态已变更，请刷新后重试", id)
+	}
+	return nil
+}
+
+// ListDueRequests 获取已到期但仍处于生效状态的申请
+func ListDueRequests(now time.Time) ([]*AccessRequest, error) {
+	var list []*AccessRequest
+	err := dao.DB().Where("status = ? AND expires_at <= ?", StatusApproved, now).Find(&list).Error
+	return list, err
+}
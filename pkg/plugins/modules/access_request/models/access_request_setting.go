@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxHours 默认单次申请的最长授权时长（小时）
+const DefaultMaxHours = 8
+
+type AccessRequestSetting struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	ApproverGroups string `gorm:"type:text" json:"approver_groups"` // 可审批的用户组，逗号分隔，平台管理员始终可审批
+	MaxHours       int    `json:"max_hours"`                        // 单次申请的最长授权时长（小时）
+	Webhooks       string `gorm:"size:255" json:"webhooks"`         // 申请、审批、回收通知的webhook接收者ID，逗号分隔
+
+	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+func (AccessRequestSetting) TableName() string {
+	return "access_request_settings"
+}
+
+func DefaultAccessRequestSetting() *AccessRequestSetting {
+	return &AccessRequestSetting{
+		MaxHours: DefaultMaxHours,
+	}
+}
+
+func GetOrCreateAccessRequestSetting() (*AccessRequestSetting, error) {
+	db := dao.DB()
+	var s AccessRequestSetting
+	if err := db.Order("id asc").First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			def := DefaultAccessRequestSetting()
+			if cErr := db.Create(def).Error; cErr != nil {
+				return nil, cErr
+			}
+			return def, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func UpdateAccessRequestSetting(in *AccessRequestSetting) (*AccessRequestSetting, error) {
+	if in == nil {
+		return nil, nil
+	}
+	if in.MaxHours <= 0 || in.MaxHours > 24*30 {
+		return nil, fmt.Errorf("最长授权时长应在 1 到 720 小时之间")
+	}
+	cur, err := GetOrCreateAccessRequestSetting()
+	if err != nil {
+		return nil, err
+	}
+
+	cur.ApproverGroups = in.ApproverGroups
+	cur.MaxHours = in.MaxHours
+	cur.Webhooks = in.Webhooks
+
+	if err := dao.DB().Save(cur).Error; err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
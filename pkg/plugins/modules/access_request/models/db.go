@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/weibaohui/k8m/internal/dao"
+	"k8s.io/klog/v2"
+)
+
+// InitDB 初始化数据库表（GORM自动迁移）
+func InitDB() error {
+	return dao.DB().AutoMigrate(&AccessRequestSetting{}, &AccessRequest{})
+}
+
+// UpgradeDB 升级临时提权插件数据库结构
+func UpgradeDB(fromVersion string, toVersion string) error {
+	klog.V(6).Infof("开始升级临时提权插件数据库：从版本 %s 到版本 %s", fromVersion, toVersion)
+	if err := dao.DB().AutoMigrate(&AccessRequestSetting{}, &AccessRequest{}); err != nil {
+		klog.V(6).Infof("自动迁移临时提权插件数据库失败: %v", err)
+		return err
+	}
+	klog.V(6).Infof("升级临时提权插件数据库完成")
+	return nil
+}
+
+// DropDB 删除临时提权插件相关的表及数据
+func DropDB() error {
+	db := dao.DB()
+	if db.Migrator().HasTable(&AccessRequestSetting{}) {
+		if err := db.Migrator().DropTable(&AccessRequestSetting{}); err != nil {
+			klog.V(6).Infof("删除临时提权配置表失败: %v", err)
+			return err
+		}
+	}
+	if db.Migrator().HasTable(&AccessRequest{}) {
+		if err := db.Migrator().DropTable(&AccessRequest{}); err != nil {
+			klog.V(6).Infof("删除临时提权申请表失败: %v", err)
+			return err
+		}
+	}
+	klog.V(6).Infof("已删除临时提权插件表及数据")
+	return nil
+}
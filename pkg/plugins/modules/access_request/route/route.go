@@ -0,0 +1,40 @@
+package route
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/admin"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/mgm"
+	"github.com/weibaohui/k8m/pkg/response"
+	"k8s.io/klog/v2"
+)
+
+// RegisterManagementRoutes 注册临时提权插件的管理类（mgm）路由，申请与审批对所有登录用户开放，审批权限在接口内校验
+func RegisterManagementRoutes(mrg chi.Router) {
+	prefix := "/plugins/" + modules.PluginNameAccessRequest
+
+	ctrl := &mgm.RequestController{}
+	mrg.Post(prefix+"/request/submit", response.Adapter(ctrl.Submit))
+	mrg.Get(prefix+"/request/my", response.Adapter(ctrl.MyList))
+	mrg.Post(prefix+"/request/{id}/cancel", response.Adapter(ctrl.Cancel))
+	mrg.Get(prefix+"/cluster/option_list", response.Adapter(ctrl.ClusterOptionList))
+	mrg.Get(prefix+"/custom_role/option_list", response.Adapter(ctrl.CustomRoleOptionList))
+
+	mrg.Get(prefix+"/approval/list", response.Adapter(ctrl.ApprovalList))
+	mrg.Post(prefix+"/approval/{id}/approve", response.Adapter(ctrl.Approve))
+	mrg.Post(prefix+"/approval/{id}/deny", response.Adapter(ctrl.Deny))
+	mrg.Post(prefix+"/approval/{id}/revoke", response.Adapter(ctrl.Revoke))
+
+	klog.V(6).Infof("注册临时提权插件管理路由(mgm)")
+}
+
+// RegisterPluginAdminRoutes 注册临时提权插件的管理员路由（平台管理员）
+func RegisterPluginAdminRoutes(arg chi.Router) {
+	prefix := "/plugins/" + modules.PluginNameAccessRequest
+
+	settingCtrl := &admin.SettingController{}
+	arg.Get(prefix+"/setting/get", response.Adapter(settingCtrl.GetSetting))
+	arg.Post(prefix+"/setting/update", response.Adapter(settingCtrl.UpdateSetting))
+
+	klog.V(6).Infof("注册临时提权插件管理路由(admin)")
+}
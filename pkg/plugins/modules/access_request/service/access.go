@@ -0,0 +1,330 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	k8mmodels "github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/api"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/access_request/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// 可申请的集群角色
+var requestableRoles = []string{
+	constants.RoleClusterReadonly,
+	constants.RoleClusterPodExec,
+	constants.RoleClusterAdmin,
+	constants.RoleClusterCustom,
+}
+
+var expireMu sync.Mutex // 同一时间只执行一次到期回收
+
+// SubmitRequest 提交临时提权申请
+func SubmitRequest(username string, in *models.AccessRequest) (*models.AccessRequest, error) {
+	setting, err := models.GetOrCreateAccessRequestSetting()
+	if err != nil {
+		return nil, err
+	}
+	if service.ClusterService().GetClusterByID(in.Cluster) == nil {
+		return nil, fmt.Errorf("集群[%s]不存在", in.Cluster)
+	}
+	if !slices.Contains(requestableRoles, in.Role) {
+		return nil, fmt.Errorf("不支持申请的角色: %s", in.Role)
+	}
+	if in.Role == constants.RoleClusterCustom {
+		if _, err := service.UserService().GetCustomRole(in.CustomRole); err != nil {
+			return nil, err
+		}
+	} else {
+		in.CustomRole = ""
+	}
+	maxHours := setting.MaxHours
+	if maxHours <= 0 {
+		maxHours = models.DefaultMaxHours
+	}
+	if in.Hours <= 0 || in.Hours > maxHours {
+		return nil, fmt.Errorf("申请时长应在 1 到 %d 小时之间", maxHours)
+	}
+	in.Reason = strings.TrimSpace(in.Reason)
+	if in.Reason == "" {
+		return nil, fmt.Errorf("请填写申请理由")
+	}
+
+	req := &models.AccessRequest{
+		Username:   username,
+		Cluster:    in.Cluster,
+		Role:       in.Role,
+		CustomRole: in.CustomRole,
+		Namespaces: strings.Join(utils.SplitAndTrim(in.Namespaces, ","), ","),
+		Hours:      in.Hours,
+		Reason:     in.Reason,
+		Status:     models.StatusPending,
+	}
+	if err := dao.DB().Create(req).Error; err != nil {
+		return nil, err
+	}
+
+	audit(username, "submit", req, nil)
+	notify(setting, req, fmt.Sprintf("【权限申请】%s 申请集群 %s 的 %s 权限 %d 小时，等待审批\n理由：%s",
+		username, req.Cluster, roleText(req), req.Hours, req.Reason))
+	return req, nil
+}
+
+// CanApprove 平台管理员或审批用户组成员可以审批
+func CanApprove(username string) bool {
+	if service.UserService().IsUserPlatformAdmin(username) {
+		return true
+	}
+	setting, err := models.GetOrCreateAccessRequestSetting()
+	if err != nil || setting.ApproverGroups == "" {
+		return false
+	}
+	groups, err := service.UserService().GetGroupNames(username)
+	if err != nil {
+		return false
+	}
+	return utils.AnyIn(groups, utils.SplitAndTrim(setting.ApproverGroups, ","))
+}
+
+// ApproveRequest 批准申请，生成带到期时间的集群授权
+func ApproveRequest(id uint, approver, comment string) error {
+	req, err := checkApprover(id, approver)
+	if err != nil {
+		return err
+	}
+	if req.Username == approver {
+		return fmt.Errorf("不能审批自己的申请")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(req.Hours) * time.Hour)
+	err = dao.DB().Transaction(func(tx *gorm.DB) error {
+		grant := &k8mmodels.ClusterUserRole{
+			Cluster:           req.Cluster,
+			Username:          req.Username,
+			Role:              req.Role,
+			CustomRole:        req.CustomRole,
+			Namespaces:        req.Namespaces,
+			AuthorizationType: constants.ClusterAuthorizationTypeUser,
+			ExpiresAt:         &expiresAt,
+		}
+		if err := tx.Create(grant).Error; err != nil {
+			return err
+		}
+		return models.TransitionStatus(tx, req.ID, models.StatusPending, map[string]any{
+			"status":      models.StatusApproved,
+			"approver":    approver,
+			"comment":     comment,
+			"approved_at": now,
+			"expires_at":  expiresAt,
+			"grant_id":    grant.ID,
+		})
+	})
+	audit(approver, "approve", req, err)
+	if err != nil {
+		return err
+	}
+	service.UserService().ClearCacheByKey("cluster")
+
+	notifyRequest(req, fmt.Sprintf("【权限申请】%s 已批准 %s 在集群 %s 的 %s 权限，%s 到期自动回收",
+		approver, req.Username, req.Cluster, roleText(req), expiresAt.Format(time.DateTime)))
+	return nil
+}
+
+// DenyRequest 拒绝申请
+func DenyRequest(id uint, approver, comment string) error {
+	req, err := checkApprover(id, approver)
+	if err != nil {
+		return err
+	}
+	err = models.TransitionStatus(dao.DB(), req.ID, models.StatusPending, map[string]any{
+		"status":   models.StatusDenied,
+		"approver": approver,
+		"comment":  comment,
+	})
+	audit(approver, "deny", req, err)
+	if err != nil {
+		return err
+	}
+	notifyRequest(req, fmt.Sprintf("【权限申请】%s 已拒绝 %s 在集群 %s 的 %s 权限申请\n意见：%s",
+		approver, req.Username, req.Cluster, roleText(req), comment))
+	return nil
+}
+
+// CancelRequest 申请人撤回待审批的申请
+func CancelRequest(id uint, username string) error {
+	req, err := models.GetAccessRequest(id)
+	if err != nil {
+		return err
+	}
+	if req.Username != username {
+		return fmt.Errorf("只能撤回自己的申请")
+	}
+	err = models.TransitionStatus(dao.DB(), req.ID, models.StatusPending, map[string]any{
+		"status": models.StatusCancelled,
+	})
+	audit(username, "cancel", req, err)
+	return err
+}
+
+// RevokeRequest 到期前提前回收已生效的授权
+func RevokeRequest(id uint, operator, comment string) error {
+	req, err := models.GetAccessRequest(id)
+	if err != nil {
+		return err
+	}
+	if !CanApprove(operator) {
+		return fmt.Errorf("用户[%s]没有审批权限", operator)
+	}
+	err = revoke(req, models.StatusRevoked, map[string]any{
+		"status":   models.StatusRevoked,
+		"approver": operator,
+		"comment":  comment,
+	})
+	audit(operator, "revoke", req, err)
+	if err != nil {
+		return err
+	}
+	notifyRequest(req, fmt.Sprintf("【权限申请】%s 已提前回收 %s 在集群 %s 的 %s 权限",
+		operator, req.Username, req.Cluster, roleText(req)))
+	return nil
+}
+
+// ExpireDueRequests 回收已到期的临时授权，由插件定时任务调用
+func ExpireDueRequests() {
+	if !expireMu.TryLock() {
+		return
+	}
+	defer expireMu.Unlock()
+
+	// 多实例部署时仅由 Leader 回收，避免重复通知
+	if plugins.ManagerInstance().IsRunning(modules.PluginNameLeader) && !service.LeaderService().IsCurrentLeader() {
+		return
+	}
+
+	now := time.Now()
+	list, err := models.ListDueRequests(now)
+	if err != nil {
+		klog.Errorf("[access_request] 获取到期申请失败: %v", err)
+		return
+	}
+	for _, req := range list {
+		err := revoke(req, models.StatusExpired, map[string]any{
+			"status": models.StatusExpired,
+		})
+		audit("system", "expire", req, err)
+		if err != nil {
+			klog.V(6).Infof("[access_request] 回收到期授权[%d]失败: %v", req.ID, err)
+			continue
+		}
+		notifyRequest(req, fmt.Sprintf("【权限申请】%s 在集群 %s 的 %s 临时权限已到期，已自动回收",
+			req.Username, req.Cluster, roleText(req)))
+	}
+
+	// 其他途径创建的临时授权同样在到期后删除
+	result := dao.DB().Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&k8mmodels.ClusterUserRole{})
+	if result.Error != nil {
+		klog.Errorf("[access_request] 删除到期集群授权失败: %v", result.Error)
+	}
+	if len(list) > 0 || result.RowsAffected > 0 {
+		service.UserService().ClearCacheByKey("cluster")
+		klog.V(6).Infof("[access_request] 回收到期申请 %d 个，删除到期授权 %d 条", len(list), result.RowsAffected)
+	}
+}
+
+// revoke 删除申请生成的集群授权并更新申请状态
+func revoke(req *models.AccessRequest, status string, updates map[string]any) error {
+	err := dao.DB().Transaction(func(tx *gorm.DB) error {
+		if req.GrantID != 0 {
+			if err := tx.Delete(&k8mmodels.ClusterUserRole{}, req.GrantID).Error; err != nil {
+				return err
+			}
+		}
+		return models.TransitionStatus(tx, req.ID, models.StatusApproved, updates)
+	})
+	if err == nil {
+		service.UserService().ClearCacheByKey("cluster")
+		req.Status = status
+	}
+	return err
+}
+
+// checkApprover 校验审批权限，并返回待审批的申请
+func checkApprover(id uint, approver string) (*models.AccessRequest, error) {
+	if !CanApprove(approver) {
+		return nil, fmt.Errorf("用户[%s]没有审批权限", approver)
+	}
+	req, err := models.GetAccessRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != models.StatusPending {
+		return nil, fmt.Errorf("申请[%d]不是待审批状态", id)
+	}
+	return req, nil
+}
+
+// audit 每个环节记录操作日志
+func audit(username, action string, req *models.AccessRequest, err error) {
+	roles, _ := service.UserService().GetRolesByUserName(username)
+	log := k8mmodels.OperationLog{
+		Action:       "access_request_" + action,
+		Cluster:      req.Cluster,
+		Namespace:    req.Namespaces,
+		Kind:         "AccessRequest",
+		Name:         fmt.Sprintf("%d", req.ID),
+		UserName:     username,
+		Role:         strings.Join(roles, ","),
+		ActionResult: "success",
+	}
+	if err != nil {
+		log.ActionResult = err.Error()
+	}
+	service.OperationLogService().Add(&log, req)
+}
+
+func notifyRequest(req *models.AccessRequest, msg string) {
+	setting, err := models.GetOrCreateAccessRequestSetting()
+	if err != nil {
+		klog.V(6).Infof("[access_request] 获取配置失败: %v", err)
+		return
+	}
+	notify(setting, req, msg)
+}
+
+// notify 推送 webhook 通知，未配置接收者时跳过
+func notify(setting *models.AccessRequestSetting, req *models.AccessRequest, msg string) {
+	if setting.Webhooks == "" {
+		return
+	}
+	receiverIDs := utils.SplitAndTrim(setting.Webhooks, ",")
+	go func() {
+		for _, r := range api.WebhookService().PushMsgToAllTargetByIDs(msg, utils.ToJSON(req), receiverIDs) {
+			if r != nil && r.Error != nil {
+				klog.V(6).Infof("[access_request] 通知推送失败: %v", r.Error)
+			}
+		}
+	}()
+}
+
+func roleText(req *models.AccessRequest) string {
+	text := req.Role
+	if req.Role == constants.RoleClusterCustom {
+		text = "自定义角色 " + req.CustomRole
+	}
+	if req.Namespaces != "" {
+		text += "（命名空间 " + req.Namespaces + "）"
+	}
+	return text
+}
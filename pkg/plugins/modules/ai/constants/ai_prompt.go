@@ -39,4 +39,7 @@ const (
 
 	// AIPromptTypeYamlGenerate YAML生成类型
 	AIPromptTypeYamlGenerate AIPromptType = "YamlGenerate"
+
+	// AIPromptTypeRootCause 故障根因分析类型
+	AIPromptTypeRootCause AIPromptType = "RootCause"
 )
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/service"
+	"github.com/weibaohui/k8m/pkg/response"
+	"gorm.io/gorm"
+)
+
+// AIDiagnosisController 故障根因分析记录控制器
+// 提供分析记录的查看、删除、重新分析与手动推送功能
+type AIDiagnosisController struct {
+}
+
+// @Summary 获取故障根因分析记录列表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/diagnosis/list [get]
+func (d *AIDiagnosisController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.AIWorkloadDiagnosis{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Omit("evidence").Order("last_seen desc")
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 获取故障根因分析记录详情（含采集的现场信息）
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/diagnosis/detail/{id} [get]
+func (d *AIDiagnosisController) Detail(c *response.Context) {
+	id := utils.ToUInt(c.Param("id"))
+	item, err := (&models.AIWorkloadDiagnosis{}).GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, item)
+}
+
+// @Summary 删除故障根因分析记录
+// @Security BearerAuth
+// @Param ids path string true "记录ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/diagnosis/delete/{ids} [post]
+func (d *AIDiagnosisController) Delete(c *response.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	m := &models.AIWorkloadDiagnosis{}
+	err := m.Delete(params, ids)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 重新分析指定故障，忽略去重窗口
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/diagnosis/reanalyze/{id} [post]
+func (d *AIDiagnosisController) Reanalyze(c *response.Context) {
+	id := utils.ToUInt(c.Param("id"))
+	ctx := amis.GetContextWithUser(c)
+	if err := service.GetDiagnosisService().Reanalyze(ctx, id); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, "已加入分析队列，请稍后刷新查看")
+}
+
+// @Summary 推送故障根因分析结果到webhook
+// @Security BearerAuth
+// @Param id path int true "记录ID"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/diagnosis/push/{id} [post]
+func (d *AIDiagnosisController) Push(c *response.Context) {
+	id := utils.ToUInt(c.Param("id"))
+	item, err := (&models.AIWorkloadDiagnosis{}).GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	ids := utils.SplitAndTrim(service.AIService().DiagnosisHooks, ",")
+	results, err := service.GetDiagnosisService().Push(item, ids)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("已推送到 %d 个webhook", len(results)))
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/service"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/k8sgpt/service/kubernetes"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/kom/kom"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// AIKnowledgeController AI知识库管理控制器
+// 提供知识文档的增删改查、重建向量与检索测试功能
+type AIKnowledgeController struct {
+}
+
+// @Summary 获取知识文档列表
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/knowledge/doc/list [get]
+func (k *AIKnowledgeController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.AIKnowledgeDoc{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存运维手册（Markdown）并导入知识库
+// @Security BearerAuth
+// @Param doc body models.AIKnowledgeDoc true "知识文档"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/knowledge/doc/save [post]
+func (k *AIKnowledgeController) Save(c *response.Context) {
+	params := dao.BuildParams(c)
+	var doc models.AIKnowledgeDoc
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if strings.TrimSpace(doc.Content) == "" {
+		amis.WriteJsonError(c, fmt.Errorf("文档内容不能为空"))
+		return
+	}
+	if doc.ID == 0 {
+		// 管理员上传的文档一律视为运维手册
+		doc.SourceType = models.KnowledgeSourceRunbook
+	} else {
+		existing, err := (&models.AIKnowledgeDoc{}).GetOne(nil, func(db *gorm.DB) *gorm.DB {
+			return db.Where("id = ?", doc.ID)
+		})
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		doc.SourceType = existing.SourceType
+		if doc.SourceRef == "" {
+			doc.SourceRef = existing.SourceRef
+		}
+	}
+
+	ctx := amis.GetContextWithUser(c)
+	if err := service.GetKnowledgeService().IngestDoc(ctx, params, &doc); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除知识文档
+// @Security BearerAuth
+// @Param ids path string true "文档ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/knowledge/doc/delete/{ids} [post]
+func (k *AIKnowledgeController) Delete(c *response.Context) {
+	ids := c.Param("ids")
+	params := dao.BuildParams(c)
+	err := service.GetKnowledgeService().DeleteDocs(params, ids)
+	amis.WriteJsonErrorOrOK(c, err)
+}
+
+// @Summary 使用当前向量模型重建全部知识文档向量
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/knowledge/reindex [post]
+func (k *AIKnowledgeController) Reindex(c *response.Context) {
+	go func() {
+		if err := service.GetKnowledgeService().Reindex(context.Background()); err != nil {
+			klog.Errorf("重建知识库向量失败: %v", err)
+		}
+	}()
+	amis.WriteJsonOKMsg(c, "已开始重建知识库向量，请稍后刷新查看")
+}
+
+// @Summary 同步内置巡检规则文档到知识库
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/knowledge/sync/inspection [post]
+func (k *AIKnowledgeController) SyncInspection(c *response.Context) {
+	go func() {
+		if err := service.GetKnowledgeService().SyncInspectionDocs(context.Background()); err != nil {
+			klog.Errorf("同步内置巡检文档到知识库失败: %v", err)
+		}
+	}()
+	amis.WriteJsonOKMsg(c, "已开始同步内置巡检文档，请稍后刷新查看")
+}
+
+// @Summary 知识库检索测试
+// @Security BearerAuth
+// @Param q query string true "检索问题"
+// @Param top_k query int false "返回片段数"
+// @Success 200 {object} string
+// @Router /admin/plugins/ai/knowledge/search [get]
+func (k *AIKnowledgeController) Search(c *response.Context) {
+	q := c.Query("q")
+	if strings.TrimSpace(q) == "" {
+		amis.WriteJsonList(c, []any{})
+		return
+	}
+	ctx := amis.GetContextWithUser(c)
+	passages, err := service.GetKnowledgeService().Retrieve(ctx, q, utils.ToInt(c.Query("top_k")))
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonList(c, passages)
+}
+
+// ApiDocRequest 导入Kubernetes字段文档请求
+type ApiDocRequest struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Depth 字段展开层数，默认3
+	Depth int `json:"depth"`
+}
+
+// @Summary 导入指定资源的Kubernetes字段文档到知识库
+// @Security BearerAuth
+// @Param cluster path string true "集群ID"
+// @Param data body ApiDocRequest true "资源GVK"
+// @Success 200 {object} string
+// @Router /k8s/cluster/{cluster}/plugins/ai/knowledge/apidoc [post]
+func (k *AIKnowledgeController) IngestApiDoc(c *response.Context) {
+	params := dao.BuildParams(c)
+	var req ApiDocRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if req.Version == "" || req.Kind == "" {
+		amis.WriteJsonError(c, fmt.Errorf("version 与 kind 不能为空"))
+		return
+	}
+	if req.Depth <= 0 {
+		req.Depth = 3
+	}
+	selectedCluster, err := amis.GetSelectedCluster(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	ctx := amis.GetContextWithUser(c)
+
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: req.Kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   req.Group,
+			Version: req.Version,
+		},
+		OpenapiSchema: kom.Cluster(selectedCluster).Status().OpenAPISchema(),
+	}
+	fields := make(map[string]string)
+	for _, p := range apiDoc.ListFieldPaths(req.Depth) {
+		if doc := apiDoc.GetApiDocV2(p); doc != "" {
+			fields[p] = doc
+		}
+	}
+
+	doc, err := service.GetKnowledgeService().IngestApiFieldDocs(ctx, params, req.Group, req.Version, req.Kind, fields)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("已导入 %s，共 %d 个字段，%d 个片段", doc.Title, len(fields), doc.ChunkCount))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	pkgmodels "github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/service"
 	"github.com/weibaohui/k8m/pkg/response"
@@ -103,6 +104,15 @@ func (m *AIModelConfigController) Save(c *response.Context) {
 		amis.WriteJsonError(c, fmt.Errorf("Temperature参数应在0-2之间"))
 		return
 	}
+	// 编辑时未修改 API Key，沿用原值
+	if config.ID > 0 && config.ApiKey == pkgmodels.SecretMask {
+		old, err := (&models.AIModelConfig{ID: config.ID}).GetOne(params)
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		config.ApiKey = old.ApiKey
+	}
 
 	// 保存到数据库
 	if err := config.Save(params); err != nil {
@@ -126,6 +136,10 @@ func (m *AIModelConfigController) List(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// API Key 不回显
+	for _, item := range items {
+		item.ApiKey = pkgmodels.MaskSecret(item.ApiKey)
+	}
 	amis.WriteJsonListWithTotal(c, total, items)
 }
 
@@ -233,6 +233,7 @@ func (s *AdminAIPromptController) AIPromptTypes(c *response.Context) {
 		{"label": "日志总结", "value": string(constants.AIPromptTypeLogSummary)},
 		{"label": "日志问答", "value": string(constants.AIPromptTypeLogAsk)},
 		{"label": "YAML生成", "value": string(constants.AIPromptTypeYamlGenerate)},
+		{"label": "故障根因分析", "value": string(constants.AIPromptTypeRootCause)},
 	}
 	amis.WriteJsonData(c, response.H{
 		"options": types,
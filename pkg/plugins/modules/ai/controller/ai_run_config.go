@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"context"
+
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/service"
 	"github.com/weibaohui/k8m/pkg/response"
+	"k8s.io/klog/v2"
 )
 
 // AIRunConfigController AI运行配置控制器
@@ -48,5 +51,14 @@ func (c *AIRunConfigController) UpdateRunConfig(ctx *response.Context) {
 		return
 	}
 
+	// 开启知识库后同步内置巡检文档，未变化的文档会被跳过
+	if config.EnableKnowledge {
+		go func() {
+			if err := service.GetKnowledgeService().SyncInspectionDocs(context.Background()); err != nil {
+				klog.Errorf("同步内置巡检文档到知识库失败: %v", err)
+			}
+		}()
+	}
+
 	amis.WriteJsonOK(ctx)
 }
@@ -54,6 +54,26 @@ type ResourceData struct {
 	Question string `form:"question" json:"question"`
 }
 
+// knowledgeQuery 组合请求中的关键信息作为知识库检索问题
+func (d ResourceData) knowledgeQuery() string {
+	parts := []string{d.Kind, d.Field, d.Reason, d.Note, d.Question, d.Cron}
+	if d.Data != "" {
+		// 日志等内容可能很长，只取开头部分参与检索
+		data := []rune(d.Data)
+		if len(data) > 500 {
+			data = data[:500]
+		}
+		parts = append(parts, string(data))
+	}
+	var query []string
+	for _, p := range parts {
+		if strings.TrimSpace(p) != "" {
+			query = append(query, p)
+		}
+	}
+	return strings.Join(query, " ")
+}
+
 func handleRequest(c *response.Context, promptFunc func(data any) string) {
 	enabled := plugins.ManagerInstance().IsRunning(modules.PluginNameAI)
 	if !enabled {
@@ -73,6 +93,7 @@ func handleRequest(c *response.Context, promptFunc func(data any) string) {
 	ctxInst := amis.GetContextWithUser(c)
 
 	prompt := promptFunc(data)
+	prompt = service.GetKnowledgeService().AugmentPrompt(ctxInst, data.knowledgeQuery(), prompt)
 
 	stream, err := service.GetChatService().GetChatStreamWithoutHistory(ctxInst, prompt)
 	if err != nil {
@@ -101,6 +122,7 @@ func handlePostRequest(c *response.Context, promptFunc func(data any) string) {
 	ctxInst := amis.GetContextWithUser(c)
 
 	prompt := promptFunc(data)
+	prompt = service.GetKnowledgeService().AugmentPrompt(ctxInst, data.knowledgeQuery(), prompt)
 
 	stream, err := service.GetChatService().GetChatStreamWithoutHistory(ctxInst, prompt)
 	if err != nil {
@@ -469,6 +491,7 @@ func (cc *Controller) YamlGenerate(c *response.Context) {
 	})
 
 	ctx := amis.GetContextWithUser(c)
+	prompt = service.GetKnowledgeService().AugmentPrompt(ctx, req.Prompt, prompt)
 	result, err := service.GetChatService().ChatWithCtxNoHistory(ctx, prompt)
 	if err != nil {
 		amis.WriteJsonError(c, fmt.Errorf("AI 生成失败：%v", err))
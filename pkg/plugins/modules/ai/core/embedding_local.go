@@ -0,0 +1,121 @@
+package core
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// LocalEmbedModel 本地向量化模型标识，未配置向量模型或调用失败时使用
+	LocalEmbedModel = "local-hash-512"
+	localEmbedDim   = 512
+)
+
+// LocalEmbedding 基于特征哈希的本地向量化，不依赖外部模型。
+// 英文/数字按单词切分，中文等非 ASCII 字符按单字与相邻双字切分，
+// 结果做 L2 归一化，可直接用点积计算余弦相似度。
+func LocalEmbedding(text string) []float32 {
+	vec := make([]float32, localEmbedDim)
+	for _, token := range tokenize(text) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(token))
+		sum := h.Sum32()
+		idx := sum % localEmbedDim
+		// 使用哈希的最高位决定符号，降低哈希冲突带来的偏差
+		if sum&0x80000000 != 0 {
+			vec[idx] -= 1
+		} else {
+			vec[idx] += 1
+		}
+	}
+	normalize(vec)
+	return vec
+}
+
+// CosineSimilarity 计算两个向量的余弦相似度，维度不一致时返回 0。
+func CosineSimilarity(a, b []float32) float32 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+// ScoredIndex 相似度检索结果，Index 为候选向量的下标
+type ScoredIndex struct {
+	Index int
+	Score float32
+}
+
+// TopKSimilar 返回与 query 余弦相似度不低于 minScore 的前 topK 个候选，按相似度从高到低排列
+func TopKSimilar(query []float32, candidates [][]float32, minScore float32, topK int) []ScoredIndex {
+	var results []ScoredIndex
+	for i, c := range candidates {
+		if s := CosineSimilarity(query, c); s >= minScore {
+			results = append(results, ScoredIndex{Index: i, Score: s})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
+
+func tokenize(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	var prev rune
+	flush := func() {
+		if word.Len() > 1 {
+			tokens = append(tokens, word.String())
+		}
+		word.Reset()
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(r)
+			prev = 0
+		case r >= unicode.MaxASCII && unicode.IsLetter(r):
+			flush()
+			tokens = append(tokens, string(r))
+			if prev != 0 {
+				tokens = append(tokens, string([]rune{prev, r}))
+			}
+			prev = r
+		default:
+			flush()
+			prev = 0
+		}
+	}
+	flush()
+	return tokens
+}
+
+func normalize(vec []float32) {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= n
+	}
+}
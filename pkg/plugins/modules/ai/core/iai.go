@@ -26,10 +26,12 @@ type IAI interface {
 	GetCompletionWithTools(ctx context.Context, contents ...any) ([]openai.ToolCall, string, error)
 	GetStreamCompletion(ctx context.Context, contents ...any) (*openai.ChatCompletionStream, error)
 	GetStreamCompletionWithTools(ctx context.Context, contents ...any) (*openai.ChatCompletionStream, error)
+	GetEmbeddings(ctx context.Context, model string, inputs []string) ([][]float32, error)
 	GetName() string
 	Close()
 	SetTools(tools []openai.Tool)
 	SaveAIHistory(ctx context.Context, content string)
+	ReplaceLastUserMessage(ctx context.Context, sent string, content string)
 	GetHistory(ctx context.Context) []openai.ChatCompletionMessage
 	ClearHistory(ctx context.Context) error
 }
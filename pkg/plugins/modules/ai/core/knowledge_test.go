@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/weibaohui/k8m/pkg/constants"
+)
+
+func TestTopKSimilar(t *testing.T) {
+	query := LocalEmbedding("pod 镜像拉取失败")
+	candidates := [][]float32{
+		LocalEmbedding("节点磁盘压力导致驱逐"),
+		LocalEmbedding("镜像拉取失败 ImagePullBackOff 排查"),
+		LocalEmbedding("pod 镜像拉取失败"),
+		{1, 2}, // 维度不一致，相似度为 0
+	}
+	results := TopKSimilar(query, candidates, 0.1, 2)
+	if len(results) != 2 || results[0].Index != 2 || results[1].Index != 1 {
+		t.Fatalf("应按相似度返回前两个候选: %+v", results)
+	}
+	if results[0].Score < results[1].Score {
+		t.Errorf("结果应按相似度从高到低排列: %+v", results)
+	}
+	if got := TopKSimilar(query, candidates, 1.1, 3); len(got) != 0 {
+		t.Errorf("低于最低相似度的候选应被过滤: %+v", got)
+	}
+}
+
+func TestReplaceLastUserMessage(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constants.JwtUserName, "alice")
+	c := &OpenAIClient{memory: NewMemoryService()}
+	augmented := "如何排查镜像拉取失败\n\n以下是从知识库中检索到的参考资料"
+	c.fillChatHistory(ctx, c.processThinkFlag(augmented)...)
+	c.SaveAIHistory(ctx, "请检查镜像地址")
+
+	c.ReplaceLastUserMessage(ctx, augmented, "如何排查镜像拉取失败")
+	history := c.GetHistory(ctx)
+	var users []string
+	for _, msg := range history {
+		if msg.Role == openai.ChatMessageRoleUser {
+			users = append(users, msg.Content)
+		}
+	}
+	if len(users) != 1 || users[0] != "/no_think如何排查镜像拉取失败" {
+		t.Fatalf("历史中应只保留原始问题: %q", users)
+	}
+	if last := history[len(history)-1]; last.Role != openai.ChatMessageRoleAssistant {
+		t.Errorf("不应影响模型的回复: %+v", last)
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// GetEmbeddings 调用模型的 embeddings 接口，对输入文本批量向量化。
+// 返回的向量顺序与 inputs 保持一致。
+func (c *OpenAIClient) GetEmbeddings(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	if model == "" {
+		return nil, fmt.Errorf("未配置向量模型")
+	}
+	if len(inputs) == 0 {
+		return [][]float32{}, nil
+	}
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: openai.EmbeddingModel(model),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(inputs) {
+		return nil, fmt.Errorf("向量数量不匹配，期望 %d，实际 %d", len(inputs), len(resp.Data))
+	}
+	result := make([][]float32, len(inputs))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(inputs) {
+			return nil, fmt.Errorf("向量索引越界: %d", d.Index)
+		}
+		result[d.Index] = d.Embedding
+	}
+	return result, nil
+}
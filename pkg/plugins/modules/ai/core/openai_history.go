@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"strings"
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/sashabaranov/go-openai"
@@ -29,6 +30,22 @@ func (c *OpenAIClient) SaveAIHistory(ctx context.Context, contents string) {
 	})
 }
 
+// ReplaceLastUserMessage 将历史中最近一条以 sent 结尾的用户消息替换为 content。
+// 发送给模型的提示词附加了知识库参考资料时，历史中只保留用户的原始问题，避免后续轮次重复携带
+func (c *OpenAIClient) ReplaceLastUserMessage(ctx context.Context, sent string, content string) {
+	history := c.GetHistory(ctx)
+	for i := len(history) - 1; i >= 0; i-- {
+		msg := &history[i]
+		if msg.Role != openai.ChatMessageRoleUser || !strings.HasSuffix(msg.Content, sent) {
+			continue
+		}
+		// 保留关闭思考时添加的前缀
+		msg.Content = strings.TrimSuffix(msg.Content, sent) + content
+		c.memory.SetUserHistory(getUsernameFromContext(ctx), history)
+		return
+	}
+}
+
 func (c *OpenAIClient) GetHistory(ctx context.Context) []openai.ChatCompletionMessage {
 	username := getUsernameFromContext(ctx)
 	return c.memory.GetUserHistory(username)
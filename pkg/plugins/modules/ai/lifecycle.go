@@ -1,6 +1,8 @@
 package ai
 
 import (
+	"context"
+
 	"github.com/weibaohui/k8m/pkg/plugins"
 	"github.com/weibaohui/k8m/pkg/plugins/api"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
@@ -62,6 +64,16 @@ func (l *AILifecycle) Start(ctx plugins.BaseContext) error {
 	klog.V(6).Infof("更新 AI 插件 运行配置")
 	service.AIService().UpdateFlagFromAIRunConfig()
 	service.RegisterAIAPI()
+	service.RegisterKnowledgeAPI()
+	service.RegisterDiagnosisAPI()
+	service.GetDiagnosisService().Start()
+	if service.AIService().EnableKnowledge {
+		go func() {
+			if err := service.GetKnowledgeService().SyncInspectionDocs(context.Background()); err != nil {
+				klog.Errorf("同步内置巡检文档到知识库失败: %v", err)
+			}
+		}()
+	}
 	return nil
 }
 
@@ -73,5 +85,8 @@ func (l *AILifecycle) StartCron(ctx plugins.BaseContext, spec string) error {
 func (l *AILifecycle) Stop(ctx plugins.BaseContext) error {
 	klog.V(6).Infof("停止 AI 插件后台任务")
 	api.UnregisterAI()
+	api.UnregisterAIKnowledge()
+	api.UnregisterPodDiagnosis()
+	service.GetDiagnosisService().Stop()
 	return nil
 }
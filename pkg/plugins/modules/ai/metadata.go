@@ -10,13 +10,16 @@ var Metadata = plugins.Module{
 	Meta: plugins.Meta{
 		Name:        modules.PluginNameAI,
 		Title:       "AI 插件",
-		Version:     "1.0.0",
-		Description: "AI功能插件，提供K8s资源智能分析、事件问诊、日志分析、Cron表达式解析等功能。支持自定义AI模型配置。",
+		Version:     "1.3.0",
+		Description: "AI功能插件，提供K8s资源智能分析、事件问诊、日志分析、Cron表达式解析等功能。支持自定义AI模型配置、知识库检索增强及故障自动根因分析。",
 	},
 	Tables: []string{
 		"ai_model_configs",
 		"ai_prompts",
 		"ai_run_configs",
+		"ai_knowledge_docs",
+		"ai_knowledge_chunks",
+		"ai_workload_diagnoses",
 	},
 	Menus: []plugins.Menu{
 		{
@@ -52,6 +55,24 @@ var Metadata = plugins.Module{
 					CustomEvent: `() => loadJsonPage("/plugins/ai/ai_run_config")`,
 					Order:       30,
 				},
+				{
+					Key:         "plugin_ai_knowledge",
+					Title:       "AI知识库",
+					Icon:        "fa-solid fa-book",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/ai/ai_knowledge")`,
+					Order:       40,
+				},
+				{
+					Key:         "plugin_ai_diagnosis",
+					Title:       "故障根因分析",
+					Icon:        "fa-solid fa-stethoscope",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/ai/ai_diagnosis")`,
+					Order:       50,
+				},
 			},
 		},
 	},
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// DiagnosisStatus 故障根因分析状态
+type DiagnosisStatus string
+
+const (
+	// DiagnosisStatusAnalyzing 正在采集现场信息并请求AI分析
+	DiagnosisStatusAnalyzing DiagnosisStatus = "analyzing"
+	// DiagnosisStatusDone 分析完成
+	DiagnosisStatusDone DiagnosisStatus = "done"
+	// DiagnosisStatusFailed 分析失败
+	DiagnosisStatusFailed DiagnosisStatus = "failed"
+)
+
+// AIWorkloadDiagnosis 工作负载故障根因分析记录
+// 同一工作负载、同一容器、同一故障类型共用一条记录（以 Fingerprint 去重），
+// 去重窗口内再次出现仅累加 Occurrences，不会重复请求AI。Pod 状态更新但故障状态未变化时不计数。
+type AIWorkloadDiagnosis struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Fingerprint  string          `gorm:"size:64;uniqueIndex" json:"fingerprint"` // 去重指纹
+	Cluster      string          `gorm:"size:255;index" json:"cluster"`          // 集群ID
+	Namespace    string          `gorm:"size:255" json:"namespace"`              // 命名空间
+	WorkloadKind string          `gorm:"size:100" json:"workload_kind"`          // 工作负载类型，如 Deployment、StatefulSet，无归属时为 Pod
+	WorkloadName string          `gorm:"size:255" json:"workload_name"`          // 工作负载名称
+	PodName      string          `gorm:"size:255" json:"pod_name"`               // 最近一次出现故障的Pod
+	Container    string          `gorm:"size:255" json:"container"`              // 故障容器，无法调度时为空
+	Reason       string          `gorm:"size:100;index" json:"reason"`           // 故障类型
+	Message      string          `gorm:"type:text" json:"message"`               // Pod 状态中的故障描述
+	Status       DiagnosisStatus `gorm:"size:20" json:"status"`                  // 分析状态
+	Summary      string          `gorm:"type:text" json:"summary,omitempty"`     // AI 根因分析结果
+	Evidence     string          `gorm:"type:text" json:"evidence,omitempty"`    // 采集的现场信息
+	ErrorMsg     string          `gorm:"type:text" json:"error_msg,omitempty"`   // 分析失败原因
+	Occurrences  int             `gorm:"default:1" json:"occurrences"`           // 出现次数
+	LastState    string          `gorm:"size:300" json:"-"`                      // 最近一次计数的故障状态（Pod与重启次数），状态未变化时不重复计数
+	FirstSeen    time.Time       `json:"first_seen"`                             // 首次出现时间
+	LastSeen     time.Time       `json:"last_seen"`                              // 最近出现时间
+	AnalyzedAt   *time.Time      `json:"analyzed_at,omitempty"`                  // 最近一次分析时间
+	PushStatus   string          `gorm:"type:text" json:"push_status,omitempty"` // webhook 推送结果
+	CreatedAt    time.Time       `json:"created_at,omitempty" gorm:"<-:create"`  // 创建时间
+	UpdatedAt    time.Time       `json:"updated_at,omitempty"`                   // 更新时间
+}
+
+// TableName 表名
+func (AIWorkloadDiagnosis) TableName() string {
+	return "ai_workload_diagnoses"
+}
+
+// List 获取故障分析记录列表
+func (m *AIWorkloadDiagnosis) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AIWorkloadDiagnosis, int64, error) {
+	return dao.GenericQuery(params, m, queryFuncs...)
+}
+
+// Save 保存故障分析记录
+func (m *AIWorkloadDiagnosis) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, m, queryFuncs...)
+}
+
+// Delete 删除故障分析记录
+func (m *AIWorkloadDiagnosis) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, m, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+// GetOne 获取单个故障分析记录
+func (m *AIWorkloadDiagnosis) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AIWorkloadDiagnosis, error) {
+	return dao.GenericGetOne(params, m, queryFuncs...)
+}
+
+// GetByFingerprint 根据去重指纹获取记录，不存在时返回 gorm.ErrRecordNotFound
+func (m *AIWorkloadDiagnosis) GetByFingerprint(fingerprint string) (*AIWorkloadDiagnosis, error) {
+	var item AIWorkloadDiagnosis
+	err := dao.DB().Where("fingerprint = ?", fingerprint).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Touch 去重窗口内再次出现故障，更新最近出现的Pod与时间；故障状态与上次不同时才累加次数
+func (m *AIWorkloadDiagnosis) Touch(podName string, message string, state string) error {
+	updates := map[string]any{
+		"pod_name":  podName,
+		"message":   message,
+		"last_seen": time.Now(),
+	}
+	if state != m.LastState {
+		updates["occurrences"] = gorm.Expr("occurrences + 1")
+		updates["last_state"] = state
+	}
+	return dao.DB().Model(&AIWorkloadDiagnosis{}).Where("id = ?", m.ID).Updates(updates).Error
+}
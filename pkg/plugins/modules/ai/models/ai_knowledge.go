@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+// KnowledgeSourceType 知识文档来源类型
+type KnowledgeSourceType string
+
+const (
+	// KnowledgeSourceInspection 内置巡检规则文档
+	KnowledgeSourceInspection KnowledgeSourceType = "inspection"
+	// KnowledgeSourceRunbook 管理员上传的 Markdown 运维手册
+	KnowledgeSourceRunbook KnowledgeSourceType = "runbook"
+	// KnowledgeSourceApiDoc Kubernetes 字段文档
+	KnowledgeSourceApiDoc KnowledgeSourceType = "apidoc"
+)
+
+// AIKnowledgeDoc AI知识库文档
+// 保存原始 Markdown 内容，SourceRef 用于同一来源的幂等更新（如巡检文档文件名、GVK）
+type AIKnowledgeDoc struct {
+	ID          uint                `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Title       string              `gorm:"size:255;not null" json:"title"`                                        // 文档标题
+	SourceType  KnowledgeSourceType `gorm:"size:50;not null;index:idx_ai_knowledge_doc_source" json:"source_type"` // 来源类型
+	SourceRef   string              `gorm:"size:255;index:idx_ai_knowledge_doc_source" json:"source_ref"`          // 来源标识，引用时展示
+	Content     string              `gorm:"type:text;not null" json:"content,omitempty"`                           // Markdown 内容
+	ContentHash string              `gorm:"size:64" json:"content_hash"`                                           // 内容摘要，未变化时跳过重新向量化
+	ChunkCount  int                 `json:"chunk_count"`                                                           // 切片数量
+	EmbedModel  string              `gorm:"size:100" json:"embed_model"`                                           // 向量化所用模型
+	CreatedBy   string              `gorm:"size:100" json:"created_by,omitempty"`                                  // 创建者
+	CreatedAt   time.Time           `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time           `json:"updated_at,omitempty"`
+}
+
+// TableName 表名
+func (AIKnowledgeDoc) TableName() string {
+	return "ai_knowledge_docs"
+}
+
+// List 获取知识文档列表
+func (m *AIKnowledgeDoc) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AIKnowledgeDoc, int64, error) {
+	return dao.GenericQuery(params, m, queryFuncs...)
+}
+
+// Save 保存知识文档
+func (m *AIKnowledgeDoc) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, m, queryFuncs...)
+}
+
+// Delete 删除知识文档
+func (m *AIKnowledgeDoc) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, m, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+// GetOne 获取单个知识文档
+func (m *AIKnowledgeDoc) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AIKnowledgeDoc, error) {
+	return dao.GenericGetOne(params, m, queryFuncs...)
+}
+
+// AIKnowledgeChunk AI知识库切片
+// Embedding 以 JSON 数组形式保存向量，兼容 SQLite/MySQL/PostgreSQL
+type AIKnowledgeChunk struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	DocID      uint      `gorm:"index:idx_ai_knowledge_chunk_doc_id" json:"doc_id"` // 所属文档ID
+	Seq        int       `json:"seq"`                                               // 文档内序号
+	Heading    string    `gorm:"size:255" json:"heading"`                           // 所在章节标题
+	Content    string    `gorm:"type:text" json:"content"`                          // 切片内容
+	EmbedModel string    `gorm:"size:100" json:"embed_model"`                       // 向量化所用模型
+	Embedding  string    `gorm:"type:text" json:"-"`                                // 向量（JSON）
+	CreatedAt  time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+}
+
+// TableName 表名
+func (AIKnowledgeChunk) TableName() string {
+	return "ai_knowledge_chunks"
+}
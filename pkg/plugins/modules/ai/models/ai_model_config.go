@@ -5,17 +5,23 @@ import (
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
 	"gorm.io/gorm"
 )
 
+func init() {
+	models.RegisterSecretFields(&AIModelConfig{}, "ApiKey")
+}
+
 type AIModelConfig struct {
 	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	ApiKey      string    `gorm:"type:text" json:"api_key"`
+	ApiKey      string    `gorm:"type:text" json:"api_key"` // 加密存储
 	ApiURL      string    `gorm:"size:255" json:"api_url"`
 	ApiModel    string    `gorm:"size:100" json:"api_model"`
 	Temperature float32   `json:"temperature"`
 	TopP        float32   `json:"top_p"`
 	Think       bool      `json:"think"`
+	EmbedModel  string    `gorm:"size:100" json:"embed_model"` // 向量模型名称，为空时使用本地向量化
 	Description string    `gorm:"type:text" json:"description,omitempty"`
 	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt   time.Time `json:"updated_at,omitempty"`
@@ -36,3 +42,18 @@ func (c *AIModelConfig) Delete(params *dao.Params, ids string, queryFuncs ...fun
 func (c *AIModelConfig) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*AIModelConfig, error) {
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
+
+// BeforeSave 在保存前加密 API Key
+func (c *AIModelConfig) BeforeSave(tx *gorm.DB) error {
+	return models.EncryptSecretFields(c)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (c *AIModelConfig) AfterSave(tx *gorm.DB) error {
+	return models.DecryptSecretFields(c)
+}
+
+// AfterFind 在查询后解密 API Key
+func (c *AIModelConfig) AfterFind(tx *gorm.DB) error {
+	return models.DecryptSecretFields(c)
+}
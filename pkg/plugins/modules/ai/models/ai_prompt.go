@@ -277,4 +277,25 @@ var BuiltinAIPrompts = []AIPrompt{
 		IsBuiltin: true,
 		IsEnabled: true,
 	},
+	{
+		Name:        "故障根因分析",
+		Description: "Pod 进入 CrashLoopBackOff、ImagePullBackOff、OOMKilled 或无法调度时，自动分析故障根因",
+		PromptType:  constants.AIPromptTypeRootCause,
+		Content: `你是一名 Kubernetes 故障排查专家。集群中的 ${Kind} ${Namespace}/${Name} 出现故障，Pod ${PodName} 的故障类型为 ${Reason}。
+以下是自动采集的现场信息，包括 Pod 定义、相关事件、容器上一次运行的日志以及节点状况：
+
+${Context}
+
+请根据以上信息给出根因分析，按以下结构输出：
+1. 故障现象：一句话概括
+2. 根本原因：结合证据说明最可能的原因，引用关键日志或事件
+3. 处理建议：给出可执行的修复步骤，必要时给出 kubectl 命令或 YAML 片段
+
+注意：
+- 使用中文回答
+- 信息不足以确定原因时，列出最可能的几种原因及进一步排查方法
+- 不要使用工具tools`,
+		IsBuiltin: true,
+		IsEnabled: true,
+	},
 }
@@ -10,15 +10,20 @@ import (
 )
 
 type AIRunConfig struct {
-	ID              uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"` // 主键ID
-	UseBuiltInModel bool      `gorm:"default:true" json:"use_built_in_model"`       // 是否使用内置模型
-	ModelID         uint      `json:"model_id"`                                     // 模型ID
-	MaxHistory      int32     `gorm:"default:10" json:"max_history"`                // 最大历史记录数
-	MaxIterations   int32     `gorm:"default:10" json:"max_iterations"`             // 最大迭代次数
-	AnySelect       bool      `gorm:"default:true" json:"any_select"`               // 是否开启任意选择
-	FloatingWindow  bool      `gorm:"default:true" json:"floating_window"`          // 是否开启浮动窗口
-	CreatedAt       time.Time `json:"created_at,omitempty" gorm:"<-:create"`        // 创建时间
-	UpdatedAt       time.Time `json:"updated_at,omitempty"`                         // 更新时间
+	ID                  uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"` // 主键ID
+	UseBuiltInModel     bool      `gorm:"default:true" json:"use_built_in_model"`       // 是否使用内置模型
+	ModelID             uint      `json:"model_id"`                                     // 模型ID
+	MaxHistory          int32     `gorm:"default:10" json:"max_history"`                // 最大历史记录数
+	MaxIterations       int32     `gorm:"default:10" json:"max_iterations"`             // 最大迭代次数
+	AnySelect           bool      `gorm:"default:true" json:"any_select"`               // 是否开启任意选择
+	FloatingWindow      bool      `gorm:"default:true" json:"floating_window"`          // 是否开启浮动窗口
+	EnableKnowledge     bool      `gorm:"default:false" json:"enable_knowledge"`        // 是否开启知识库检索增强
+	KnowledgeTopK       int32     `gorm:"default:3" json:"knowledge_top_k"`             // 知识库检索片段数
+	EnableDiagnosis     bool      `gorm:"default:false" json:"enable_diagnosis"`        // 是否开启故障自动根因分析
+	DiagnosisWebhooks   string    `json:"diagnosis_webhooks"`                           // 根因分析结果推送的webhook接收者ID，逗号分隔
+	DiagnosisDedupHours int32     `gorm:"default:24" json:"diagnosis_dedup_hours"`      // 去重窗口（小时），窗口内同一故障不重复分析
+	CreatedAt           time.Time `json:"created_at,omitempty" gorm:"<-:create"`        // 创建时间
+	UpdatedAt           time.Time `json:"updated_at,omitempty"`                         // 更新时间
 }
 
 func (c *AIRunConfig) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*AIRunConfig, int64, error) {
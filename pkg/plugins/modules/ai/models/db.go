@@ -7,16 +7,27 @@ import (
 )
 
 func InitDB() error {
-	return dao.DB().AutoMigrate(&AIModelConfig{}, &AIPrompt{}, &AIRunConfig{})
+	return dao.DB().AutoMigrate(&AIModelConfig{}, &AIPrompt{}, &AIRunConfig{}, &AIKnowledgeDoc{}, &AIKnowledgeChunk{}, &AIWorkloadDiagnosis{})
 }
 
 func UpgradeDB(fromVersion string, toVersion string) error {
 	klog.V(6).Infof("开始升级 AI 插件数据库：从版本 %s 到版本 %s", fromVersion, toVersion)
-	if err := dao.DB().AutoMigrate(&AIModelConfig{}, &AIPrompt{}, &AIRunConfig{}); err != nil {
+	if err := dao.DB().AutoMigrate(&AIModelConfig{}, &AIPrompt{}, &AIRunConfig{}, &AIKnowledgeDoc{}, &AIKnowledgeChunk{}, &AIWorkloadDiagnosis{}); err != nil {
 		klog.V(6).Infof("自动迁移 AI 插件数据库失败: %v", err)
 		return err
 	}
 
+	if err := InitMissingBuiltinAIPrompts(); err != nil {
+		klog.V(6).Infof("补充 AI 插件内置提示词失败: %v", err)
+		return err
+	}
+
+	// 历史数据中的 API Key 为明文，升级时加密
+	if err := models.MigrateSecretFields(&AIModelConfig{}); err != nil {
+		klog.V(6).Infof("加密 AI 模型 API Key 失败: %v", err)
+		return err
+	}
+
 	klog.V(6).Infof("升级 AI 插件数据库完成")
 	return nil
 }
@@ -41,6 +52,24 @@ func DropDB() error {
 			return err
 		}
 	}
+	if db.Migrator().HasTable(&AIKnowledgeDoc{}) {
+		if err := db.Migrator().DropTable(&AIKnowledgeDoc{}); err != nil {
+			klog.V(6).Infof("删除 AI Knowledge Doc 表失败: %v", err)
+			return err
+		}
+	}
+	if db.Migrator().HasTable(&AIKnowledgeChunk{}) {
+		if err := db.Migrator().DropTable(&AIKnowledgeChunk{}); err != nil {
+			klog.V(6).Infof("删除 AI Knowledge Chunk 表失败: %v", err)
+			return err
+		}
+	}
+	if db.Migrator().HasTable(&AIWorkloadDiagnosis{}) {
+		if err := db.Migrator().DropTable(&AIWorkloadDiagnosis{}); err != nil {
+			klog.V(6).Infof("删除 AI Workload Diagnosis 表失败: %v", err)
+			return err
+		}
+	}
 	klog.V(6).Infof("已删除 AI 插件表及数据")
 	return nil
 }
@@ -67,6 +96,21 @@ func InitBuiltinAIPrompts() error {
 	return nil
 }
 
+// InitMissingBuiltinAIPrompts 升级时补充新版本增加的内置提示词类型，已存在的类型不做改动
+func InitMissingBuiltinAIPrompts() error {
+	for _, prompt := range BuiltinAIPrompts {
+		var count int64
+		dao.DB().Model(&AIPrompt{}).Where("prompt_type = ? AND is_builtin = ?", prompt.PromptType, true).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := dao.DB().Create(&prompt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func MigrateAIModel() error {
 	model := &AIModelConfig{}
 	_, count, err := model.List(nil)
@@ -34,6 +34,9 @@ func RegisterClusterRoutes(arg chi.Router) {
 	prefix := "/plugins/" + modules.PluginNameAI
 	ctrl := &controller.Controller{}
 	arg.Get(prefix+"/chat/describe", response.Adapter(ctrl.Describe))
+
+	kc := &controller.AIKnowledgeController{}
+	arg.Post(prefix+"/knowledge/apidoc", response.Adapter(kc.IngestApiDoc))
 }
 
 func RegisterPluginAdminRoutes(arg chi.Router) {
@@ -60,5 +63,20 @@ func RegisterPluginAdminRoutes(arg chi.Router) {
 	arg.Get(prefix+"/run_config", response.Adapter(arc.GetRunConfig))
 	arg.Post(prefix+"/run_config", response.Adapter(arc.UpdateRunConfig))
 
+	kc := &controller.AIKnowledgeController{}
+	arg.Get(prefix+"/knowledge/doc/list", response.Adapter(kc.List))
+	arg.Post(prefix+"/knowledge/doc/save", response.Adapter(kc.Save))
+	arg.Post(prefix+"/knowledge/doc/delete/{ids}", response.Adapter(kc.Delete))
+	arg.Post(prefix+"/knowledge/reindex", response.Adapter(kc.Reindex))
+	arg.Post(prefix+"/knowledge/sync/inspection", response.Adapter(kc.SyncInspection))
+	arg.Get(prefix+"/knowledge/search", response.Adapter(kc.Search))
+
+	dc := &controller.AIDiagnosisController{}
+	arg.Get(prefix+"/diagnosis/list", response.Adapter(dc.List))
+	arg.Get(prefix+"/diagnosis/detail/{id}", response.Adapter(dc.Detail))
+	arg.Post(prefix+"/diagnosis/delete/{ids}", response.Adapter(dc.Delete))
+	arg.Post(prefix+"/diagnosis/reanalyze/{id}", response.Adapter(dc.Reanalyze))
+	arg.Post(prefix+"/diagnosis/push/{id}", response.Adapter(dc.Push))
+
 	klog.V(6).Infof("注册 AI 插件 admin管理路由")
 }
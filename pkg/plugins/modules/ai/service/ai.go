@@ -30,6 +30,12 @@ type aiService struct {
 	Think           bool    // 是否开启思考模式
 	Temperature     float32 // 温度参数，控制生成文本的随机性
 	TopP            float32 // Top-p采样参数，控制生成文本的多样性
+	EmbedModel      string  // 向量模型名称，为空时使用本地向量化
+	EnableKnowledge bool    // 是否开启知识库检索增强
+	KnowledgeTopK   int32   // 知识库检索片段数
+	EnableDiagnosis bool    // 是否开启故障自动根因分析
+	DiagnosisHooks  string  // 根因分析结果推送的webhook接收者ID，逗号分隔
+	DiagnosisDedup  int32   // 去重窗口（小时）
 }
 
 var (
@@ -170,6 +176,11 @@ func (c *aiService) UpdateFlagFromAIRunConfig() error {
 	c.FloatingWindow = runConfig.FloatingWindow
 	c.MaxHistory = runConfig.MaxHistory
 	c.MaxIterations = runConfig.MaxIterations
+	c.EnableKnowledge = runConfig.EnableKnowledge
+	c.KnowledgeTopK = runConfig.KnowledgeTopK
+	c.EnableDiagnosis = runConfig.EnableDiagnosis
+	c.DiagnosisHooks = runConfig.DiagnosisWebhooks
+	c.DiagnosisDedup = runConfig.DiagnosisDedupHours
 
 	// 如果不使用内置模型，加载模型配置
 	if !runConfig.UseBuiltInModel {
@@ -189,6 +200,7 @@ func (c *aiService) UpdateFlagFromAIRunConfig() error {
 		c.ApiModel = modelConfig.ApiModel
 		c.ApiURL = modelConfig.ApiURL
 		c.Think = modelConfig.Think
+		c.EmbedModel = modelConfig.EmbedModel
 		if modelConfig.Temperature > 0 {
 			c.Temperature = modelConfig.Temperature
 		}
@@ -102,7 +102,9 @@ func (c *chatService) RunOneRound(ctx context.Context, chat string, writer io.Wr
 	var currChatContent []any
 
 	// Set the initial message to start the conversation
-	currChatContent = append(currChatContent, chat)
+	// 附加知识库检索到的参考资料，对话历史中只保存用户的原始问题
+	prompt := GetKnowledgeService().AugmentPrompt(ctx, chat, chat)
+	currChatContent = append(currChatContent, prompt)
 
 	currentIteration := int32(0)
 	maxIterations := AIService().MaxIterations
@@ -115,6 +117,9 @@ func (c *chatService) RunOneRound(ctx context.Context, chat string, writer io.Wr
 		}
 		klog.V(6).Infof("Sending to LLM: %v", utils.ToJSON(currChatContent))
 		stream, err := client.GetStreamCompletionWithTools(ctx, currChatContent...)
+		if currentIteration == 0 && prompt != chat {
+			client.ReplaceLastUserMessage(ctx, prompt, chat)
+		}
 		// Clear our "response" now that we sent the last response
 		if err != nil {
 			klog.V(6).Infof("ChatCompletion error: %v\n", err)
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/htpl"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/api"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/constants"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"github.com/weibaohui/kom/kom"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// diagnosisQueueSize 待分析队列长度，队列满时丢弃新上报的故障，等待下一次状态变化再处理
+	diagnosisQueueSize = 100
+	// diagnosisLogTailLines 采集上一次运行日志的行数
+	diagnosisLogTailLines int64 = 100
+	// diagnosisMaxEvents 采集的事件条数上限
+	diagnosisMaxEvents = 20
+	// diagnosisTimeout 单次分析（采集+AI请求）的超时时间
+	diagnosisTimeout = 3 * time.Minute
+	// diagnosisRetryInterval 分析失败后的重试间隔，避免AI不可用时反复请求
+	diagnosisRetryInterval = 30 * time.Minute
+)
+
+// diagnosisTask 待分析的故障
+type diagnosisTask struct {
+	cluster      string
+	pod          *corev1.Pod
+	failure      *podFailure
+	workloadKind string
+	workloadName string
+	fingerprint  string
+	// force 手动触发的重新分析，忽略去重窗口
+	force bool
+}
+
+type diagnosisService struct {
+	mu sync.Mutex
+	// pending 已入队尚未处理完成的故障指纹，避免 Pod 频繁变化时重复入队
+	pending map[string]struct{}
+	queue   chan *diagnosisTask
+	cancel  context.CancelFunc
+}
+
+var (
+	diagnosisInstance *diagnosisService
+	diagnosisOnce     sync.Once
+)
+
+// GetDiagnosisService 获取故障根因分析服务的单例实例
+func GetDiagnosisService() *diagnosisService {
+	diagnosisOnce.Do(func() {
+		diagnosisInstance = &diagnosisService{
+			pending: make(map[string]struct{}),
+			queue:   make(chan *diagnosisTask, diagnosisQueueSize),
+		}
+	})
+	return diagnosisInstance
+}
+
+// Start 启动后台分析协程，重复调用不会启动多个
+func (d *diagnosisService) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.worker(ctx)
+	klog.V(6).Infof("启动故障根因分析后台任务")
+}
+
+// Stop 停止后台分析协程，清空队列中未处理的故障
+func (d *diagnosisService) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	d.cancel = nil
+	dropped := 0
+	for {
+		select {
+		case <-d.queue:
+			dropped++
+			continue
+		default:
+		}
+		break
+	}
+	d.pending = make(map[string]struct{})
+	klog.V(6).Infof("停止故障根因分析后台任务，丢弃未处理的故障 %d 个", dropped)
+}
+
+// ObservePod 实现 api.PodDiagnosis 接口，识别故障后入队，不阻塞 Pod 监听器
+func (d *diagnosisService) ObservePod(selectedCluster string, pod *corev1.Pod) {
+	if !AIService().EnableDiagnosis {
+		return
+	}
+	// 多实例部署时仅由 Leader 分析，避免重复请求AI
+	if plugins.ManagerInstance().IsRunning(modules.PluginNameLeader) && !service.LeaderService().IsCurrentLeader() {
+		return
+	}
+	failure := detectPodFailure(pod)
+	if failure == nil {
+		return
+	}
+	kind, name := resolveWorkload(pod)
+	task := &diagnosisTask{
+		cluster:      selectedCluster,
+		pod:          pod.DeepCopy(),
+		failure:      failure,
+		workloadKind: kind,
+		workloadName: name,
+		fingerprint:  diagnosisFingerprint(selectedCluster, pod.Namespace, kind, name, failure.Container, failure.Reason),
+	}
+	d.enqueue(task)
+}
+
+func (d *diagnosisService) enqueue(task *diagnosisTask) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// 未启动时不入队，避免停止期间队列堆积
+	if d.cancel == nil {
+		return false
+	}
+	if _, ok := d.pending[task.fingerprint]; ok {
+		return false
+	}
+	select {
+	case d.queue <- task:
+		d.pending[task.fingerprint] = struct{}{}
+		return true
+	default:
+		klog.V(4).Infof("故障根因分析队列已满，丢弃 %s/%s %s", task.pod.Namespace, task.pod.Name, task.failure.Reason)
+		return false
+	}
+}
+
+func (d *diagnosisService) done(fingerprint string) {
+	d.mu.Lock()
+	delete(d.pending, fingerprint)
+	d.mu.Unlock()
+}
+
+func (d *diagnosisService) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-d.queue:
+			// 停止与取出任务同时发生时，不再处理
+			if ctx.Err() != nil {
+				return
+			}
+			d.handle(ctx, task)
+			d.done(task.fingerprint)
+		}
+	}
+}
+
+// handle 去重后采集现场信息、请求AI分析并推送结果
+func (d *diagnosisService) handle(ctx context.Context, task *diagnosisTask) {
+	pod := task.pod
+	record, err := (&models.AIWorkloadDiagnosis{}).GetByFingerprint(task.fingerprint)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		klog.Errorf("查询故障分析记录失败: %v", err)
+		return
+	}
+
+	now := time.Now()
+	state := task.failure.stateKey(pod.Name)
+	if record != nil && !task.force && d.inDedupWindow(record, now) {
+		if err := record.Touch(pod.Name, task.failure.Message, state); err != nil {
+			klog.Errorf("更新故障分析记录 %d 失败: %v", record.ID, err)
+		}
+		return
+	}
+	if record == nil {
+		record = &models.AIWorkloadDiagnosis{
+			Fingerprint:  task.fingerprint,
+			Cluster:      task.cluster,
+			Namespace:    pod.Namespace,
+			WorkloadKind: task.workloadKind,
+			WorkloadName: task.workloadName,
+			Container:    task.failure.Container,
+			Reason:       task.failure.Reason,
+			Occurrences:  1,
+			FirstSeen:    now,
+		}
+	} else if !task.force && record.LastState != state {
+		record.Occurrences++
+	}
+	record.LastState = state
+	record.PodName = pod.Name
+	record.Message = task.failure.Message
+	record.LastSeen = now
+	record.Status = models.DiagnosisStatusAnalyzing
+	record.ErrorMsg = ""
+	if err := dao.DB().Save(record).Error; err != nil {
+		klog.Errorf("保存故障分析记录失败: %v", err)
+		return
+	}
+
+	klog.V(6).Infof("开始分析 %s %s/%s 故障 %s", task.cluster, pod.Namespace, pod.Name, task.failure.Reason)
+	actx, cancel := context.WithTimeout(utils.GetContextWithAdminFromCtx(ctx), diagnosisTimeout)
+	defer cancel()
+
+	evidence := d.collectEvidence(actx, task)
+	summary, err := d.analyze(actx, task, evidence)
+	analyzedAt := time.Now()
+	record.Evidence = evidence
+	record.AnalyzedAt = &analyzedAt
+	if err != nil {
+		klog.Errorf("%s %s/%s 故障根因分析失败: %v", task.cluster, pod.Namespace, pod.Name, err)
+		record.Status = models.DiagnosisStatusFailed
+		record.ErrorMsg = err.Error()
+		if err := dao.DB().Save(record).Error; err != nil {
+			klog.Errorf("保存故障分析记录失败: %v", err)
+		}
+		return
+	}
+	record.Status = models.DiagnosisStatusDone
+	record.Summary = summary
+	if err := dao.DB().Save(record).Error; err != nil {
+		klog.Errorf("保存故障分析记录失败: %v", err)
+		return
+	}
+
+	if ids := utils.SplitAndTrim(AIService().DiagnosisHooks, ","); len(ids) > 0 {
+		if _, err := d.Push(record, ids); err != nil {
+			klog.Errorf("推送故障分析结果失败: %v", err)
+		}
+	}
+}
+
+// inDedupWindow 判断是否无需重新分析：
+// 正在分析中（未超时）、失败后的重试间隔内、或成功分析后的去重窗口内。
+func (d *diagnosisService) inDedupWindow(record *models.AIWorkloadDiagnosis, now time.Time) bool {
+	switch record.Status {
+	case models.DiagnosisStatusAnalyzing:
+		// 进程重启等原因导致状态残留时，超时后允许重新分析
+		return now.Sub(record.UpdatedAt) < diagnosisTimeout
+	case models.DiagnosisStatusFailed:
+		return record.AnalyzedAt != nil && now.Sub(*record.AnalyzedAt) < diagnosisRetryInterval
+	case models.DiagnosisStatusDone:
+		hours := AIService().DiagnosisDedup
+		if hours <= 0 {
+			hours = 24
+		}
+		return record.AnalyzedAt != nil && now.Sub(*record.AnalyzedAt) < time.Duration(hours)*time.Hour
+	}
+	return false
+}
+
+// Reanalyze 手动重新分析指定记录，使用该工作负载最近一次出现故障的 Pod
+func (d *diagnosisService) Reanalyze(ctx context.Context, id uint) error {
+	record, err := (&models.AIWorkloadDiagnosis{}).GetOne(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		return err
+	}
+	var pod corev1.Pod
+	err = kom.Cluster(record.Cluster).WithContext(utils.GetContextWithAdminFromCtx(ctx)).
+		Resource(&pod).Namespace(record.Namespace).Name(record.PodName).Get(&pod).Error
+	if err != nil {
+		return fmt.Errorf("获取Pod %s/%s 失败，可能已被删除: %w", record.Namespace, record.PodName, err)
+	}
+	failure := detectPodFailure(&pod)
+	if failure == nil {
+		// Pod 已恢复时仍按原故障类型分析，便于事后复盘
+		failure = &podFailure{Reason: record.Reason, Container: record.Container, Message: record.Message}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == record.Container {
+				failure.Restarts = cs.RestartCount
+			}
+		}
+	}
+	task := &diagnosisTask{
+		cluster:      record.Cluster,
+		pod:          &pod,
+		failure:      failure,
+		workloadKind: record.WorkloadKind,
+		workloadName: record.WorkloadName,
+		fingerprint:  record.Fingerprint,
+		force:        true,
+	}
+	if !d.enqueue(task) {
+		return fmt.Errorf("该故障正在分析中或分析队列已满，请稍后再试")
+	}
+	return nil
+}
+
+// Push 将分析结果推送到指定的 webhook 接收者，并记录推送结果
+func (d *diagnosisService) Push(record *models.AIWorkloadDiagnosis, receiverIDs []string) ([]*api.SendResult, error) {
+	if record.Status != models.DiagnosisStatusDone {
+		return nil, fmt.Errorf("分析尚未完成，无法推送")
+	}
+	if len(receiverIDs) == 0 {
+		return nil, fmt.Errorf("未配置webhook接收者")
+	}
+	msg := fmt.Sprintf("【故障根因分析】集群 %s %s %s/%s 出现 %s\nPod：%s\n出现次数：%d\n\n%s",
+		record.Cluster, record.WorkloadKind, record.Namespace, record.WorkloadName, record.Reason,
+		record.PodName, record.Occurrences, record.Summary)
+	raw := utils.ToJSON(map[string]any{
+		"cluster":       record.Cluster,
+		"namespace":     record.Namespace,
+		"workload_kind": record.WorkloadKind,
+		"workload_name": record.WorkloadName,
+		"pod_name":      record.PodName,
+		"container":     record.Container,
+		"reason":        record.Reason,
+		"message":       record.Message,
+		"occurrences":   record.Occurrences,
+		"summary":       record.Summary,
+	})
+	results := api.WebhookService().PushMsgToAllTargetByIDs(msg, raw, receiverIDs)
+
+	var status []string
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if r.Error != nil {
+			status = append(status, fmt.Sprintf("%s(%d): %v", r.Status, r.StatusCode, r.Error))
+		} else {
+			status = append(status, fmt.Sprintf("%s(%d)", r.Status, r.StatusCode))
+		}
+	}
+	pushStatus := fmt.Sprintf("%s %s", time.Now().Format(time.DateTime), strings.Join(status, "; "))
+	if err := dao.DB().Model(&models.AIWorkloadDiagnosis{}).Where("id = ?", record.ID).Update("push_status", pushStatus).Error; err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// analyze 渲染根因分析提示词并请求AI
+func (d *diagnosisService) analyze(ctx context.Context, task *diagnosisTask, evidence string) (string, error) {
+	templateStr, err := GetPromptService().GetPrompt(ctx, constants.AIPromptTypeRootCause)
+	if err != nil {
+		klog.V(6).Infof("获取%s prompt模板失败，使用内置模板: %v", constants.AIPromptTypeRootCause, err)
+		templateStr = models.GetBuiltinPromptContent(constants.AIPromptTypeRootCause)
+	}
+	tpl, err := htpl.NewEngine().ParseString(templateStr)
+	if err != nil {
+		return "", fmt.Errorf("解析根因分析提示词失败: %w", err)
+	}
+	prompt, err := tpl.Render(map[string]any{
+		"Kind":      task.workloadKind,
+		"Namespace": task.pod.Namespace,
+		"Name":      task.workloadName,
+		"PodName":   task.pod.Name,
+		"Reason":    task.failure.Reason,
+		"Context":   evidence,
+	})
+	if err != nil {
+		return "", fmt.Errorf("渲染根因分析提示词失败: %w", err)
+	}
+	prompt = GetKnowledgeService().AugmentPrompt(ctx, task.failure.Reason+" "+task.failure.Message, prompt)
+	return GetChatService().ChatWithCtxNoHistory(ctx, prompt)
+}
+
+// collectEvidence 采集 Pod 定义、相关事件、上一次运行日志以及节点状况，单项失败不影响整体分析
+func (d *diagnosisService) collectEvidence(ctx context.Context, task *diagnosisTask) string {
+	pod := task.pod
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("## 故障描述\n类型：%s\n容器：%s\n描述：%s\n", task.failure.Reason, task.failure.Container, task.failure.Message))
+
+	clean := pod.DeepCopy()
+	clean.ManagedFields = nil
+	clean.Annotations = nil
+	if bs, err := yaml.Marshal(map[string]any{"spec": clean.Spec, "status": clean.Status}); err == nil {
+		b.WriteString("\n## Pod 定义与状态\n```yaml\n")
+		b.Write(bs)
+		b.WriteString("```\n")
+	}
+
+	b.WriteString("\n## 相关事件\n")
+	b.WriteString(d.collectEvents(ctx, task.cluster, pod))
+
+	if task.failure.Container != "" && task.failure.Restarts > 0 {
+		b.WriteString(fmt.Sprintf("\n## 容器 %s 上一次运行日志（最后 %d 行）\n```\n", task.failure.Container, diagnosisLogTailLines))
+		b.WriteString(d.collectPreviousLogs(ctx, task.cluster, pod, task.failure.Container))
+		b.WriteString("\n```\n")
+	}
+
+	if pod.Spec.NodeName != "" {
+		b.WriteString(fmt.Sprintf("\n## 节点 %s 状况\n", pod.Spec.NodeName))
+		b.WriteString(d.collectNodeConditions(ctx, task.cluster, pod.Spec.NodeName))
+	}
+	return b.String()
+}
+
+func (d *diagnosisService) collectEvents(ctx context.Context, cluster string, pod *corev1.Pod) string {
+	var events []corev1.Event
+	err := kom.Cluster(cluster).WithContext(ctx).Resource(&corev1.Event{}).Namespace(pod.Namespace).
+		List(&events, metav1.ListOptions{FieldSelector: "involvedObject.name=" + pod.Name}).Error
+	if err != nil {
+		return fmt.Sprintf("获取事件失败: %v\n", err)
+	}
+	if len(events) == 0 {
+		return "无\n"
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].LastTimestamp.After(events[j].LastTimestamp.Time)
+	})
+	if len(events) > diagnosisMaxEvents {
+		events = events[:diagnosisMaxEvents]
+	}
+	var b strings.Builder
+	for _, e := range events {
+		b.WriteString(fmt.Sprintf("- [%s] %s %s (x%d): %s\n", e.LastTimestamp.Format(time.DateTime), e.Type, e.Reason, e.Count, e.Message))
+	}
+	return b.String()
+}
+
+func (d *diagnosisService) collectPreviousLogs(ctx context.Context, cluster string, pod *corev1.Pod, container string) string {
+	tail := diagnosisLogTailLines
+	var stream io.ReadCloser
+	err := kom.Cluster(cluster).WithContext(ctx).Namespace(pod.Namespace).Name(pod.Name).Ctl().Pod().
+		ContainerName(container).GetLogs(&stream, &corev1.PodLogOptions{
+		Container: container,
+		Previous:  true,
+		TailLines: &tail,
+	}).Error
+	if err != nil {
+		return fmt.Sprintf("获取日志失败: %v", err)
+	}
+	if stream == nil {
+		return "无日志"
+	}
+	defer stream.Close()
+	// 限制读取大小，避免异常输出占满提示词
+	data, err := io.ReadAll(io.LimitReader(stream, 16*1024))
+	if err != nil {
+		return fmt.Sprintf("读取日志失败: %v", err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (d *diagnosisService) collectNodeConditions(ctx context.Context, cluster string, nodeName string) string {
+	var node corev1.Node
+	err := kom.Cluster(cluster).WithContext(ctx).Resource(&node).Name(nodeName).Get(&node).Error
+	if err != nil {
+		return fmt.Sprintf("获取节点失败: %v\n", err)
+	}
+	var b strings.Builder
+	for _, c := range node.Status.Conditions {
+		b.WriteString(fmt.Sprintf("- %s=%s %s %s\n", c.Type, c.Status, c.Reason, c.Message))
+	}
+	if node.Spec.Unschedulable {
+		b.WriteString("- 节点已被标记为不可调度(cordon)\n")
+	}
+	for _, t := range node.Spec.Taints {
+		b.WriteString(fmt.Sprintf("- 污点 %s=%s:%s\n", t.Key, t.Value, t.Effect))
+	}
+	b.WriteString(fmt.Sprintf("- 可分配资源 cpu=%s memory=%s pods=%s\n",
+		node.Status.Allocatable.Cpu().String(), node.Status.Allocatable.Memory().String(), node.Status.Allocatable.Pods().String()))
+	return b.String()
+}
+
+// RegisterDiagnosisAPI 将故障根因分析能力注册到统一访问控制层
+func RegisterDiagnosisAPI() {
+	api.RegisterPodDiagnosis(GetDiagnosisService())
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 自动根因分析支持的故障类型
+const (
+	FailureCrashLoopBackOff = "CrashLoopBackOff"
+	FailureImagePullBackOff = "ImagePullBackOff"
+	FailureOOMKilled        = "OOMKilled"
+	FailureUnschedulable    = "Unschedulable"
+)
+
+// podFailure Pod 故障识别结果
+type podFailure struct {
+	Reason    string
+	Container string
+	Message   string
+	// Restarts 故障容器的重启次数，大于0时采集上一次运行的日志
+	Restarts int32
+}
+
+// detectPodFailure 根据 Pod 状态识别需要分析的故障，未命中时返回 nil。
+// 容器因 OOM 退出后进入 CrashLoopBackOff 的，归类为 OOMKilled，便于按根因去重。
+func detectPodFailure(pod *corev1.Pod) *podFailure {
+	if pod == nil || pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if t := cs.State.Terminated; t != nil && t.Reason == FailureOOMKilled {
+			return &podFailure{Reason: FailureOOMKilled, Container: cs.Name, Message: terminatedMessage(t), Restarts: cs.RestartCount}
+		}
+		w := cs.State.Waiting
+		if w == nil {
+			continue
+		}
+		switch w.Reason {
+		case FailureCrashLoopBackOff:
+			if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == FailureOOMKilled {
+				return &podFailure{Reason: FailureOOMKilled, Container: cs.Name, Message: terminatedMessage(t), Restarts: cs.RestartCount}
+			}
+			return &podFailure{Reason: FailureCrashLoopBackOff, Container: cs.Name, Message: w.Message, Restarts: cs.RestartCount}
+		case FailureImagePullBackOff, "ErrImagePull":
+			return &podFailure{Reason: FailureImagePullBackOff, Container: cs.Name, Message: w.Message, Restarts: cs.RestartCount}
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+				return &podFailure{Reason: FailureUnschedulable, Message: c.Message}
+			}
+		}
+	}
+	return nil
+}
+
+// stateKey 故障状态标识。同一 Pod 的容器重启次数未变化时，状态更新仍属于同一次故障，不重复计数
+func (f *podFailure) stateKey(podName string) string {
+	return fmt.Sprintf("%s/%d", podName, f.Restarts)
+}
+
+func terminatedMessage(t *corev1.ContainerStateTerminated) string {
+	if t.Message != "" {
+		return t.Message
+	}
+	return fmt.Sprintf("容器退出，退出码 %d", t.ExitCode)
+}
+
+// resolveWorkload 根据 OwnerReference 推断 Pod 所属的工作负载。
+// Deployment 创建的 ReplicaSet 通过 pod-template-hash 还原为 Deployment，
+// 使同一 Deployment 滚动更新前后的故障归到同一工作负载下。
+func resolveWorkload(pod *corev1.Pod) (kind string, name string) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "Pod", pod.Name
+	}
+	if ref.Kind == "ReplicaSet" {
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+	return ref.Kind, ref.Name
+}
+
+// diagnosisFingerprint 生成去重指纹：集群、命名空间、工作负载、容器、故障类型相同即视为同一故障
+func diagnosisFingerprint(cluster, namespace, kind, name, container, reason string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{cluster, namespace, kind, name, container, reason}, "/")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/plugins/api"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/core"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/inspection"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// knowledgeMinScore 低于该相似度的片段不会被引用
+	knowledgeMinScore = 0.15
+	// knowledgeEmbedBatch 每次调用 embeddings 接口的文本数量
+	knowledgeEmbedBatch = 16
+)
+
+// knowledgeChunkCache 内存中的向量缓存，按向量模型区分
+type knowledgeChunkCache struct {
+	model  string
+	chunks []*knowledgeCachedChunk
+}
+
+type knowledgeCachedChunk struct {
+	title   string
+	source  string
+	heading string
+	content string
+	vector  []float32
+}
+
+type knowledgeService struct {
+	mu    sync.RWMutex
+	cache *knowledgeChunkCache
+	// syncMu 避免内置文档同步并发执行
+	syncMu sync.Mutex
+}
+
+var (
+	knowledgeInstance *knowledgeService
+	knowledgeOnce     sync.Once
+)
+
+// GetKnowledgeService 获取知识库服务的单例实例
+func GetKnowledgeService() *knowledgeService {
+	knowledgeOnce.Do(func() {
+		knowledgeInstance = &knowledgeService{}
+	})
+	return knowledgeInstance
+}
+
+// embedModel 返回当前生效的向量模型名称，未配置时使用本地向量化
+func (k *knowledgeService) embedModel() string {
+	if !AIService().UseBuiltInModel && AIService().EmbedModel != "" {
+		return AIService().EmbedModel
+	}
+	return core.LocalEmbedModel
+}
+
+// embed 对文本批量向量化，远程模型调用失败时回退为本地向量化。
+// 返回实际使用的模型名称，保证同一批向量维度一致。
+func (k *knowledgeService) embed(ctx context.Context, texts []string) ([][]float32, string) {
+	model := k.embedModel()
+	if model != core.LocalEmbedModel {
+		if client, err := AIService().DefaultClient(); err == nil {
+			vectors := make([][]float32, 0, len(texts))
+			for i := 0; i < len(texts); i += knowledgeEmbedBatch {
+				end := min(i+knowledgeEmbedBatch, len(texts))
+				batch, err := client.GetEmbeddings(ctx, model, texts[i:end])
+				if err != nil {
+					klog.Errorf("调用向量模型 %s 失败，回退为本地向量化: %v", model, err)
+					vectors = nil
+					break
+				}
+				vectors = append(vectors, batch...)
+			}
+			if vectors != nil {
+				return vectors, model
+			}
+		} else {
+			klog.V(6).Infof("获取AI客户端失败，回退为本地向量化: %v", err)
+		}
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, t := range texts {
+		vectors[i] = core.LocalEmbedding(t)
+	}
+	return vectors, core.LocalEmbedModel
+}
+
+// IngestDoc 切片、向量化并保存文档。
+// 已存在的文档（按ID或来源标识匹配）若内容与向量模型均未变化则跳过。
+func (k *knowledgeService) IngestDoc(ctx context.Context, params *dao.Params, doc *models.AIKnowledgeDoc) error {
+	if strings.TrimSpace(doc.Content) == "" {
+		return fmt.Errorf("文档内容不能为空")
+	}
+	if doc.Title == "" {
+		doc.Title = markdownTitle(doc.Content, doc.SourceRef)
+	}
+	if doc.SourceRef == "" {
+		doc.SourceRef = doc.Title
+	}
+
+	sum := sha256.Sum256([]byte(doc.Title + "\n" + doc.Content))
+	hash := hex.EncodeToString(sum[:])
+
+	existing := &models.AIKnowledgeDoc{}
+	var err error
+	if doc.ID > 0 {
+		err = dao.DB().Where("id = ?", doc.ID).First(existing).Error
+	} else {
+		err = dao.DB().Where("source_type = ? AND source_ref = ?", doc.SourceType, doc.SourceRef).First(existing).Error
+	}
+	if err == nil {
+		if existing.ContentHash == hash && existing.EmbedModel == k.embedModel() {
+			klog.V(6).Infof("知识文档 [%s] 未变化，跳过向量化", doc.Title)
+			return nil
+		}
+		doc.ID = existing.ID
+		doc.CreatedBy = existing.CreatedBy
+	}
+
+	chunks := splitMarkdown(doc.Content)
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = doc.Title + "\n" + c.heading + "\n" + c.content
+	}
+	vectors, model := k.embed(ctx, texts)
+
+	doc.ContentHash = hash
+	doc.ChunkCount = len(chunks)
+	doc.EmbedModel = model
+
+	err = dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(doc).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("doc_id = ?", doc.ID).Delete(&models.AIKnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		records := make([]*models.AIKnowledgeChunk, 0, len(chunks))
+		for i, c := range chunks {
+			vec, _ := json.Marshal(vectors[i])
+			records = append(records, &models.AIKnowledgeChunk{
+				DocID:      doc.ID,
+				Seq:        i,
+				Heading:    c.heading,
+				Content:    c.content,
+				EmbedModel: model,
+				Embedding:  string(vec),
+			})
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(records, 100).Error
+	})
+	if err != nil {
+		return fmt.Errorf("保存知识文档失败: %w", err)
+	}
+	if params != nil && params.UserName != "" && doc.CreatedBy == "" {
+		dao.DB().Model(doc).Update("created_by", params.UserName)
+	}
+
+	k.invalidate()
+	klog.V(6).Infof("知识文档 [%s] 已切分为 %d 个片段，向量模型 %s", doc.Title, len(chunks), model)
+	return nil
+}
+
+// DeleteDocs 删除文档及其切片
+func (k *knowledgeService) DeleteDocs(params *dao.Params, ids string) error {
+	doc := &models.AIKnowledgeDoc{}
+	if err := doc.Delete(params, ids); err != nil {
+		return err
+	}
+	// 清理已不存在文档的切片
+	err := dao.DB().Where("doc_id NOT IN (?)", dao.DB().Model(&models.AIKnowledgeDoc{}).Select("id")).
+		Delete(&models.AIKnowledgeChunk{}).Error
+	k.invalidate()
+	return err
+}
+
+// Reindex 使用当前向量模型重新向量化全部文档，切换向量模型后需要执行
+func (k *knowledgeService) Reindex(ctx context.Context) error {
+	var docs []*models.AIKnowledgeDoc
+	if err := dao.DB().Find(&docs).Error; err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		// 清空摘要以强制重新向量化
+		doc.ContentHash = ""
+		if err := dao.DB().Model(doc).Update("content_hash", "").Error; err != nil {
+			return err
+		}
+		if err := k.IngestDoc(ctx, nil, doc); err != nil {
+			klog.Errorf("重新向量化文档 [%s] 失败: %v", doc.Title, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncInspectionDocs 将内置巡检规则文档同步到知识库，内容未变化的文档会被跳过
+func (k *knowledgeService) SyncInspectionDocs(ctx context.Context) error {
+	k.syncMu.Lock()
+	defer k.syncMu.Unlock()
+
+	docFS := inspection.BuiltinDocs()
+	entries, err := fs.ReadDir(docFS, ".")
+	if err != nil {
+		return fmt.Errorf("读取内置巡检文档失败: %w", err)
+	}
+	count := 0
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".md" || e.Name() == "README.md" {
+			continue
+		}
+		content, err := fs.ReadFile(docFS, e.Name())
+		if err != nil {
+			klog.Errorf("读取巡检文档 %s 失败: %v", e.Name(), err)
+			continue
+		}
+		doc := &models.AIKnowledgeDoc{
+			SourceType: models.KnowledgeSourceInspection,
+			SourceRef:  e.Name(),
+			Content:    string(content),
+		}
+		if err := k.IngestDoc(ctx, nil, doc); err != nil {
+			klog.Errorf("导入巡检文档 %s 失败: %v", e.Name(), err)
+			continue
+		}
+		count++
+	}
+	klog.V(6).Infof("内置巡检文档同步完成，共 %d 个", count)
+	return nil
+}
+
+// IngestApiFieldDocs 将某个资源的字段说明整理为 Markdown 文档并导入知识库。
+// fields 为字段路径到说明的映射，通常来自 K8sApiReference.GetApiDocV2。
+func (k *knowledgeService) IngestApiFieldDocs(ctx context.Context, params *dao.Params, group, version, kind string, fields map[string]string) (*models.AIKnowledgeDoc, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("未获取到 %s 的字段文档", kind)
+	}
+	gv := version
+	if group != "" {
+		gv = group + "/" + version
+	}
+	paths := make([]string, 0, len(fields))
+	for p := range fields {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s (%s) 字段文档\n\n", kind, gv))
+	for _, p := range paths {
+		b.WriteString(fmt.Sprintf("## %s.%s\n\n%s\n\n", kind, p, fields[p]))
+	}
+	doc := &models.AIKnowledgeDoc{
+		Title:      fmt.Sprintf("%s (%s) 字段文档", kind, gv),
+		SourceType: models.KnowledgeSourceApiDoc,
+		SourceRef:  gv + "/" + kind,
+		Content:    b.String(),
+	}
+	if err := k.IngestDoc(ctx, params, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Retrieve 实现 api.AIKnowledge 接口，返回与问题最相关的知识片段
+func (k *knowledgeService) Retrieve(ctx context.Context, query string, topK int) ([]api.KnowledgePassage, error) {
+	if !AIService().EnableKnowledge || strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	if topK <= 0 {
+		topK = int(AIService().KnowledgeTopK)
+	}
+	if topK <= 0 {
+		topK = 3
+	}
+
+	cache, err := k.loadCache()
+	if err != nil {
+		return nil, err
+	}
+	if len(cache.chunks) == 0 {
+		return nil, nil
+	}
+
+	vectors, model := k.embed(ctx, []string{query})
+	if model != cache.model {
+		// 远程向量模型调用失败回退为本地向量，与库中向量不可比较
+		klog.V(6).Infof("查询向量模型 %s 与知识库向量模型 %s 不一致，跳过检索", model, cache.model)
+		return nil, nil
+	}
+
+	candidates := make([][]float32, len(cache.chunks))
+	for i, c := range cache.chunks {
+		candidates[i] = c.vector
+	}
+	results := core.TopKSimilar(vectors[0], candidates, knowledgeMinScore, topK)
+	passages := make([]api.KnowledgePassage, 0, len(results))
+	for _, r := range results {
+		chunk := cache.chunks[r.Index]
+		title := chunk.title
+		if chunk.heading != "" && chunk.heading != chunk.title {
+			title = title + " - " + chunk.heading
+		}
+		passages = append(passages, api.KnowledgePassage{
+			Title:   title,
+			Source:  chunk.source,
+			Content: chunk.content,
+			Score:   r.Score,
+		})
+	}
+	return passages, nil
+}
+
+// AugmentPrompt 实现 api.AIKnowledge 接口，将检索结果附加到提示词并要求模型标注引用
+func (k *knowledgeService) AugmentPrompt(ctx context.Context, query string, prompt string) string {
+	passages, err := k.Retrieve(ctx, query, 0)
+	if err != nil {
+		klog.Errorf("知识库检索失败: %v", err)
+		return prompt
+	}
+	return api.AugmentWithPassages(prompt, passages)
+}
+
+// loadCache 加载当前向量模型的全部切片到内存
+func (k *knowledgeService) loadCache() (*knowledgeChunkCache, error) {
+	model := k.embedModel()
+	k.mu.RLock()
+	cache := k.cache
+	k.mu.RUnlock()
+	if cache != nil && cache.model == model {
+		return cache, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.cache != nil && k.cache.model == model {
+		return k.cache, nil
+	}
+
+	var docs []*models.AIKnowledgeDoc
+	if err := dao.DB().Select("id", "title", "source_type", "source_ref").Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("查询知识文档失败: %w", err)
+	}
+	docMap := make(map[uint]*models.AIKnowledgeDoc, len(docs))
+	for _, d := range docs {
+		docMap[d.ID] = d
+	}
+
+	var chunks []*models.AIKnowledgeChunk
+	if err := dao.DB().Where("embed_model = ?", model).Find(&chunks).Error; err != nil {
+		return nil, fmt.Errorf("查询知识切片失败: %w", err)
+	}
+
+	cache = &knowledgeChunkCache{model: model}
+	for _, c := range chunks {
+		d, ok := docMap[c.DocID]
+		if !ok {
+			continue
+		}
+		var vec []float32
+		if err := json.Unmarshal([]byte(c.Embedding), &vec); err != nil {
+			klog.V(6).Infof("解析知识切片 %d 向量失败: %v", c.ID, err)
+			continue
+		}
+		cache.chunks = append(cache.chunks, &knowledgeCachedChunk{
+			title:   d.Title,
+			source:  fmt.Sprintf("%s:%s", d.SourceType, d.SourceRef),
+			heading: c.Heading,
+			content: c.Content,
+			vector:  vec,
+		})
+	}
+	k.cache = cache
+	klog.V(6).Infof("加载知识库向量缓存，模型 %s，片段 %d 个", model, len(cache.chunks))
+	return cache, nil
+}
+
+func (k *knowledgeService) invalidate() {
+	k.mu.Lock()
+	k.cache = nil
+	k.mu.Unlock()
+}
+
+// RegisterKnowledgeAPI 将知识库能力注册到统一访问控制层
+func RegisterKnowledgeAPI() {
+	api.RegisterAIKnowledge(GetKnowledgeService())
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"strings"
+)
+
+const (
+	// knowledgeChunkSize 单个切片的最大字符数（按 rune 计）
+	knowledgeChunkSize = 800
+	// knowledgeChunkOverlap 超长段落切分时相邻切片的重叠字符数
+	knowledgeChunkOverlap = 100
+)
+
+type markdownChunk struct {
+	heading string
+	content string
+}
+
+// splitMarkdown 按标题切分 Markdown，章节过长时再按段落合并/切分。
+// 代码块内的 # 不视为标题，代码块不会被拆散到不同段落。
+func splitMarkdown(content string) []markdownChunk {
+	type section struct {
+		heading    string
+		paragraphs []string
+	}
+
+	var sections []*section
+	current := &section{}
+	var para []string
+	inFence := false
+	fence := ""
+
+	flushPara := func() {
+		text := strings.TrimSpace(strings.Join(para, "\n"))
+		if text != "" {
+			current.paragraphs = append(current.paragraphs, text)
+		}
+		para = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			marker := trimmed[:3]
+			if !inFence {
+				inFence = true
+				fence = marker
+			} else if marker == fence {
+				inFence = false
+			}
+			para = append(para, line)
+			continue
+		}
+		if !inFence && strings.HasPrefix(trimmed, "#") {
+			flushPara()
+			sections = append(sections, current)
+			current = &section{heading: strings.TrimSpace(strings.TrimLeft(trimmed, "#"))}
+			continue
+		}
+		if !inFence && trimmed == "" {
+			flushPara()
+			continue
+		}
+		para = append(para, line)
+	}
+	flushPara()
+	sections = append(sections, current)
+
+	var chunks []markdownChunk
+	for _, s := range sections {
+		var buf []string
+		size := 0
+		emit := func() {
+			if len(buf) > 0 {
+				chunks = append(chunks, markdownChunk{heading: s.heading, content: strings.Join(buf, "\n\n")})
+			}
+			buf = nil
+			size = 0
+		}
+		for _, p := range s.paragraphs {
+			n := len([]rune(p))
+			if n > knowledgeChunkSize {
+				emit()
+				for _, piece := range splitRunes(p, knowledgeChunkSize, knowledgeChunkOverlap) {
+					chunks = append(chunks, markdownChunk{heading: s.heading, content: piece})
+				}
+				continue
+			}
+			if size+n > knowledgeChunkSize {
+				emit()
+			}
+			buf = append(buf, p)
+			size += n
+		}
+		emit()
+	}
+	return chunks
+}
+
+// splitRunes 将超长文本按固定长度切分，相邻片段保留 overlap 个字符的重叠
+func splitRunes(text string, size, overlap int) []string {
+	runes := []rune(text)
+	var pieces []string
+	step := size - overlap
+	if step <= 0 {
+		step = size
+	}
+	for start := 0; start < len(runes); start += step {
+		end := min(start+size, len(runes))
+		pieces = append(pieces, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return pieces
+}
+
+// markdownTitle 取第一个一级标题作为文档标题，没有时使用 fallback
+func markdownTitle(content string, fallback string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(trimmed, "# "))
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return "未命名文档"
+}
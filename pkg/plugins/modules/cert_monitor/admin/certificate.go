@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/models"
+	certmonitor "github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/service"
+	"github.com/weibaohui/k8m/pkg/response"
+	"gorm.io/gorm"
+)
+
+type CertificateController struct{}
+
+// @Summary 证书到期列表
+// @Description 获取全部集群的证书到期信息，默认按到期时间从近到远排序
+// @Security BearerAuth
+// @Param within query int false "仅返回指定天数内到期（含已过期）的证书"
+// @Success 200 {object} string
+// @Router /admin/plugins/cert_monitor/certificate/list [get]
+func (d *CertificateController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	if c.Query("orderBy") == "" {
+		params.OrderBy = "not_after"
+		params.OrderDir = "asc"
+	}
+	// within 不是表字段，避免被当作过滤条件
+	delete(params.Queries, "within")
+
+	m := &models.CertificateExpiry{}
+	var queryFuncs []func(*gorm.DB) *gorm.DB
+	if within := utils.ToInt(c.Query("within")); within > 0 {
+		deadline := time.Now().Add(time.Duration(within) * 24 * time.Hour)
+		queryFuncs = append(queryFuncs, func(db *gorm.DB) *gorm.DB {
+			return db.Where("not_after <= ?", deadline)
+		})
+	}
+	items, total, err := m.List(params, queryFuncs...)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 证书到期概览
+// @Description 按预警档位统计证书数量，包括已过期及各档位内到期的证书
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/cert_monitor/certificate/summary [get]
+func (d *CertificateController) Summary(c *response.Context) {
+	setting, err := models.GetOrCreateCertMonitorSetting()
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	warnDays, err := models.ParseWarnDays(setting.WarnDays)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	var notAfters []time.Time
+	if err := dao.DB().Model(&models.CertificateExpiry{}).Pluck("not_after", &notAfters).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	now := time.Now()
+	expired := 0
+	staged := map[int]int{}
+	for _, t := range notAfters {
+		daysLeft := models.DaysLeft(t, now)
+		if daysLeft < 0 {
+			expired++
+			continue
+		}
+		// 各档位统计的是该档位内到期的全部证书，档位之间存在包含关系
+		for _, d := range warnDays {
+			if daysLeft <= d {
+				staged[d]++
+			}
+		}
+	}
+	stages := make([]response.H, 0, len(warnDays))
+	for _, d := range warnDays {
+		stages = append(stages, response.H{"days": d, "count": staged[d]})
+	}
+	amis.WriteJsonData(c, response.H{
+		"total":   len(notAfters),
+		"expired": expired,
+		"stages":  stages,
+	})
+}
+
+// @Summary 立即执行证书巡检
+// @Description 后台巡检全部集群证书，命中预警档位时推送到配置的webhook
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/cert_monitor/certificate/scan [post]
+func (d *CertificateController) Scan(c *response.Context) {
+	go certmonitor.ScanAllClusters()
+	amis.WriteJsonOKMsg(c, "证书巡检已在后台执行，请稍后刷新查看结果")
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/models"
+	"github.com/weibaohui/k8m/pkg/response"
+)
+
+type SettingController struct{}
+
+// @Summary 获取证书巡检配置
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/cert_monitor/setting/get [get]
+func (s *SettingController) GetSetting(c *response.Context) {
+	setting, err := models.GetOrCreateCertMonitorSetting()
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, setting)
+}
+
+// @Summary 更新证书巡检配置
+// @Security BearerAuth
+// @Param request body models.CertMonitorSetting true "证书巡检配置"
+// @Success 200 {object} string
+// @Router /admin/plugins/cert_monitor/setting/update [post]
+func (s *SettingController) UpdateSetting(c *response.Context) {
+	var in models.CertMonitorSetting
+	if err := c.ShouldBindJSON(&in); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	if _, err := models.UpdateCertMonitorSetting(&in); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
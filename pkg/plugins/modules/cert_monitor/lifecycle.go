@@ -0,0 +1,113 @@
+package cert_monitor
+
+import (
+	"context"
+
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/eventbus"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/models"
+	certmonitor "github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/service"
+	"k8s.io/klog/v2"
+)
+
+// CertMonitorLifecycle 证书巡检插件生命周期实现
+type CertMonitorLifecycle struct {
+	leaderWatchCancel context.CancelFunc
+}
+
+// Install 安装证书巡检插件
+func (l *CertMonitorLifecycle) Install(ctx plugins.InstallContext) error {
+	if err := models.InitDB(); err != nil {
+		klog.V(6).Infof("安装证书巡检插件失败: %v", err)
+		return err
+	}
+	klog.V(6).Infof("安装证书巡检插件成功")
+	return nil
+}
+
+// Upgrade 升级证书巡检插件
+func (l *CertMonitorLifecycle) Upgrade(ctx plugins.UpgradeContext) error {
+	klog.V(6).Infof("升级证书巡检插件：从版本 %s 到版本 %s", ctx.FromVersion(), ctx.ToVersion())
+	if err := models.UpgradeDB(ctx.FromVersion(), ctx.ToVersion()); err != nil {
+		klog.V(6).Infof("升级证书巡检插件失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Enable 启用证书巡检插件
+func (l *CertMonitorLifecycle) Enable(ctx plugins.EnableContext) error {
+	klog.V(6).Infof("启用证书巡检插件")
+	return nil
+}
+
+// Disable 禁用证书巡检插件
+func (l *CertMonitorLifecycle) Disable(ctx plugins.BaseContext) error {
+	klog.V(6).Infof("禁用证书巡检插件")
+	return nil
+}
+
+// Uninstall 卸载证书巡检插件
+func (l *CertMonitorLifecycle) Uninstall(ctx plugins.UninstallContext) error {
+	// 根据keepData参数决定是否删除数据库
+	if !ctx.KeepData() {
+		if err := models.DropDB(); err != nil {
+			klog.V(6).Infof("卸载证书巡检插件失败: %v", err)
+			return err
+		}
+	}
+	klog.V(6).Infof("卸载证书巡检插件成功")
+	return nil
+}
+
+// Start 启动证书巡检定时任务（不可阻塞），启用 Leader 插件时仅在主实例上巡检
+func (l *CertMonitorLifecycle) Start(ctx plugins.BaseContext) error {
+	if plugins.ManagerInstance().IsRunning(modules.PluginNameLeader) {
+		elect := ctx.Bus().Subscribe(eventbus.EventLeaderElected)
+		lost := ctx.Bus().Subscribe(eventbus.EventLeaderLost)
+
+		leaderWatchCtx, cancel := context.WithCancel(context.Background())
+		l.leaderWatchCancel = cancel
+
+		go func() {
+			for {
+				select {
+				case <-elect:
+					klog.V(6).Infof("成为Leader，启动证书巡检定时任务")
+					certmonitor.StartScanInBackground()
+				case <-lost:
+					klog.V(6).Infof("不再是Leader，停止证书巡检定时任务")
+					certmonitor.StopScanInBackground()
+				case <-leaderWatchCtx.Done():
+					klog.V(6).Infof("证书巡检插件 Leader 监听 goroutine 退出")
+					return
+				}
+			}
+		}()
+
+		klog.V(6).Infof("根据实例Leader状态启动证书巡检插件后台任务")
+	} else {
+		certmonitor.StartScanInBackground()
+		klog.V(6).Infof("启动证书巡检插件后台任务")
+	}
+	return nil
+}
+
+// StartCron 证书巡检插件使用可配置的定时任务，留空实现
+func (l *CertMonitorLifecycle) StartCron(ctx plugins.BaseContext, spec string) error {
+	return nil
+}
+
+// Stop 停止证书巡检插件的后台任务
+func (l *CertMonitorLifecycle) Stop(ctx plugins.BaseContext) error {
+	klog.V(6).Infof("停止证书巡检插件后台任务")
+
+	if l.leaderWatchCancel != nil {
+		l.leaderWatchCancel()
+		l.leaderWatchCancel = nil
+	}
+
+	certmonitor.StopScanInBackground()
+	return nil
+}
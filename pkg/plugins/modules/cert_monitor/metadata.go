@@ -0,0 +1,53 @@
+package cert_monitor
+
+import (
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/route"
+)
+
+var Metadata = plugins.Module{
+	Meta: plugins.Meta{
+		Name:        modules.PluginNameCertMonitor,
+		Title:       "证书到期巡检插件",
+		Version:     "1.0.0",
+		Description: "定时巡检 kubeconfig 客户端证书、API Server 服务端证书、集群内 TLS Secret 及 cert-manager Certificate 的到期时间，按 30/14/7 天等预警档位推送 webhook。",
+	},
+	Tables: []string{
+		"cert_monitor_settings",
+		"cert_monitor_certificates",
+	},
+	Menus: []plugins.Menu{
+		{
+			Key:   "plugin_cert_monitor_index",
+			Title: "证书巡检",
+			Icon:  "fa-solid fa-certificate",
+			Show:  "isPlatformAdmin()==true",
+			Order: 55,
+			Children: []plugins.Menu{
+				{
+					Key:         "plugin_cert_monitor_dashboard",
+					Title:       "证书到期概览",
+					Icon:        "fa-solid fa-hourglass-half",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/cert_monitor/dashboard")`,
+					Order:       10,
+				},
+				{
+					Key:         "plugin_cert_monitor_setting",
+					Title:       "巡检参数设置",
+					Icon:        "fa-solid fa-gear",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/cert_monitor/setting")`,
+					Order:       20,
+				},
+			},
+		},
+	},
+	Dependencies:      []string{},
+	RunAfter:          []string{modules.PluginNameLeader, modules.PluginNameWebhook},
+	Lifecycle:         &CertMonitorLifecycle{},
+	PluginAdminRouter: route.RegisterPluginAdminRoutes,
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/internal/dao"
+	"gorm.io/gorm"
+)
+
+// DefaultWarnDays 默认的到期预警档位（天）
+const DefaultWarnDays = "30,14,7"
+
+type CertMonitorSetting struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	ScanCron    string `gorm:"size:64" json:"scan_cron"` // 证书巡检Cron，为空不巡检
+	WarnDays    string `gorm:"size:64" json:"warn_days"` // 预警档位（天），逗号分隔，例如 30,14,7
+	Webhooks    string `gorm:"size:255" json:"webhooks"` // 预警推送的webhook接收者ID，逗号分隔
+	Secrets     bool   `json:"secrets"`                  // 是否巡检集群内 kubernetes.io/tls 类型的 Secret
+	CertManager bool   `json:"cert_manager"`             // 是否巡检集群内 cert-manager Certificate
+
+	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+func (CertMonitorSetting) TableName() string {
+	return "cert_monitor_settings"
+}
+
+func DefaultCertMonitorSetting() *CertMonitorSetting {
+	return &CertMonitorSetting{
+		ScanCron:    "0 3 * * *",
+		WarnDays:    DefaultWarnDays,
+		Secrets:     true,
+		CertManager: true,
+	}
+}
+
+func GetOrCreateCertMonitorSetting() (*CertMonitorSetting, error) {
+	db := dao.DB()
+	var s CertMonitorSetting
+	if err := db.Order("id asc").First(&s).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			def := DefaultCertMonitorSetting()
+			if cErr := db.Create(def).Error; cErr != nil {
+				return nil, cErr
+			}
+			return def, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func UpdateCertMonitorSetting(in *CertMonitorSetting) (*CertMonitorSetting, error) {
+	if in == nil {
+		return nil, nil
+	}
+	if in.ScanCron != "" {
+		if _, err := cron.ParseStandard(in.ScanCron); err != nil {
+			return nil, fmt.Errorf("非法的巡检Cron表达式 %q: %w", in.ScanCron, err)
+		}
+	}
+	if _, err := ParseWarnDays(in.WarnDays); err != nil {
+		return nil, err
+	}
+	cur, err := GetOrCreateCertMonitorSetting()
+	if err != nil {
+		return nil, err
+	}
+
+	cur.ScanCron = in.ScanCron
+	cur.WarnDays = in.WarnDays
+	cur.Webhooks = in.Webhooks
+	cur.Secrets = in.Secrets
+	cur.CertManager = in.CertManager
+
+	if err := dao.DB().Save(cur).Error; err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+// ParseWarnDays 解析预警档位，去重后按天数从大到小排列，为空时使用默认档位
+func ParseWarnDays(s string) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		s = DefaultWarnDays
+	}
+	seen := map[int]bool{}
+	var days []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := strconv.Atoi(part)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("非法的预警天数 %q，应为正整数", part)
+		}
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days, nil
+}
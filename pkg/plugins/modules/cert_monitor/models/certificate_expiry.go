@@ -0,0 +1,98 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"gorm.io/gorm"
+)
+
+const (
+	CertKindKubeconfig  = "kubeconfig"   // kubeconfig 中的客户端证书
+	CertKindAPIServer   = "apiserver"    // API Server 服务端证书
+	CertKindSecret      = "secret"       // kubernetes.io/tls 类型的 Secret
+	CertKindCertManager = "cert-manager" // cert-manager Certificate
+)
+
+// CertificateExpiry 证书到期信息，每个证书一条，巡检时覆盖更新
+type CertificateExpiry struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Cluster       string    `gorm:"size:255;index:idx_cert_expiry_cluster" json:"cluster,omitempty"`
+	Kind          string    `gorm:"size:32;index:idx_cert_expiry_kind" json:"kind,omitempty"`
+	Namespace     string    `gorm:"size:100" json:"namespace,omitempty"`
+	Name          string    `gorm:"size:255" json:"name,omitempty"`
+	Subject       string    `gorm:"size:500" json:"subject,omitempty"`
+	Issuer        string    `gorm:"size:500" json:"issuer,omitempty"`
+	DNSNames      string    `gorm:"type:text" json:"dns_names,omitempty"` // 逗号分隔
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `gorm:"index:idx_cert_expiry_not_after" json:"not_after"`
+	NotifiedStage int       `json:"notified_stage"` // 已推送的预警档位（天），0 表示尚未推送
+	ScannedAt     time.Time `json:"scanned_at"`
+	DaysLeft      int       `gorm:"-" json:"days_left"` // 剩余天数，小于 0 表示已过期，仅用于展示
+	CreatedAt     time.Time `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty"`
+}
+
+func (CertificateExpiry) TableName() string {
+	return "cert_monitor_certificates"
+}
+
+func (c *CertificateExpiry) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*CertificateExpiry, int64, error) {
+	items, total, err := dao.GenericQuery(params, c, queryFuncs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for _, item := range items {
+		item.DaysLeft = DaysLeft(item.NotAfter, now)
+	}
+	return items, total, nil
+}
+
+// DaysLeft 距离到期的天数，不足一天按 0 计，已过期为负数
+func DaysLeft(notAfter time.Time, now time.Time) int {
+	return int(math.Floor(notAfter.Sub(now).Hours() / 24))
+}
+
+// NotifyStage 剩余天数命中的最小预警档位，warnDays 需按从大到小排列，未命中返回 0
+func NotifyStage(daysLeft int, warnDays []int) int {
+	stage := 0
+	for _, d := range warnDays {
+		if daysLeft <= d {
+			stage = d
+		}
+	}
+	return stage
+}
+
+// SaveCertificateExpiry 按集群、类型、命名空间、名称覆盖保存。
+// 证书未变化时保留已推送的档位，证书续期后重置预警状态
+func SaveCertificateExpiry(c *CertificateExpiry) error {
+	var existing CertificateExpiry
+	err := dao.DB().Where("cluster = ? AND kind = ? AND namespace = ? AND name = ?", c.Cluster, c.Kind, c.Namespace, c.Name).First(&existing).Error
+	if err == nil {
+		c.ID = existing.ID
+		c.CreatedAt = existing.CreatedAt
+		if existing.NotAfter.Equal(c.NotAfter) {
+			c.NotifiedStage = existing.NotifiedStage
+		}
+	}
+	return dao.DB().Save(c).Error
+}
+
+// UpdateNotifiedStage 记录已推送的预警档位
+func UpdateNotifiedStage(ids []uint, stage int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.DB().Model(&CertificateExpiry{}).Where("id in ?", ids).UpdateColumn("notified_stage", stage).Error
+}
+
+// DeleteStaleCertificates 删除集群中本次巡检未再发现的证书，kinds 为本次成功巡检的类型
+func DeleteStaleCertificates(cluster string, kinds []string, scannedBefore time.Time) error {
+	if len(kinds) == 0 {
+		return nil
+	}
+	return dao.DB().Where("cluster = ? AND kind in ? AND scanned_at < ?", cluster, kinds, scannedBefore).Delete(&CertificateExpiry{}).Error
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWarnDays(t *testing.T) {
+	days, err := ParseWarnDays("7, 30,14,7")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if !reflect.DeepEqual(days, []int{30, 14, 7}) {
+		t.Errorf("预警档位应去重并从大到小排列: %v", days)
+	}
+	if days, _ := ParseWarnDays(""); !reflect.DeepEqual(days, []int{30, 14, 7}) {
+		t.Errorf("为空时应使用默认档位: %v", days)
+	}
+	if _, err := ParseWarnDays("30,abc"); err == nil {
+		t.Errorf("非数字档位应报错")
+	}
+	if _, err := ParseWarnDays("0"); err == nil {
+		t.Errorf("非正数档位应报错")
+	}
+}
+
+func TestNotifyStage(t *testing.T) {
+	warnDays := []int{30, 14, 7}
+	cases := map[int]int{
+		45: 0,
+		30: 30,
+		20: 30,
+		14: 14,
+		8:  14,
+		7:  7,
+		0:  7,
+		-3: 7,
+	}
+	for daysLeft, want := range cases {
+		if got := NotifyStage(daysLeft, warnDays); got != want {
+			t.Errorf("剩余 %d 天，期望档位 %d，实际 %d", daysLeft, want, got)
+		}
+	}
+}
+
+func TestDaysLeft(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := DaysLeft(now.Add(30*24*time.Hour+time.Hour), now); got != 30 {
+		t.Errorf("期望 30，实际 %d", got)
+	}
+	if got := DaysLeft(now.Add(time.Hour), now); got != 0 {
+		t.Errorf("不足一天应为 0，实际 %d", got)
+	}
+	if got := DaysLeft(now.Add(-time.Hour), now); got != -1 {
+		t.Errorf("已过期应为负数，实际 %d", got)
+	}
+}
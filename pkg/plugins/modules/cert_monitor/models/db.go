@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/weibaohui/k8m/internal/dao"
+	"k8s.io/klog/v2"
+)
+
+// InitDB 初始化数据库表（GORM自动迁移）
+func InitDB() error {
+	return dao.DB().AutoMigrate(&CertMonitorSetting{}, &CertificateExpiry{})
+}
+
+// UpgradeDB 升级证书巡检插件数据库结构
+func UpgradeDB(fromVersion string, toVersion string) error {
+	klog.V(6).Infof("开始升级证书巡检插件数据库：从版本 %s 到版本 %s", fromVersion, toVersion)
+	if err := dao.DB().AutoMigrate(&CertMonitorSetting{}, &CertificateExpiry{}); err != nil {
+		klog.V(6).Infof("自动迁移证书巡检插件数据库失败: %v", err)
+		return err
+	}
+	klog.V(6).Infof("升级证书巡检插件数据库完成")
+	return nil
+}
+
+// DropDB 删除证书巡检插件相关的表及数据
+func DropDB() error {
+	db := dao.DB()
+	if db.Migrator().HasTable(&CertMonitorSetting{}) {
+		if err := db.Migrator().DropTable(&CertMonitorSetting{}); err != nil {
+			klog.V(6).Infof("删除证书巡检配置表失败: %v", err)
+			return err
+		}
+	}
+	if db.Migrator().HasTable(&CertificateExpiry{}) {
+		if err := db.Migrator().DropTable(&CertificateExpiry{}); err != nil {
+			klog.V(6).Infof("删除证书到期信息表失败: %v", err)
+			return err
+		}
+	}
+	klog.V(6).Infof("已删除证书巡检插件表及数据")
+	return nil
+}
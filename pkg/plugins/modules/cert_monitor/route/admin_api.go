@@ -0,0 +1,25 @@
+package route
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/admin"
+	"github.com/weibaohui/k8m/pkg/response"
+	"k8s.io/klog/v2"
+)
+
+// RegisterPluginAdminRoutes 注册证书巡检插件的管理员路由（平台管理员）
+func RegisterPluginAdminRoutes(arg chi.Router) {
+	prefix := "/plugins/" + modules.PluginNameCertMonitor
+
+	settingCtrl := &admin.SettingController{}
+	arg.Get(prefix+"/setting/get", response.Adapter(settingCtrl.GetSetting))
+	arg.Post(prefix+"/setting/update", response.Adapter(settingCtrl.UpdateSetting))
+
+	certCtrl := &admin.CertificateController{}
+	arg.Get(prefix+"/certificate/list", response.Adapter(certCtrl.List))
+	arg.Get(prefix+"/certificate/summary", response.Adapter(certCtrl.Summary))
+	arg.Post(prefix+"/certificate/scan", response.Adapter(certCtrl.Scan))
+
+	klog.V(6).Infof("注册证书巡检插件管理路由(admin)")
+}
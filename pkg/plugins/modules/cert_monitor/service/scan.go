@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/plugins/api"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"github.com/weibaohui/kom/kom"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+// 连接 API Server 读取服务端证书的超时时间
+const tlsDialTimeout = 10 * time.Second
+
+var (
+	scanCron *cron.Cron
+	cronMu   sync.Mutex
+	scanMu   sync.Mutex // 同一时间只执行一次巡检
+)
+
+// StartScanInBackground 按配置启动证书巡检定时任务
+func StartScanInBackground() {
+	setting, err := models.GetOrCreateCertMonitorSetting()
+	if err != nil {
+		klog.Errorf("获取证书巡检配置失败: %v", err)
+		return
+	}
+
+	cn := setting.ScanCron
+	if cn == "" {
+		klog.V(6).Infof("证书巡检 Cron 表达式为空，跳过证书巡检")
+		return
+	}
+	if _, err := cron.ParseStandard(cn); err != nil {
+		klog.Errorf("非法的证书巡检 Cron 表达式 %q: %v", cn, err)
+		return
+	}
+
+	cronMu.Lock()
+	defer cronMu.Unlock()
+
+	if scanCron != nil {
+		scanCron.Stop()
+	}
+
+	inst := cron.New()
+	if _, err := inst.AddFunc(cn, ScanAllClusters); err != nil {
+		klog.Errorf("新增证书巡检定时任务失败: %v", err)
+		return
+	}
+	scanCron = inst
+	inst.Start()
+	klog.V(6).Infof("新增证书巡检定时任务 %s", cn)
+}
+
+// StopScanInBackground 停止证书巡检定时任务
+func StopScanInBackground() {
+	cronMu.Lock()
+	defer cronMu.Unlock()
+
+	if scanCron != nil {
+		klog.V(6).Infof("停止证书巡检定时任务")
+		scanCron.Stop()
+		scanCron = nil
+	}
+}
+
+// ScanAllClusters 巡检全部集群的证书到期情况，命中预警档位时推送 webhook
+func ScanAllClusters() {
+	if !scanMu.TryLock() {
+		klog.V(6).Infof("[cert_monitor] 上一次证书巡检尚未结束，跳过本次巡检")
+		return
+	}
+	defer scanMu.Unlock()
+
+	setting, err := models.GetOrCreateCertMonitorSetting()
+	if err != nil {
+		klog.Errorf("获取证书巡检配置失败: %v", err)
+		return
+	}
+	warnDays, err := models.ParseWarnDays(setting.WarnDays)
+	if err != nil {
+		klog.Errorf("证书巡检预警档位配置错误: %v", err)
+		return
+	}
+
+	var certs []*models.CertificateExpiry
+	for _, cluster := range service.ClusterService().AllClusters() {
+		certs = append(certs, scanCluster(cluster, setting)...)
+	}
+	klog.V(6).Infof("[cert_monitor] 证书巡检完成，共 %d 个证书", len(certs))
+
+	notify(certs, warnDays, setting.Webhooks)
+}
+
+// scanCluster 巡检单个集群，保存结果并返回本次发现的证书
+func scanCluster(cluster *service.ClusterConfig, setting *models.CertMonitorSetting) []*models.CertificateExpiry {
+	clusterID := cluster.GetClusterID()
+	startedAt := time.Now()
+	var certs []*models.CertificateExpiry
+	var scannedKinds []string
+
+	// kubeconfig 客户端证书不依赖集群连接
+	scannedKinds = append(scannedKinds, models.CertKindKubeconfig)
+	if cert := cluster.GetClientCertificate(); cert != nil {
+		certs = append(certs, newCertificateExpiry(clusterID, models.CertKindKubeconfig, "", cluster.UserName, cert))
+	}
+
+	if cluster.ClusterConnectStatus == constants.ClusterConnectStatusConnected {
+		// Agent 集群经由反向隧道访问，无法直接读取 API Server 证书
+		if cluster.Source != service.ClusterConfigSourceAgent {
+			if cert, err := apiServerCertificate(cluster.Server); err != nil {
+				klog.V(6).Infof("[cert_monitor] 读取集群[%s] API Server 证书失败: %v", clusterID, err)
+			} else {
+				scannedKinds = append(scannedKinds, models.CertKindAPIServer)
+				certs = append(certs, newCertificateExpiry(clusterID, models.CertKindAPIServer, "", cluster.Server, cert))
+			}
+		}
+
+		ctx := utils.GetContextWithAdmin()
+		if setting.Secrets {
+			if items, err := secretCertificates(ctx, clusterID); err != nil {
+				klog.V(6).Infof("[cert_monitor] 巡检集群[%s] TLS Secret 失败: %v", clusterID, err)
+			} else {
+				scannedKinds = append(scannedKinds, models.CertKindSecret)
+				certs = append(certs, items...)
+			}
+		}
+		if setting.CertManager {
+			if items, err := certManagerCertificates(ctx, clusterID); err != nil {
+				// 未安装 cert-manager 时同样会失败，仅记录日志
+				klog.V(6).Infof("[cert_monitor] 巡检集群[%s] cert-manager Certificate 失败: %v", clusterID, err)
+			} else {
+				scannedKinds = append(scannedKinds, models.CertKindCertManager)
+				certs = append(certs, items...)
+			}
+		}
+	}
+
+	for _, c := range certs {
+		c.ScannedAt = startedAt
+		if err := models.SaveCertificateExpiry(c); err != nil {
+			klog.V(6).Infof("[cert_monitor] 保存证书[%s/%s/%s]失败: %v", clusterID, c.Kind, c.Name, err)
+		}
+	}
+	// 仅清理本次巡检成功的类型，避免临时故障导致记录丢失
+	if err := models.DeleteStaleCertificates(clusterID, scannedKinds, startedAt); err != nil {
+		klog.V(6).Infof("[cert_monitor] 清理集群[%s]过期记录失败: %v", clusterID, err)
+	}
+	return certs
+}
+
+// apiServerCertificate 建立 TLS 连接读取 API Server 的服务端证书
+func apiServerCertificate(server string) (*x509.Certificate, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("非 HTTPS 地址")
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: tlsDialTimeout}, "tcp", host, &tls.Config{
+		ServerName: u.Hostname(),
+		// 仅读取证书信息，不做校验
+		InsecureSkipVerify: true, //nolint:gosec
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	peers := conn.ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("未返回证书")
+	}
+	return peers[0], nil
+}
+
+// secretCertificates 读取集群中全部 kubernetes.io/tls 类型 Secret 的证书
+func secretCertificates(ctx context.Context, clusterID string) ([]*models.CertificateExpiry, error) {
+	var list []*v1.Secret
+	err := kom.Cluster(clusterID).WithContext(ctx).Resource(&v1.Secret{}).AllNamespace().
+		List(&list, metav1.ListOptions{FieldSelector: "type=" + string(v1.SecretTypeTLS)}).Error
+	if err != nil {
+		return nil, err
+	}
+	var certs []*models.CertificateExpiry
+	for _, secret := range list {
+		data := secret.Data[v1.TLSCertKey]
+		if len(data) == 0 {
+			continue
+		}
+		cert, err := utils.ParseCertificate(data)
+		if err != nil {
+			klog.V(6).Infof("[cert_monitor] 解析 Secret[%s/%s]证书失败: %v", secret.Namespace, secret.Name, err)
+			continue
+		}
+		certs = append(certs, newCertificateExpiry(clusterID, models.CertKindSecret, secret.Namespace, secret.Name, cert))
+	}
+	return certs, nil
+}
+
+// certManagerCertificates 读取集群中 cert-manager Certificate 的到期时间
+func certManagerCertificates(ctx context.Context, clusterID string) ([]*models.CertificateExpiry, error) {
+	var list []*unstructured.Unstructured
+	err := kom.Cluster(clusterID).WithContext(ctx).RemoveManagedFields().AllNamespace().
+		CRD("cert-manager.io", "v1", "Certificate").List(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	var certs []*models.CertificateExpiry
+	for _, item := range list {
+		notAfterStr, _, _ := unstructured.NestedString(item.Object, "status", "notAfter")
+		if notAfterStr == "" {
+			// 尚未签发
+			continue
+		}
+		notAfter, err := time.Parse(time.RFC3339, notAfterStr)
+		if err != nil {
+			continue
+		}
+		c := &models.CertificateExpiry{
+			Cluster:   clusterID,
+			Kind:      models.CertKindCertManager,
+			Namespace: item.GetNamespace(),
+			Name:      item.GetName(),
+			NotAfter:  notAfter,
+		}
+		if notBefore, _, _ := unstructured.NestedString(item.Object, "status", "notBefore"); notBefore != "" {
+			c.NotBefore, _ = time.Parse(time.RFC3339, notBefore)
+		}
+		c.Subject, _, _ = unstructured.NestedString(item.Object, "spec", "commonName")
+		dnsNames, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "dnsNames")
+		c.DNSNames = strings.Join(dnsNames, ",")
+		issuerKind, _, _ := unstructured.NestedString(item.Object, "spec", "issuerRef", "kind")
+		issuerName, _, _ := unstructured.NestedString(item.Object, "spec", "issuerRef", "name")
+		if issuerName != "" {
+			if issuerKind == "" {
+				issuerKind = "Issuer"
+			}
+			c.Issuer = issuerKind + "/" + issuerName
+		}
+		certs = append(certs, c)
+	}
+	return certs, nil
+}
+
+func newCertificateExpiry(clusterID string, kind string, namespace string, name string, cert *x509.Certificate) *models.CertificateExpiry {
+	return &models.CertificateExpiry{
+		Cluster:   clusterID,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  strings.Join(cert.DNSNames, ","),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+}
+
+// notify 按预警档位汇总推送，每个证书在每个档位只推送一次
+func notify(certs []*models.CertificateExpiry, warnDays []int, webhooks string) {
+	if webhooks == "" || len(warnDays) == 0 {
+		return
+	}
+	now := time.Now()
+	// 档位 -> 命中的证书
+	staged := map[int][]*models.CertificateExpiry{}
+	for _, c := range certs {
+		c.DaysLeft = models.DaysLeft(c.NotAfter, now)
+		stage := models.NotifyStage(c.DaysLeft, warnDays)
+		if stage == 0 {
+			continue
+		}
+		if c.NotifiedStage != 0 && c.NotifiedStage <= stage {
+			continue
+		}
+		staged[stage] = append(staged[stage], c)
+	}
+	if len(staged) == 0 {
+		return
+	}
+
+	stages := make([]int, 0, len(staged))
+	for stage := range staged {
+		stages = append(stages, stage)
+	}
+	sort.Ints(stages)
+
+	receiverIDs := utils.SplitAndTrim(webhooks, ",")
+	for _, stage := range stages {
+		items := staged[stage]
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].NotAfter.Before(items[j].NotAfter)
+		})
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "【证书到期预警】%d 个证书将在 %d 天内到期\n", len(items), stage)
+		ids := make([]uint, 0, len(items))
+		for _, c := range items {
+			ids = append(ids, c.ID)
+			target := c.Name
+			if c.Namespace != "" {
+				target = c.Namespace + "/" + c.Name
+			}
+			if c.DaysLeft < 0 {
+				fmt.Fprintf(&sb, "集群 %s：[%s] %s 已于 %s 过期\n", c.Cluster, c.Kind, target, c.NotAfter.Local().Format(time.DateTime))
+			} else {
+				fmt.Fprintf(&sb, "集群 %s：[%s] %s 剩余 %d 天（%s 到期）\n", c.Cluster, c.Kind, target, c.DaysLeft, c.NotAfter.Local().Format(time.DateTime))
+			}
+		}
+
+		results := api.WebhookService().PushMsgToAllTargetByIDs(sb.String(), utils.ToJSON(items), receiverIDs)
+		// webhook 插件未启用时没有推送结果，同样视为失败
+		failed := len(results) == 0
+		for _, r := range results {
+			if r != nil && r.Error != nil {
+				failed = true
+				klog.V(6).Infof("[cert_monitor] 证书到期预警推送失败: %v", r.Error)
+			}
+		}
+		// 推送失败时不记录档位，下次巡检重试
+		if failed {
+			continue
+		}
+		if err := models.UpdateNotifiedStage(ids, stage); err != nil {
+			klog.V(6).Infof("[cert_monitor] 记录预警档位失败: %v", err)
+		}
+	}
+}
@@ -9,34 +9,44 @@ import (
 	helm "github.com/weibaohui/k8m/pkg/plugins/modules/helm/service"
 	"github.com/weibaohui/k8m/pkg/response"
 	"github.com/weibaohui/k8m/pkg/service"
+	"k8s.io/klog/v2"
 )
 
+// getHelm 获取当前选中集群的 Helm 客户端，错误由调用方写入响应
 func getHelm(c *response.Context) (helm.Helm, error) {
 
 	selectedCluster, err := amis.GetSelectedCluster(c)
 	if err != nil {
-		amis.WriteJsonError(c, err)
 		return nil, err
 	}
 	cluster := service.ClusterService().GetClusterByID(selectedCluster)
-
-	// return h, err
-	cmd := helm.NewHelmCmd("helm", selectedCluster, cluster)
-	return cmd, nil
+	return helm.NewHelmSDK(amis.GetContextWithUser(c), selectedCluster, cluster)
 }
 func getHelmWithNoCluster() (helm.Helm, error) {
-	// return h, err
-	cmd := helm.NewHelmCmdWithNoCluster("helm")
-	return cmd, nil
+	return helm.NewHelmSDKWithNoCluster(), nil
+}
+
+// handleCommonLogic 校验权限并记录操作日志，params 作为操作参数一并记录
+func handleCommonLogic(c *response.Context, action string, releaseName, namespace, repoName string, params ...any) error {
+	cluster, _ := amis.GetSelectedCluster(c)
+	err := check(c, cluster, namespace, releaseName, action)
+	addOperationLog(c, action, releaseName, namespace, repoName, err, params...)
+	return err
 }
 
-func handleCommonLogic(c *response.Context, action string, releaseName, namespace, repoName string) error {
+// checkPermission 仅校验权限，不记录操作日志。用于预览等不产生变更的操作，或需在执行后按实际结果记录日志的操作
+func checkPermission(c *response.Context, action string, releaseName, namespace string) error {
 	cluster, _ := amis.GetSelectedCluster(c)
+	return check(c, cluster, namespace, releaseName, action)
+}
 
+// addOperationLog 记录操作日志，result 为空表示操作成功
+func addOperationLog(c *response.Context, action string, releaseName, namespace, repoName string, result error, params ...any) {
+	cluster, _ := amis.GetSelectedCluster(c)
 	username := amis.GetLoginUser(c)
 	roles, err := service.UserService().GetRolesByUserName(username)
 	if err != nil {
-		return err
+		klog.V(6).Infof("获取用户[%s]角色失败: %v", username, err)
 	}
 
 	log := models.OperationLog{
@@ -50,13 +60,10 @@ func handleCommonLogic(c *response.Context, action string, releaseName, namespac
 		Role:         strings.Join(roles, ","),
 		ActionResult: "success",
 	}
-
-	err = check(c, cluster, namespace, releaseName, action)
-	if err != nil {
-		log.ActionResult = err.Error()
+	if result != nil {
+		log.ActionResult = result.Error()
 	}
-	go service.OperationLogService().Add(&log)
-	return err
+	go service.OperationLogService().Add(&log, params...)
 }
 func check(c *response.Context, cluster, ns, name, action string) error {
 	ctx := amis.GetContextWithUser(c)
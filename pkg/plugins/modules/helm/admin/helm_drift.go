@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	helm "github.com/weibaohui/k8m/pkg/plugins/modules/helm/service"
+	"github.com/weibaohui/k8m/pkg/response"
+)
+
+type DriftController struct{}
+
+// @Summary Release漂移检测结果列表
+// @Description 获取各集群Release最近一次漂移检测结果
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/drift/list [get]
+func (d *DriftController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.HelmReleaseDrift{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	for _, item := range items {
+		if item.Detail != "" {
+			_ = json.Unmarshal([]byte(item.Detail), &item.Resources)
+		}
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 立即执行Release漂移巡检
+// @Description 后台巡检全部已连接集群，发现漂移时推送到配置的webhook
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/drift/scan [post]
+func (d *DriftController) Scan(c *response.Context) {
+	// 后台执行不随请求结束而取消，沿用发起人的身份
+	go helm.ScanAllClustersDrift(context.WithoutCancel(amis.GetContextWithUser(c)))
+	amis.WriteJsonOKMsg(c, "漂移巡检已在后台执行，请稍后刷新查看结果")
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/duke-git/lancet/v2/slice"
@@ -138,6 +139,57 @@ func (hr *ReleaseController) InstallRelease(c *response.Context) {
 	amis.WriteJsonOKMsg(c, "正在安装中，界面显示可能有延迟")
 }
 
+// @Summary 预览Helm Release安装
+// @Description 以服务端dry-run方式渲染安装清单，返回将要创建的资源
+// @Security BearerAuth
+// @Param cluster query string true "集群名称"
+// @Param release path string true "Release名称"
+// @Param repo path string true "仓库名称"
+// @Param chart path string true "Chart名称"
+// @Param version path string true "版本号"
+// @Param body body object true "安装参数"
+// @Success 200 {object} string
+// @Router /k8s/cluster/{cluster}/plugins/helm/release/{release}/repo/{repo}/chart/{chart}/version/{version}/install/preview [post]
+func (hr *ReleaseController) PreviewInstallRelease(c *response.Context) {
+	releaseName := c.Param("release")
+	repoName := c.Param("repo")
+	chartName := c.Param("chart")
+	version := c.Param("version")
+
+	var req struct {
+		Values    string `json:"values,omitempty"`
+		Namespace string `json:"ns,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	// 检查权限，预览不产生变更，不记录操作日志
+	err := checkPermission(c, "create", releaseName, req.Namespace)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	h, err := getHelm(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	// 未指定名称时使用临时名称渲染，实际安装时会重新生成
+	if releaseName == "" {
+		releaseName = fmt.Sprintf("%s-preview", chartName)
+	}
+	preview, err := h.PreviewInstall(req.Namespace, releaseName, repoName, chartName, version, req.Values)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, preview)
+}
+
 // @Summary 卸载Helm Release
 // @Security BearerAuth
 // @Param cluster query string true "集群名称"
@@ -331,6 +383,7 @@ func (hr *ReleaseController) UpgradeRelease(c *response.Context) {
 	var req struct {
 		Name      string `json:"name,omitempty"`
 		Namespace string `json:"namespace,omitempty"`
+		Version   string `json:"version,omitempty"`
 		Values    string `json:"values,omitempty"`
 	}
 
@@ -353,9 +406,125 @@ func (hr *ReleaseController) UpgradeRelease(c *response.Context) {
 		return
 	}
 
-	if err := h.UpgradeRelease(req.Namespace, req.Name, req.Values); err != nil {
+	if err := h.UpgradeRelease(req.Namespace, req.Name, req.Version, req.Values); err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
 	amis.WriteJsonOK(c)
 }
+
+// @Summary 预览Helm Release升级
+// @Description 使用目标版本与新的values渲染清单，并与当前部署的清单逐个资源对比
+// @Security BearerAuth
+// @Param cluster query string true "集群名称"
+// @Param body body object true "升级参数"
+// @Success 200 {object} string
+// @Router /k8s/cluster/{cluster}/plugins/helm/release/upgrade/preview [post]
+func (hr *ReleaseController) PreviewUpgradeRelease(c *response.Context) {
+	var req struct {
+		Name      string `json:"name,omitempty"`
+		Namespace string `json:"namespace,omitempty"`
+		Version   string `json:"version,omitempty"`
+		Values    string `json:"values,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	// 检查权限，预览不产生变更，不记录操作日志
+	err := checkPermission(c, "update", req.Name, req.Namespace)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	h, err := getHelm(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	preview, err := h.PreviewUpgrade(req.Namespace, req.Name, req.Version, req.Values)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, preview)
+}
+
+// @Summary 回滚Helm Release
+// @Description 回滚到指定的历史版本，操作记录到操作日志
+// @Security BearerAuth
+// @Param cluster query string true "集群名称"
+// @Param ns path string true "命名空间"
+// @Param name path string true "Release名称"
+// @Param revision path int true "目标版本号"
+// @Success 200 {object} string
+// @Router /k8s/cluster/{cluster}/plugins/helm/release/ns/{ns}/name/{name}/revision/{revision}/rollback [post]
+func (hr *ReleaseController) RollbackRelease(c *response.Context) {
+	releaseName := c.Param("name")
+	ns := c.Param("ns")
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		amis.WriteJsonError(c, fmt.Errorf("非法的版本号 %q", c.Param("revision")))
+		return
+	}
+
+	// 检查权限，回滚属于变更操作，执行后按实际结果记录操作日志
+	logParams := response.H{
+		"operation": "rollback",
+		"revision":  revision,
+	}
+	err = checkPermission(c, "update", releaseName, ns)
+	if err != nil {
+		addOperationLog(c, "update", releaseName, ns, "", err, logParams)
+		amis.WriteJsonError(c, err)
+		return
+	}
+	h, err := getHelm(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	err = h.RollbackRelease(ns, releaseName, revision)
+	addOperationLog(c, "update", releaseName, ns, "", err, logParams)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOKMsg(c, fmt.Sprintf("已回滚到版本 %d", revision))
+}
+
+// @Summary 检测Helm Release漂移
+// @Description 对比Release部署清单与集群中的实际资源，列出被修改的字段及被删除的资源
+// @Security BearerAuth
+// @Param cluster query string true "集群名称"
+// @Param ns path string true "命名空间"
+// @Param name path string true "Release名称"
+// @Success 200 {object} string
+// @Router /k8s/cluster/{cluster}/plugins/helm/release/ns/{ns}/name/{name}/drift [get]
+func (hr *ReleaseController) CheckReleaseDrift(c *response.Context) {
+	releaseName := c.Param("name")
+	ns := c.Param("ns")
+
+	// 检查权限
+	err := handleCommonLogic(c, "get", releaseName, ns, "")
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	h, err := getHelm(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+
+	report, err := h.CheckReleaseDrift(ns, releaseName)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, report)
+}
@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	pkgmodels "github.com/weibaohui/k8m/pkg/models"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	helm "github.com/weibaohui/k8m/pkg/plugins/modules/helm/service"
+	"github.com/weibaohui/k8m/pkg/response"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+type ReleaseSetController struct{}
+
+// @Summary Release集合列表
+// @Description 获取声明式多集群Release集合
+// @Security BearerAuth
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/release_set/list [get]
+func (r *ReleaseSetController) List(c *response.Context) {
+	params := dao.BuildParams(c)
+	m := &models.HelmReleaseSet{}
+	items, total, err := m.List(params)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+// @Summary 保存Release集合
+// @Description 新增或更新Release集合，保存前校验声明格式
+// @Security BearerAuth
+// @Param set body models.HelmReleaseSet true "Release集合"
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/release_set/save [post]
+func (r *ReleaseSetController) Save(c *response.Context) {
+	var m models.HelmReleaseSet
+	if err := c.ShouldBindJSON(&m); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if _, err := helm.ParseReleaseSetSpec(m.Spec); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	// 对账结果由后台维护，不接受前端提交
+	omits := []string{"out_of_sync", "failed", "last_sync_at"}
+	if m.ID == 0 {
+		m.CreatedBy = amis.GetLoginUser(c)
+	} else {
+		omits = append(omits, "created_by")
+	}
+	if err := dao.DB().Omit(omits...).Save(&m).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 删除Release集合
+// @Description 删除Release集合及其对账状态，不会卸载已部署的Release
+// @Security BearerAuth
+// @Param ids path string true "Release集合ID，多个用逗号分隔"
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/release_set/delete/{ids} [post]
+func (r *ReleaseSetController) Delete(c *response.Context) {
+	ids := strings.Split(c.Param("ids"), ",")
+	if err := dao.DB().Where("id in ?", ids).Delete(&models.HelmReleaseSet{}).Error; err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	dao.DB().Where("set_id in ?", ids).Delete(&models.HelmReleaseSetStatus{})
+	amis.WriteJsonOK(c)
+}
+
+// @Summary 生成Release集合对账计划
+// @Description 对比声明与各目标集群的实际部署情况，返回每个集群每个Release的状态
+// @Security BearerAuth
+// @Param id path int true "Release集合ID"
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/release_set/id/{id}/plan [post]
+func (r *ReleaseSetController) Plan(c *response.Context) {
+	set, err := getReleaseSet(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	statuses, err := helm.PlanReleaseSet(amis.GetContextWithUser(c), set)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, int64(len(statuses)), statuses)
+}
+
+// @Summary 应用Release集合
+// @Description 在后台对不一致的Release执行安装或升级，clusters为空时应用到全部目标集群
+// @Security BearerAuth
+// @Param id path int true "Release集合ID"
+// @Param body body object false "{clusters: 集群ID，多个用逗号分隔}"
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/release_set/id/{id}/apply [post]
+func (r *ReleaseSetController) Apply(c *response.Context) {
+	var req struct {
+		Clusters string `json:"clusters,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	set, err := getReleaseSet(c)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	clusters := utils.SplitAndTrim(req.Clusters, ",")
+
+	username := amis.GetLoginUser(c)
+	roles, _ := service.UserService().GetRolesByUserName(username)
+	log := pkgmodels.OperationLog{
+		Action:       "apply",
+		Cluster:      req.Clusters,
+		Kind:         "HelmReleaseSet",
+		Name:         set.Name,
+		UserName:     username,
+		Role:         strings.Join(roles, ","),
+		ActionResult: "success",
+	}
+	go service.OperationLogService().Add(&log, set.Spec)
+
+	// 后台执行不随请求结束而取消，沿用发起人的身份
+	ctx := context.WithoutCancel(amis.GetContextWithUser(c))
+	go func() {
+		if _, err := helm.ApplyReleaseSet(ctx, set, clusters); err != nil {
+			klog.V(6).Infof("[helm] 应用 Release 集合[%s]失败: %v", set.Name, err)
+		}
+	}()
+	amis.WriteJsonOKMsg(c, "已在后台应用，请稍后刷新查看各集群状态")
+}
+
+// @Summary Release集合对账状态
+// @Description 获取Release集合最近一次对账中每个集群每个Release的状态
+// @Security BearerAuth
+// @Param id path int true "Release集合ID"
+// @Success 200 {object} string
+// @Router /admin/plugins/helm/release_set/id/{id}/status [get]
+func (r *ReleaseSetController) Status(c *response.Context) {
+	id := c.Param("id")
+	params := dao.BuildParams(c)
+	m := &models.HelmReleaseSetStatus{}
+	items, total, err := m.List(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("set_id = ?", id)
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonListWithTotal(c, total, items)
+}
+
+func getReleaseSet(c *response.Context) (*models.HelmReleaseSet, error) {
+	id := c.Param("id")
+	m := &models.HelmReleaseSet{}
+	set, err := m.GetOne(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Release 集合不存在: %w", err)
+	}
+	return set, nil
+}
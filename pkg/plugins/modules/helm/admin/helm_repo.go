@@ -6,6 +6,7 @@ import (
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	pkgmodels "github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
 	"github.com/weibaohui/k8m/pkg/response"
 	"gorm.io/gorm"
@@ -28,6 +29,10 @@ func (r *RepoController) List(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 密码不回显
+	for _, item := range items {
+		item.Password = pkgmodels.MaskSecret(item.Password)
+	}
 	amis.WriteJsonListWithTotal(c, total, items)
 }
 
@@ -44,6 +49,15 @@ func (r *RepoController) Save(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 编辑时未修改密码，沿用原值
+	if repo.ID > 0 && repo.Password == pkgmodels.SecretMask {
+		old, err := (&models.HelmRepository{ID: repo.ID}).GetOne(nil)
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		repo.Password = old.Password
+	}
 
 	h, err := getHelmWithNoCluster()
 	if err != nil {
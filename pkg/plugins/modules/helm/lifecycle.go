@@ -82,9 +82,13 @@ func (l *HelmLifecycle) Start(ctx plugins.BaseContext) error {
 				case <-elect:
 					klog.V(6).Infof("成为Leader，启动 Helm 仓库更新定时任务")
 					helm.StartUpdateHelmRepoInBackground()
+					helm.StartDriftScanInBackground()
+					helm.StartReleaseSetSyncInBackground()
 				case <-lost:
 					klog.V(6).Infof("不再是Leader，停止 Helm 仓库更新定时任务")
 					helm.StopUpdateHelmRepoInBackground()
+					helm.StopDriftScanInBackground()
+					helm.StopReleaseSetSyncInBackground()
 				case <-leaderWatchCtx.Done():
 					klog.V(6).Infof("Helm 插件 Leader 监听 goroutine 退出")
 					return
@@ -96,6 +100,8 @@ func (l *HelmLifecycle) Start(ctx plugins.BaseContext) error {
 	} else {
 		// 没有启用 Leader 插件，直接启动定时任务
 		helm.StartUpdateHelmRepoInBackground()
+		helm.StartDriftScanInBackground()
+		helm.StartReleaseSetSyncInBackground()
 		klog.V(6).Infof("启动 Helm 插件后台任务")
 	}
 	return nil
@@ -116,5 +122,7 @@ func (l *HelmLifecycle) Stop(ctx plugins.BaseContext) error {
 	}
 
 	helm.StopUpdateHelmRepoInBackground()
+	helm.StopDriftScanInBackground()
+	helm.StopReleaseSetSyncInBackground()
 	return nil
 }
@@ -10,13 +10,16 @@ var Metadata = plugins.Module{
 	Meta: plugins.Meta{
 		Name:        modules.PluginNameHelm,
 		Title:       "Helm 管理插件",
-		Version:     "1.0.0",
-		Description: "Helm 仓库、Chart、Release 管理。包括仓库添加、Chart浏览、Release安装升级卸载等功能。定时更新仓库索引。",
+		Version:     "1.4.0",
+		Description: "Helm 仓库、Chart、Release 管理。包括仓库添加、Chart浏览、Release安装升级回滚卸载等功能。定时更新仓库索引，巡检Release漂移，按声明对账多集群Release集合。",
 	},
 	Tables: []string{
 		"helm_repositories",
 		"helm_charts",
 		"helm_releases",
+		"helm_release_drifts",
+		"helm_release_sets",
+		"helm_release_set_statuses",
 	},
 	Menus: []plugins.Menu{
 		{
@@ -59,6 +62,24 @@ var Metadata = plugins.Module{
 					CustomEvent: `() => loadJsonPage("/plugins/helm/release")`,
 					Order:       30,
 				},
+				{
+					Key:         "plugin_helm_drift",
+					Title:       "Release 漂移巡检",
+					Icon:        "fa-solid fa-code-compare",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/helm/drift")`,
+					Order:       40,
+				},
+				{
+					Key:         "plugin_helm_release_set",
+					Title:       "Release 集合",
+					Icon:        "fa-solid fa-layer-group",
+					Show:        "isPlatformAdmin()==true",
+					EventType:   "custom",
+					CustomEvent: `() => loadJsonPage("/plugins/helm/release_set")`,
+					Order:       50,
+				},
 			},
 		},
 	},
@@ -2,21 +2,27 @@ package models
 
 import (
 	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/models"
 	"k8s.io/klog/v2"
 )
 
 // InitDB 初始化数据库表（GORM自动迁移）
 func InitDB() error {
-	return dao.DB().AutoMigrate(&HelmRepository{}, &HelmChart{}, &HelmRelease{}, &HelmSetting{})
+	return dao.DB().AutoMigrate(&HelmRepository{}, &HelmChart{}, &HelmRelease{}, &HelmSetting{}, &HelmReleaseDrift{}, &HelmReleaseSet{}, &HelmReleaseSetStatus{})
 }
 
 // UpgradeDB 升级 Helm 插件数据库结构与数据
 func UpgradeDB(fromVersion string, toVersion string) error {
 	klog.V(6).Infof("开始升级 Helm 插件数据库：从版本 %s 到版本 %s", fromVersion, toVersion)
-	if err := dao.DB().AutoMigrate(&HelmRepository{}, &HelmChart{}, &HelmRelease{}, &HelmSetting{}); err != nil {
+	if err := dao.DB().AutoMigrate(&HelmRepository{}, &HelmChart{}, &HelmRelease{}, &HelmSetting{}, &HelmReleaseDrift{}, &HelmReleaseSet{}, &HelmReleaseSetStatus{}); err != nil {
 		klog.V(6).Infof("自动迁移 Helm 插件数据库失败: %v", err)
 		return err
 	}
+	// 历史数据中的仓库密码为明文，升级时加密
+	if err := models.MigrateSecretFields(&HelmRepository{}); err != nil {
+		klog.V(6).Infof("加密 Helm 仓库密码失败: %v", err)
+		return err
+	}
 	klog.V(6).Infof("升级 Helm 插件数据库完成")
 	return nil
 }
@@ -48,6 +54,24 @@ func DropDB() error {
 			return err
 		}
 	}
+	if db.Migrator().HasTable(&HelmReleaseDrift{}) {
+		if err := db.Migrator().DropTable(&HelmReleaseDrift{}); err != nil {
+			klog.V(6).Infof("删除 Helm Release Drift 表失败: %v", err)
+			return err
+		}
+	}
+	if db.Migrator().HasTable(&HelmReleaseSet{}) {
+		if err := db.Migrator().DropTable(&HelmReleaseSet{}); err != nil {
+			klog.V(6).Infof("删除 Helm Release Set 表失败: %v", err)
+			return err
+		}
+	}
+	if db.Migrator().HasTable(&HelmReleaseSetStatus{}) {
+		if err := db.Migrator().DropTable(&HelmReleaseSetStatus{}); err != nil {
+			klog.V(6).Infof("删除 Helm Release Set Status 表失败: %v", err)
+			return err
+		}
+	}
 	klog.V(6).Infof("已删除 Helm 插件表及数据")
 	return nil
 }
@@ -66,3 +66,29 @@ type ReleaseHistory struct {
 	Chart      string `json:"chart"`
 	AppVersion string `json:"app_version"`
 }
+
+// ManifestChange 预览中单个资源的变更
+type ManifestChange struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"api_version"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Action     string `json:"action"`        // added/removed/changed/unchanged
+	Old        string `json:"old,omitempty"` // 当前部署的资源YAML
+	New        string `json:"new,omitempty"` // 渲染后的资源YAML
+}
+
+// ReleasePreview 安装/升级前渲染的资源清单与当前部署的差异
+type ReleasePreview struct {
+	Release   string            `json:"release"`
+	Namespace string            `json:"namespace"`
+	Chart     string            `json:"chart"`
+	Version   string            `json:"version"`
+	Revision  int               `json:"revision"` // 当前部署的版本号，安装时为0
+	Added     int               `json:"added"`
+	Removed   int               `json:"removed"`
+	Changed   int               `json:"changed"`
+	Unchanged int               `json:"unchanged"`
+	Notes     string            `json:"notes,omitempty"`
+	Changes   []*ManifestChange `json:"changes"`
+}
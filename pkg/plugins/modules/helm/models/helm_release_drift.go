@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	DriftStatusMissing = "missing" // 资源已被删除
+	DriftStatusDrifted = "drifted" // 资源字段被修改
+)
+
+// HelmReleaseDrift 记录 Release 最近一次漂移检测结果，每个 Release 一条
+type HelmReleaseDrift struct {
+	ID               uint             `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Cluster          string           `gorm:"size:100;index:idx_helm_release_drift_cluster" json:"cluster,omitempty"`
+	Namespace        string           `gorm:"size:100" json:"namespace,omitempty"`
+	ReleaseName      string           `gorm:"size:255" json:"release_name,omitempty"`
+	Revision         int              `json:"revision"`
+	Drifted          bool             `json:"drifted"`
+	DriftedResources int              `json:"drifted_resources"`                 // 发生漂移的资源数量
+	Detail           string           `gorm:"type:text" json:"detail,omitempty"` // 漂移明细JSON
+	Error            string           `gorm:"type:text" json:"error,omitempty"`
+	CheckedAt        time.Time        `json:"checked_at"`
+	Resources        []*ResourceDrift `gorm:"-" json:"resources,omitempty"` // 由 Detail 解析，仅用于展示
+	CreatedAt        time.Time        `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt        time.Time        `json:"updated_at,omitempty"`
+}
+
+func (HelmReleaseDrift) TableName() string {
+	return "helm_release_drifts"
+}
+
+func (r *HelmReleaseDrift) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*HelmReleaseDrift, int64, error) {
+	return dao.GenericQuery(params, r, queryFuncs...)
+}
+
+func (r *HelmReleaseDrift) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, r, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+// SaveHelmReleaseDrift 按集群、命名空间、名称覆盖保存检测结果
+func SaveHelmReleaseDrift(d *HelmReleaseDrift) error {
+	var existing HelmReleaseDrift
+	err := dao.DB().Where("cluster = ? AND namespace = ? AND release_name = ?", d.Cluster, d.Namespace, d.ReleaseName).First(&existing).Error
+	if err == nil {
+		d.ID = existing.ID
+		d.CreatedAt = existing.CreatedAt
+	}
+	return dao.DB().Save(d).Error
+}
+
+// FieldDrift 单个字段的期望值与实际值
+type FieldDrift struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ResourceDrift 单个资源的漂移情况
+type ResourceDrift struct {
+	Kind       string        `json:"kind"`
+	APIVersion string        `json:"api_version"`
+	Namespace  string        `json:"namespace"`
+	Name       string        `json:"name"`
+	Status     string        `json:"status"` // missing/drifted
+	Fields     []*FieldDrift `json:"fields,omitempty"`
+}
+
+// ReleaseDriftReport Release 部署清单与集群中实际资源的对比结果
+type ReleaseDriftReport struct {
+	Cluster   string           `json:"cluster"`
+	Namespace string           `json:"namespace"`
+	Release   string           `json:"release"`
+	Revision  int              `json:"revision"`
+	Checked   int              `json:"checked"` // 检查的资源数量
+	Drifted   bool             `json:"drifted"`
+	Resources []*ResourceDrift `json:"resources"`
+	CheckedAt time.Time        `json:"checked_at"`
+}
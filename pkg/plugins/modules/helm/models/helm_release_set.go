@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	ReleaseSetStatusInSync    = "in-sync"     // 与声明一致
+	ReleaseSetStatusOutOfSync = "out-of-sync" // 未安装、版本或参数与声明不一致
+	ReleaseSetStatusFailed    = "failed"      // 应用失败
+	ReleaseSetStatusError     = "error"       // 集群不可用等原因无法检查
+
+	ReleaseSetActionNone    = "none"
+	ReleaseSetActionInstall = "install"
+	ReleaseSetActionUpgrade = "upgrade"
+)
+
+// HelmReleaseSet 声明式的多集群 Release 集合，Spec 为 YAML 格式的 ReleaseSetSpec
+type HelmReleaseSet struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	Name        string     `gorm:"size:100;uniqueIndex:idx_helm_release_set_name;not null" json:"name,omitempty"`
+	Description string     `gorm:"type:text" json:"description,omitempty"`
+	Spec        string     `gorm:"type:text" json:"spec,omitempty"`
+	AutoSync    bool       `json:"auto_sync"`              // 定时对账时是否自动应用
+	OutOfSync   int        `json:"out_of_sync"`            // 最近一次对账中不一致的数量
+	Failed      int        `json:"failed"`                 // 最近一次对账中失败、异常的数量
+	LastSyncAt  *time.Time `json:"last_sync_at,omitempty"` // 最近一次对账时间
+	CreatedBy   string     `gorm:"size:100" json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+}
+
+func (HelmReleaseSet) TableName() string {
+	return "helm_release_sets"
+}
+
+func (r *HelmReleaseSet) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*HelmReleaseSet, int64, error) {
+	return dao.GenericQuery(params, r, queryFuncs...)
+}
+
+func (r *HelmReleaseSet) Save(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericSave(params, r, queryFuncs...)
+}
+
+func (r *HelmReleaseSet) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm.DB) *gorm.DB) error {
+	return dao.GenericDelete(params, r, utils.ToInt64Slice(ids), queryFuncs...)
+}
+
+func (r *HelmReleaseSet) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*HelmReleaseSet, error) {
+	return dao.GenericGetOne(params, r, queryFuncs...)
+}
+
+// HelmReleaseSetStatus Release 集合中单个集群、单个 Release 的对账状态
+type HelmReleaseSetStatus struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
+	SetID           uint       `gorm:"index:idx_helm_release_set_status_set_id;not null" json:"set_id,omitempty"`
+	Cluster         string     `gorm:"size:100" json:"cluster,omitempty"`
+	Namespace       string     `gorm:"size:100" json:"namespace,omitempty"`
+	ReleaseName     string     `gorm:"size:255" json:"release_name,omitempty"`
+	Chart           string     `gorm:"size:255" json:"chart,omitempty"`
+	DesiredVersion  string     `gorm:"size:64" json:"desired_version,omitempty"`
+	CurrentVersion  string     `gorm:"size:64" json:"current_version,omitempty"`
+	CurrentRevision int        `json:"current_revision"`
+	Action          string     `gorm:"size:20" json:"action,omitempty"` // 计划执行的操作 none/install/upgrade
+	Status          string     `gorm:"size:20" json:"status,omitempty"`
+	Message         string     `gorm:"type:text" json:"message,omitempty"`
+	LastAppliedAt   *time.Time `json:"last_applied_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at,omitempty" gorm:"<-:create"`
+	UpdatedAt       time.Time  `json:"updated_at,omitempty"`
+}
+
+func (HelmReleaseSetStatus) TableName() string {
+	return "helm_release_set_statuses"
+}
+
+func (r *HelmReleaseSetStatus) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*HelmReleaseSetStatus, int64, error) {
+	return dao.GenericQuery(params, r, queryFuncs...)
+}
+
+// ReleaseSetSpec Release 集合的声明
+type ReleaseSetSpec struct {
+	Releases  []*ReleaseSetEntry    `json:"releases"`
+	Targets   []*ReleaseSetTarget   `json:"targets"`
+	Overrides []*ReleaseSetOverride `json:"overrides,omitempty"`
+}
+
+// ReleaseSetEntry 需要部署的 Chart
+type ReleaseSetEntry struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace"`
+	Repo      string         `json:"repo"`
+	Chart     string         `json:"chart"`
+	Version   string         `json:"version"`
+	Values    map[string]any `json:"values,omitempty"` // 基础参数
+}
+
+// ReleaseSetTarget 目标集群，labels 用于匹配覆盖参数，未声明的标签取自集群标签
+type ReleaseSetTarget struct {
+	Cluster string            `json:"cluster"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// ReleaseSetOverride 覆盖参数。cluster 与 selector 均为空时对全部集群生效，
+// release 为空时对全部 Release 生效。按 selector 覆盖先于按 cluster 覆盖
+type ReleaseSetOverride struct {
+	Cluster  string         `json:"cluster,omitempty"`
+	Selector string         `json:"selector,omitempty"` // 形如 env=prod,region=eu
+	Release  string         `json:"release,omitempty"`
+	Values   map[string]any `json:"values"`
+}
@@ -1,13 +1,19 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
 	"gorm.io/gorm"
 )
 
+func init() {
+	models.RegisterSecretFields(&HelmRepository{}, "Password")
+}
+
 type HelmRepository struct {
 	ID                    uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
 	Name                  string    `gorm:"size:100;uniqueIndex:idx_helm_repository_name;not null" json:"name,omitempty"` // 仓库名称（唯一）
@@ -16,7 +22,7 @@ type HelmRepository struct {
 	Description           string    `gorm:"type:text" json:"description,omitempty"` // 仓库描述
 	AuthType              string    `gorm:"size:50;comment:认证类型（Basic/AuthToken/OAuth）" json:"auth_type,omitempty"`
 	Username              string    `gorm:"size:255" json:"username,omitempty"` // 认证用户名（加密存储）
-	Password              string    `gorm:"size:255;comment:密码（临时字段，存储时需加密）" json:"password,omitempty"`
+	Password              string    `gorm:"type:text;comment:密码（加密存储）" json:"password,omitempty"`
 	EncryptedSecret       string    `gorm:"type:text;comment:加密后的凭据" json:"encrypted_secret,omitempty"`
 	IsActive              bool      `gorm:"default:true" json:"is_active,omitempty"` // 是否启用
 	Generated             string    `gorm:"size:64" json:"generated,omitempty"`      // repo 索引文件创建时间
@@ -25,6 +31,8 @@ type HelmRepository struct {
 	CAFile                string    `gorm:"size:255" json:"caFile,omitempty"`
 	InsecureSkipTLSverify bool      `json:"insecure_skip_tls_verify,omitempty"`
 	PassCredentialsAll    bool      `json:"pass_credentials_all,omitempty"`
+	PlainHTTP             bool      `json:"plain_http,omitempty"`                         // OCI 仓库使用 HTTP 访问
+	Charts                string    `gorm:"type:text" json:"charts,omitempty"`           // OCI 仓库中的 Chart 名称，逗号分隔
 	CreatedAt             time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt             time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
 }
@@ -45,6 +53,21 @@ func (c *HelmRepository) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB)
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
 
+// BeforeSave 在保存前加密仓库密码
+func (c *HelmRepository) BeforeSave(tx *gorm.DB) error {
+	return models.EncryptSecretFields(c)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (c *HelmRepository) AfterSave(tx *gorm.DB) error {
+	return models.DecryptSecretFields(c)
+}
+
+// AfterFind 在查询后解密仓库密码
+func (c *HelmRepository) AfterFind(tx *gorm.DB) error {
+	return models.DecryptSecretFields(c)
+}
+
 func (c *HelmRepository) GetIDByNameAndURL(params *dao.Params) (uint, error) {
 	t, err := c.GetOne(params, func(db *gorm.DB) *gorm.DB {
 		return db.Select("id").Where("name = ? AND url = ?", c.Name, c.URL).First(c)
@@ -54,3 +77,17 @@ func (c *HelmRepository) GetIDByNameAndURL(params *dao.Params) (uint, error) {
 	}
 	return t.ID, err
 }
+
+// IsOCI 是否为 OCI 镜像仓库
+func (c *HelmRepository) IsOCI() bool {
+	return strings.HasPrefix(c.URL, "oci://") || strings.EqualFold(c.Type, "OCI")
+}
+
+// GetHelmRepositoryByName 根据仓库名称获取仓库信息
+func GetHelmRepositoryByName(name string) (*HelmRepository, error) {
+	r := &HelmRepository{}
+	if err := dao.DB().Where("name = ?", name).First(r).Error; err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -12,6 +12,9 @@ type HelmSetting struct {
 	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
 	HelmCachePath  string `gorm:"size:255" json:"helm_cache_path"`
 	HelmUpdateCron string `gorm:"size:64" json:"helm_update_cron"`
+	DriftScanCron  string `gorm:"size:64" json:"drift_scan_cron"`  // Release 漂移巡检Cron，为空不巡检
+	DriftWebhooks  string `gorm:"size:255" json:"drift_webhooks"`  // 发现漂移时推送的webhook接收者ID，逗号分隔
+	ReleaseSetCron string `gorm:"size:64" json:"release_set_cron"` // Release 集合对账Cron，为空不对账
 
 	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
@@ -59,6 +62,9 @@ func UpdateHelmSetting(in *HelmSetting) (*HelmSetting, error) {
 
 	cur.HelmCachePath = in.HelmCachePath
 	cur.HelmUpdateCron = in.HelmUpdateCron
+	cur.DriftScanCron = in.DriftScanCron
+	cur.DriftWebhooks = in.DriftWebhooks
+	cur.ReleaseSetCron = in.ReleaseSetCron
 
 	if err := dao.DB().Save(cur).Error; err != nil {
 		return nil, err
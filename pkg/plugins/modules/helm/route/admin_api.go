@@ -22,6 +22,18 @@ func RegisterPluginAdminRoutes(arg chi.Router) {
 	arg.Get(prefix+"/setting/get", response.Adapter(settingCtrl.GetSetting))
 	arg.Post(prefix+"/setting/update", response.Adapter(settingCtrl.UpdateSetting))
 
+	driftCtrl := &admin.DriftController{}
+	arg.Get(prefix+"/drift/list", response.Adapter(driftCtrl.List))
+	arg.Post(prefix+"/drift/scan", response.Adapter(driftCtrl.Scan))
+
+	setCtrl := &admin.ReleaseSetController{}
+	arg.Get(prefix+"/release_set/list", response.Adapter(setCtrl.List))
+	arg.Post(prefix+"/release_set/save", response.Adapter(setCtrl.Save))
+	arg.Post(prefix+"/release_set/delete/{ids}", response.Adapter(setCtrl.Delete))
+	arg.Post(prefix+"/release_set/id/{id}/plan", response.Adapter(setCtrl.Plan))
+	arg.Post(prefix+"/release_set/id/{id}/apply", response.Adapter(setCtrl.Apply))
+	arg.Get(prefix+"/release_set/id/{id}/status", response.Adapter(setCtrl.Status))
+
 	klog.V(6).Infof("注册 Helm 插件管理路由(admin)")
 }
 
@@ -34,12 +46,16 @@ func RegisterPluginAPIRoutes(arg chi.Router) {
 	arg.Get(prefix+"/release/list", response.Adapter(ctrl.ListRelease))
 	arg.Get(prefix+"/release/ns/{ns}/name/{name}/history/list", response.Adapter(ctrl.ListReleaseHistory))
 	arg.Post(prefix+"/release/{release}/repo/{repo}/chart/{chart}/version/{version}/install", response.Adapter(ctrl.InstallRelease))
+	arg.Post(prefix+"/release/{release}/repo/{repo}/chart/{chart}/version/{version}/install/preview", response.Adapter(ctrl.PreviewInstallRelease))
 	arg.Post(prefix+"/release/ns/{ns}/name/{name}/uninstall", response.Adapter(ctrl.UninstallRelease))
 	arg.Get(prefix+"/release/ns/{ns}/name/{name}/revision/{revision}/values", response.Adapter(ctrl.GetReleaseValues))
 	arg.Get(prefix+"/release/ns/{ns}/name/{name}/revision/{revision}/notes", response.Adapter(ctrl.GetReleaseNote))
 	arg.Get(prefix+"/release/ns/{ns}/name/{name}/revision/{revision}/install_log", response.Adapter(ctrl.GetReleaseInstallLog))
+	arg.Post(prefix+"/release/ns/{ns}/name/{name}/revision/{revision}/rollback", response.Adapter(ctrl.RollbackRelease))
+	arg.Get(prefix+"/release/ns/{ns}/name/{name}/drift", response.Adapter(ctrl.CheckReleaseDrift))
 	arg.Post(prefix+"/release/batch/uninstall", response.Adapter(ctrl.BatchUninstallRelease))
 	arg.Post(prefix+"/release/upgrade", response.Adapter(ctrl.UpgradeRelease))
+	arg.Post(prefix+"/release/upgrade/preview", response.Adapter(ctrl.PreviewUpgradeRelease))
 
 	klog.V(6).Infof("注册 Helm 插件 API 路由(api)")
 }
@@ -4,14 +4,17 @@ import (
 	"sync"
 
 	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
 	"k8s.io/klog/v2"
 )
 
 // 添加全局变量来存储 cron 实例和 mutex
 var (
-	helmCron *cron.Cron
-	helmMu   sync.Mutex
+	helmCron       *cron.Cron
+	driftCron      *cron.Cron
+	releaseSetCron *cron.Cron
+	helmMu         sync.Mutex
 )
 
 type Helm interface {
@@ -19,7 +22,11 @@ type Helm interface {
 	GetReleaseHistory(ns, releaseName string) ([]*models.ReleaseHistory, error)
 	InstallRelease(ns, releaseName, repoName, chartName, version string, values ...string) error
 	UninstallRelease(ns, releaseName string) error
-	UpgradeRelease(ns, name string, values ...string) error
+	UpgradeRelease(ns, name, version string, values ...string) error
+	RollbackRelease(ns, name string, revision int) error
+	CheckReleaseDrift(ns, name string) (*models.ReleaseDriftReport, error)
+	PreviewInstall(ns, releaseName, repoName, chartName, version string, values ...string) (*models.ReleasePreview, error)
+	PreviewUpgrade(ns, name, version string, values ...string) (*models.ReleasePreview, error)
 	GetChartValue(repoName, chartName, version string) (string, error)
 	GetChartVersions(repoName, chartName string) ([]string, error)
 	UpdateReposIndex(ids string)
@@ -60,7 +67,7 @@ func StartUpdateHelmRepoInBackground() {
 
 	inst := cron.New()
 	_, addErr := inst.AddFunc(cn, func() {
-		h := NewBackgroundHelmCmd("helm")
+		h := NewHelmSDKWithNoCluster()
 		h.ReAddMissingRepo()
 		h.UpdateAllReposIndex()
 	})
@@ -84,3 +91,97 @@ func StopUpdateHelmRepoInBackground() {
 		helmCron = nil
 	}
 }
+
+// StartDriftScanInBackground 按配置启动 Release 漂移巡检定时任务
+func StartDriftScanInBackground() {
+	setting, err := models.GetOrCreateHelmSetting()
+	if err != nil {
+		klog.Errorf("获取 Helm 配置失败: %v", err)
+		return
+	}
+
+	cn := setting.DriftScanCron
+	if cn == "" {
+		klog.V(6).Infof(" DriftScanCron 表达式 为空，跳过漂移巡检")
+		return
+	}
+	if _, err := cron.ParseStandard(cn); err != nil {
+		klog.Errorf("非法的 DriftScanCron 表达式 %q: %v", cn, err)
+		return
+	}
+
+	helmMu.Lock()
+	defer helmMu.Unlock()
+
+	if driftCron != nil {
+		driftCron.Stop()
+	}
+
+	inst := cron.New()
+	if _, err := inst.AddFunc(cn, func() { ScanAllClustersDrift(utils.GetContextWithAdmin()) }); err != nil {
+		klog.Errorf("新增Helm漂移巡检定时任务失败: %v", err)
+		return
+	}
+	driftCron = inst
+	inst.Start()
+	klog.V(6).Infof("新增 Helm 漂移巡检定时任务 %s", cn)
+}
+
+// StopDriftScanInBackground 停止 Release 漂移巡检定时任务
+func StopDriftScanInBackground() {
+	helmMu.Lock()
+	defer helmMu.Unlock()
+
+	if driftCron != nil {
+		klog.V(6).Infof("停止 Helm 漂移巡检定时任务")
+		driftCron.Stop()
+		driftCron = nil
+	}
+}
+
+// StartReleaseSetSyncInBackground 按配置启动 Release 集合对账定时任务
+func StartReleaseSetSyncInBackground() {
+	setting, err := models.GetOrCreateHelmSetting()
+	if err != nil {
+		klog.Errorf("获取 Helm 配置失败: %v", err)
+		return
+	}
+
+	cn := setting.ReleaseSetCron
+	if cn == "" {
+		klog.V(6).Infof(" ReleaseSetCron 表达式 为空，跳过 Release 集合对账")
+		return
+	}
+	if _, err := cron.ParseStandard(cn); err != nil {
+		klog.Errorf("非法的 ReleaseSetCron 表达式 %q: %v", cn, err)
+		return
+	}
+
+	helmMu.Lock()
+	defer helmMu.Unlock()
+
+	if releaseSetCron != nil {
+		releaseSetCron.Stop()
+	}
+
+	inst := cron.New()
+	if _, err := inst.AddFunc(cn, ReconcileAllReleaseSets); err != nil {
+		klog.Errorf("新增Helm Release 集合对账定时任务失败: %v", err)
+		return
+	}
+	releaseSetCron = inst
+	inst.Start()
+	klog.V(6).Infof("新增 Helm Release 集合对账定时任务 %s", cn)
+}
+
+// StopReleaseSetSyncInBackground 停止 Release 集合对账定时任务
+func StopReleaseSetSyncInBackground() {
+	helmMu.Lock()
+	defer helmMu.Unlock()
+
+	if releaseSetCron != nil {
+		klog.V(6).Infof("停止 Helm Release 集合对账定时任务")
+		releaseSetCron.Stop()
+		releaseSetCron = nil
+	}
+}
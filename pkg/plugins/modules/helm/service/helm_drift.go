@@ -0,0 +1,357 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/plugins/api"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// 单个资源读取超时时间
+const driftGetTimeout = 10 * time.Second
+
+// driftChecker 对比 Release 清单与集群中的实际资源。
+// 只比较清单中声明的字段，集群补充的默认值、status 等不视为漂移
+type driftChecker struct {
+	clusterID string
+	mapper    meta.RESTMapper
+	dyn       dynamic.Interface
+}
+
+func (h *HelmSDK) newDriftChecker() (*driftChecker, error) {
+	if h.restConfig == nil {
+		return nil, fmt.Errorf("未指定集群，无法检测漂移")
+	}
+	mapper, err := newRESTConfigGetter(h.restConfig, "").ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(h.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &driftChecker{clusterID: h.clusterID, mapper: mapper, dyn: dyn}, nil
+}
+
+// CheckReleaseDrift 检测单个 Release 的漂移情况，并记录检测结果
+func (h *HelmSDK) CheckReleaseDrift(ns, name string) (*models.ReleaseDriftReport, error) {
+	rel, err := h.getRelease(ns, name, "")
+	if err != nil {
+		return nil, err
+	}
+	checker, err := h.newDriftChecker()
+	if err != nil {
+		return nil, err
+	}
+	report, err := checker.check(rel)
+	saveDriftReport(h.clusterID, rel, report, err)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ScanDrift 检测当前集群中全部已部署的 Release，返回存在漂移的结果
+func (h *HelmSDK) ScanDrift() ([]*models.ReleaseDriftReport, error) {
+	cfg, err := h.actionConfig("")
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewList(cfg)
+	client.AllNamespaces = true
+	client.StateMask = action.ListDeployed
+	releases, err := client.Run()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Release 列表失败: %w", err)
+	}
+	checker, err := h.newDriftChecker()
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []*models.ReleaseDriftReport
+	for _, rel := range releases {
+		report, err := checker.check(rel)
+		saveDriftReport(h.clusterID, rel, report, err)
+		if err != nil {
+			klog.V(6).Infof("[helm] 检测 Release %s/%s 漂移失败: %v", rel.Namespace, rel.Name, err)
+			continue
+		}
+		if report.Drifted {
+			drifted = append(drifted, report)
+		}
+	}
+	return drifted, nil
+}
+
+// ScanAllClustersDrift 巡检全部已连接集群的 Release 漂移，发现漂移时推送 webhook。
+// ctx 为发起巡检的用户，定时任务使用平台管理员上下文
+func ScanAllClustersDrift(ctx context.Context) {
+	var drifted []*models.ReleaseDriftReport
+	for _, cluster := range service.ClusterService().ConnectedClusters() {
+		clusterID := cluster.GetClusterID()
+		h, err := NewHelmSDK(ctx, clusterID, cluster)
+		if err != nil {
+			klog.V(6).Infof("[helm] 集群[%s]漂移巡检跳过: %v", clusterID, err)
+			continue
+		}
+		reports, err := h.ScanDrift()
+		if err != nil {
+			klog.V(6).Infof("[helm] 集群[%s]漂移巡检失败: %v", clusterID, err)
+			continue
+		}
+		drifted = append(drifted, reports...)
+	}
+	klog.V(6).Infof("[helm] 漂移巡检完成，%d 个 Release 存在漂移", len(drifted))
+	if len(drifted) == 0 {
+		return
+	}
+
+	setting, err := models.GetOrCreateHelmSetting()
+	if err != nil || setting.DriftWebhooks == "" {
+		return
+	}
+	receiverIDs := utils.SplitAndTrim(setting.DriftWebhooks, ",")
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "【Helm漂移巡检】发现 %d 个 Release 的资源被手动修改或删除\n", len(drifted))
+	for _, r := range drifted {
+		fmt.Fprintf(&sb, "集群 %s：%s/%s（%d 个资源）\n", r.Cluster, r.Namespace, r.Release, len(r.Resources))
+	}
+	results := api.WebhookService().PushMsgToAllTargetByIDs(sb.String(), utils.ToJSON(drifted), receiverIDs)
+	for _, r := range results {
+		if r != nil && r.Error != nil {
+			klog.V(6).Infof("[helm] 漂移巡检结果推送失败: %v", r.Error)
+		}
+	}
+}
+
+// saveDriftReport 记录检测结果，忽略错误，防止影响主流程
+func saveDriftReport(clusterID string, rel *release.Release, report *models.ReleaseDriftReport, checkErr error) {
+	record := &models.HelmReleaseDrift{
+		Cluster:     clusterID,
+		Namespace:   rel.Namespace,
+		ReleaseName: rel.Name,
+		Revision:    rel.Version,
+		CheckedAt:   time.Now(),
+	}
+	if checkErr != nil {
+		record.Error = checkErr.Error()
+	} else {
+		record.Drifted = report.Drifted
+		record.DriftedResources = len(report.Resources)
+		record.Detail = utils.ToJSON(report.Resources)
+	}
+	if err := models.SaveHelmReleaseDrift(record); err != nil {
+		klog.V(6).Infof("[helm] 保存漂移检测结果失败: %v", err)
+	}
+}
+
+func (c *driftChecker) check(rel *release.Release) (*models.ReleaseDriftReport, error) {
+	objs, err := parseManifest(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.ReleaseDriftReport{
+		Cluster:   c.clusterID,
+		Namespace: rel.Namespace,
+		Release:   rel.Name,
+		Revision:  rel.Version,
+		Checked:   len(objs),
+		Resources: make([]*models.ResourceDrift, 0),
+		CheckedAt: time.Now(),
+	}
+
+	keys := make([]string, 0, len(objs))
+	for k := range objs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		o := objs[k]
+		drift, err := c.checkObject(rel.Namespace, o)
+		if err != nil {
+			return nil, fmt.Errorf("检测 %s/%s 失败: %w", o.kind, o.name, err)
+		}
+		if drift != nil {
+			report.Resources = append(report.Resources, drift)
+		}
+	}
+	report.Drifted = len(report.Resources) > 0
+	return report, nil
+}
+
+// checkObject 对比单个资源，无漂移时返回 nil
+func (c *driftChecker) checkObject(releaseNs string, o *manifestObject) (*models.ResourceDrift, error) {
+	drift := &models.ResourceDrift{
+		Kind:       o.kind,
+		APIVersion: o.apiVersion,
+		Namespace:  o.namespace,
+		Name:       o.name,
+	}
+	gv, err := schema.ParseGroupVersion(o.apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := c.mapper.RESTMapping(gv.WithKind(o.kind).GroupKind(), gv.Version)
+	if err != nil {
+		// CRD 被删除等情况，资源类型已不存在
+		if meta.IsNoMatchError(err) {
+			drift.Status = models.DriftStatusMissing
+			return drift, nil
+		}
+		return nil, err
+	}
+
+	var ri dynamic.ResourceInterface = c.dyn.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if drift.Namespace == "" {
+			drift.Namespace = releaseNs
+		}
+		ri = c.dyn.Resource(mapping.Resource).Namespace(drift.Namespace)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), driftGetTimeout)
+	defer cancel()
+	live, err := ri.Get(ctx, o.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		drift.Status = models.DriftStatusMissing
+		return drift, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 统一数字类型，与 YAML 解析结果保持一致
+	var liveObj map[string]any
+	data, err := json.Marshal(live.Object)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &liveObj); err != nil {
+		return nil, err
+	}
+
+	desired := o.object
+	if o.kind == "Secret" {
+		// stringData 只写不读，写入后体现在 data 中，无法对比
+		desired = make(map[string]any, len(o.object))
+		for k, v := range o.object {
+			if k != "stringData" {
+				desired[k] = v
+			}
+		}
+	}
+	compareFields("", desired, liveObj, &drift.Fields)
+	if len(drift.Fields) == 0 {
+		return nil, nil
+	}
+	if o.kind == "Secret" {
+		for _, f := range drift.Fields {
+			if strings.HasPrefix(f.Path, "data") {
+				f.Expected, f.Actual = maskedSecretValue, maskedSecretValue
+			}
+		}
+	}
+	drift.Status = models.DriftStatusDrifted
+	return drift, nil
+}
+
+// compareFields 递归比较清单中声明的字段，记录与实际值不一致的字段
+func compareFields(path string, desired, live any, out *[]*models.FieldDrift) {
+	if path == "status" {
+		return
+	}
+	switch d := desired.(type) {
+	case nil:
+		return
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			if len(d) > 0 {
+				*out = append(*out, newFieldDrift(path, desired, live))
+			}
+			return
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			compareFields(p, d[k], l[k], out)
+		}
+	case []any:
+		l, ok := live.([]any)
+		if !ok || len(l) != len(d) {
+			if len(d) > 0 || len(l) > 0 {
+				*out = append(*out, newFieldDrift(path, desired, live))
+			}
+			return
+		}
+		for i := range d {
+			compareFields(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], out)
+		}
+	default:
+		if !scalarEqual(desired, live) {
+			*out = append(*out, newFieldDrift(path, desired, live))
+		}
+	}
+}
+
+// scalarEqual 比较标量值，兼容零值省略与资源数量的不同写法（如 1000m 与 1）
+func scalarEqual(desired, live any) bool {
+	if reflect.DeepEqual(desired, live) {
+		return true
+	}
+	if live == nil {
+		return reflect.ValueOf(desired).IsZero()
+	}
+	ds, lds := fmt.Sprint(desired), fmt.Sprint(live)
+	if ds == lds {
+		return true
+	}
+	dq, err1 := resource.ParseQuantity(ds)
+	lq, err2 := resource.ParseQuantity(lds)
+	return err1 == nil && err2 == nil && dq.Cmp(lq) == 0
+}
+
+func newFieldDrift(path string, desired, live any) *models.FieldDrift {
+	return &models.FieldDrift{
+		Path:     path,
+		Expected: formatFieldValue(desired),
+		Actual:   formatFieldValue(live),
+	}
+}
+
+func formatFieldValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "<不存在>"
+	case string:
+		return val
+	case map[string]any, []any:
+		return utils.ToJSON(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
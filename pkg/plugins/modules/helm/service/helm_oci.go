@@ -0,0 +1,220 @@
+package helm
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/registry"
+	"k8s.io/klog/v2"
+)
+
+// OCI 仓库没有 index.yaml，需在仓库中登记 Chart 名称，版本即镜像 tag。
+// 仓库 URL 形如 oci://harbor.example.com/library，Chart 引用为 oci://harbor.example.com/library/<chart>:<version>。
+
+// ociChartRef 生成 OCI Chart 引用（不含 oci:// 前缀与 tag）
+func ociChartRef(helmRepo *models.HelmRepository, chartName string) string {
+	base := strings.TrimSuffix(strings.TrimPrefix(helmRepo.URL, "oci://"), "/")
+	return fmt.Sprintf("%s/%s", base, chartName)
+}
+
+// ociHTTPClient 根据仓库 TLS 配置创建 HTTP 客户端，未配置时返回 nil 使用默认客户端
+func ociHTTPClient(helmRepo *models.HelmRepository) (*http.Client, error) {
+	if helmRepo.CertFile == "" && helmRepo.KeyFile == "" && helmRepo.CAFile == "" && !helmRepo.InsecureSkipTLSverify {
+		return nil, nil
+	}
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: helmRepo.InsecureSkipTLSverify,
+	}
+	if helmRepo.CertFile != "" && helmRepo.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(helmRepo.CertFile, helmRepo.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	if helmRepo.CAFile != "" {
+		ca, err := os.ReadFile(helmRepo.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("CA证书格式错误: %s", helmRepo.CAFile)
+		}
+		tlsConf.RootCAs = pool
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConf,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+	}, nil
+}
+
+// registryClient 创建仓库专用的 OCI 客户端，凭据取自仓库配置，不写入共享的凭据文件
+func (h *HelmSDK) registryClient(helmRepo *models.HelmRepository) (*registry.Client, error) {
+	opts := []registry.ClientOption{
+		registry.ClientOptWriter(io.Discard),
+		registry.ClientOptEnableCache(true),
+		registry.ClientOptCredentialsFile(h.settings.RegistryConfig),
+	}
+	if helmRepo.Username != "" {
+		opts = append(opts, registry.ClientOptBasicAuth(helmRepo.Username, helmRepo.Password))
+	}
+	if helmRepo.PlainHTTP {
+		opts = append(opts, registry.ClientOptPlainHTTP())
+	}
+	httpClient, err := ociHTTPClient(helmRepo)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		opts = append(opts, registry.ClientOptHTTPClient(httpClient))
+	}
+	client, err := registry.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建 OCI 仓库[%s]客户端失败: %w", helmRepo.Name, err)
+	}
+	return client, nil
+}
+
+// ociTags 获取 OCI Chart 的全部版本，按版本号倒序
+func (h *HelmSDK) ociTags(helmRepo *models.HelmRepository, chartName string) ([]string, error) {
+	client, err := h.registryClient(helmRepo)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := client.Tags(ociChartRef(helmRepo, chartName))
+	if err != nil {
+		return nil, fmt.Errorf("获取 OCI Chart %s 版本失败: %w", ociChartRef(helmRepo, chartName), err)
+	}
+	return tags, nil
+}
+
+// ociVersionRef 生成指定版本的 OCI Chart 引用，version 为空时使用最新版本
+func ociVersionRef(client *registry.Client, helmRepo *models.HelmRepository, chartName, version string) (string, error) {
+	if version == "" {
+		tags, err := client.Tags(ociChartRef(helmRepo, chartName))
+		if err != nil {
+			return "", fmt.Errorf("获取 OCI Chart %s 版本失败: %w", ociChartRef(helmRepo, chartName), err)
+		}
+		if len(tags) == 0 {
+			return "", fmt.Errorf("OCI Chart %s 没有可用版本", ociChartRef(helmRepo, chartName))
+		}
+		version = tags[0]
+	}
+	// tag 中不允许出现 +，Helm 推送时会替换为 _
+	return fmt.Sprintf("%s:%s", ociChartRef(helmRepo, chartName), strings.ReplaceAll(version, "+", "_")), nil
+}
+
+// ociChartMetadata 读取最新版本 OCI Chart 的元数据。
+// 元数据保存在 config blob 中，只拉取 manifest、config 及体积很小的签名文件，不下载 Chart 压缩包
+func (h *HelmSDK) ociChartMetadata(helmRepo *models.HelmRepository, chartName string) (*chart.Metadata, error) {
+	client, err := h.registryClient(helmRepo)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := ociVersionRef(client, helmRepo, chartName, "")
+	if err != nil {
+		return nil, err
+	}
+	// Pull 要求至少拉取 Chart 或签名文件之一，签名文件不存在时忽略
+	result, err := client.Pull(ref,
+		registry.PullOptWithChart(false),
+		registry.PullOptWithProv(true),
+		registry.PullOptIgnoreMissingProv(true))
+	if err != nil {
+		return nil, fmt.Errorf("获取 OCI Chart %s 元数据失败: %w", ref, err)
+	}
+	if result.Chart.Meta == nil {
+		return nil, fmt.Errorf("OCI Chart %s 缺少元数据", ref)
+	}
+	return result.Chart.Meta, nil
+}
+
+// pullOCIChart 拉取并加载 OCI Chart，version 为空时使用最新版本
+func (h *HelmSDK) pullOCIChart(helmRepo *models.HelmRepository, chartName, version string) (*chart.Chart, error) {
+	client, err := h.registryClient(helmRepo)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := ociVersionRef(client, helmRepo, chartName, version)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.Pull(ref, registry.PullOptWithChart(true))
+	if err != nil {
+		return nil, fmt.Errorf("拉取 OCI Chart %s 失败: %w", ref, err)
+	}
+	chrt, err := loader.LoadArchive(bytes.NewReader(result.Chart.Data))
+	if err != nil {
+		return nil, fmt.Errorf("加载 OCI Chart %s 失败: %w", ref, err)
+	}
+	return chrt, nil
+}
+
+// syncOCIRepo 按仓库登记的 Chart 名称同步最新版本信息到数据库
+func (h *HelmSDK) syncOCIRepo(helmRepo *models.HelmRepository) error {
+	chartNames := utils.SplitAndTrim(helmRepo.Charts, ",")
+	if len(chartNames) == 0 {
+		return fmt.Errorf("OCI 仓库[%s]未登记 Chart 名称", helmRepo.Name)
+	}
+
+	var charts []models.HelmChart
+	var errs []error
+	for _, name := range chartNames {
+		md, err := h.ociChartMetadata(helmRepo, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m := models.HelmChart{
+			RepositoryID:   helmRepo.ID,
+			RepositoryName: helmRepo.Name,
+			Name:           name,
+			LatestVersion:  md.Version,
+			Description:    md.Description,
+			Home:           md.Home,
+			Icon:           md.Icon,
+			KubeVersion:    md.KubeVersion,
+			AppVersion:     md.AppVersion,
+			Deprecated:     md.Deprecated,
+			Keywords:       strings.Join(md.Keywords, ","),
+		}
+		if len(md.Sources) > 0 {
+			m.Sources = md.Sources[0]
+		}
+		charts = append(charts, m)
+	}
+	if len(charts) == 0 {
+		return fmt.Errorf("同步 OCI 仓库[%s]失败: %w", helmRepo.Name, errors.Join(errs...))
+	}
+	for _, err := range errs {
+		klog.V(6).Infof("[helm] warn: %v", err)
+	}
+
+	// 清空数据库中对应的chart repo
+	dao.DB().Where("repository_id = ?", helmRepo.ID).Delete(models.HelmChart{})
+	for i := range charts {
+		if err := charts[i].Save(nil); err != nil {
+			klog.V(6).Infof("[helm] warn: save helm chart to database error: %v\n", err)
+		}
+	}
+	// OCI 仓库没有索引生成时间，记录同步时间
+	helmRepo.Generated = time.Now().Format(time.DateTime)
+	_ = helmRepo.Save(nil)
+	return nil
+}
@@ -0,0 +1,246 @@
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeChanged   = "changed"
+	ChangeUnchanged = "unchanged"
+)
+
+// 预览中 Secret 的数据统一打码，仅体现是否变更
+const maskedSecretValue = "******"
+
+// PreviewInstall 以服务端 dry-run 方式渲染安装清单，Kubernetes 会对渲染结果做 Schema 校验
+func (h *HelmSDK) PreviewInstall(namespace, releaseName, repoName, chartName, version string, values ...string) (*models.ReleasePreview, error) {
+	vals, err := parseValues(values...)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewInstall(cfg)
+	client.Namespace = namespace
+	client.ReleaseName = releaseName
+	client.Version = version
+	client.DryRun = true
+	client.DryRunOption = "server"
+
+	chrt, err := h.loadChart(&client.ChartPathOptions, repoName, chartName)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := client.Run(chrt, vals)
+	if err != nil {
+		return nil, fmt.Errorf("预览安装 Release %s/%s 失败: %w", namespace, releaseName, err)
+	}
+
+	preview := &models.ReleasePreview{
+		Release:   releaseName,
+		Namespace: namespace,
+		Chart:     chartName,
+		Version:   chrt.Metadata.Version,
+	}
+	if rel.Info != nil {
+		preview.Notes = rel.Info.Notes
+	}
+	if err := diffManifests(preview, "", rel.Manifest); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// PreviewUpgrade 使用目标版本与新的 values 渲染清单，并与当前部署的清单逐个资源对比。
+// version 为空时沿用当前记录的 Chart 版本
+func (h *HelmSDK) PreviewUpgrade(ns, name, version string, values ...string) (*models.ReleasePreview, error) {
+	hr, err := models.GetHelmReleaseByNsAndReleaseName(ns, name, h.clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("get repoName from db failed: %v", err)
+	}
+	vals, err := parseValues(values...)
+	if err != nil {
+		return nil, err
+	}
+	current, err := h.getRelease(ns, name, "")
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := h.actionConfig(ns)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		version = hr.ChartVersion
+	}
+	client := action.NewUpgrade(cfg)
+	client.Namespace = ns
+	client.Version = version
+	client.DryRun = true
+	client.DryRunOption = "server"
+
+	chrt, err := h.loadChart(&client.ChartPathOptions, hr.RepoName, hr.ChartName)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := client.Run(name, chrt, vals)
+	if err != nil {
+		return nil, fmt.Errorf("预览升级 Release %s/%s 失败: %w", ns, name, err)
+	}
+
+	preview := &models.ReleasePreview{
+		Release:   name,
+		Namespace: ns,
+		Chart:     hr.ChartName,
+		Version:   chrt.Metadata.Version,
+		Revision:  current.Version,
+	}
+	if rel.Info != nil {
+		preview.Notes = rel.Info.Notes
+	}
+	if err := diffManifests(preview, current.Manifest, rel.Manifest); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// manifestObject 清单中的单个资源
+type manifestObject struct {
+	kind       string
+	apiVersion string
+	namespace  string
+	name       string
+	object     map[string]any
+	content    string // 规范化后的YAML，用于比较
+	display    string // 展示用YAML，Secret 数据已打码
+}
+
+func (o *manifestObject) key() string {
+	return fmt.Sprintf("%s/%s/%s", o.kind, o.namespace, o.name)
+}
+
+// parseManifest 拆分清单并规范化每个资源，消除缩进、字段顺序带来的差异
+func parseManifest(manifest string) (map[string]*manifestObject, error) {
+	objects := make(map[string]*manifestObject)
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var obj map[string]any
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("解析渲染结果失败: %w", err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		o := &manifestObject{object: obj}
+		o.kind, _ = obj["kind"].(string)
+		o.apiVersion, _ = obj["apiVersion"].(string)
+		if md, ok := obj["metadata"].(map[string]any); ok {
+			o.name, _ = md["name"].(string)
+			o.namespace, _ = md["namespace"].(string)
+		}
+		content, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		o.content = string(content)
+		o.display = o.content
+		if o.kind == "Secret" {
+			masked := make(map[string]any, len(obj))
+			for k, v := range obj {
+				masked[k] = v
+			}
+			for _, field := range []string{"data", "stringData"} {
+				if data, ok := obj[field].(map[string]any); ok {
+					m := make(map[string]any, len(data))
+					for k := range data {
+						m[k] = maskedSecretValue
+					}
+					masked[field] = m
+				}
+			}
+			display, err := yaml.Marshal(masked)
+			if err != nil {
+				return nil, err
+			}
+			o.display = string(display)
+		}
+		objects[o.key()] = o
+	}
+	return objects, nil
+}
+
+// diffManifests 对比新旧清单，填充预览中的资源变更
+func diffManifests(preview *models.ReleasePreview, oldManifest, newManifest string) error {
+	oldObjs, err := parseManifest(oldManifest)
+	if err != nil {
+		return err
+	}
+	newObjs, err := parseManifest(newManifest)
+	if err != nil {
+		return err
+	}
+
+	preview.Changes = make([]*models.ManifestChange, 0, len(newObjs))
+	for key, n := range newObjs {
+		change := &models.ManifestChange{
+			Kind:       n.kind,
+			APIVersion: n.apiVersion,
+			Namespace:  n.namespace,
+			Name:       n.name,
+			New:        n.display,
+		}
+		o, ok := oldObjs[key]
+		switch {
+		case !ok:
+			change.Action = ChangeAdded
+			preview.Added++
+		case o.content != n.content:
+			change.Action = ChangeChanged
+			change.Old = o.display
+			preview.Changed++
+		default:
+			change.Action = ChangeUnchanged
+			change.Old = o.display
+			preview.Unchanged++
+		}
+		preview.Changes = append(preview.Changes, change)
+	}
+	for key, o := range oldObjs {
+		if _, ok := newObjs[key]; ok {
+			continue
+		}
+		preview.Changes = append(preview.Changes, &models.ManifestChange{
+			Kind:       o.kind,
+			APIVersion: o.apiVersion,
+			Namespace:  o.namespace,
+			Name:       o.name,
+			Action:     ChangeRemoved,
+			Old:        o.display,
+		})
+		preview.Removed++
+	}
+
+	// 有变更的资源排在前面，其余按类型、名称排序
+	order := map[string]int{ChangeChanged: 0, ChangeAdded: 1, ChangeRemoved: 2, ChangeUnchanged: 3}
+	sort.Slice(preview.Changes, func(i, j int) bool {
+		a, b := preview.Changes[i], preview.Changes[j]
+		if order[a.Action] != order[b.Action] {
+			return order[a.Action] < order[b.Action]
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name) < 0
+	})
+	return nil
+}
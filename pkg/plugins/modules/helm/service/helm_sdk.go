@@ -0,0 +1,717 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"gorm.io/gorm"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// helmDriver Release 存储方式，与 helm 命令行默认值一致
+const helmDriver = "secret"
+
+// repoFileMu 保护 repositories.yaml 的并发读写
+var repoFileMu sync.Mutex
+
+// HelmSDK 基于 Helm Go SDK 实现 Helm 接口。
+// 使用集群内存中的 rest.Config 访问集群，不依赖 helm 二进制，也不落地 kubeconfig 文件。
+type HelmSDK struct {
+	repoCacheDir string
+	clusterID    string
+	restConfig   *rest.Config
+	settings     *cli.EnvSettings
+}
+
+// NewHelmSDK 创建可操作指定集群 Release 的 Helm 客户端，ctx 中的用户用于开启用户模拟模式的集群
+func NewHelmSDK(ctx context.Context, clusterID string, cluster *service.ClusterConfig) (*HelmSDK, error) {
+	if cluster == nil || cluster.GetRestConfig() == nil {
+		return nil, fmt.Errorf("集群[%s]不存在或未连接", clusterID)
+	}
+	h := NewHelmSDKWithNoCluster()
+	h.clusterID = clusterID
+	h.restConfig = cluster.GetRestConfigWithContext(ctx)
+	return h, nil
+}
+
+// NewHelmSDKWithNoCluster 创建仅用于仓库、Chart 管理的 Helm 客户端
+func NewHelmSDKWithNoCluster() *HelmSDK {
+	setting, _ := models.GetOrCreateHelmSetting()
+
+	h := &HelmSDK{}
+	if setting != nil {
+		h.repoCacheDir = setting.HelmCachePath
+	}
+	if h.repoCacheDir == "" {
+		h.repoCacheDir = "/tmp/helm-cache"
+	}
+
+	// 确保目录存在
+	if err := os.MkdirAll(h.repoCacheDir, 0755); err != nil {
+		klog.V(6).Infof("[helm] 创建缓存目录失败: %v", err)
+	}
+
+	settings := cli.New()
+	settings.RepositoryConfig = filepath.Join(h.repoCacheDir, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(h.repoCacheDir, "repository")
+	settings.RegistryConfig = filepath.Join(h.repoCacheDir, "registry", "config.json")
+	h.settings = settings
+	return h
+}
+
+func debugLog(format string, v ...any) {
+	klog.V(6).Infof("[helm] "+format, v...)
+}
+
+// actionConfig 创建指定命名空间的 action 配置，namespace 为空时表示全部命名空间
+func (h *HelmSDK) actionConfig(namespace string) (*action.Configuration, error) {
+	if h.restConfig == nil {
+		return nil, fmt.Errorf("未指定集群，无法操作 Release")
+	}
+	cfg := new(action.Configuration)
+	if err := cfg.Init(newRESTConfigGetter(h.restConfig, namespace), namespace, helmDriver, debugLog); err != nil {
+		return nil, fmt.Errorf("初始化 Helm 配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// parseValues 解析 values.yaml 内容
+func parseValues(values ...string) (map[string]any, error) {
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		return map[string]any{}, nil
+	}
+	vals, err := chartutil.ReadValues([]byte(values[0]))
+	if err != nil {
+		return nil, fmt.Errorf("解析 values 失败: %w", err)
+	}
+	return vals, nil
+}
+
+// loadChart 从仓库定位并加载 Chart，OCI 仓库直接从镜像仓库拉取
+func (h *HelmSDK) loadChart(opts *action.ChartPathOptions, repoName, chartName string) (*chart.Chart, error) {
+	if helmRepo, err := models.GetHelmRepositoryByName(repoName); err == nil && helmRepo.IsOCI() {
+		return h.pullOCIChart(helmRepo, chartName, opts.Version)
+	}
+	chartRef := fmt.Sprintf("%s/%s", repoName, chartName)
+	chartPath, err := opts.LocateChart(chartRef, h.settings)
+	if err != nil {
+		return nil, fmt.Errorf("定位 Chart %s:%s 失败: %w", chartRef, opts.Version, err)
+	}
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载 Chart %s:%s 失败: %w", chartRef, opts.Version, err)
+	}
+	return chrt, nil
+}
+
+// releaseSummary 生成与 helm install/upgrade 输出一致的结果摘要
+func releaseSummary(rel *release.Release) string {
+	if rel == nil {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "NAME: %s\n", rel.Name)
+	if rel.Info != nil {
+		if !rel.Info.LastDeployed.IsZero() {
+			fmt.Fprintf(&sb, "LAST DEPLOYED: %s\n", rel.Info.LastDeployed.Format(time.ANSIC))
+		}
+		fmt.Fprintf(&sb, "NAMESPACE: %s\n", rel.Namespace)
+		fmt.Fprintf(&sb, "STATUS: %s\n", rel.Info.Status.String())
+		fmt.Fprintf(&sb, "REVISION: %d\n", rel.Version)
+		if rel.Info.Description != "" {
+			fmt.Fprintf(&sb, "DESCRIPTION: %s\n", rel.Info.Description)
+		}
+		if rel.Info.Notes != "" {
+			fmt.Fprintf(&sb, "NOTES:\n%s\n", strings.TrimSpace(rel.Info.Notes))
+		}
+	}
+	return sb.String()
+}
+
+// repoEntry 根据数据库中的仓库信息生成 Helm 仓库配置
+func repoEntry(helmRepo *models.HelmRepository) *repo.Entry {
+	return &repo.Entry{
+		Name:                  helmRepo.Name,
+		URL:                   helmRepo.URL,
+		Username:              helmRepo.Username,
+		Password:              helmRepo.Password,
+		CertFile:              helmRepo.CertFile,
+		KeyFile:               helmRepo.KeyFile,
+		CAFile:                helmRepo.CAFile,
+		InsecureSkipTLSverify: helmRepo.InsecureSkipTLSverify,
+		PassCredentialsAll:    helmRepo.PassCredentialsAll,
+	}
+}
+
+// loadRepoFile 读取 repositories.yaml，文件不存在时返回空配置，调用方需持有 repoFileMu
+func (h *HelmSDK) loadRepoFile() (*repo.File, error) {
+	f, err := repo.LoadFile(h.settings.RepositoryConfig)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return repo.NewFile(), nil
+		}
+		return nil, fmt.Errorf("读取仓库配置失败: %w", err)
+	}
+	return f, nil
+}
+
+// AddOrUpdateRepo 添加或更新 Helm 仓库
+func (h *HelmSDK) AddOrUpdateRepo(helmRepo *models.HelmRepository) error {
+	// 1. 先执行数据库操作，保存 HelmRepository 信息
+	// 2. 再写入仓库配置并更新索引
+	if helmRepo.IsOCI() {
+		helmRepo.Type = "OCI"
+	}
+
+	// 判断该名称、URL的仓库是否存在
+	if id, err := helmRepo.GetIDByNameAndURL(nil); err == nil && id > 0 {
+		helmRepo.ID = id
+	} else {
+		// 第一次创建，先保存到数据库
+		if err = helmRepo.Save(nil); err != nil {
+			return fmt.Errorf("save helm repository to database error: %v", err)
+		}
+	}
+
+	// OCI 仓库没有索引文件，也无需写入仓库配置
+	if helmRepo.IsOCI() {
+		return h.syncOCIRepo(helmRepo)
+	}
+
+	// 3. 先下载索引，确认仓库可用后再写入仓库配置
+	if _, err := h.updateRepoByName(helmRepo); err != nil {
+		return err
+	}
+
+	repoFileMu.Lock()
+	defer repoFileMu.Unlock()
+	f, err := h.loadRepoFile()
+	if err != nil {
+		return err
+	}
+	f.Update(repoEntry(helmRepo))
+	if err := os.MkdirAll(filepath.Dir(h.settings.RepositoryConfig), 0755); err != nil {
+		return fmt.Errorf("创建仓库配置目录失败: %w", err)
+	}
+	// 仓库配置中包含认证信息，仅允许当前用户读写
+	if err := f.WriteFile(h.settings.RepositoryConfig, 0600); err != nil {
+		return fmt.Errorf("写入仓库配置失败: %w", err)
+	}
+	return nil
+}
+
+// updateRepoByName 下载仓库索引，并将所有 Chart 记录到数据库
+func (h *HelmSDK) updateRepoByName(helmRepo *models.HelmRepository) (bool, error) {
+	if helmRepo.IsOCI() {
+		return false, h.syncOCIRepo(helmRepo)
+	}
+	chartRepo, err := repo.NewChartRepository(repoEntry(helmRepo), getter.All(h.settings))
+	if err != nil {
+		return false, fmt.Errorf("创建仓库[%s]客户端失败: %w", helmRepo.Name, err)
+	}
+	chartRepo.CachePath = h.settings.RepositoryCache
+	indexPath, err := chartRepo.DownloadIndexFile()
+	if err != nil {
+		return false, fmt.Errorf("下载仓库[%s]索引失败: %w", helmRepo.Name, err)
+	}
+
+	index, err := repo.LoadIndexFile(indexPath)
+	if err != nil {
+		klog.V(6).Infof("[helm] warn: load repo index file failed: %v\n", err)
+		return true, nil // 不阻断主流程
+	}
+	// 清空数据库中对应的chart repo
+	dao.DB().Where("repository_id = ?", helmRepo.ID).Delete(models.HelmChart{})
+	for chartName, versionList := range index.Entries {
+		if len(versionList) == 0 {
+			continue
+		}
+		slice.SortBy(versionList, func(a *repo.ChartVersion, b *repo.ChartVersion) bool {
+			return a.Created.After(b.Created)
+		})
+		ct := versionList[0]
+		if ct.Metadata != nil {
+			m := models.HelmChart{
+				RepositoryID:   helmRepo.ID,
+				RepositoryName: helmRepo.Name,
+				Name:           chartName,
+			}
+			if ct.Version != "" {
+				m.LatestVersion = ct.Version
+			}
+			if ct.Description != "" {
+				m.Description = ct.Description
+			}
+			if ct.Home != "" {
+				m.Home = ct.Home
+			}
+			if ct.Icon != "" {
+				m.Icon = ct.Icon
+			}
+			if ct.KubeVersion != "" {
+				m.KubeVersion = ct.KubeVersion
+			}
+			if ct.AppVersion != "" {
+				m.AppVersion = ct.AppVersion
+			}
+			m.Deprecated = ct.Deprecated
+			if len(ct.Keywords) > 0 {
+				m.Keywords = strings.Join(ct.Keywords, ",")
+			}
+			if len(ct.Sources) > 0 {
+				m.Sources = ct.Sources[0]
+			}
+			err = m.Save(nil)
+			if err != nil {
+				klog.V(6).Infof("[helm] warn: save helm chart to database error: %v\n", err)
+			}
+		}
+	}
+
+	if !index.Generated.IsZero() {
+		// 更新索引时间
+		helmRepo.Generated = index.Generated.Format(time.DateTime)
+		_ = helmRepo.Save(nil)
+	}
+
+	return false, nil
+}
+
+func (h *HelmSDK) GetReleaseHistory(namespace string, releaseName string) ([]*models.ReleaseHistory, error) {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewHistory(cfg)
+	client.Max = 256
+	hist, err := client.Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Release %s/%s 历史失败: %w", namespace, releaseName, err)
+	}
+	sort.Slice(hist, func(i, j int) bool {
+		return hist[i].Version < hist[j].Version
+	})
+	var releases []*models.ReleaseHistory
+	for _, r := range hist {
+		item := &models.ReleaseHistory{
+			Revision: r.Version,
+		}
+		if r.Info != nil {
+			item.Updated = r.Info.LastDeployed.Format(time.RFC3339)
+			item.Status = r.Info.Status.String()
+		}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			item.Chart = fmt.Sprintf("%s-%s", r.Chart.Metadata.Name, r.Chart.Metadata.Version)
+			item.AppVersion = r.Chart.Metadata.AppVersion
+		}
+		releases = append(releases, item)
+	}
+	return releases, nil
+}
+
+func (h *HelmSDK) UninstallRelease(namespace string, releaseName string) error {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return err
+	}
+	if _, err := action.NewUninstall(cfg).Run(releaseName); err != nil {
+		return fmt.Errorf("卸载 Release %s/%s 失败: %w", namespace, releaseName, err)
+	}
+	// 删除数据库HelmRelease
+	_ = models.DeleteHelmReleaseByNsAndReleaseName(namespace, releaseName, h.clusterID) // 忽略错误
+	return nil
+}
+
+func (h *HelmSDK) InstallRelease(namespace, releaseName, repoName, chartName, version string, values ...string) error {
+	vals, err := parseValues(values...)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return err
+	}
+	client := action.NewInstall(cfg)
+	client.Namespace = namespace
+	client.ReleaseName = releaseName
+	client.CreateNamespace = true
+	client.Version = version
+
+	// 安装结果记录到数据库
+	record := &models.HelmRelease{
+		Cluster:      h.clusterID,
+		ReleaseName:  releaseName,
+		RepoName:     repoName,
+		Namespace:    namespace,
+		ChartName:    chartName,
+		ChartVersion: version,
+	}
+	if len(values) > 0 {
+		record.Values = values[0]
+	}
+
+	chrt, err := h.loadChart(&client.ChartPathOptions, repoName, chartName)
+	if err == nil {
+		var rel *release.Release
+		rel, err = client.Run(chrt, vals)
+		record.Result = releaseSummary(rel)
+	}
+	if err != nil {
+		record.Status = "failed"
+		record.Result = err.Error()
+		_ = record.Save(nil) // 忽略错误，防止影响主流程
+		return fmt.Errorf("安装 Release %s/%s 失败: %w", namespace, releaseName, err)
+	}
+	record.Status = "installed"
+	_ = record.Save(nil) // 忽略错误，防止影响主流程
+	return nil
+}
+
+// UpgradeRelease 升级 Release，version 为空时沿用当前记录的 Chart 版本
+func (h *HelmSDK) UpgradeRelease(ns, name, version string, values ...string) error {
+	hr, err := models.GetHelmReleaseByNsAndReleaseName(ns, name, h.clusterID)
+	if err != nil {
+		return fmt.Errorf("get repoName from db failed: %v", err)
+	}
+	if version == "" {
+		version = hr.ChartVersion
+	}
+	return h.upgradeRelease(hr, version, values...)
+}
+
+// upgradeRelease 使用记录中的仓库、Chart 升级 Release，并更新记录
+func (h *HelmSDK) upgradeRelease(hr *models.HelmRelease, version string, values ...string) error {
+	ns, name := hr.Namespace, hr.ReleaseName
+	vals, err := parseValues(values...)
+	if err != nil {
+		return err
+	}
+	cfg, err := h.actionConfig(ns)
+	if err != nil {
+		return err
+	}
+	client := action.NewUpgrade(cfg)
+	client.Namespace = ns
+	client.Version = version
+
+	chrt, err := h.loadChart(&client.ChartPathOptions, hr.RepoName, hr.ChartName)
+	if err == nil {
+		var rel *release.Release
+		rel, err = client.Run(name, chrt, vals)
+		hr.Result = releaseSummary(rel)
+	}
+
+	// 更新values、result
+	if len(values) > 0 {
+		hr.Values = values[0]
+	}
+	if err != nil {
+		hr.Result = err.Error()
+	} else {
+		hr.ChartVersion = version
+		hr.Status = "installed"
+	}
+	_ = hr.Save(nil) // 忽略错误，防止影响主流程
+
+	if err != nil {
+		return fmt.Errorf("升级 Release %s/%s 失败: %w", ns, name, err)
+	}
+	return nil
+}
+
+// RollbackRelease 回滚 Release 到指定版本，revision 为 0 时回滚到上一个版本
+func (h *HelmSDK) RollbackRelease(ns, name string, revision int) error {
+	cfg, err := h.actionConfig(ns)
+	if err != nil {
+		return err
+	}
+	client := action.NewRollback(cfg)
+	client.Version = revision
+	if err := client.Run(name); err != nil {
+		return fmt.Errorf("回滚 Release %s/%s 到版本 %d 失败: %w", ns, name, revision, err)
+	}
+
+	// 同步数据库记录，保证后续升级使用回滚后的版本与参数
+	hr, err := models.GetHelmReleaseByNsAndReleaseName(ns, name, h.clusterID)
+	if err != nil {
+		return nil
+	}
+	rel, err := h.getRelease(ns, name, "")
+	if err != nil {
+		return nil
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		hr.ChartVersion = rel.Chart.Metadata.Version
+	}
+	if values, err := yaml.Marshal(rel.Config); err == nil && len(rel.Config) > 0 {
+		hr.Values = string(values)
+	}
+	hr.Result = releaseSummary(rel)
+	_ = hr.Save(nil) // 忽略错误，防止影响主流程
+	return nil
+}
+
+func (h *HelmSDK) GetChartValue(repoName, chartName, version string) (string, error) {
+	opts := &action.ChartPathOptions{Version: version}
+	chrt, err := h.loadChart(opts, repoName, chartName)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range chrt.Raw {
+		if f.Name == chartutil.ValuesfileName {
+			return string(f.Data), nil
+		}
+	}
+	return "", nil
+}
+
+// GetChartVersions 获取 Chart 的全部版本，按版本号倒序。
+// 普通仓库读取本地缓存的索引，OCI 仓库实时列出镜像 tag。
+func (h *HelmSDK) GetChartVersions(repoName string, chartName string) ([]string, error) {
+	if helmRepo, err := models.GetHelmRepositoryByName(repoName); err == nil && helmRepo.IsOCI() {
+		return h.ociTags(helmRepo, chartName)
+	}
+	indexPath := filepath.Join(h.settings.RepositoryCache, fmt.Sprintf("%s-index.yaml", repoName))
+	index, err := repo.LoadIndexFile(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取仓库[%s]索引失败，请先更新仓库索引: %w", repoName, err)
+	}
+	index.SortEntries()
+	list, ok := index.Entries[chartName]
+	if !ok {
+		return nil, fmt.Errorf("仓库[%s]中未找到 Chart %s", repoName, chartName)
+	}
+	var versions []string
+	for _, v := range list {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+func (h *HelmSDK) UpdateReposIndex(ids string) {
+	// 解析ids为数组
+	idsArray := strings.Split(ids, ",")
+	m := models.HelmRepository{}
+	list, _, err := m.List(nil, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id in ?", idsArray).Find(&m)
+	})
+	if err != nil {
+		klog.V(6).Infof("get helm repository list error: %v", err)
+		return
+	}
+
+	// 遍历ids，更新每个repo的index
+	for _, item := range list {
+		if _, err := h.updateRepoByName(item); err != nil {
+			klog.V(6).Infof("[helm] %v", err)
+		}
+	}
+
+}
+func (h *HelmSDK) UpdateAllReposIndex() {
+	m := models.HelmRepository{}
+	list, _, err := m.List(nil)
+	if err != nil {
+		klog.V(6).Infof("get helm repository list error: %v", err)
+		return
+	}
+	for _, item := range list {
+		if _, err := h.updateRepoByName(item); err != nil {
+			klog.V(6).Infof("[helm] %v", err)
+		}
+	}
+}
+
+func (h *HelmSDK) GetReleaseList() ([]*models.Release, error) {
+	cfg, err := h.actionConfig("")
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewList(cfg)
+	client.AllNamespaces = true
+	client.SetStateMask()
+	list, err := client.Run()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Release 列表失败: %w", err)
+	}
+	var releases []*models.Release
+	for _, r := range list {
+		item := &models.Release{
+			Name:      r.Name,
+			Namespace: r.Namespace,
+			Revision:  strconv.Itoa(r.Version),
+		}
+		if r.Info != nil {
+			item.Updated = r.Info.LastDeployed.Format("2006-01-02 15:04:05.999999999 -0700 MST")
+			item.Status = r.Info.Status.String()
+			item.Description = r.Info.Description
+		}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			item.Chart = fmt.Sprintf("%s-%s", r.Chart.Metadata.Name, r.Chart.Metadata.Version)
+			item.AppVersion = r.Chart.Metadata.AppVersion
+		}
+		releases = append(releases, item)
+	}
+	return releases, nil
+}
+
+func (h *HelmSDK) GetRepoList() ([]*RepoVO, error) {
+	repoFileMu.Lock()
+	defer repoFileMu.Unlock()
+	f, err := h.loadRepoFile()
+	if err != nil {
+		return nil, err
+	}
+	var list []*RepoVO
+	for _, e := range f.Repositories {
+		list = append(list, &RepoVO{Name: e.Name, URL: e.URL})
+	}
+	return list, nil
+}
+
+// getRelease 获取 Release，revision 为空时获取最新版本
+func (h *HelmSDK) getRelease(ns, name, revision string) (*release.Release, error) {
+	cfg, err := h.actionConfig(ns)
+	if err != nil {
+		return nil, err
+	}
+	client := action.NewGet(cfg)
+	if revision != "" {
+		v, err := strconv.Atoi(revision)
+		if err != nil {
+			return nil, fmt.Errorf("非法的版本号 %q", revision)
+		}
+		client.Version = v
+	}
+	rel, err := client.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Release %s/%s 失败: %w", ns, name, err)
+	}
+	return rel, nil
+}
+
+func (h *HelmSDK) GetReleaseNote(ns string, name string) (string, error) {
+	return h.GetReleaseNoteWithRevision(ns, name, "")
+}
+func (h *HelmSDK) GetReleaseNoteWithRevision(ns string, name string, revision string) (string, error) {
+	rel, err := h.getRelease(ns, name, revision)
+	if err != nil {
+		return "", err
+	}
+	if rel.Info == nil {
+		return "", nil
+	}
+	return rel.Info.Notes, nil
+}
+
+// GetReleaseValues 获取 Release 合并后的全部 values
+func (h *HelmSDK) GetReleaseValues(ns string, name string) (string, error) {
+	return h.getReleaseValues(ns, name, "", true)
+}
+
+// GetReleaseValuesWithRevision 获取 Release 指定版本用户提供的 values
+func (h *HelmSDK) GetReleaseValuesWithRevision(ns string, name string, revision string) (string, error) {
+	return h.getReleaseValues(ns, name, revision, false)
+}
+
+func (h *HelmSDK) getReleaseValues(ns, name, revision string, all bool) (string, error) {
+	cfg, err := h.actionConfig(ns)
+	if err != nil {
+		return "", err
+	}
+	client := action.NewGetValues(cfg)
+	client.AllValues = all
+	if revision != "" {
+		v, err := strconv.Atoi(revision)
+		if err != nil {
+			return "", fmt.Errorf("非法的版本号 %q", revision)
+		}
+		client.Version = v
+	}
+	vals, err := client.Run(name)
+	if err != nil {
+		return "", fmt.Errorf("获取 Release %s/%s values 失败: %w", ns, name, err)
+	}
+	if len(vals) == 0 {
+		return "", nil
+	}
+	out, err := yaml.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (h *HelmSDK) RemoveRepo(repoName string) error {
+	repoFileMu.Lock()
+	defer repoFileMu.Unlock()
+	f, err := h.loadRepoFile()
+	if err != nil {
+		return err
+	}
+	if !f.Remove(repoName) {
+		return fmt.Errorf("仓库 %s 不存在", repoName)
+	}
+	if err := f.WriteFile(h.settings.RepositoryConfig, 0600); err != nil {
+		return fmt.Errorf("写入仓库配置失败: %w", err)
+	}
+	// 清理仓库索引缓存
+	for _, file := range []string{repoName + "-index.yaml", repoName + "-charts.txt"} {
+		if err := os.Remove(filepath.Join(h.settings.RepositoryCache, file)); err != nil && !os.IsNotExist(err) {
+			klog.V(6).Infof("[helm] 清理仓库缓存失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ReAddMissingRepo 重新添加丢失的Repo，比如在容器环境中重启了。
+func (h *HelmSDK) ReAddMissingRepo() {
+	m := models.HelmRepository{}
+	list, _, err := m.List(nil)
+	if err != nil {
+		klog.V(6).Infof("get helm repository list error: %v", err)
+		return
+	}
+	repoList, err := h.GetRepoList()
+	if err != nil {
+		klog.V(6).Infof("get helm repository list error: %v", err)
+		return
+	}
+	var repos []string
+	for _, vo := range repoList {
+		repos = append(repos, vo.Name)
+	}
+	for _, item := range list {
+		// OCI 仓库不写入仓库配置，由定时更新同步
+		if item.IsOCI() {
+			continue
+		}
+		if !slice.Contain(repos, item.Name) {
+			klog.V(6).Infof("helm repository adding %s", item.Name)
+			_ = h.AddOrUpdateRepo(item)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/helm/models"
+	"github.com/weibaohui/k8m/pkg/service"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// 同时对账的集群数量
+const releaseSetConcurrency = 5
+
+// 同一个 Release 集合同一时间只允许一个对账任务
+var releaseSetLocks sync.Map
+
+// ParseReleaseSetSpec 解析并校验 Release 集合声明
+func ParseReleaseSetSpec(spec string) (*models.ReleaseSetSpec, error) {
+	var s models.ReleaseSetSpec
+	if err := yaml.Unmarshal([]byte(spec), &s); err != nil {
+		return nil, fmt.Errorf("解析声明失败: %w", err)
+	}
+	if len(s.Releases) == 0 {
+		return nil, fmt.Errorf("声明中 releases 不能为空")
+	}
+	if len(s.Targets) == 0 {
+		return nil, fmt.Errorf("声明中 targets 不能为空")
+	}
+	names := make(map[string]bool)
+	for _, e := range s.Releases {
+		if e.Name == "" || e.Namespace == "" || e.Repo == "" || e.Chart == "" {
+			return nil, fmt.Errorf("release 的 name、namespace、repo、chart 均不能为空")
+		}
+		key := e.Namespace + "/" + e.Name
+		if names[key] {
+			return nil, fmt.Errorf("release %s 重复声明", key)
+		}
+		names[key] = true
+	}
+	for _, t := range s.Targets {
+		if t.Cluster == "" {
+			return nil, fmt.Errorf("target 的 cluster 不能为空")
+		}
+	}
+	for _, o := range s.Overrides {
+		if _, err := parseSelector(o.Selector); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// parseSelector 解析形如 env=prod,region=eu 的标签选择器
+func parseSelector(selector string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, item := range utils.SplitAndTrim(selector, ",") {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("非法的标签选择器 %q", selector)
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result, nil
+}
+
+// matchOverride 判断覆盖参数是否作用于指定集群与 Release
+func matchOverride(o *models.ReleaseSetOverride, target *models.ReleaseSetTarget, releaseName string) bool {
+	if o.Release != "" && o.Release != releaseName {
+		return false
+	}
+	if o.Cluster != "" && o.Cluster != target.Cluster {
+		return false
+	}
+	selector, _ := parseSelector(o.Selector)
+	for k, v := range selector {
+		if target.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// desiredValues 计算目标集群上 Release 的最终参数：
+// 基础参数 < 全局覆盖 < 按标签覆盖 < 按集群覆盖，同级按声明顺序
+func desiredValues(spec *models.ReleaseSetSpec, target *models.ReleaseSetTarget, entry *models.ReleaseSetEntry) map[string]any {
+	values := mergeValues(map[string]any{}, entry.Values)
+	for _, level := range []func(o *models.ReleaseSetOverride) bool{
+		func(o *models.ReleaseSetOverride) bool { return o.Cluster == "" && o.Selector == "" },
+		func(o *models.ReleaseSetOverride) bool { return o.Cluster == "" && o.Selector != "" },
+		func(o *models.ReleaseSetOverride) bool { return o.Cluster != "" },
+	} {
+		for _, o := range spec.Overrides {
+			if level(o) && matchOverride(o, target, entry.Name) {
+				values = mergeValues(values, o.Values)
+			}
+		}
+	}
+	return values
+}
+
+// mergeValues 深度合并参数，src 覆盖 dst
+func mergeValues(dst, src map[string]any) map[string]any {
+	for k, v := range src {
+		if sv, ok := v.(map[string]any); ok {
+			if dv, ok := dst[k].(map[string]any); ok {
+				dst[k] = mergeValues(dv, sv)
+				continue
+			}
+			dst[k] = mergeValues(map[string]any{}, sv)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// valuesEqual 比较参数是否一致，统一经过 JSON 序列化消除数字类型差异
+func valuesEqual(a, b map[string]any) bool {
+	normalize := func(m map[string]any) any {
+		if len(m) == 0 {
+			return nil
+		}
+		var out any
+		data, _ := json.Marshal(m)
+		_ = json.Unmarshal(data, &out)
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// releaseSetItem 对账过程中单个集群、单个 Release 的上下文
+type releaseSetItem struct {
+	status *models.HelmReleaseSetStatus
+	entry  *models.ReleaseSetEntry
+	values string
+}
+
+// PlanReleaseSet 对比声明与各集群实际部署情况，记录并返回对账结果。ctx 为发起对账的用户
+func PlanReleaseSet(ctx context.Context, set *models.HelmReleaseSet) ([]*models.HelmReleaseSetStatus, error) {
+	return reconcileReleaseSet(ctx, set, nil, false)
+}
+
+// ApplyReleaseSet 对不一致的 Release 执行安装或升级，clusters 为空时对全部目标集群执行。ctx 为发起应用的用户
+func ApplyReleaseSet(ctx context.Context, set *models.HelmReleaseSet, clusters []string) ([]*models.HelmReleaseSetStatus, error) {
+	return reconcileReleaseSet(ctx, set, clusters, true)
+}
+
+func reconcileReleaseSet(ctx context.Context, set *models.HelmReleaseSet, clusters []string, apply bool) ([]*models.HelmReleaseSetStatus, error) {
+	spec, err := ParseReleaseSetSpec(set.Spec)
+	if err != nil {
+		return nil, err
+	}
+	lock, _ := releaseSetLocks.LoadOrStore(set.ID, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	if !mu.TryLock() {
+		return nil, fmt.Errorf("Release 集合[%s]正在对账中，请稍后再试", set.Name)
+	}
+	defer mu.Unlock()
+	mergeClusterLabels(spec)
+
+	var (
+		wg       sync.WaitGroup
+		resultMu sync.Mutex
+		results  []*models.HelmReleaseSetStatus
+		sem      = make(chan struct{}, releaseSetConcurrency)
+	)
+	for _, target := range spec.Targets {
+		if len(clusters) > 0 && !slices.Contains(clusters, target.Cluster) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(target *models.ReleaseSetTarget) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			statuses := reconcileCluster(ctx, set, spec, target, apply)
+			resultMu.Lock()
+			results = append(results, statuses...)
+			resultMu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	saveReleaseSetStatuses(set, results, len(clusters) == 0)
+	return results, nil
+}
+
+// mergeClusterLabels 目标集群未声明的标签取自平台中维护的集群标签
+func mergeClusterLabels(spec *models.ReleaseSetSpec) {
+	all := service.ClusterService().ClusterLabels()
+	for _, target := range spec.Targets {
+		for k, v := range all[target.Cluster] {
+			if target.Labels == nil {
+				target.Labels = make(map[string]string)
+			}
+			if _, ok := target.Labels[k]; !ok {
+				target.Labels[k] = v
+			}
+		}
+	}
+}
+
+// reconcileCluster 对单个集群对账
+func reconcileCluster(ctx context.Context, set *models.HelmReleaseSet, spec *models.ReleaseSetSpec, target *models.ReleaseSetTarget, apply bool) []*models.HelmReleaseSetStatus {
+	items := make([]*releaseSetItem, 0, len(spec.Releases))
+	for _, entry := range spec.Releases {
+		item := &releaseSetItem{
+			entry: entry,
+			status: &models.HelmReleaseSetStatus{
+				SetID:          set.ID,
+				Cluster:        target.Cluster,
+				Namespace:      entry.Namespace,
+				ReleaseName:    entry.Name,
+				Chart:          entry.Chart,
+				DesiredVersion: entry.Version,
+			},
+		}
+		vals := desiredValues(spec, target, entry)
+		if len(vals) > 0 {
+			data, err := yaml.Marshal(vals)
+			if err != nil {
+				item.status.Status = models.ReleaseSetStatusError
+				item.status.Message = err.Error()
+			}
+			item.values = string(data)
+		}
+		items = append(items, item)
+	}
+
+	statuses := make([]*models.HelmReleaseSetStatus, 0, len(items))
+	fail := func(msg string) []*models.HelmReleaseSetStatus {
+		for _, item := range items {
+			item.status.Status = models.ReleaseSetStatusError
+			item.status.Message = msg
+			statuses = append(statuses, item.status)
+		}
+		return statuses
+	}
+
+	cluster := service.ClusterService().GetClusterByID(target.Cluster)
+	if cluster == nil {
+		return fail("集群不存在")
+	}
+	if !service.ClusterService().IsConnected(target.Cluster) {
+		return fail("集群未连接")
+	}
+	h, err := NewHelmSDK(ctx, target.Cluster, cluster)
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	for _, item := range items {
+		if item.status.Status == "" {
+			h.planItem(item)
+			if apply && item.status.Status == models.ReleaseSetStatusOutOfSync {
+				h.applyItem(item)
+			}
+		}
+		statuses = append(statuses, item.status)
+	}
+	return statuses
+}
+
+// planItem 对比集群中 Release 的 Chart、版本、参数与声明是否一致
+func (h *HelmSDK) planItem(item *releaseSetItem) {
+	st, entry := item.status, item.entry
+	rel, err := h.getRelease(entry.Namespace, entry.Name, "")
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			st.Action = models.ReleaseSetActionInstall
+			st.Status = models.ReleaseSetStatusOutOfSync
+			st.Message = "未安装"
+			return
+		}
+		st.Status = models.ReleaseSetStatusError
+		st.Message = err.Error()
+		return
+	}
+
+	st.CurrentRevision = rel.Version
+	var reasons []string
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		st.CurrentVersion = rel.Chart.Metadata.Version
+		if rel.Chart.Metadata.Name != entry.Chart {
+			reasons = append(reasons, fmt.Sprintf("Chart为%s", rel.Chart.Metadata.Name))
+		}
+	}
+	if entry.Version != "" && st.CurrentVersion != entry.Version {
+		reasons = append(reasons, fmt.Sprintf("版本为%s", st.CurrentVersion))
+	}
+	var desired map[string]any
+	if item.values != "" {
+		_ = yaml.Unmarshal([]byte(item.values), &desired)
+	}
+	if !valuesEqual(rel.Config, desired) {
+		reasons = append(reasons, "参数不一致")
+	}
+	if rel.Info != nil && rel.Info.Status != release.StatusDeployed {
+		reasons = append(reasons, fmt.Sprintf("状态为%s", rel.Info.Status))
+	}
+
+	if len(reasons) == 0 {
+		st.Action = models.ReleaseSetActionNone
+		st.Status = models.ReleaseSetStatusInSync
+		st.Message = ""
+		return
+	}
+	st.Action = models.ReleaseSetActionUpgrade
+	st.Status = models.ReleaseSetStatusOutOfSync
+	st.Message = strings.Join(reasons, "；")
+}
+
+// applyItem 按计划安装或升级
+func (h *HelmSDK) applyItem(item *releaseSetItem) {
+	st, entry := item.status, item.entry
+	var err error
+	switch st.Action {
+	case models.ReleaseSetActionInstall:
+		err = h.InstallRelease(entry.Namespace, entry.Name, entry.Repo, entry.Chart, entry.Version, item.values)
+	case models.ReleaseSetActionUpgrade:
+		hr, getErr := models.GetHelmReleaseByNsAndReleaseName(entry.Namespace, entry.Name, h.clusterID)
+		if getErr != nil {
+			// 非 k8m 安装的 Release，补充记录后纳管
+			hr = &models.HelmRelease{
+				Cluster:     h.clusterID,
+				ReleaseName: entry.Name,
+				Namespace:   entry.Namespace,
+			}
+		}
+		hr.RepoName = entry.Repo
+		hr.ChartName = entry.Chart
+		err = h.upgradeRelease(hr, entry.Version, item.values)
+	default:
+		return
+	}
+	now := time.Now()
+	st.LastAppliedAt = &now
+	if err != nil {
+		st.Status = models.ReleaseSetStatusFailed
+		st.Message = err.Error()
+		return
+	}
+	st.Action = models.ReleaseSetActionNone
+	st.Status = models.ReleaseSetStatusInSync
+	st.Message = "已应用"
+	if entry.Version != "" {
+		st.CurrentVersion = entry.Version
+	}
+}
+
+// saveReleaseSetStatuses 保存对账结果。全量对账时替换全部状态，否则只替换涉及的集群
+func saveReleaseSetStatuses(set *models.HelmReleaseSet, statuses []*models.HelmReleaseSetStatus, full bool) {
+	db := dao.DB()
+	clusters := make(map[string]bool)
+	for _, st := range statuses {
+		clusters[st.Cluster] = true
+	}
+	q := db.Where("set_id = ?", set.ID)
+	if !full {
+		var list []string
+		for c := range clusters {
+			list = append(list, c)
+		}
+		q = q.Where("cluster in ?", list)
+	}
+	if err := q.Delete(&models.HelmReleaseSetStatus{}).Error; err != nil {
+		klog.V(6).Infof("[helm] 清理 Release 集合[%s]状态失败: %v", set.Name, err)
+	}
+	if len(statuses) > 0 {
+		if err := db.Create(&statuses).Error; err != nil {
+			klog.V(6).Infof("[helm] 保存 Release 集合[%s]状态失败: %v", set.Name, err)
+		}
+	}
+
+	// 汇总全部集群的状态
+	var outOfSync, failed int64
+	db.Model(&models.HelmReleaseSetStatus{}).Where("set_id = ? AND status = ?", set.ID, models.ReleaseSetStatusOutOfSync).Count(&outOfSync)
+	db.Model(&models.HelmReleaseSetStatus{}).Where("set_id = ? AND status in ?", set.ID,
+		[]string{models.ReleaseSetStatusFailed, models.ReleaseSetStatusError}).Count(&failed)
+	now := time.Now()
+	db.Model(&models.HelmReleaseSet{}).Where("id = ?", set.ID).Updates(map[string]any{
+		"out_of_sync":  outOfSync,
+		"failed":       failed,
+		"last_sync_at": &now,
+	})
+}
+
+// ReconcileAllReleaseSets 定时对账全部 Release 集合，开启自动同步的集合直接应用，使用平台管理员上下文
+func ReconcileAllReleaseSets() {
+	ctx := utils.GetContextWithAdmin()
+	var sets []*models.HelmReleaseSet
+	if err := dao.DB().Find(&sets).Error; err != nil {
+		klog.V(6).Infof("[helm] 获取 Release 集合失败: %v", err)
+		return
+	}
+	for _, set := range sets {
+		if _, err := reconcileReleaseSet(ctx, set, nil, set.AutoSync); err != nil {
+			klog.V(6).Infof("[helm] Release 集合[%s]对账失败: %v", set.Name, err)
+		}
+	}
+}
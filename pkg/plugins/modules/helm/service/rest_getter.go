@@ -0,0 +1,57 @@
+package helm
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// restConfigGetter 基于内存中的 rest.Config 实现 genericclioptions.RESTClientGetter，
+// 供 Helm SDK 访问集群，无需落地 kubeconfig 文件
+type restConfigGetter struct {
+	config    *rest.Config
+	namespace string
+}
+
+func newRESTConfigGetter(config *rest.Config, namespace string) *restConfigGetter {
+	return &restConfigGetter{
+		config:    config,
+		namespace: namespace,
+	}
+}
+
+func (g *restConfigGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(g.config), nil
+}
+
+func (g *restConfigGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	config := rest.CopyConfig(g.config)
+	// 与 kubectl 保持一致，放宽发现接口的限流
+	config.Burst = 300
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(dc), nil
+}
+
+func (g *restConfigGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	dc, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(dc)
+	return restmapper.NewShortcutExpander(mapper, dc, nil), nil
+}
+
+// ToRawKubeConfigLoader 仅用于提供默认命名空间
+func (g *restConfigGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	overrides := &clientcmd.ConfigOverrides{
+		Context: clientcmdapi.Context{Namespace: g.namespace},
+	}
+	return clientcmd.NewDefaultClientConfig(*clientcmdapi.NewConfig(), overrides)
+}
@@ -307,6 +307,56 @@ func (s *AdminScheduleController) Start(c *response.Context) {
 	amis.WriteJsonOKMsg(c, "巡检开始，请稍后刷新查看结果")
 }
 
+// @Summary 按集群标签选择器执行巡检计划
+// @Description 在标签选择器匹配的全部集群上执行一次巡检计划，等待执行完成后按集群返回巡检记录
+// @Security BearerAuth
+// @Param id path int true "巡检计划ID"
+// @Param request body object true "{selector: 标签选择器}"
+// @Success 200 {object} string
+// @Router /admin/plugins/inspection/schedule/id/{id}/fleet_run [post]
+func (s *AdminScheduleController) FleetRun(c *response.Context) {
+	var req struct {
+		Selector string `json:"selector"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	m := &models.InspectionSchedule{
+		ID: utils.ToUInt(c.Param("id")),
+	}
+	one, err := m.GetOne(nil)
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if strings.TrimSpace(one.ScriptCodes) == "" {
+		amis.WriteJsonError(c, fmt.Errorf("无检测规则，请先在 操作-管理规则 菜单中配置"))
+		return
+	}
+
+	sb := lua.NewScheduleBackground()
+	results, err := service.ClusterService().RunOnClusters(req.Selector, func(cluster string) (any, error) {
+		record, err := sb.RunByCluster(context.Background(), &one.ID, cluster, lua.TriggerTypeManual)
+		if err != nil {
+			return nil, err
+		}
+		return response.H{
+			"record_id":   record.ID,
+			"status":      record.Status,
+			"error_count": record.ErrorCount,
+		}, nil
+	})
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	amis.WriteJsonData(c, response.H{
+		"rows":  results,
+		"total": len(results),
+	})
+}
+
 // @Summary 更新巡检脚本代码
 // @Security BearerAuth
 // @Param id path int true "巡检计划ID"
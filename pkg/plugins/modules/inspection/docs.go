@@ -0,0 +1,18 @@
+package inspection
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed doc/*.md
+var builtinDocs embed.FS
+
+// BuiltinDocs 返回内置巡检规则说明文档（doc/*.md），供 AI 知识库等功能读取。
+func BuiltinDocs() fs.FS {
+	sub, err := fs.Sub(builtinDocs, "doc")
+	if err != nil {
+		return builtinDocs
+	}
+	return sub
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
@@ -205,6 +206,13 @@ func (s *ScheduleBackground) generateAISummary(ctx context.Context, msg *Summary
 		`
 	prompt = fmt.Sprintf(prompt, customTemplate, utils.ToJSONCompact(msg))
 
+	// 以失败规则作为检索问题，附加巡检文档、运维手册等参考资料
+	var queries []string
+	for _, e := range msg.FailedList {
+		queries = append(queries, e.ScriptName+" "+e.Kind+" "+e.EventMsg)
+	}
+	prompt = api.AIKnowledgeService().AugmentPrompt(ctx, strings.Join(queries, "\n"), prompt)
+
 	// 使用统一 AI 能力接口，避免跨插件直接依赖实现
 	ai := api.AIChatService()
 	summary, err := ai.ChatNoHistory(ctx, prompt)
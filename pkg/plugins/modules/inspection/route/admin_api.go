@@ -22,6 +22,7 @@ func RegisterPluginAdminRoutes(arg chi.Router) {
 	arg.Post(prefix+"/schedule/delete/{ids}", response.Adapter(ctrl.Delete))
 	arg.Post(prefix+"/schedule/save/id/{id}/status/{enabled}", response.Adapter(ctrl.QuickSave))
 	arg.Post(prefix+"/schedule/start/id/{id}", response.Adapter(ctrl.Start))
+	arg.Post(prefix+"/schedule/id/{id}/fleet_run", response.Adapter(ctrl.FleetRun))
 	arg.Post(prefix+"/schedule/id/{id}/update_script_code", response.Adapter(ctrl.UpdateScriptCode))
 	arg.Post(prefix+"/schedule/id/{id}/summary", response.Adapter(ctrl.SummaryBySchedule))
 	arg.Post(prefix+"/schedule/id/{id}/summary/cluster/{cluster}/start_time/{start_time}/end_time/{end_time}", response.Adapter(ctrl.SummaryBySchedule))
@@ -10,8 +10,8 @@ var Metadata = plugins.Module{
 	Meta: plugins.Meta{
 		Name:        modules.PluginNameK8mMcpServer,
 		Title:       "K8M MCP Server插件",
-		Version:     "1.0.0",
-		Description: "将K8M作为MCP Server使用。可以添加到MCP运行管理中使用。本插件监听/mcp/k8m/sse提供服务。",
+		Version:     "1.1.0",
+		Description: "将K8M作为MCP Server使用。可以添加到MCP运行管理中使用。本插件监听/mcp/k8m/sse（SSE）与/mcp/k8m/mcp（Streamable HTTP）提供服务，支持工具、集群资源与提示词。",
 	},
 	Tables:            []string{},
 	Menus:             []plugins.Menu{},
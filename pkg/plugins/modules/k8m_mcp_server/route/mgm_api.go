@@ -1,6 +1,8 @@
 package route
 
 import (
+	"net/http"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/k8m_mcp_server/server"
 	"github.com/weibaohui/k8m/pkg/response"
@@ -10,14 +12,28 @@ import (
 // RegisterRootRoutes 注册路由
 
 func RegisterRootRoutes(r chi.Router) {
-	sseServer := server.GetMcpSSEServer()
+	sseServer, streamableServer := server.GetMcpServers()
+	messageHandler := func() http.Handler {
+		return server.SSESubscribeHandler(sseServer, sseServer.MessageHandler())
+	}
+	streamableHandler := func() http.Handler {
+		return server.StreamableSubscribeHandler(streamableServer)
+	}
 
 	r.Get("/mcp/k8m/sse", response.Adapter(server.Adapt(sseServer.SSEHandler)))
 	r.Post("/mcp/k8m/sse", response.Adapter(server.Adapt(sseServer.SSEHandler)))
-	r.Post("/mcp/k8m/message", response.Adapter(server.Adapt(sseServer.MessageHandler)))
+	r.Post("/mcp/k8m/message", response.Adapter(server.Adapt(messageHandler)))
 	r.Get("/mcp/k8m/{key}/sse", response.Adapter(server.Adapt(sseServer.SSEHandler)))
 	r.Post("/mcp/k8m/{key}/sse", response.Adapter(server.Adapt(sseServer.SSEHandler)))
-	r.Post("/mcp/k8m/{key}/message", response.Adapter(server.Adapt(sseServer.MessageHandler)))
+	r.Post("/mcp/k8m/{key}/message", response.Adapter(server.Adapt(messageHandler)))
+
+	// Streamable HTTP 传输，GET 建立通知流，POST 发送请求，DELETE 结束会话
+	r.Get("/mcp/k8m/mcp", response.Adapter(server.Adapt(streamableHandler)))
+	r.Post("/mcp/k8m/mcp", response.Adapter(server.Adapt(streamableHandler)))
+	r.Delete("/mcp/k8m/mcp", response.Adapter(server.Adapt(streamableHandler)))
+	r.Get("/mcp/k8m/{key}/mcp", response.Adapter(server.Adapt(streamableHandler)))
+	r.Post("/mcp/k8m/{key}/mcp", response.Adapter(server.Adapt(streamableHandler)))
+	r.Delete("/mcp/k8m/{key}/mcp", response.Adapter(server.Adapt(streamableHandler)))
 
 	klog.V(6).Infof("注册k8m_mcp_server插件管理路由(mgm)")
 }
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	mcp2 "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/weibaohui/htpl"
+	"github.com/weibaohui/k8m/pkg/plugins"
+	"github.com/weibaohui/k8m/pkg/plugins/modules"
+	aiModels "github.com/weibaohui/k8m/pkg/plugins/modules/ai/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// promptVarPattern 匹配提示词中的 ${Var} 变量
+var promptVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// promptSyncer 将 AI 插件中已启用的提示词同步为 MCP Prompts。
+// 在 prompts/list 与 prompts/get 前按需同步，提示词未变化时不重复设置，避免频繁发送 list_changed 通知。
+type promptSyncer struct {
+	mu        sync.Mutex
+	serv      *server.MCPServer
+	signature string
+}
+
+var promptSync = &promptSyncer{}
+
+// registerHooks 注册 prompts/list 与 prompts/get 前的同步钩子
+func (p *promptSyncer) registerHooks(hooks *server.Hooks) {
+	hooks.AddBeforeListPrompts(func(ctx context.Context, id any, message *mcp2.ListPromptsRequest) {
+		p.sync()
+	})
+	hooks.AddBeforeGetPrompt(func(ctx context.Context, id any, message *mcp2.GetPromptRequest) {
+		p.sync()
+	})
+}
+
+// sync 从数据库加载已启用的提示词，内容有变化时整体替换 MCP Prompts
+func (p *promptSyncer) sync() {
+	var prompts []*aiModels.AIPrompt
+	if plugins.ManagerInstance().IsRunning(modules.PluginNameAI) {
+		items, _, err := (&aiModels.AIPrompt{}).List(nil, func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_enabled = ?", true).Order("id asc")
+		})
+		if err != nil {
+			klog.V(6).Infof("同步MCP Prompts失败: %v", err)
+			return
+		}
+		prompts = items
+	}
+
+	var sb strings.Builder
+	for _, item := range prompts {
+		sb.WriteString(fmt.Sprintf("%d|%s|%s|%s\n", item.ID, item.Name, item.UpdatedAt.String(), item.Description))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if sb.String() == p.signature {
+		return
+	}
+	p.signature = sb.String()
+
+	used := map[string]bool{}
+	serverPrompts := make([]server.ServerPrompt, 0, len(prompts))
+	for _, item := range prompts {
+		name := item.Name
+		if used[name] {
+			name = fmt.Sprintf("%s-%d", item.Name, item.ID)
+		}
+		used[name] = true
+		serverPrompts = append(serverPrompts, buildServerPrompt(name, item))
+	}
+	p.serv.SetPrompts(serverPrompts...)
+	klog.V(6).Infof("已同步 %d 个MCP Prompts", len(serverPrompts))
+}
+
+// promptArguments 提取提示词中的变量名，按出现顺序去重
+func promptArguments(content string) []string {
+	var args []string
+	seen := map[string]bool{}
+	for _, m := range promptVarPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			args = append(args, m[1])
+		}
+	}
+	return args
+}
+
+// buildServerPrompt 将提示词转换为 MCP Prompt，提示词变量作为 Prompt 参数
+func buildServerPrompt(name string, item *aiModels.AIPrompt) server.ServerPrompt {
+	args := promptArguments(item.Content)
+	opts := []mcp2.PromptOption{
+		mcp2.WithPromptDescription(item.Description),
+	}
+	for _, arg := range args {
+		opts = append(opts, mcp2.WithArgument(arg, mcp2.ArgumentDescription(fmt.Sprintf("替换提示词中的 ${%s}", arg))))
+	}
+
+	content := item.Content
+	description := item.Description
+	handler := func(ctx context.Context, request mcp2.GetPromptRequest) (*mcp2.GetPromptResult, error) {
+		data := make(map[string]any, len(args))
+		for _, arg := range args {
+			data[arg] = request.Params.Arguments[arg]
+		}
+		tpl, err := htpl.NewEngine().ParseString(content)
+		if err != nil {
+			return nil, fmt.Errorf("解析提示词模板失败: %w", err)
+		}
+		text, err := tpl.Render(data)
+		if err != nil {
+			return nil, fmt.Errorf("渲染提示词失败: %w", err)
+		}
+		return mcp2.NewGetPromptResult(description, []mcp2.PromptMessage{
+			mcp2.NewPromptMessage(mcp2.RoleUser, mcp2.NewTextContent(text)),
+		}), nil
+	}
+	return server.ServerPrompt{
+		Prompt:  mcp2.NewPrompt(name, opts...),
+		Handler: handler,
+	}
+}
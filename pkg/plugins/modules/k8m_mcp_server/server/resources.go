@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	mcp2 "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/weibaohui/k8m/pkg/comm"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/service"
+	"github.com/weibaohui/kom/kom"
+	komUtils "github.com/weibaohui/kom/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// k8m 资源 URI 约定：
+//
+//	k8m://clusters                                         当前用户可访问的集群列表
+//	k8m://cluster/{cluster}/ns/{namespace}/{kind}/{name}   命名空间级资源
+//	k8m://cluster/{cluster}/ns/{namespace}/{kind}          命名空间级资源列表
+//	k8m://cluster/{cluster}/{kind}/{name}                  集群级资源
+//	k8m://cluster/{cluster}/{kind}                         集群级资源列表
+//
+// 集群ID形如 fileName/contextName，放入 URI 时需使用 url.PathEscape 编码。
+// kind 支持 Kind、复数名、单数名及简称，如 Deployment、deployments、deploy。
+const (
+	resourceScheme       = "k8m://"
+	clustersResourceURI  = "k8m://clusters"
+	nsResourceTemplate   = "k8m://cluster/{cluster}/ns/{namespace}/{kind}/{name}"
+	nsListTemplate       = "k8m://cluster/{cluster}/ns/{namespace}/{kind}"
+	clusterResTemplate   = "k8m://cluster/{cluster}/{kind}/{name}"
+	clusterListTemplate  = "k8m://cluster/{cluster}/{kind}"
+	resourceMIMEType     = "application/yaml"
+	resourceListMIMEType = "application/json"
+)
+
+// resourceRef 由 URI 解析出的资源定位信息，Name 为空时表示列表
+type resourceRef struct {
+	Cluster   string
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// BuildResourceURI 根据资源定位信息生成 k8m 资源 URI
+func BuildResourceURI(cluster, namespace, kind, name string) string {
+	uri := resourceScheme + "cluster/" + url.PathEscape(cluster)
+	if namespace != "" {
+		uri += "/ns/" + url.PathEscape(namespace)
+	}
+	uri += "/" + url.PathEscape(kind)
+	if name != "" {
+		uri += "/" + url.PathEscape(name)
+	}
+	return uri
+}
+
+// parseResourceURI 解析 k8m 资源 URI，用于订阅时校验和定位资源
+func parseResourceURI(uri string) (*resourceRef, error) {
+	rest, ok := strings.CutPrefix(uri, resourceScheme+"cluster/")
+	if !ok {
+		return nil, fmt.Errorf("不支持的资源URI: %s", uri)
+	}
+	parts := strings.Split(rest, "/")
+	for i, p := range parts {
+		v, err := url.PathUnescape(p)
+		if err != nil || v == "" {
+			return nil, fmt.Errorf("资源URI格式错误: %s", uri)
+		}
+		parts[i] = v
+	}
+	ref := &resourceRef{Cluster: parts[0]}
+	switch {
+	case len(parts) == 5 && parts[1] == "ns":
+		ref.Namespace, ref.Kind, ref.Name = parts[2], parts[3], parts[4]
+	case len(parts) == 4 && parts[1] == "ns":
+		ref.Namespace, ref.Kind = parts[2], parts[3]
+	case len(parts) == 3:
+		ref.Kind, ref.Name = parts[1], parts[2]
+	case len(parts) == 2:
+		ref.Kind = parts[1]
+	default:
+		return nil, fmt.Errorf("资源URI格式错误: %s", uri)
+	}
+	return ref, nil
+}
+
+// refFromArguments 从资源模板匹配出的参数中还原资源定位信息
+func refFromArguments(args map[string]any) *resourceRef {
+	get := func(key string) string {
+		if v, ok := args[key]; ok {
+			// 模板变量可能被解析为字符串或字符串数组
+			switch val := v.(type) {
+			case string:
+				return val
+			case []string:
+				return strings.Join(val, ",")
+			}
+		}
+		return ""
+	}
+	return &resourceRef{
+		Cluster:   get("cluster"),
+		Namespace: get("namespace"),
+		Kind:      get("kind"),
+		Name:      get("name"),
+	}
+}
+
+// resolveAPIResource 在集群已发现的 API 资源中查找 kind，
+// 依次匹配 Kind、复数名、单数名与简称，同名时优先核心组。
+func resolveAPIResource(cluster, kind string) (*metav1.APIResource, error) {
+	var found *metav1.APIResource
+	for _, r := range kom.Cluster(cluster).Status().APIResources() {
+		if r == nil || strings.Contains(r.Name, "/") {
+			// 跳过 pods/log 等子资源
+			continue
+		}
+		if !strings.EqualFold(r.Kind, kind) && !strings.EqualFold(r.Name, kind) &&
+			!strings.EqualFold(r.SingularName, kind) && !containsFold(r.ShortNames, kind) {
+			continue
+		}
+		if found == nil || (found.Group != "" && r.Group == "") {
+			found = r
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("集群[%s]中未找到资源类型[%s]", cluster, kind)
+	}
+	return found, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchResource 以当前用户身份读取资源，权限由 kom 回调统一校验。
+// 返回资源文本内容及用于变更检测的版本标识。
+func fetchResource(ctx context.Context, ref *resourceRef) (text string, mimeType string, version string, err error) {
+	if service.ClusterService().GetClusterByID(ref.Cluster) == nil {
+		return "", "", "", fmt.Errorf("集群[%s]不存在", ref.Cluster)
+	}
+	ar, err := resolveAPIResource(ref.Cluster, ref.Kind)
+	if err != nil {
+		return "", "", "", err
+	}
+	ns := ref.Namespace
+	if !ar.Namespaced {
+		ns = ""
+	}
+
+	if ref.Name != "" {
+		var obj *unstructured.Unstructured
+		err = kom.Cluster(ref.Cluster).WithContext(ctx).RemoveManagedFields().Name(ref.Name).Namespace(ns).CRD(ar.Group, ar.Version, ar.Kind).Get(&obj).Error
+		if err != nil {
+			return "", "", "", err
+		}
+		text, err = komUtils.ConvertUnstructuredToYAML(obj)
+		if err != nil {
+			return "", "", "", err
+		}
+		return text, resourceMIMEType, obj.GetResourceVersion(), nil
+	}
+
+	var list []*unstructured.Unstructured
+	err = kom.Cluster(ref.Cluster).WithContext(ctx).RemoveManagedFields().Namespace(ns).CRD(ar.Group, ar.Version, ar.Kind).List(&list).Error
+	if err != nil {
+		return "", "", "", err
+	}
+	// 列表只输出摘要，避免一次返回过多内容；版本标识由各项名称与版本拼接而成
+	items := make([]map[string]any, 0, len(list))
+	versions := make([]string, 0, len(list))
+	for _, item := range list {
+		uri := BuildResourceURI(ref.Cluster, item.GetNamespace(), ar.Kind, item.GetName())
+		items = append(items, map[string]any{
+			"name":              item.GetName(),
+			"namespace":         item.GetNamespace(),
+			"uri":               uri,
+			"creationTimestamp": item.GetCreationTimestamp().String(),
+		})
+		versions = append(versions, item.GetNamespace()+"/"+item.GetName()+"@"+item.GetResourceVersion())
+	}
+	return utils.ToJSON(items), resourceListMIMEType, strings.Join(versions, ","), nil
+}
+
+// readResourceTemplate 资源模板读取处理函数
+func readResourceTemplate(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	ref := refFromArguments(request.Params.Arguments)
+	if ref.Cluster == "" || ref.Kind == "" {
+		// 模板未携带参数时退回按 URI 解析
+		parsed, err := parseResourceURI(request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		ref = parsed
+	}
+	text, mimeType, _, err := fetchResource(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp2.ResourceContents{
+		mcp2.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: mimeType,
+			Text:     text,
+		},
+	}, nil
+}
+
+// readClusters 列出当前用户可访问且已连接的集群，并给出对应的资源 URI 前缀
+func readClusters(ctx context.Context, request mcp2.ReadResourceRequest) ([]mcp2.ResourceContents, error) {
+	username, _ := ctx.Value(constants.JwtUserName).(string)
+	if username == "" {
+		return nil, fmt.Errorf("未认证的用户，无法访问集群资源")
+	}
+	scope := comm.GetAccessScope(ctx)
+	if scope != nil && scope.Denied != "" {
+		return nil, fmt.Errorf("访问被拒绝: %s", scope.Denied)
+	}
+	isAdmin := service.UserService().IsUserPlatformAdmin(username)
+	allowed, err := service.UserService().GetClusterNames(username)
+	if err != nil && !isAdmin {
+		return nil, err
+	}
+
+	var clusters []map[string]any
+	for _, c := range service.ClusterService().ConnectedClusters() {
+		id := service.ClusterService().ClusterID(c)
+		if !isAdmin && !slices.Contains(allowed, id) {
+			continue
+		}
+		if scope != nil && len(scope.Clusters) > 0 && !slices.Contains(scope.Clusters, id) {
+			continue
+		}
+		clusters = append(clusters, map[string]any{
+			"id":            id,
+			"serverVersion": c.ServerVersion,
+			"uriPrefix":     resourceScheme + "cluster/" + url.PathEscape(id),
+		})
+	}
+	return []mcp2.ResourceContents{
+		mcp2.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: resourceListMIMEType,
+			Text:     utils.ToJSON(clusters),
+		},
+	}, nil
+}
+
+// registerResources 注册集群资源及资源模板
+func registerResources(serv *server.MCPServer) {
+	serv.AddResource(
+		mcp2.NewResource(clustersResourceURI, "k8m集群列表",
+			mcp2.WithResourceDescription("当前用户可访问的已连接集群，以及用于拼接资源URI的前缀"),
+			mcp2.WithMIMEType(resourceListMIMEType),
+		),
+		readClusters,
+	)
+	templates := []struct {
+		uri  string
+		name string
+		desc string
+		mime string
+	}{
+		{nsResourceTemplate, "命名空间级资源", "读取命名空间级资源的YAML，kind支持Kind、复数名或简称，cluster需URL编码", resourceMIMEType},
+		{nsListTemplate, "命名空间级资源列表", "列出命名空间下指定类型的资源", resourceListMIMEType},
+		{clusterResTemplate, "集群级资源", "读取集群级资源（如Node、Namespace）的YAML", resourceMIMEType},
+		{clusterListTemplate, "集群级资源列表", "列出集群内指定类型的资源，命名空间级资源将列出全部命名空间", resourceListMIMEType},
+	}
+	for _, t := range templates {
+		serv.AddResourceTemplate(
+			mcp2.NewResourceTemplate(t.uri, t.name,
+				mcp2.WithTemplateDescription(t.desc),
+				mcp2.WithTemplateMIMEType(t.mime),
+			),
+			readResourceTemplate,
+		)
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	mcp2 "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/weibaohui/k8m/pkg/comm"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	mcpModels "github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/models"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/service"
+)
+
+const (
+	// mcpKeyCtxKey context 中保存当前请求所用 MCP Key 的键
+	mcpKeyCtxKey = "mcpKey"
+	// metaRejected 被访问范围拒绝的工具调用结果标记，已单独记录日志，调用后钩子不再重复记录
+	metaRejected = "k8m/rejected"
+)
+
+// remoteIP 获取请求来源IP。仅使用连接地址，不信任 X-Forwarded-For 等可伪造的请求头
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// keyScope 根据 MCP Key 的配置生成访问范围，密钥不可用时范围为全部拒绝
+func keyScope(key *mcpModels.McpKey, ip string) *comm.AccessScope {
+	scope := &comm.AccessScope{
+		Clusters:   utils.SplitAndTrim(key.Clusters, ","),
+		Namespaces: utils.SplitAndTrim(key.Namespaces, ","),
+		ReadOnly:   key.ReadOnly,
+	}
+	if err := key.CheckAccess(ip, time.Now()); err != nil {
+		scope.Denied = err.Error()
+	}
+	return scope
+}
+
+func mcpKeyFromContext(ctx context.Context) *mcpModels.McpKey {
+	key, _ := ctx.Value(mcpKeyCtxKey).(*mcpModels.McpKey)
+	return key
+}
+
+// isDestructiveTool 工具是否为变更类。仅显式声明 readOnlyHint 为 true 的工具视为只读，
+// mcp-go 创建工具时默认 destructiveHint 为 true，因此未声明的工具按变更类处理。
+func isDestructiveTool(tool *mcp2.Tool) bool {
+	if tool == nil {
+		return true
+	}
+	return tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint
+}
+
+// checkToolScope 校验工具调用是否在 MCP Key 的访问范围内，返回拒绝原因，允许时返回空字符串。
+// 集群与命名空间参数在此提前校验，实际访问时 kom 回调仍会按访问范围再次校验。
+func checkToolScope(ctx context.Context, request mcp2.CallToolRequest) string {
+	key := mcpKeyFromContext(ctx)
+	if key == nil {
+		return ""
+	}
+	scope := comm.GetAccessScope(ctx)
+	if scope != nil && scope.Denied != "" {
+		return scope.Denied
+	}
+
+	var tool *mcp2.Tool
+	if serv := server.ServerFromContext(ctx); serv != nil {
+		if st := serv.GetTool(request.Params.Name); st != nil {
+			tool = &st.Tool
+		}
+	}
+	if err := key.CheckTool(request.Params.Name, isDestructiveTool(tool)); err != nil {
+		return err.Error()
+	}
+
+	if scope == nil {
+		return ""
+	}
+	if cluster := request.GetString("cluster", ""); cluster != "" && len(scope.Clusters) > 0 && !slices.Contains(scope.Clusters, cluster) {
+		return fmt.Sprintf("访问范围不包含集群[%s]", cluster)
+	}
+	if ns := request.GetString("namespace", ""); ns != "" && len(scope.Namespaces) > 0 && !slices.Contains(scope.Namespaces, ns) {
+		return fmt.Sprintf("访问范围不包含命名空间[%s]", ns)
+	}
+	return ""
+}
+
+// toolScopeMiddleware 在工具执行前校验 MCP Key 的访问范围，拒绝的调用记录到工具执行日志
+func toolScopeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp2.CallToolRequest) (*mcp2.CallToolResult, error) {
+		reason := checkToolScope(ctx, request)
+		if reason == "" {
+			return next(ctx, request)
+		}
+
+		host := service.McpService().Host()
+		toolName := request.Params.Name
+		errStr := fmt.Sprintf("MCP Key 访问范围拒绝执行: %s", reason)
+		resultInfo := mcpModels.MCPToolCallResult{
+			ToolName:   toolName,
+			Parameters: request.Params.Arguments,
+			Result:     errStr,
+			Error:      errStr,
+		}
+		host.LogToolExecution(ctx, toolName, host.GetServerNameByToolName(toolName), request.Params.Arguments, resultInfo, 0)
+
+		result := mcp2.NewToolResultError(errStr)
+		result.Meta = &mcp2.Meta{AdditionalFields: map[string]any{metaRejected: true}}
+		return result, nil
+	}
+}
+
+// toolScopeFilter 在工具列表中隐藏 MCP Key 不允许调用的工具
+func toolScopeFilter(ctx context.Context, tools []mcp2.Tool) []mcp2.Tool {
+	key := mcpKeyFromContext(ctx)
+	if key == nil {
+		return tools
+	}
+	if scope := comm.GetAccessScope(ctx); scope != nil && scope.Denied != "" {
+		return []mcp2.Tool{}
+	}
+	filtered := make([]mcp2.Tool, 0, len(tools))
+	for i := range tools {
+		if key.CheckTool(tools[i].Name, isDestructiveTool(&tools[i])) == nil {
+			filtered = append(filtered, tools[i])
+		}
+	}
+	return filtered
+}
+
+// isRejectedResult 判断工具结果是否为访问范围拒绝
+func isRejectedResult(result *mcp2.CallToolResult) bool {
+	if result == nil || result.Meta == nil {
+		return false
+	}
+	rejected, _ := result.Meta.AdditionalFields[metaRejected].(bool)
+	return rejected
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/duke-git/lancet/v2/slice"
 	mcp2 "github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/weibaohui/k8m/pkg/comm"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/flag"
@@ -30,49 +31,77 @@ func Adapt(fn func() http.Handler) response.HandlerFunc {
 
 func extractKey(path string) string {
 	parts := strings.Split(path, "/")
-	endpoints := []string{"sse", "message"}
+	endpoints := []string{"sse", "message", "mcp"}
 	if len(parts) >= 5 && parts[1] == "mcp" && parts[2] == "k8m" && slice.Contain(endpoints, parts[4]) {
 		return parts[3]
 	}
 	return ""
 }
 
-// createServerConfig 返回一个配置了 JWT 用户名提取、工具调用日志钩子及相关服务器和 SSE 选项的 MCP 服务器配置。
+// authContext 从请求中解析用户身份，返回携带用户名的新上下文。
 // 支持两种传递认证的方式，一是将mcpKey作为路径参数传递，二是将JWT token作为Authorization头部传递。
-// mcpKey 与用户的信息绑定，mcpKey代表了一个用户
+// mcpKey 与用户的信息绑定，mcpKey代表了一个用户，并可进一步限定集群、命名空间、只读、工具范围、有效期与来源IP
 // token 与用户的JWT token绑定，代表了用户的权限，这个token与前端页面使用的jwt token 一致。
-func createServerConfig(basePath string) *mcp.ServerConfig {
-	cfg := flag.Init()
-
-	var ctxFn = func(ctx context.Context, r *http.Request) context.Context {
-		newCtx := context.Background()
-
-		mcpKey := extractKey(r.URL.Path)
-		if mcpKey != "" {
-			username, err := service.McpService().GetUserByMCPKey(mcpKey)
-			if err != nil {
-				klog.V(6).Infof("Failed to extract username from mcpKey: %v", err)
-			}
-			if username != "" {
-				newCtx = context.WithValue(newCtx, constants.JwtUserName, username)
-				return newCtx
-			}
-
-		}
-
-		auth := r.Header.Get("Authorization")
-		if after, ok := strings.CutPrefix(auth, "Bearer "); ok {
-			auth = after
+// MCP Key 生成的 token 携带密钥ID，按ID重新读取密钥，密钥删除后 token 随之失效。
+func authContext(ctx context.Context, r *http.Request) context.Context {
+	newCtx := context.Background()
+
+	mcpKey := extractKey(r.URL.Path)
+	if mcpKey != "" {
+		key, err := service.McpService().GetMCPKey(mcpKey)
+		if err != nil {
+			klog.V(6).Infof("Failed to extract username from mcpKey: %v", err)
 		}
-		klog.V(6).Infof("Authorization: %v", auth)
-		if username, err := utils.GetUsernameFromToken(auth, cfg.JwtTokenSecret); err == nil {
-			klog.V(6).Infof("Extracted username from token: %v", username)
-			newCtx = context.WithValue(newCtx, constants.JwtUserName, username)
-		} else {
-			klog.V(6).Infof("Failed to extract username from token: %v", err)
+		if key != nil {
+			// 密钥过期、IP不在白名单等情况仍保留用户名，便于执行日志记录拒绝原因
+			newCtx = context.WithValue(newCtx, constants.JwtUserName, key.Username)
+			newCtx = context.WithValue(newCtx, mcpKeyCtxKey, key)
+			newCtx = comm.WithAccessScope(newCtx, keyScope(key, remoteIP(r)))
+			return newCtx
 		}
+
+	}
+
+	auth := r.Header.Get("Authorization")
+	if after, ok := strings.CutPrefix(auth, "Bearer "); ok {
+		auth = after
+	}
+	klog.V(6).Infof("Authorization: %v", auth)
+	claims, err := utils.GetJwtMapClaimsFromToken(auth, flag.Init().JwtTokenSecret)
+	if err != nil {
+		klog.V(6).Infof("Failed to extract username from token: %v", err)
+		return newCtx
+	}
+	username, _ := claims[constants.JwtUserName].(string)
+	if username == "" {
 		return newCtx
 	}
+	klog.V(6).Infof("Extracted username from token: %v", username)
+
+	var key *mcpModels.McpKey
+	if scope, _ := claims[constants.JwtScope].(string); scope == constants.JwtScopeMCP {
+		kid, _ := claims[constants.JwtKeyID].(float64)
+		key, err = service.McpService().GetMCPKeyByID(uint(kid))
+		if err != nil || key.Username != username || key.Jwt != auth {
+			klog.V(6).Infof("MCP Key 令牌对应的密钥[%v]不可用: %v", kid, err)
+			return newCtx
+		}
+	} else if k, err := service.McpService().GetMCPKeyByJwt(auth); err == nil && k.Username == username {
+		// 升级前生成的 MCP Key 令牌不携带密钥ID，按令牌查找
+		key = k
+	}
+	newCtx = context.WithValue(newCtx, constants.JwtUserName, username)
+	// 该 token 为某个 MCP Key 绑定的 JWT 时，同样应用密钥的访问范围
+	if key != nil {
+		newCtx = context.WithValue(newCtx, mcpKeyCtxKey, key)
+		newCtx = comm.WithAccessScope(newCtx, keyScope(key, remoteIP(r)))
+	}
+	return newCtx
+}
+
+// createServerConfig 返回一个配置了 JWT 用户名提取、工具调用日志钩子及相关服务器和 SSE 选项的 MCP 服务器配置。
+func createServerConfig(basePath string) *mcp.ServerConfig {
+	cfg := flag.Init()
 
 	var errFn = func(ctx context.Context, id any, method mcp2.MCPMethod, message any, err error) {
 		if request, ok := message.(*mcp2.CallToolRequest); ok {
@@ -93,6 +122,10 @@ func createServerConfig(basePath string) *mcp.ServerConfig {
 
 	var actFn = func(ctx context.Context, id any, request *mcp2.CallToolRequest, result *mcp2.CallToolResult) {
 		klog.V(8).Infof("CallToolRequest: %v", utils.ToJSON(request))
+		if isRejectedResult(result) {
+			// 访问范围拒绝的调用已在执行前记录
+			return
+		}
 		host := service.McpService().Host()
 		toolName := request.Params.Name
 		serverName := host.GetServerNameByToolName(toolName)
@@ -117,15 +150,19 @@ func createServerConfig(basePath string) *mcp.ServerConfig {
 		OnError:         []server.OnErrorHookFunc{errFn},
 		OnAfterCallTool: []server.OnAfterCallToolFunc{actFn},
 	}
+	subManager.registerHooks(hooks)
+	promptSync.registerHooks(hooks)
 
 	return &mcp.ServerConfig{
 		Name:    "k8m mcp server",
 		Version: cfg.Version,
 		ServerOptions: []server.ServerOption{
-			server.WithResourceCapabilities(false, false),
-			server.WithPromptCapabilities(false),
+			server.WithResourceCapabilities(true, false),
+			server.WithPromptCapabilities(true),
 			server.WithLogging(),
 			server.WithHooks(hooks),
+			server.WithToolHandlerMiddleware(toolScopeMiddleware),
+			server.WithToolFilter(toolScopeFilter),
 		},
 		SSEOption: []server.SSEOption{
 			server.WithDynamicBasePath(func(r *http.Request, sessionID string) string {
@@ -136,7 +173,7 @@ func createServerConfig(basePath string) *mcp.ServerConfig {
 				return basePath + "/" + key
 			}),
 			server.WithStaticBasePath(basePath),
-			server.WithSSEContextFunc(ctxFn),
+			server.WithSSEContextFunc(authContext),
 		},
 		AuthKey: constants.JwtUserName,
 	}
@@ -179,9 +216,18 @@ func SaveYamlTemplateToolHandler(ctx context.Context, request mcp2.CallToolReque
 	return tools.TextResult("保存成功", nil)
 }
 
-func GetMcpSSEServer() *server.SSEServer {
+// GetMcpServers 创建 k8m MCP Server，并返回共用同一 MCPServer 的 SSE 与 Streamable HTTP 两种传输
+func GetMcpServers() (*server.SSEServer, *server.StreamableHTTPServer) {
 	sc := createServerConfig("/mcp/k8m")
 	serv := mcp.GetMCPServerWithOption(sc)
 	serv.AddTool(SaveYamlTemplateTool(), SaveYamlTemplateToolHandler)
-	return mcp.GetMCPSSEServerWithServerAndOption(serv, sc)
+	registerResources(serv)
+	subManager.serv = serv
+	promptSync.serv = serv
+
+	sseServer := mcp.GetMCPSSEServerWithServerAndOption(serv, sc)
+	streamableServer := server.NewStreamableHTTPServer(serv,
+		server.WithHTTPContextFunc(authContext),
+	)
+	return sseServer, streamableServer
 }
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	mcp2 "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/weibaohui/k8m/pkg/comm"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/service"
+	"k8s.io/klog/v2"
+)
+
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+	// subscriptionPollInterval 订阅资源的变更检测周期
+	subscriptionPollInterval = 15 * time.Second
+	// maxMessageBytes 拦截请求时读取请求体的上限
+	maxMessageBytes = 10 << 20
+)
+
+// sessionOwner 会话所属身份。会话首次订阅时绑定，之后的订阅请求必须来自同一用户与同一 MCP Key
+type sessionOwner struct {
+	username string
+	keyID    uint   // 使用用户 JWT 访问时为 0
+	ip       string // 订阅时的来源IP，轮询时用于重新校验密钥的IP白名单
+}
+
+// ownerFromContext 从认证上下文中取出当前请求的身份，密钥不可用（停用、过期、IP不符）时返回错误
+func ownerFromContext(ctx context.Context, r *http.Request) (*sessionOwner, error) {
+	username, _ := ctx.Value(constants.JwtUserName).(string)
+	if username == "" {
+		return nil, errors.New("订阅资源需要有效的认证信息")
+	}
+	owner := &sessionOwner{username: username, ip: remoteIP(r)}
+	if key := mcpKeyFromContext(ctx); key != nil {
+		if scope := comm.GetAccessScope(ctx); scope != nil && scope.Denied != "" {
+			return nil, errors.New(scope.Denied)
+		}
+		owner.keyID = key.ID
+	}
+	return owner, nil
+}
+
+// validate 重新读取 MCP Key，校验其未被删除、停用或过期
+func (o *sessionOwner) validate() error {
+	if o.keyID == 0 {
+		return nil
+	}
+	key, err := service.McpService().GetMCPKeyByID(o.keyID)
+	if err != nil {
+		return fmt.Errorf("MCP Key 不可用: %w", err)
+	}
+	if key.Username != o.username {
+		return errors.New("MCP Key 所属用户已变更")
+	}
+	return key.CheckAccess(o.ip, time.Now())
+}
+
+// subscription 单个会话对单个资源 URI 的订阅
+type subscription struct {
+	ref     *resourceRef
+	ctx     context.Context // 订阅者身份，轮询时以该身份读取资源，确保权限一致
+	version string
+}
+
+// subscriptionManager 管理资源订阅。
+// mcp-go 尚未处理 resources/subscribe 请求，因此在 HTTP 层拦截订阅请求，
+// 由本管理器定期比对资源版本，变更时向订阅会话推送 notifications/resources/updated。
+type subscriptionManager struct {
+	mu     sync.Mutex
+	subs   map[string]map[string]*subscription // sessionID -> uri -> 订阅
+	owners map[string]*sessionOwner            // sessionID -> 会话绑定的身份
+	serv   *server.MCPServer
+	once   sync.Once
+}
+
+var subManager = &subscriptionManager{
+	subs:   map[string]map[string]*subscription{},
+	owners: map[string]*sessionOwner{},
+}
+
+// jsonRPCSubscribeMessage 订阅相关的 JSON-RPC 请求
+type jsonRPCSubscribeMessage struct {
+	ID     mcp2.RequestId `json:"id"`
+	Method string         `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// registerHooks 注册会话注销时的订阅清理钩子
+func (m *subscriptionManager) registerHooks(hooks *server.Hooks) {
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		m.removeSession(session.SessionID())
+	})
+}
+
+// bind 将会话绑定到当前身份，已绑定的会话校验身份是否一致，防止他人凭会话ID操作订阅
+func (m *subscriptionManager) bind(sessionID string, owner *sessionOwner) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bound, ok := m.owners[sessionID]
+	if !ok {
+		m.owners[sessionID] = owner
+		return nil
+	}
+	if bound.username != owner.username || bound.keyID != owner.keyID {
+		return errors.New("会话不属于当前认证身份")
+	}
+	return nil
+}
+
+// subscribe 登记订阅，并读取一次资源作为初始版本，读取失败（如无权限）时拒绝订阅
+func (m *subscriptionManager) subscribe(ctx context.Context, sessionID, uri string) error {
+	ref, err := parseResourceURI(uri)
+	if err != nil {
+		return err
+	}
+	_, _, version, err := fetchResource(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if m.subs[sessionID] == nil {
+		m.subs[sessionID] = map[string]*subscription{}
+	}
+	m.subs[sessionID][uri] = &subscription{ref: ref, ctx: ctx, version: version}
+	m.mu.Unlock()
+
+	m.once.Do(func() {
+		go m.poll()
+	})
+	klog.V(6).Infof("MCP会话[%s]订阅资源 %s", sessionID, uri)
+	return nil
+}
+
+func (m *subscriptionManager) unsubscribe(sessionID, uri string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs[sessionID], uri)
+	if len(m.subs[sessionID]) == 0 {
+		delete(m.subs, sessionID)
+	}
+}
+
+func (m *subscriptionManager) removeSession(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs, sessionID)
+	delete(m.owners, sessionID)
+}
+
+// poll 定期检测订阅资源的版本变化
+func (m *subscriptionManager) poll() {
+	ticker := time.NewTicker(subscriptionPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.check()
+	}
+}
+
+func (m *subscriptionManager) check() {
+	type item struct {
+		sessionID string
+		uri       string
+		sub       *subscription
+	}
+	m.mu.Lock()
+	var items []item
+	owners := map[string]*sessionOwner{}
+	for sessionID, uris := range m.subs {
+		owners[sessionID] = m.owners[sessionID]
+		for uri, sub := range uris {
+			items = append(items, item{sessionID: sessionID, uri: uri, sub: sub})
+		}
+	}
+	m.mu.Unlock()
+
+	// 密钥被删除、停用或过期的会话不再推送
+	revoked := map[string]bool{}
+	for sessionID, owner := range owners {
+		if owner == nil {
+			continue
+		}
+		if err := owner.validate(); err != nil {
+			klog.V(6).Infof("MCP会话[%s]的密钥已不可用，取消全部订阅: %v", sessionID, err)
+			m.removeSession(sessionID)
+			revoked[sessionID] = true
+		}
+	}
+
+	for _, it := range items {
+		if revoked[it.sessionID] {
+			continue
+		}
+		_, _, version, err := fetchResource(it.sub.ctx, it.sub.ref)
+		if err != nil {
+			// 资源被删除时同样视为变更，版本置空避免重复通知
+			klog.V(6).Infof("MCP订阅资源 %s 读取失败: %v", it.uri, err)
+			version = ""
+		}
+		if version == it.sub.version {
+			continue
+		}
+		it.sub.version = version
+
+		err = m.serv.SendNotificationToSpecificClient(it.sessionID, mcp2.MethodNotificationResourceUpdated, map[string]any{
+			"uri": it.uri,
+		})
+		if err != nil {
+			klog.V(6).Infof("MCP会话[%s]推送资源变更通知失败，取消订阅: %v", it.sessionID, err)
+			m.unsubscribe(it.sessionID, it.uri)
+		}
+	}
+}
+
+// handle 处理订阅请求，返回 JSON-RPC 响应。每次请求都重新校验密钥，并要求与会话绑定的身份一致
+func (m *subscriptionManager) handle(ctx context.Context, r *http.Request, sessionID string, msg *jsonRPCSubscribeMessage) any {
+	if sessionID == "" {
+		return mcp2.NewJSONRPCError(msg.ID, mcp2.INVALID_REQUEST, "订阅资源需要有效的会话", nil)
+	}
+	owner, err := ownerFromContext(ctx, r)
+	if err == nil {
+		err = m.bind(sessionID, owner)
+	}
+	if err != nil {
+		klog.V(6).Infof("MCP会话[%s]订阅请求被拒绝: %v", sessionID, err)
+		return mcp2.NewJSONRPCError(msg.ID, mcp2.INVALID_REQUEST, err.Error(), nil)
+	}
+	switch msg.Method {
+	case methodResourcesSubscribe:
+		if err := m.subscribe(ctx, sessionID, msg.Params.URI); err != nil {
+			return mcp2.NewJSONRPCError(msg.ID, mcp2.INVALID_PARAMS, err.Error(), nil)
+		}
+	case methodResourcesUnsubscribe:
+		m.unsubscribe(sessionID, msg.Params.URI)
+	}
+	return mcp2.NewJSONRPCResultResponse(msg.ID, mcp2.EmptyResult{})
+}
+
+// readSubscribeMessage 读取请求体并判断是否为订阅请求，非订阅请求时恢复请求体供后续处理。
+// 请求体超过 maxMessageBytes 时返回错误。
+func readSubscribeMessage(w http.ResponseWriter, r *http.Request) (*jsonRPCSubscribeMessage, error) {
+	if r.Method != http.MethodPost || r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageBytes))
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	var msg jsonRPCSubscribeMessage
+	if json.Unmarshal(body, &msg) != nil {
+		// 批量请求等其他格式交由 mcp-go 处理
+		return nil, nil
+	}
+	if msg.Method != methodResourcesSubscribe && msg.Method != methodResourcesUnsubscribe {
+		return nil, nil
+	}
+	return &msg, nil
+}
+
+// writeBodyError 请求体读取失败时的响应，超过大小上限返回 413
+func writeBodyError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// SSESubscribeHandler 包装 SSE 消息处理器。
+// 与 SSE 协议一致，订阅请求返回 202，响应通过会话的 SSE 流下发。
+func SSESubscribeHandler(sse *server.SSEServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg, err := readSubscribeMessage(w, r)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if msg == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sessionID := r.URL.Query().Get("sessionId")
+		resp := subManager.handle(authContext(r.Context(), r), r, sessionID, msg)
+		if err := sse.SendEventToSession(sessionID, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// StreamableSubscribeHandler 包装 Streamable HTTP 处理器，订阅请求直接以 JSON 响应
+func StreamableSubscribeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg, err := readSubscribeMessage(w, r)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if msg == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sessionID := r.Header.Get(server.HeaderKeySessionID)
+		resp := subManager.handle(authContext(r.Context(), r), r, sessionID, msg)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
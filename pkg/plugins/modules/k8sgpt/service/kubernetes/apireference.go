@@ -68,3 +68,60 @@ func (k *K8sApiReference) recursePath(definitions []*openapi_v2.NamedSchema, lea
 
 	return description
 }
+
+// ListFieldPaths 列出当前 Kind 的字段路径（如 spec.replicas），最多展开 maxDepth 层。
+// 返回的路径可直接传给 GetApiDocV2 获取字段说明。
+func (k *K8sApiReference) ListFieldPaths(maxDepth int) []string {
+	definitions := k.OpenapiSchema.GetDefinitions().GetAdditionalProperties()
+	group := strings.Split(k.ApiVersion.Group, ".")
+	startPoint := ""
+	for _, prop := range definitions {
+		if strings.HasSuffix(prop.GetName(), fmt.Sprintf("%s.%s.%s", group[0], k.ApiVersion.Version, k.Kind)) {
+			startPoint = prop.GetName()
+			break
+		}
+	}
+	if startPoint == "" {
+		return nil
+	}
+
+	var paths []string
+	k.collectPaths(definitions, startPoint, "", maxDepth, map[string]bool{}, &paths)
+	return paths
+}
+
+func (k *K8sApiReference) collectPaths(definitions []*openapi_v2.NamedSchema, leaf string, prefix string, depth int, visiting map[string]bool, paths *[]string) {
+	if depth <= 0 || visiting[leaf] {
+		return
+	}
+	visiting[leaf] = true
+	defer delete(visiting, leaf)
+
+	for _, prop := range definitions {
+		if prop.GetName() != leaf {
+			continue
+		}
+		for _, addProp := range prop.GetValue().GetProperties().GetAdditionalProperties() {
+			name := addProp.GetName()
+			// apiVersion/kind/metadata 为通用字段，不再展开
+			if prefix == "" && (name == "apiVersion" || name == "kind" || name == "metadata") {
+				continue
+			}
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			*paths = append(*paths, path)
+
+			ref := addProp.GetValue().GetXRef()
+			if ref == "" && len(addProp.GetValue().GetItems().GetSchema()) == 1 {
+				ref = addProp.GetValue().GetItems().GetSchema()[0].GetXRef()
+			}
+			if ref != "" {
+				splitRef := strings.Split(ref, "/")
+				k.collectPaths(definitions, splitRef[len(splitRef)-1], path, depth-1, visiting, paths)
+			}
+		}
+		break
+	}
+}
@@ -21,4 +21,6 @@ const (
 	PluginNameOpenKruise   = "openkruise"
 	PluginNameYamlEditor   = "yaml_editor"
 	PluginNameKubeconfigExport = "kubeconfig_export"
+	PluginNameCertMonitor = "cert_monitor"
+	PluginNameAccessRequest = "access_request"
 )
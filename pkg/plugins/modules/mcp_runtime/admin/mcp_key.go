@@ -1,6 +1,9 @@
 package admin
 
 import (
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -20,14 +23,66 @@ func RegisterMCPKeysRoutes(mgm chi.Router) {
 	ctrl := &KeyController{}
 	mgm.Get("/user/profile/mcp_keys/list", response.Adapter(ctrl.List))
 	mgm.Post("/user/profile/mcp_keys/create", response.Adapter(ctrl.Create))
+	mgm.Post("/user/profile/mcp_keys/update/{id}", response.Adapter(ctrl.Update))
 	mgm.Post("/user/profile/mcp_keys/delete/{id}", response.Adapter(ctrl.Delete))
 }
 
+// keyScopeRequest MCP密钥的访问范围设置
+type keyScopeRequest struct {
+	Description string     `json:"description"`
+	Clusters    string     `json:"clusters"`
+	Namespaces  string     `json:"namespaces"`
+	ReadOnly    bool       `json:"read_only"`
+	AllowTools  string     `json:"allow_tools"`
+	DenyTools   string     `json:"deny_tools"`
+	AllowIPs    string     `json:"allow_ips"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// validate 校验访问范围设置，IP白名单需为合法的IP或CIDR
+func (r *keyScopeRequest) validate() error {
+	for _, item := range utils.SplitAndTrim(r.AllowIPs, ",") {
+		if strings.Contains(item, "/") {
+			if _, _, err := net.ParseCIDR(item); err != nil {
+				return fmt.Errorf("IP白名单格式错误: %s", item)
+			}
+			continue
+		}
+		if net.ParseIP(item) == nil {
+			return fmt.Errorf("IP白名单格式错误: %s", item)
+		}
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.IsZero() && r.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("过期时间不能早于当前时间")
+	}
+	return nil
+}
+
+// apply 将访问范围写入密钥，列表类字段统一规整为逗号分隔
+func (r *keyScopeRequest) apply(mcpKey *models.McpKey) {
+	join := func(s string) string {
+		return strings.Join(utils.SplitAndTrim(s, ","), ",")
+	}
+	mcpKey.Description = r.Description
+	mcpKey.Clusters = join(r.Clusters)
+	mcpKey.Namespaces = join(r.Namespaces)
+	mcpKey.ReadOnly = r.ReadOnly
+	mcpKey.AllowTools = join(r.AllowTools)
+	mcpKey.DenyTools = join(r.DenyTools)
+	mcpKey.AllowIPs = join(r.AllowIPs)
+	mcpKey.ExpiresAt = r.ExpiresAt
+	if mcpKey.ExpiresAt != nil && mcpKey.ExpiresAt.IsZero() {
+		mcpKey.ExpiresAt = nil
+	}
+}
+
 // Create 处理创建新的MCP密钥的HTTP请求。
-// 从请求中解析描述信息，获取当前用户，生成有效期为10年的JWT令牌，并创建包含该信息的MCP密钥记录保存到数据库。
+// 从请求中解析描述信息及访问范围，获取当前用户，创建MCP密钥记录，再生成携带密钥ID、有效期为10年的JWT令牌。
+// 该令牌仅可用于 /mcp/ 接口，密钥删除后随之失效。
+// 访问范围只能在用户自身权限内进一步收窄。
 // 失败时返回JSON格式的错误响应，成功时返回操作成功的JSON响应。
 // @Summary 创建MCP密钥
-// @Description 为当前用户创建一个新的MCP密钥（10年有效期）
+// @Description 为当前用户创建一个新的MCP密钥（10年有效期），可限定集群、命名空间、只读、工具黑白名单、过期时间与IP白名单
 // @Security BearerAuth
 // @Param description body string false "密钥描述"
 // @Success 200 {object} string "操作成功"
@@ -35,38 +90,83 @@ func RegisterMCPKeysRoutes(mgm chi.Router) {
 func (mc *KeyController) Create(c *response.Context) {
 	params := dao.BuildParams(c)
 
-	var req struct {
-		Description string `json:"description"`
-	}
+	var req keyScopeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if err := req.validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	// 从JWT中获取用户信息
 	username := c.GetString(constants.JwtUserName)
 
-	jwt, err := service.UserService().GenerateJWTTokenOnlyUserName(username, time.Hour*24*365*10)
+	// 生成MCP密钥
+	mcpKey := &models.McpKey{
+		Username:   username,
+		McpKey:     utils.RandNLengthString(8),
+		LastUsedAt: time.Now(),
+	}
+	req.apply(mcpKey)
+
+	// 保存到数据库，令牌需携带密钥ID，保存后再生成
+	if err := mcpKey.Save(params); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	jwt, err := service.UserService().GenerateMCPKeyToken(username, mcpKey.ID, time.Hour*24*365*10)
+	if err == nil {
+		err = dao.DB().Model(mcpKey).Update("jwt", jwt).Error
+	}
 	if err != nil {
+		_ = mcpKey.Delete(params, fmt.Sprintf("%d", mcpKey.ID))
 		amis.WriteJsonError(c, err)
 		return
 	}
 
-	// 生成MCP密钥
-	mcpKey := &models.McpKey{
-		Username:    username,
-		McpKey:      utils.RandNLengthString(8),
-		Jwt:         jwt,
-		Description: req.Description,
-		LastUsedAt:  time.Now(),
+	amis.WriteJsonOK(c)
+}
+
+// Update 修改MCP密钥的描述及访问范围，只能修改自己的密钥
+// @Summary 修改MCP密钥访问范围
+// @Description 修改指定MCP密钥的描述、集群、命名空间、只读、工具黑白名单、过期时间与IP白名单
+// @Security BearerAuth
+// @Param id path string true "MCP密钥ID"
+// @Success 200 {object} string "操作成功"
+// @Router /mgm/user/profile/mcp_keys/update/{id} [post]
+func (mc *KeyController) Update(c *response.Context) {
+	id := c.Param("id")
+	params := dao.BuildParams(c)
+	username := c.GetString(constants.JwtUserName)
+
+	var req keyScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		amis.WriteJsonError(c, err)
+		return
 	}
 
-	// 保存到数据库
-	if err := mcpKey.Save(params); err != nil {
+	mcpKey, err := (&models.McpKey{}).GetOne(params, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? and username = ?", id, username)
+	})
+	if err != nil {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	req.apply(mcpKey)
 
+	// 清空过期时间、取消只读等零值也需要写入
+	err = dao.DB().Model(mcpKey).Select("description", "clusters", "namespaces", "read_only",
+		"allow_tools", "deny_tools", "allow_ips", "expires_at").Updates(mcpKey).Error
+	if err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 	amis.WriteJsonOK(c)
 }
 
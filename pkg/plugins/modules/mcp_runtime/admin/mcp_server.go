@@ -1,7 +1,8 @@
 package admin
 
 import (
-	"context"
+	"fmt"
+	"strings"
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
@@ -9,7 +10,6 @@ import (
 	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/service"
 	"github.com/weibaohui/k8m/pkg/response"
-	"k8s.io/klog/v2"
 )
 
 type ServerController struct {
@@ -23,6 +23,12 @@ func (m *ServerController) List(c *response.Context) {
 	params := dao.BuildParams(c)
 	var mcpServer models.MCPServerConfig
 	list, count, err := mcpServer.List(params)
+	for _, item := range list {
+		status := service.McpService().Host().ServerStatus(item.Name)
+		item.Status = status.Status
+		item.LastError = status.LastError
+		item.Restarts = status.Restarts
+	}
 	amis.WriteJsonListTotalWithError(c, count, list, err)
 }
 
@@ -75,6 +81,10 @@ func (m *ServerController) Save(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	if err := validateServer(&entity); err != nil {
+		amis.WriteJsonError(c, err)
+		return
+	}
 
 	err := entity.Save(params)
 	if err != nil {
@@ -82,14 +92,47 @@ func (m *ServerController) Save(c *response.Context) {
 		return
 	}
 	ctx := amis.GetContextWithUser(c)
+	// 已启用的服务器由托管循环连接并同步工具列表
 	service.McpService().UpdateServer(ctx, entity)
-	removeTools(entity)
-
-	addTools(ctx, params, entity)
+	if !entity.Enabled {
+		removeTools(entity)
+	}
 
 	amis.WriteJsonErrorOrOK(c, err)
 }
 
+// validateServer 校验服务器配置，stdio 类型需要启动命令，其他类型需要连接地址
+func validateServer(entity *models.MCPServerConfig) error {
+	if entity.Type == "" {
+		entity.Type = models.MCPServerTypeSSE
+	}
+	switch entity.Type {
+	case models.MCPServerTypeStdio:
+		if strings.TrimSpace(entity.Command) == "" {
+			return fmt.Errorf("stdio 类型的 MCP Server 需要配置启动命令")
+		}
+		switch entity.RestartPolicy {
+		case "":
+			entity.RestartPolicy = models.RestartPolicyOnFailure
+		case models.RestartPolicyAlways, models.RestartPolicyOnFailure, models.RestartPolicyNever:
+		default:
+			return fmt.Errorf("不支持的重启策略: %s", entity.RestartPolicy)
+		}
+		for _, kv := range utils.SplitAndTrim(entity.Env, "\n") {
+			if !strings.Contains(kv, "=") {
+				return fmt.Errorf("环境变量格式错误，应为 KEY=VALUE: %s", kv)
+			}
+		}
+	case models.MCPServerTypeSSE, models.MCPServerTypeStreamable:
+		if strings.TrimSpace(entity.URL) == "" {
+			return fmt.Errorf("%s 类型的 MCP Server 需要配置连接地址", entity.Type)
+		}
+	default:
+		return fmt.Errorf("不支持的 MCP Server 类型: %s", entity.Type)
+	}
+	return nil
+}
+
 // @Summary 快速更新MCP服务器状态
 // @Security BearerAuth
 // @Param id path int true "MCP服务器ID"
@@ -119,11 +162,10 @@ func (m *ServerController) QuickSave(c *response.Context) {
 		return
 	}
 
-	removeTools(entity)
 	ctx := amis.GetContextWithUser(c)
 	service.McpService().UpdateServer(ctx, entity)
-	if status == "true" {
-		addTools(ctx, params, entity)
+	if !entity.Enabled {
+		removeTools(entity)
 	}
 
 	amis.WriteJsonErrorOrOK(c, err)
@@ -140,28 +182,6 @@ func (m *ServerController) MCPLogList(c *response.Context) {
 	amis.WriteJsonListTotalWithError(c, count, list, err)
 }
 
-func addTools(ctx context.Context, params *dao.Params, entity models.MCPServerConfig) bool {
-	// 获取Tools列表
-	if tools, err := service.McpService().GetTools(ctx, entity); err == nil {
-		for _, tool := range tools {
-			mt := models.MCPTool{
-				ServerName:  entity.Name,
-				Name:        tool.Name,
-				Description: tool.Description,
-				InputSchema: utils.ToJSON(tool.InputSchema),
-				Enabled:     true,
-			}
-			err = mt.Save(params)
-			if err != nil {
-				klog.V(6).Infof("保存工具失败:[%s/%s] %v\n", entity.Name, tool.Name, err)
-				return true
-			}
-		}
-
-	}
-	return false
-}
-
 func removeTools(entity models.MCPServerConfig) {
 	dao.DB().Where("server_name = ?", entity.Name).Delete(&models.MCPTool{})
 }
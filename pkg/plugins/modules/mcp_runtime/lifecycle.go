@@ -65,6 +65,7 @@ func (l *McpLifecycle) Start(ctx plugins.BaseContext) error {
 
 	go func() {
 		service.McpService().Init()
+		service.McpService().ReissueLegacyKeyTokens()
 		select {
 		case <-time.After(30 * time.Second):
 			service.McpService().Start()
@@ -89,6 +90,10 @@ func (l *McpLifecycle) Stop(ctx plugins.BaseContext) error {
 		l.cancelStart()
 		l.cancelStart = nil
 	}
+	// 停止 stdio 子进程及远程服务器的健康检查
+	if host := service.McpService().Host(); host != nil {
+		host.StopSupervisors()
+	}
 
 	return nil
 }
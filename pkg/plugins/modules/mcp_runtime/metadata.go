@@ -10,7 +10,7 @@ var Metadata = plugins.Module{
 	Meta: plugins.Meta{
 		Name:        modules.PluginNameMCPRuntime,
 		Title:       "MCP运行时管理插件",
-		Version:     "1.0.0",
+		Version:     "1.2.0",
 		Description: "管理大模型对话使用的MCP服务器。包括MCP服务器配置、工具管理、执行日志查看、开放MCP服务等功能。对话调用MCP时会自动添加Authorization头部，值为JWT token。",
 	},
 	Tables: []string{
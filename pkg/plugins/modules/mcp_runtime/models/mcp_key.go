@@ -1,6 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/weibaohui/k8m/internal/dao"
@@ -19,6 +23,15 @@ type McpKey struct {
 	CreatedAt   time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt   time.Time `json:"updated_at,omitempty"`
 	CreatedBy   string    `gorm:"size:255" json:"created_by,omitempty"`
+
+	// 以下为访问范围限制，均为空时与创建用户的权限一致
+	Clusters   string     `gorm:"type:text" json:"clusters"`      // 允许访问的集群，逗号分隔
+	Namespaces string     `gorm:"type:text" json:"namespaces"`    // 允许访问的命名空间，逗号分隔
+	ReadOnly   bool       `gorm:"default:false" json:"read_only"` // 只读，禁止变更类操作
+	AllowTools string     `gorm:"type:text" json:"allow_tools"`   // 工具白名单，逗号分隔，为空表示不限制
+	DenyTools  string     `gorm:"type:text" json:"deny_tools"`    // 工具黑名单，逗号分隔，优先于白名单
+	AllowIPs   string     `gorm:"type:text" json:"allow_ips"`     // 来源IP白名单，支持IP与CIDR，逗号分隔
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`           // 过期时间，为空表示不过期
 }
 
 func (c *McpKey) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*McpKey, int64, error) {
@@ -36,3 +49,48 @@ func (c *McpKey) Delete(params *dao.Params, ids string, queryFuncs ...func(*gorm
 func (c *McpKey) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) (*McpKey, error) {
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
+
+// CheckAccess 校验密钥是否可用：启用状态、过期时间与来源IP
+func (c *McpKey) CheckAccess(remoteIP string, now time.Time) error {
+	if !c.Enabled {
+		return fmt.Errorf("MCP Key 已停用")
+	}
+	if c.ExpiresAt != nil && !c.ExpiresAt.IsZero() && now.After(*c.ExpiresAt) {
+		return fmt.Errorf("MCP Key 已于 %s 过期", c.ExpiresAt.Format(time.DateTime))
+	}
+	allowIPs := utils.SplitAndTrim(c.AllowIPs, ",")
+	if len(allowIPs) == 0 {
+		return nil
+	}
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return fmt.Errorf("无法识别来源IP[%s]", remoteIP)
+	}
+	for _, item := range allowIPs {
+		if strings.Contains(item, "/") {
+			if _, cidr, err := net.ParseCIDR(item); err == nil && cidr.Contains(ip) {
+				return nil
+			}
+			continue
+		}
+		if allowed := net.ParseIP(item); allowed != nil && allowed.Equal(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("来源IP[%s]不在MCP Key白名单中", remoteIP)
+}
+
+// CheckTool 校验工具是否允许调用。destructive 为工具声明的破坏性操作标记，只读密钥禁止调用
+func (c *McpKey) CheckTool(toolName string, destructive bool) error {
+	if slices.Contains(utils.SplitAndTrim(c.DenyTools, ","), toolName) {
+		return fmt.Errorf("工具[%s]在MCP Key黑名单中", toolName)
+	}
+	allowTools := utils.SplitAndTrim(c.AllowTools, ",")
+	if len(allowTools) > 0 && !slices.Contains(allowTools, toolName) {
+		return fmt.Errorf("工具[%s]不在MCP Key白名单中", toolName)
+	}
+	if c.ReadOnly && destructive {
+		return fmt.Errorf("MCP Key 为只读，禁止调用变更类工具[%s]", toolName)
+	}
+	return nil
+}
@@ -8,6 +8,20 @@ import (
 	"gorm.io/gorm"
 )
 
+// MCP Server 连接类型
+const (
+	MCPServerTypeSSE        = "sse"
+	MCPServerTypeStreamable = "streamable"
+	MCPServerTypeStdio      = "stdio"
+)
+
+// stdio 子进程重启策略
+const (
+	RestartPolicyAlways    = "always"     // 进程退出后总是重启
+	RestartPolicyOnFailure = "on-failure" // 仅异常退出时重启
+	RestartPolicyNever     = "never"      // 不重启
+)
+
 type MCPServerConfig struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
 	URL       string    `gorm:"size:255;not null" json:"url,omitempty"`
@@ -15,6 +29,23 @@ type MCPServerConfig struct {
 	Enabled   bool      `gorm:"default:false" json:"enabled,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+
+	Type          string `gorm:"size:20;default:sse" json:"type,omitempty"`                  // 连接类型 sse/streamable/stdio
+	Command       string `gorm:"size:500" json:"command,omitempty"`                          // stdio 启动命令
+	Args          string `gorm:"type:text" json:"args,omitempty"`                            // stdio 启动参数，每行一个
+	Env           string `gorm:"type:text" json:"env,omitempty"`                             // stdio 环境变量，每行一个 KEY=VALUE
+	WorkDir       string `gorm:"size:500" json:"work_dir,omitempty"`                         // stdio 工作目录
+	RestartPolicy string `gorm:"size:20;default:on-failure" json:"restart_policy,omitempty"` // stdio 重启策略
+
+	// 运行时状态，不落库
+	Status    string `gorm:"-" json:"status,omitempty"`
+	LastError string `gorm:"-" json:"last_error,omitempty"`
+	Restarts  int    `gorm:"-" json:"restarts,omitempty"`
+}
+
+// IsStdio 是否为本地子进程方式启动的 MCP Server
+func (c *MCPServerConfig) IsStdio() bool {
+	return c.Type == MCPServerTypeStdio
 }
 
 func (c *MCPServerConfig) List(params *dao.Params, queryFuncs ...func(*gorm.DB) *gorm.DB) ([]*MCPServerConfig, int64, error) {
@@ -36,6 +36,7 @@ func RegisterPluginMgmRoutes(arg chi.Router) {
 	keyCtrl := &admin.KeyController{}
 	arg.Get(prefix+"/user/profile/mcp_keys/list", response.Adapter(keyCtrl.List))
 	arg.Post(prefix+"/user/profile/mcp_keys/create", response.Adapter(keyCtrl.Create))
+	arg.Post(prefix+"/user/profile/mcp_keys/update/{id}", response.Adapter(keyCtrl.Update))
 	arg.Post(prefix+"/user/profile/mcp_keys/delete/{id}", response.Adapter(keyCtrl.Delete))
 
 	klog.V(6).Infof("注册 MCP 插件管理路由(mgm)")
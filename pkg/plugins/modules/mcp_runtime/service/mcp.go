@@ -11,9 +11,13 @@ import (
 	mcp2 "github.com/mark3labs/mcp-go/mcp"
 	"github.com/sashabaranov/go-openai"
 	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/flag"
 	uModels "github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/models"
+	gservice "github.com/weibaohui/k8m/pkg/service"
 
 	"gorm.io/gorm"
 
@@ -34,14 +38,7 @@ func (m *mcpService) Host() *MCPHost {
 	return m.host
 }
 func (m *mcpService) AddServer(ctx context.Context, server models.MCPServerConfig) {
-	// 将server转换为mcp.ServerConfig
-	serverConfig := ServerConfig{
-		ID:      server.ID,
-		Name:    server.Name,
-		URL:     server.URL,
-		Enabled: server.Enabled,
-	}
-	err := m.host.AddServer(serverConfig)
+	err := m.host.AddServer(toServerConfig(server))
 	if err != nil {
 		klog.V(6).Infof("添加服务器 %s 失败: %v", server.Name, err)
 		return
@@ -61,14 +58,7 @@ func (m *mcpService) AddServer(ctx context.Context, server models.MCPServerConfi
 }
 func (m *mcpService) AddServers(ctx context.Context, servers []models.MCPServerConfig) {
 	for _, server := range servers {
-		// 将server转换为mcp.ServerConfig
-		serverConfig := ServerConfig{
-			ID:      server.ID,
-			Name:    server.Name,
-			URL:     server.URL,
-			Enabled: server.Enabled,
-		}
-		err := m.host.AddServer(serverConfig)
+		err := m.host.AddServer(toServerConfig(server))
 		if err != nil {
 			klog.V(6).Infof("添加服务器 %s 失败: %v", server.Name, err)
 			continue
@@ -90,13 +80,27 @@ func (m *mcpService) AddServers(ctx context.Context, servers []models.MCPServerC
 
 }
 func (m *mcpService) RemoveServer(server models.MCPServerConfig) {
-	// 将server转换为mcp.ServerConfig
-	serverConfig := ServerConfig{
-		Name:    server.Name,
-		URL:     server.URL,
-		Enabled: server.Enabled,
+	m.host.RemoveServer(toServerConfig(server))
+}
+
+// toServerConfig 将server转换为mcp.ServerConfig
+func toServerConfig(server models.MCPServerConfig) ServerConfig {
+	serverType := server.Type
+	if serverType == "" {
+		serverType = models.MCPServerTypeSSE
+	}
+	return ServerConfig{
+		ID:            server.ID,
+		Name:          server.Name,
+		URL:           server.URL,
+		Enabled:       server.Enabled,
+		Type:          serverType,
+		Command:       server.Command,
+		Args:          server.Args,
+		Env:           server.Env,
+		WorkDir:       server.WorkDir,
+		RestartPolicy: server.RestartPolicy,
 	}
-	m.host.RemoveServer(serverConfig)
 }
 func (m *mcpService) Start() {
 
@@ -156,30 +160,94 @@ func (m *mcpService) GetAllEnabledTools() []openai.Tool {
 }
 
 func (m *mcpService) GetUserByMCPKey(mcpKey string) (string, error) {
+	item, err := m.GetMCPKey(mcpKey)
+	if err != nil {
+		return "", err
+	}
+	return item.Username, nil
+}
+
+// GetMCPKey 获取 MCP Key 记录，并校验所属用户是否可用。
+// 密钥自身的启用状态、过期时间、来源IP等访问范围由调用方通过 McpKey.CheckAccess 校验。
+func (m *mcpService) GetMCPKey(mcpKey string) (*models.McpKey, error) {
+	return m.getMCPKey(func(db *gorm.DB) *gorm.DB {
+		return db.Where(" mcp_key = ?", mcpKey)
+	})
+}
+
+// GetMCPKeyByJwt 根据 MCP Key 绑定的 JWT 获取密钥记录。
+// 使用静态地址+Authorization 头部访问时，同样需要应用该密钥的访问范围。
+func (m *mcpService) GetMCPKeyByJwt(jwt string) (*models.McpKey, error) {
+	if jwt == "" {
+		return nil, errors.New("jwt is empty")
+	}
+	return m.getMCPKey(func(db *gorm.DB) *gorm.DB {
+		return db.Where(" jwt = ?", jwt)
+	})
+}
+
+// GetMCPKeyByID 根据ID获取密钥记录，用于长连接期间重新校验密钥是否被删除或停用
+func (m *mcpService) GetMCPKeyByID(id uint) (*models.McpKey, error) {
+	return m.getMCPKey(func(db *gorm.DB) *gorm.DB {
+		return db.Where(" id = ?", id)
+	})
+}
+
+// ReissueLegacyKeyTokens 为升级前生成的 MCP Key 重新签发携带密钥ID与使用范围的令牌，保持原过期时间
+func (m *mcpService) ReissueLegacyKeyTokens() {
+	var keys []*models.McpKey
+	if err := dao.DB().Find(&keys).Error; err != nil {
+		klog.V(6).Infof("读取 MCP Key 失败: %v", err)
+		return
+	}
+	for _, key := range keys {
+		claims, err := utils.GetJwtMapClaimsFromToken(key.Jwt, flag.Init().JwtTokenSecret)
+		if err != nil {
+			continue
+		}
+		if scope, _ := claims[constants.JwtScope].(string); scope == constants.JwtScopeMCP {
+			continue
+		}
+		exp, _ := claims["exp"].(float64)
+		duration := time.Until(time.Unix(int64(exp), 0))
+		if duration <= 0 {
+			continue
+		}
+		token, err := gservice.UserService().GenerateMCPKeyToken(key.Username, key.ID, duration)
+		if err != nil {
+			klog.V(6).Infof("重新签发 MCP Key[%d] 令牌失败: %v", key.ID, err)
+			continue
+		}
+		if err := dao.DB().Model(key).Update("jwt", token).Error; err != nil {
+			klog.V(6).Infof("保存 MCP Key[%d] 令牌失败: %v", key.ID, err)
+			continue
+		}
+		klog.V(6).Infof("已为 MCP Key[%d] 重新签发令牌", key.ID)
+	}
+}
+
+func (m *mcpService) getMCPKey(queryFunc func(db *gorm.DB) *gorm.DB) (*models.McpKey, error) {
 	params := &dao.Params{}
 	md := &models.McpKey{}
-	queryFunc := func(db *gorm.DB) *gorm.DB {
-		return db.Select("username").Where(" mcp_key = ?", mcpKey)
-	}
 	item, err := md.GetOne(params, queryFunc)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if item.Username == "" {
-		return "", errors.New("username is empty")
+		return nil, errors.New("username is empty")
 	}
 
 	// 检测用户是否被禁用
 	user := &uModels.User{}
 	disabled, err := user.IsDisabled(item.Username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if disabled {
-		return "", fmt.Errorf("用户[%s]被禁用", item.Username)
+		return nil, fmt.Errorf("用户[%s]被禁用", item.Username)
 	}
-	return item.Username, nil
+	return item, nil
 }
 
 // BuildMCPToolName 构建完整的工具名称
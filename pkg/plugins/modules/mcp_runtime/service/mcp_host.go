@@ -10,23 +10,32 @@ import (
 
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/sashabaranov/go-openai"
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	"github.com/weibaohui/k8m/pkg/comm/utils/audit"
 	"github.com/weibaohui/k8m/pkg/constants"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/models"
+	gservice "github.com/weibaohui/k8m/pkg/service"
 
 	"k8s.io/klog/v2"
 )
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	ID      uint   `json:"id"`
-	URL     string `json:"url,omitempty"`
-	Name    string `json:"name,omitempty"`
-	Enabled bool   `json:"enabled,omitempty"`
+	ID            uint   `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Enabled       bool   `json:"enabled,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Command       string `json:"command,omitempty"`
+	Args          string `json:"args,omitempty"`
+	Env           string `json:"env,omitempty"`
+	WorkDir       string `json:"work_dir,omitempty"`
+	RestartPolicy string `json:"restart_policy,omitempty"`
 }
 
 // MCPHost MCP服务器管理器
@@ -39,6 +48,8 @@ type MCPHost struct {
 	Resources map[string][]mcp.Resource
 	// 记录每个服务器的提示能力
 	Prompts map[string][]mcp.Prompt
+	// 托管中的服务器，负责 stdio 进程管理、健康检查与自动重连
+	supervisors map[string]*managedServer
 
 	buffer    []*models.MCPToolLog
 	bufferMux sync.Mutex
@@ -57,10 +68,11 @@ type MCPServer struct {
 // NewMCPHost 创建新的MCP管理器
 func NewMCPHost() *MCPHost {
 	host := &MCPHost{
-		configs:   make(map[string]ServerConfig),
-		Tools:     make(map[string][]mcp.Tool),
-		Resources: make(map[string][]mcp.Resource),
-		Prompts:   make(map[string][]mcp.Prompt),
+		configs:     make(map[string]ServerConfig),
+		Tools:       make(map[string][]mcp.Tool),
+		Resources:   make(map[string][]mcp.Resource),
+		Prompts:     make(map[string][]mcp.Prompt),
+		supervisors: make(map[string]*managedServer),
 
 		buffer:   make([]*models.MCPToolLog, 0, 100),
 		ticker:   time.NewTicker(2 * time.Second),
@@ -92,12 +104,24 @@ func (m *MCPHost) ListServers() []MCPServer {
 
 }
 
-// AddServer 添加服务器配置
+// AddServer 添加服务器配置，已启用的服务器交由托管循环负责连接、健康检查与自动重连。
+// 配置未变化且已在托管中时保持原连接，避免重复启动 stdio 进程。
 func (m *MCPHost) AddServer(config ServerConfig) error {
+	m.mutex.RLock()
+	old, exists := m.configs[config.Name]
+	_, supervised := m.supervisors[config.Name]
+	m.mutex.RUnlock()
+	if exists && old == config && (supervised || !config.Enabled) {
+		return nil
+	}
+
 	m.RemoveServer(config)
 	m.mutex.Lock()
 	m.configs[config.Name] = config
 	m.mutex.Unlock()
+	if config.Enabled {
+		m.startSupervisor(config)
+	}
 	return nil
 }
 
@@ -149,13 +173,16 @@ func (m *MCPHost) ConnectServer(ctx context.Context, serverName string) error {
 
 	// 在锁外同步服务器能力
 	if err := m.SyncServerCapabilities(ctx, serverName); err != nil {
+		// 连接失败时唤醒托管循环立即重试，无需等待退避结束
+		m.Reconnect(serverName)
 		return fmt.Errorf("同步服务器 %s 的能力失败: %v", serverName, err)
 	}
 
 	return nil
 }
 
-// GetClient 获取指定服务器的客户端
+// GetClient 为 sse/streamable 类型服务器创建携带当前用户身份的客户端，调用方负责关闭。
+// stdio 类型服务器只有托管的常驻连接，请使用 withClient。
 func (m *MCPHost) GetClient(ctx context.Context, serverName string) (*client.Client, error) {
 
 	// 获取配置信息
@@ -163,16 +190,28 @@ func (m *MCPHost) GetClient(ctx context.Context, serverName string) (*client.Cli
 	if !exists {
 		return nil, fmt.Errorf("MCP Server 配置不存在: %s", serverName)
 	}
+	if config.Type == models.MCPServerTypeStdio {
+		return nil, fmt.Errorf("MCP Server %s 为 stdio 类型，不支持创建独立连接", serverName)
+	}
 
+	// 执行时携带用户名、角色信息。
+	// 平台管理员上下文（后台监控、能力同步）不代表具体用户，不携带用户令牌
+	headers := map[string]string{}
 	username := m.getUserFromMCPCtx(ctx)
-	jwt, err := amis.GenerateJWTTokenOnlyUserNameInMCP(username, time.Hour*1)
-	if err != nil {
-		return nil, fmt.Errorf("生成 JWT 令牌失败: %v", err)
+	if username != "" || ctx.Value(constants.RolePlatformAdmin) != constants.RolePlatformAdmin {
+		jwt, err := amis.GenerateJWTTokenOnlyUserNameInMCP(username, time.Hour*1)
+		if err != nil {
+			return nil, fmt.Errorf("生成 JWT 令牌失败: %v", err)
+		}
+		headers["Authorization"] = jwt
+	}
+	var newCli *client.Client
+	var err error
+	if config.Type == models.MCPServerTypeStreamable {
+		newCli, err = client.NewStreamableHttpClient(config.URL, transport.WithHTTPHeaders(headers))
+	} else {
+		newCli, err = client.NewSSEMCPClient(config.URL, client.WithHeaders(headers))
 	}
-	// 执行时携带用户名、角色信息
-	newCli, err := client.NewSSEMCPClient(config.URL, client.WithHeaders(map[string]string{
-		"Authorization": jwt,
-	}))
 	if err != nil {
 		return nil, fmt.Errorf("创建新客户端 %s 失败: %v", serverName, err)
 	}
@@ -200,6 +239,33 @@ func (m *MCPHost) GetClient(ctx context.Context, serverName string) (*client.Cli
 
 }
 
+// withClient 获取服务器客户端并执行 fn。
+// stdio 类型使用托管的常驻连接；其他类型按当前用户身份新建连接，执行完毕后关闭。
+func (m *MCPHost) withClient(ctx context.Context, serverName string, fn func(cli *client.Client) error) error {
+	m.mutex.RLock()
+	config, exists := m.configs[serverName]
+	m.mutex.RUnlock()
+	if !exists {
+		return fmt.Errorf("MCP Server 配置不存在: %s", serverName)
+	}
+
+	if config.Type == models.MCPServerTypeStdio {
+		cli := m.managedClient(serverName)
+		if cli == nil {
+			status := m.ServerStatus(serverName)
+			return fmt.Errorf("MCP Server %s 未运行[%s]: %s", serverName, status.Status, status.LastError)
+		}
+		return fn(cli)
+	}
+
+	cli, err := m.GetClient(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	return fn(cli)
+}
+
 func (m *MCPHost) getUserFromMCPCtx(ctx context.Context) string {
 	username := ""
 	if usernameVal, ok := ctx.Value(constants.JwtUserName).(string); ok {
@@ -239,51 +305,51 @@ func (m *MCPHost) GetAllTools(ctx context.Context) []openai.Tool {
 
 // GetTools 获取指定服务器的工具列表
 func (m *MCPHost) GetTools(ctx context.Context, serverName string) ([]mcp.Tool, error) {
-	cli, err := m.GetClient(ctx, serverName)
-	if err != nil {
-		return nil, err
-	}
-
-	toolsRequest := mcp.ListToolsRequest{}
-	toolsResult, err := cli.ListTools(ctx, toolsRequest)
-	if err != nil {
-		return nil, fmt.Errorf("获取服务器 %s 的工具失败: %v", serverName, err)
-	}
-
-	return toolsResult.Tools, nil
+	var tools []mcp.Tool
+	err := m.withClient(ctx, serverName, func(cli *client.Client) error {
+		toolsRequest := mcp.ListToolsRequest{}
+		toolsResult, err := cli.ListTools(ctx, toolsRequest)
+		if err != nil {
+			return fmt.Errorf("获取服务器 %s 的工具失败: %v", serverName, err)
+		}
+		tools = toolsResult.Tools
+		return nil
+	})
+	return tools, err
 }
 
 // GetResources 获取指定服务器的资源能力
 func (m *MCPHost) GetResources(ctx context.Context, serverName string) ([]mcp.Resource, error) {
-	cli, err := m.GetClient(ctx, serverName)
-	if err != nil {
-		return nil, err
-	}
-	req := mcp.ListResourcesRequest{}
-	result, err := cli.ListResources(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("获取服务器 %s 的资源失败: %v", serverName, err)
-	}
-
-	return result.Resources, nil
+	var resources []mcp.Resource
+	err := m.withClient(ctx, serverName, func(cli *client.Client) error {
+		req := mcp.ListResourcesRequest{}
+		result, err := cli.ListResources(ctx, req)
+		if err != nil {
+			return fmt.Errorf("获取服务器 %s 的资源失败: %v", serverName, err)
+		}
+		resources = result.Resources
+		return nil
+	})
+	return resources, err
 }
 
 // GetPrompts 获取指定服务器的提示能力
 func (m *MCPHost) GetPrompts(ctx context.Context, serverName string) ([]mcp.Prompt, error) {
-	cli, err := m.GetClient(ctx, serverName)
-	if err != nil {
-		return nil, err
-	}
-	req := mcp.ListPromptsRequest{}
-	result, err := cli.ListPrompts(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("获取服务器 %s 的提示失败: %v", serverName, err)
-	}
-
-	return result.Prompts, nil
+	var prompts []mcp.Prompt
+	err := m.withClient(ctx, serverName, func(cli *client.Client) error {
+		req := mcp.ListPromptsRequest{}
+		result, err := cli.ListPrompts(ctx, req)
+		if err != nil {
+			return fmt.Errorf("获取服务器 %s 的提示失败: %v", serverName, err)
+		}
+		prompts = result.Prompts
+		return nil
+	})
+	return prompts, err
 }
 
 func (m *MCPHost) RemoveServer(config ServerConfig) {
+	m.stopSupervisor(config.Name)
 	m.mutex.Lock()
 
 	// 删除服务器配置
@@ -344,6 +410,21 @@ func (m *MCPHost) LogToolExecution(ctx context.Context, toolName, serverName str
 	}
 
 	dao.DB().Create(log)
+
+	e := &audit.Event{
+		Time:   log.CreatedAt,
+		Type:   audit.EventMCPTool,
+		Action: toolName,
+		User:   username,
+		Name:   serverName,
+		Result: audit.ResultSuccess,
+		Detail: log.Parameters,
+	}
+	if log.Error != "" {
+		e.Result = audit.ResultFailure
+		e.Message = log.Error
+	}
+	gservice.AuditService().Emit(e)
 }
 
 func (m *MCPHost) ExecTools(ctx context.Context, toolCalls []openai.ToolCall) []models.MCPToolCallResult {
@@ -378,7 +459,6 @@ func (m *MCPHost) ExecTools(ctx context.Context, toolCalls []openai.ToolCall) []
 
 			result.Parameters = args
 
-			var cli *client.Client
 			var toolName, serverName string
 			var err error
 
@@ -402,17 +482,13 @@ func (m *MCPHost) ExecTools(ctx context.Context, toolCalls []openai.ToolCall) []
 			callRequest.Params.Name = toolName
 			callRequest.Params.Arguments = args
 			klog.V(6).Infof("执行工具调用: %s\n", utils.ToJSON(callRequest))
-			cli, err = m.GetClient(ctx, serverName)
-
-			if err != nil {
-				klog.V(6).Infof("获取MCP Client 失败: %v\n", err)
-				result.Error = fmt.Sprintf("获取MCP Client 失败: %v", err)
-				results = append(results, result)
-				continue
-			}
 			// 执行工具
-			callResult, err := cli.CallTool(ctx, callRequest)
-			_ = cli.Close()
+			var callResult *mcp.CallToolResult
+			err = m.withClient(ctx, serverName, func(cli *client.Client) error {
+				var callErr error
+				callResult, callErr = cli.CallTool(ctx, callRequest)
+				return callErr
+			})
 			// 记录执行日志
 			executeTime := time.Since(startTime).Milliseconds()
 			if err != nil {
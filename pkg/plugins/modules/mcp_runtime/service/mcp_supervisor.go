@@ -0,0 +1,466 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/plugins/modules/mcp_runtime/models"
+	"k8s.io/klog/v2"
+)
+
+// MCP Server 运行状态
+const (
+	ServerStatusStarting = "starting" // 启动/连接中
+	ServerStatusRunning  = "running"  // 运行中，健康检查正常
+	ServerStatusBackoff  = "backoff"  // 连接失败或进程退出，等待退避后重试
+	ServerStatusStopped  = "stopped"  // 已停止，不再重试
+)
+
+const (
+	healthCheckInterval = 30 * time.Second
+	healthCheckTimeout  = 10 * time.Second
+	// healthFailThreshold 远程服务器连续健康检查失败次数达到该值后重连，stdio 进程一次失败即重启
+	healthFailThreshold = 2
+	backoffInitial      = 2 * time.Second
+	backoffMax          = 5 * time.Minute
+	// firstStartTimeout 添加服务器时等待首次启动完成的最长时间
+	firstStartTimeout = 30 * time.Second
+	syncTimeout       = 30 * time.Second
+)
+
+// ServerRuntimeStatus MCP Server 运行时状态
+type ServerRuntimeStatus struct {
+	Status    string `json:"status"`
+	LastError string `json:"last_error,omitempty"`
+	Restarts  int    `json:"restarts"`
+}
+
+// managedServer 托管的 MCP Server。
+// stdio 类型保持一个常驻子进程，所有调用共用其连接；
+// sse/streamable 类型保持一个监控连接，用于健康检查与接收能力变更通知，工具调用仍按用户身份单独建立连接。
+type managedServer struct {
+	config ServerConfig
+
+	mu        sync.RWMutex
+	client    *client.Client
+	status    string
+	lastError string
+	restarts  int
+
+	cancel context.CancelFunc
+	// wake 在退避等待期间立即触发重试
+	wake chan struct{}
+	// syncCh 收到 list_changed 通知后触发能力同步
+	syncCh chan struct{}
+	ready  chan struct{}
+	done   chan struct{}
+}
+
+func (s *managedServer) setStatus(status, lastError string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	if lastError != "" || status == ServerStatusRunning {
+		s.lastError = lastError
+	}
+}
+
+func (s *managedServer) setClient(cli *client.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = cli
+}
+
+func (s *managedServer) getClient() *client.Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
+
+func (s *managedServer) runtimeStatus() ServerRuntimeStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return ServerRuntimeStatus{Status: s.status, LastError: s.lastError, Restarts: s.restarts}
+}
+
+// startSupervisor 启动服务器托管，并等待首次启动完成，便于随后同步工具列表
+func (m *MCPHost) startSupervisor(config ServerConfig) {
+	m.stopSupervisor(config.Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ms := &managedServer{
+		config: config,
+		status: ServerStatusStarting,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		syncCh: make(chan struct{}, 1),
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	m.mutex.Lock()
+	m.supervisors[config.Name] = ms
+	m.mutex.Unlock()
+
+	go m.supervise(ctx, ms)
+
+	select {
+	case <-ms.ready:
+	case <-time.After(firstStartTimeout):
+		klog.V(6).Infof("MCP Server %s 首次启动超时，继续在后台重试", config.Name)
+	}
+}
+
+// stopSupervisor 停止服务器托管，stdio 类型同时结束子进程
+func (m *MCPHost) stopSupervisor(serverName string) {
+	m.mutex.Lock()
+	ms, ok := m.supervisors[serverName]
+	delete(m.supervisors, serverName)
+	m.mutex.Unlock()
+	if !ok {
+		return
+	}
+	ms.cancel()
+	select {
+	case <-ms.done:
+	case <-time.After(10 * time.Second):
+		klog.V(6).Infof("等待 MCP Server %s 停止超时", serverName)
+	}
+}
+
+// StopSupervisors 停止全部托管的服务器
+func (m *MCPHost) StopSupervisors() {
+	m.mutex.RLock()
+	names := make([]string, 0, len(m.supervisors))
+	for name := range m.supervisors {
+		names = append(names, name)
+	}
+	m.mutex.RUnlock()
+	for _, name := range names {
+		m.stopSupervisor(name)
+	}
+}
+
+// ServerStatus 获取服务器运行时状态，未托管（如未启用）时返回 stopped
+func (m *MCPHost) ServerStatus(serverName string) ServerRuntimeStatus {
+	m.mutex.RLock()
+	ms, ok := m.supervisors[serverName]
+	m.mutex.RUnlock()
+	if !ok {
+		return ServerRuntimeStatus{Status: ServerStatusStopped}
+	}
+	return ms.runtimeStatus()
+}
+
+// Reconnect 运行中时立即同步能力，退避等待中时立即重试
+func (m *MCPHost) Reconnect(serverName string) {
+	m.mutex.RLock()
+	ms, ok := m.supervisors[serverName]
+	m.mutex.RUnlock()
+	if !ok {
+		return
+	}
+	select {
+	case ms.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *MCPHost) managedClient(serverName string) *client.Client {
+	m.mutex.RLock()
+	ms, ok := m.supervisors[serverName]
+	m.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	return ms.getClient()
+}
+
+// supervise 托管主循环：连接/启动 -> 健康检查 -> 断开后按退避策略重试
+func (m *MCPHost) supervise(ctx context.Context, ms *managedServer) {
+	defer close(ms.done)
+	name := ms.config.Name
+	backoff := backoffInitial
+	readyOnce := sync.Once{}
+	markReady := func() { readyOnce.Do(func() { close(ms.ready) }) }
+	defer markReady()
+
+	for {
+		ms.setStatus(ServerStatusStarting, "")
+		runCtx, runCancel := context.WithCancel(ctx)
+		cli, err := m.connectManaged(runCtx, ms)
+		if err != nil {
+			runCancel()
+			markReady()
+			if ctx.Err() != nil {
+				ms.setStatus(ServerStatusStopped, "")
+				return
+			}
+			klog.V(6).Infof("MCP Server %s 连接失败，%s 后重试: %v", name, backoff, err)
+			ms.setStatus(ServerStatusBackoff, err.Error())
+			if !waitBackoff(ctx, ms, backoff) {
+				ms.setStatus(ServerStatusStopped, "")
+				return
+			}
+			backoff = min(backoff*2, backoffMax)
+			continue
+		}
+
+		backoff = backoffInitial
+		ms.setClient(cli)
+		ms.setStatus(ServerStatusRunning, "")
+		klog.V(6).Infof("MCP Server %s 已连接", name)
+		m.syncManaged(ctx, name)
+		markReady()
+
+		watchErr := m.watch(ctx, ms, cli)
+		ms.setClient(nil)
+		runCancel()
+		exitErr := cli.Close()
+		if ctx.Err() != nil {
+			ms.setStatus(ServerStatusStopped, "")
+			return
+		}
+
+		reason := watchErr.Error()
+		if exitErr != nil {
+			reason = fmt.Sprintf("%s, 进程退出: %v", reason, exitErr)
+		}
+		if ms.config.Type == models.MCPServerTypeStdio && !shouldRestart(ms.config.RestartPolicy, exitErr) {
+			klog.V(6).Infof("MCP Server %s 已断开，按重启策略[%s]不再重启: %s", name, ms.config.RestartPolicy, reason)
+			ms.setStatus(ServerStatusStopped, reason)
+			return
+		}
+
+		ms.mu.Lock()
+		ms.restarts++
+		ms.mu.Unlock()
+		klog.V(6).Infof("MCP Server %s 连接中断，%s 后重连: %s", name, backoff, reason)
+		ms.setStatus(ServerStatusBackoff, reason)
+		if !waitBackoff(ctx, ms, backoff) {
+			ms.setStatus(ServerStatusStopped, "")
+			return
+		}
+	}
+}
+
+// shouldRestart 根据重启策略判断 stdio 进程退出后是否重启，exitErr 为 nil 表示进程正常退出
+func shouldRestart(policy string, exitErr error) bool {
+	switch policy {
+	case models.RestartPolicyNever:
+		return false
+	case models.RestartPolicyAlways:
+		return true
+	default:
+		return exitErr != nil
+	}
+}
+
+// waitBackoff 退避等待，期间可被手动重连唤醒，ctx 结束时返回 false
+func waitBackoff(ctx context.Context, ms *managedServer, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-ms.wake:
+		return true
+	case <-timer.C:
+		return true
+	}
+}
+
+// watch 定期健康检查并处理能力变更通知，连接不健康时返回原因，ctx 结束时返回 nil
+func (m *MCPHost) watch(ctx context.Context, ms *managedServer, cli *client.Client) error {
+	threshold := healthFailThreshold
+	if ms.config.Type == models.MCPServerTypeStdio {
+		threshold = 1
+	}
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ms.syncCh:
+			m.syncManaged(ctx, ms.config.Name)
+		case <-ms.wake:
+			m.syncManaged(ctx, ms.config.Name)
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			err := cli.Ping(pingCtx)
+			cancel()
+			if err == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			klog.V(6).Infof("MCP Server %s 健康检查失败(%d/%d): %v", ms.config.Name, failures, threshold, err)
+			if failures >= threshold {
+				return fmt.Errorf("健康检查失败: %w", err)
+			}
+		}
+	}
+}
+
+// connectManaged 建立托管连接并注册能力变更通知
+func (m *MCPHost) connectManaged(ctx context.Context, ms *managedServer) (*client.Client, error) {
+	var cli *client.Client
+	var err error
+	if ms.config.Type == models.MCPServerTypeStdio {
+		cli, err = startStdioClient(ctx, ms)
+	} else {
+		// 监控连接以管理员身份建立，连接生命周期跟随 ctx
+		cli, err = m.GetClient(utils.GetContextWithAdminFromCtx(ctx), ms.config.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cli.OnNotification(func(notification mcp.JSONRPCNotification) {
+		switch notification.Method {
+		case mcp.MethodNotificationToolsListChanged,
+			mcp.MethodNotificationResourcesListChanged,
+			mcp.MethodNotificationPromptsListChanged:
+			klog.V(6).Infof("MCP Server %s 能力变更: %s", ms.config.Name, notification.Method)
+			select {
+			case ms.syncCh <- struct{}{}:
+			default:
+			}
+		}
+	})
+	return cli, nil
+}
+
+// startStdioClient 以子进程方式启动 MCP Server 并完成初始化，进程生命周期跟随 ctx
+func startStdioClient(ctx context.Context, ms *managedServer) (*client.Client, error) {
+	cfg := ms.config
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("MCP Server %s 未配置启动命令", cfg.Name)
+	}
+	args := utils.SplitAndTrim(cfg.Args, "\n")
+	env := utils.SplitAndTrim(cfg.Env, "\n")
+	t := transport.NewStdioWithOptions(cfg.Command, env, args,
+		transport.WithCommandFunc(func(ctx context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+			cmd := exec.CommandContext(ctx, command, args...)
+			cmd.Env = append(stdioBaseEnv(), env...)
+			cmd.Dir = cfg.WorkDir
+			return cmd, nil
+		}),
+	)
+	cli := client.NewClient(t)
+	if err := cli.Start(ctx); err != nil {
+		return nil, fmt.Errorf("启动进程失败: %w", err)
+	}
+	go drainStderr(ms, t)
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    "multi-server-client",
+		Version: "1.0.0",
+	}
+	initCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	if _, err := cli.Initialize(initCtx, initRequest); err != nil {
+		_ = cli.Close()
+		return nil, fmt.Errorf("初始化失败: %w", err)
+	}
+	return cli, nil
+}
+
+// stdioInheritEnv 子进程从 k8m 继承的环境变量。MCP Server 多为第三方代码，
+// 不能继承主密钥、数据库连接、JWT Secret 等敏感配置，其余变量需在 Server 配置中显式设置
+var stdioInheritEnv = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "LC_CTYPE", "TZ", "TMPDIR",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+func stdioBaseEnv() []string {
+	env := make([]string, 0, len(stdioInheritEnv))
+	for _, key := range stdioInheritEnv {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	return env
+}
+
+// drainStderr 持续读取子进程 stderr，避免管道写满阻塞进程，并记录最后一行便于排查
+func drainStderr(ms *managedServer, t *transport.Stdio) {
+	stderr := t.Stderr()
+	if stderr == nil {
+		return
+	}
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		klog.V(6).Infof("MCP Server %s stderr: %s", ms.config.Name, line)
+		ms.mu.Lock()
+		ms.lastError = line
+		ms.mu.Unlock()
+	}
+}
+
+// syncManaged 同步服务器能力，并将工具列表变化同步到工具表
+func (m *MCPHost) syncManaged(ctx context.Context, serverName string) {
+	syncCtx, cancel := context.WithTimeout(utils.GetContextWithAdminFromCtx(ctx), syncTimeout)
+	defer cancel()
+	if err := m.SyncServerCapabilities(syncCtx, serverName); err != nil {
+		klog.V(6).Infof("同步 MCP Server %s 能力失败: %v", serverName, err)
+		return
+	}
+	m.mutex.RLock()
+	tools := m.Tools[serverName]
+	m.mutex.RUnlock()
+	if err := syncToolRecords(serverName, tools); err != nil {
+		klog.V(6).Infof("同步 MCP Server %s 工具表失败: %v", serverName, err)
+	}
+}
+
+// syncToolRecords 按最新工具列表更新工具表：新增工具默认启用，已有工具保留启用状态，已下线的工具删除
+func syncToolRecords(serverName string, tools []mcp.Tool) error {
+	var existing []models.MCPTool
+	if err := dao.DB().Where("server_name = ?", serverName).Find(&existing).Error; err != nil {
+		return err
+	}
+	byName := make(map[string]models.MCPTool, len(existing))
+	for _, t := range existing {
+		byName[t.Name] = t
+	}
+
+	seen := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		seen[tool.Name] = true
+		record, ok := byName[tool.Name]
+		if !ok {
+			record = models.MCPTool{ServerName: serverName, Name: tool.Name, Enabled: true}
+		}
+		record.Description = tool.Description
+		record.InputSchema = utils.ToJSON(tool.InputSchema)
+		if err := dao.DB().Save(&record).Error; err != nil {
+			return err
+		}
+	}
+	for name, record := range byName {
+		if !seen[name] {
+			if err := dao.DB().Delete(&models.MCPTool{}, record.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
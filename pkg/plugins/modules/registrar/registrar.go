@@ -2,7 +2,9 @@ package registrar
 
 import (
 	"github.com/weibaohui/k8m/pkg/plugins"
+	access_request "github.com/weibaohui/k8m/pkg/plugins/modules/access_request"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/ai"
+	cert_monitor "github.com/weibaohui/k8m/pkg/plugins/modules/cert_monitor"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/demo"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/eventhandler"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/gatewayapi"
@@ -129,5 +131,15 @@ func init() {
 		} else {
 			klog.V(6).Infof("注册kubeconfig-export插件成功")
 		}
+		if err := m.Register(cert_monitor.Metadata); err != nil {
+			klog.V(6).Infof("注册cert-monitor插件失败: %v", err)
+		} else {
+			klog.V(6).Infof("注册cert-monitor插件成功")
+		}
+		if err := m.Register(access_request.Metadata); err != nil {
+			klog.V(6).Infof("注册access-request插件失败: %v", err)
+		} else {
+			klog.V(6).Infof("注册access-request插件成功")
+		}
 	})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/comm/utils/amis"
+	pkgmodels "github.com/weibaohui/k8m/pkg/models"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/webhook/core"
 	"github.com/weibaohui/k8m/pkg/plugins/modules/webhook/models"
 	"github.com/weibaohui/k8m/pkg/response"
@@ -72,6 +73,10 @@ func (s *Controller) WebhookList(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 签名密钥不回显
+	for _, item := range items {
+		item.SignSecret = pkgmodels.MaskSecret(item.SignSecret)
+	}
 	amis.WriteJsonListWithTotal(c, total, items)
 }
 
@@ -83,6 +88,15 @@ func (s *Controller) WebhookSave(c *response.Context) {
 		amis.WriteJsonError(c, err)
 		return
 	}
+	// 编辑时未修改签名密钥，沿用原值
+	if m.ID > 0 && m.SignSecret == pkgmodels.SecretMask {
+		old, err := (&models.WebhookReceiver{ID: m.ID}).GetOne(params)
+		if err != nil {
+			amis.WriteJsonError(c, err)
+			return
+		}
+		m.SignSecret = old.SignSecret
+	}
 	err = m.Save(params)
 	if err != nil {
 		amis.WriteJsonError(c, err)
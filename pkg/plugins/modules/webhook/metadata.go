@@ -10,7 +10,7 @@ var Metadata = plugins.Module{
 	Meta: plugins.Meta{
 		Name:        modules.PluginNameWebhook,
 		Title:       "Webhook插件",
-		Version:     "1.0.0",
+		Version:     "1.1.0",
 		Description: "Webhook接收器管理、测试发送与发送记录查询",
 	},
 	Tables: []string{
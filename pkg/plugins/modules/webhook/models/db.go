@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/models"
 	"k8s.io/klog/v2"
 )
 
@@ -17,6 +18,11 @@ func UpgradeDB(fromVersion string, toVersion string) error {
 		klog.V(6).Infof("自动迁移webhook插件数据库失败: %v", err)
 		return err
 	}
+	// 历史数据中的签名密钥为明文，升级时加密
+	if err := models.MigrateSecretFields(&WebhookReceiver{}); err != nil {
+		klog.V(6).Infof("加密webhook签名密钥失败: %v", err)
+		return err
+	}
 	klog.V(6).Infof("升级webhook插件数据库完成")
 	return nil
 }
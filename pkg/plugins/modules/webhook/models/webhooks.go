@@ -5,16 +5,21 @@ import (
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
 	"gorm.io/gorm"
 )
 
+func init() {
+	models.RegisterSecretFields(&WebhookReceiver{}, "SignSecret")
+}
+
 type WebhookReceiver struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id,omitempty"`
 	Name         string    `gorm:"size:255;uniqueIndex:idx_webhook_receiver_name" json:"name,omitempty"` // webhook名称
 	Platform     string    `gorm:"size:50" json:"platform,omitempty"`                    // feishu,dingtalk
 	TargetURL    string    `gorm:"size:255" json:"target_url,omitempty"`
 	BodyTemplate string    `gorm:"type:text" json:"body_template,omitempty"` // 发送到webhook的body模板
-	SignSecret   string    `gorm:"size:255" json:"sign_secret,omitempty"`
+	SignSecret   string    `gorm:"size:255" json:"sign_secret,omitempty"` // 签名密钥，加密存储
 	CreatedAt    time.Time `json:"created_at,omitempty" gorm:"<-:create"`
 	UpdatedAt    time.Time `json:"updated_at,omitempty"` // Automatically managed by GORM for update time
 }
@@ -35,6 +40,21 @@ func (c *WebhookReceiver) GetOne(params *dao.Params, queryFuncs ...func(*gorm.DB
 	return dao.GenericGetOne(params, c, queryFuncs...)
 }
 
+// BeforeSave 在保存前加密签名密钥
+func (c *WebhookReceiver) BeforeSave(tx *gorm.DB) error {
+	return models.EncryptSecretFields(c)
+}
+
+// AfterSave 保存后恢复明文，调用方可继续使用
+func (c *WebhookReceiver) AfterSave(tx *gorm.DB) error {
+	return models.DecryptSecretFields(c)
+}
+
+// AfterFind 在查询后解密签名密钥
+func (c *WebhookReceiver) AfterFind(tx *gorm.DB) error {
+	return models.DecryptSecretFields(c)
+}
+
 // GetNamesByIds 根据webhook ID列表获取对应的名称列表
 func (c *WebhookReceiver) GetNamesByIds(ids []string) ([]string, error) {
 	receivers, _, err := c.List(dao.BuildDefaultParams(), func(db *gorm.DB) *gorm.DB {
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/audit"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/klog/v2"
+)
+
+// auditSpoolDir 审计队列根目录，位于数据库文件所在目录下，每个目标一个子目录
+const auditSpoolDir = "audit-spool"
+
+type auditForwarder struct {
+	*audit.Forwarder
+	filter audit.Filter
+}
+
+type auditService struct {
+	reloadMu   sync.Mutex // 串行执行 Reload
+	mu         sync.RWMutex
+	forwarders map[uint]*auditForwarder
+	spools     map[uint]*audit.Spool // 启用目标的本地队列，重建转发器时保留，写入事件不受影响
+}
+
+var auditSvc = &auditService{forwarders: map[uint]*auditForwarder{}, spools: map[uint]*audit.Spool{}}
+
+// AuditService 审计日志转发服务
+func AuditService() *auditService {
+	return auditSvc
+}
+
+// Start 启动已启用的转发目标
+func (a *auditService) Start() {
+	if err := a.Reload(); err != nil {
+		klog.Errorf("加载审计日志转发目标失败: %v", err)
+	}
+}
+
+// Reload 按数据库中的配置重建转发目标，目标配置变更后调用。
+// 停用的目标保留未发送的队列，启用后继续发送；已删除目标的队列一并清理。
+// 各目标的队列在重建期间保持打开，写入事件不等待旧转发器停止
+func (a *auditService) Reload() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	var sinks []*models.AuditSink
+	if err := dao.DB().Order("id asc").Find(&sinks).Error; err != nil {
+		return err
+	}
+
+	// 在锁外创建新的目标，连接目标可能较慢。a.spools 只在持有 reloadMu 时修改，此处可直接读取
+	exists := map[string]bool{}
+	forwarders := map[uint]*auditForwarder{}
+	spools := map[uint]*audit.Spool{}
+	var errs []error
+	for _, m := range sinks {
+		exists[strconv.Itoa(int(m.ID))] = true
+		if !m.Enabled {
+			continue
+		}
+		spool := a.spools[m.ID]
+		if spool != nil {
+			spool.SetMaxMB(m.SpoolMaxMB)
+		} else {
+			var err error
+			spool, err = audit.OpenSpool(filepath.Join(a.spoolRoot(), strconv.Itoa(int(m.ID))), m.SpoolMaxMB)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("审计目标[%s]: %w", m.Name, err))
+				continue
+			}
+		}
+		spools[m.ID] = spool
+		sink, err := NewAuditSink(m)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("审计目标[%s]: %w", m.Name, err))
+			continue
+		}
+		forwarders[m.ID] = &auditForwarder{Forwarder: audit.NewForwarder(sink, spool), filter: audit.NewFilter(m.EventTypes, m.Actions)}
+	}
+
+	a.mu.Lock()
+	oldForwarders, oldSpools := a.forwarders, a.spools
+	a.forwarders, a.spools = forwarders, spools
+	a.mu.Unlock()
+
+	// 旧转发器可能正在等待目标响应，在锁外停止；期间写入的事件留在队列中，由新转发器继续发送
+	for _, f := range oldForwarders {
+		f.Stop()
+	}
+	for id, spool := range oldSpools {
+		if spools[id] != spool {
+			_ = spool.Close()
+		}
+	}
+	for _, f := range forwarders {
+		f.Start()
+	}
+
+	entries, _ := os.ReadDir(a.spoolRoot())
+	for _, entry := range entries {
+		if entry.IsDir() && !exists[entry.Name()] {
+			_ = os.RemoveAll(filepath.Join(a.spoolRoot(), entry.Name()))
+		}
+	}
+	klog.V(6).Infof("已加载 %d 个审计日志转发目标", len(forwarders))
+	return errors.Join(errs...)
+}
+
+func (a *auditService) spoolRoot() string {
+	return filepath.Join(filepath.Dir(flag.Init().SqlitePath), auditSpoolDir)
+}
+
+// NewAuditSink 按配置创建发送目标
+func NewAuditSink(m *models.AuditSink) (audit.Sink, error) {
+	switch m.Type {
+	case audit.SinkSyslog:
+		return audit.NewSyslogSink(audit.SyslogConfig{
+			Address:            m.Address,
+			Protocol:           m.Protocol,
+			Facility:           m.Facility,
+			AppName:            m.AppName,
+			CACert:             m.CACert,
+			InsecureSkipVerify: m.InsecureSkipVerify,
+		})
+	case audit.SinkFile:
+		return audit.NewFileSink(audit.FileConfig{Path: m.FilePath, MaxSizeMB: m.MaxSizeMB, MaxBackups: m.MaxBackups})
+	case audit.SinkOTLP:
+		return audit.NewOTLPSink(audit.OTLPConfig{
+			Endpoint:           m.Endpoint,
+			Headers:            audit.ParseHeaders(m.Headers),
+			CACert:             m.CACert,
+			InsecureSkipVerify: m.InsecureSkipVerify,
+		})
+	}
+	return nil, fmt.Errorf("不支持的审计目标类型: %s", m.Type)
+}
+
+// Test 直接向目标发送一条测试事件，不经过队列
+func (a *auditService) Test(m *models.AuditSink, username string) error {
+	sink, err := NewAuditSink(m)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+	return sink.Write([]*audit.Event{{
+		Time:    time.Now(),
+		Type:    audit.EventOperation,
+		Action:  "test",
+		User:    username,
+		Result:  audit.ResultSuccess,
+		Message: "k8m 审计日志转发测试",
+	}})
+}
+
+// Status 各启用目标的发送状态
+func (a *auditService) Status() map[uint]audit.Status {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	status := make(map[uint]audit.Status, len(a.forwarders))
+	for id, f := range a.forwarders {
+		status[id] = f.Status()
+	}
+	return status
+}
+
+// Emit 将事件写入匹配目标的本地队列，由各目标的转发协程异步发送
+func (a *auditService) Emit(e *audit.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var data []byte
+	for id, f := range a.forwarders {
+		if !f.filter.Match(e) {
+			continue
+		}
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(e); err != nil {
+				klog.Errorf("序列化审计事件失败: %v", err)
+				return
+			}
+		}
+		if err := f.Spool().Append(data); err != nil {
+			klog.Errorf("审计目标[%d]写入队列失败: %v", id, err)
+		}
+	}
+}
+
+// EmitLogin 记录登录事件，err 为空表示登录成功
+func (a *auditService) EmitLogin(r *http.Request, username, method string, err error) {
+	e := &audit.Event{
+		Type:     audit.EventLogin,
+		Action:   method,
+		User:     username,
+		ClientIP: clientIP(r),
+		Result:   audit.ResultSuccess,
+		Detail:   truncate(r.UserAgent(), 512),
+	}
+	if err != nil {
+		e.Result = audit.ResultFailure
+		if errors.Is(err, ErrAccountLocked) {
+			e.Result = audit.ResultDenied
+		}
+		e.Message = err.Error()
+	}
+	a.Emit(e)
+}
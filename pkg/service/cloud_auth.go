@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/jwt"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+)
+
+// 云厂商集群（GKE/AKS）使用原生认证：纳管时调用云厂商 API 获取集群地址与 CA 生成 kubeconfig，
+// 连接时使用保存的凭据获取访问令牌，令牌在过期前自动续期
+
+const (
+	CloudProviderGKE = "gke"
+	CloudProviderAKS = "aks"
+)
+
+const (
+	gkeDefaultTokenURL = "https://oauth2.googleapis.com/token"
+	gkeScope           = "https://www.googleapis.com/auth/cloud-platform"
+	gkeAPIEndpoint     = "https://container.googleapis.com/v1"
+
+	azureLoginEndpoint = "https://login.microsoftonline.com"
+	azureARMEndpoint   = "https://management.azure.com"
+	azureARMScope      = "https://management.azure.com/.default"
+	// aksServerScope AKS 托管 Azure AD 集成的服务端应用 ID，对所有 AKS 集群相同
+	aksServerScope    = "6dae42f8-4368-4678-94ff-3960e28e3630/.default"
+	aksAPIVersion     = "2024-02-01"
+	cloudAPITimeout   = 30 * time.Second
+	cloudTokenRenewal = 5 * time.Minute // 令牌在过期前提前续期的时间
+)
+
+// GKEAuthConfig GKE 集群认证配置，使用服务账号 JSON 密钥
+type GKEAuthConfig struct {
+	ServiceAccountJSON string `json:"serviceAccountJson"`
+	ProjectID          string `json:"projectId"`   // 为空时取服务账号所属项目
+	Location           string `json:"location"`    // 集群所在区域或可用区，例如 asia-east1、asia-east1-a
+	ClusterName        string `json:"clusterName"` // GKE 集群名称
+}
+
+// AKSAuthConfig AKS 集群认证配置，使用 Azure AD 服务主体
+type AKSAuthConfig struct {
+	TenantID       string `json:"tenantId"`
+	ClientID       string `json:"clientId"`
+	ClientSecret   string `json:"clientSecret"`
+	SubscriptionID string `json:"subscriptionId"`
+	ResourceGroup  string `json:"resourceGroup"`
+	ClusterName    string `json:"clusterName"` // AKS 集群名称
+}
+
+// gkeServiceAccount 服务账号 JSON 密钥中用到的字段
+type gkeServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// validateGKEConfig 验证GKE配置，项目ID为空时从服务账号中补全
+func (c *clusterService) validateGKEConfig(config *GKEAuthConfig) error {
+	if config == nil {
+		return fmt.Errorf("GKE配置不能为空")
+	}
+	sa, err := parseGKEServiceAccount(config.ServiceAccountJSON)
+	if err != nil {
+		return err
+	}
+	if config.ProjectID == "" {
+		config.ProjectID = sa.ProjectID
+	}
+	if config.ProjectID == "" {
+		return fmt.Errorf("GCP项目ID不能为空")
+	}
+	if config.Location == "" {
+		return fmt.Errorf("GKE集群区域不能为空")
+	}
+	if config.ClusterName == "" {
+		return fmt.Errorf("GKE集群名称不能为空")
+	}
+	return nil
+}
+
+// validateAKSConfig 验证AKS配置
+func (c *clusterService) validateAKSConfig(config *AKSAuthConfig) error {
+	if config == nil {
+		return fmt.Errorf("AKS配置不能为空")
+	}
+	if config.TenantID == "" {
+		return fmt.Errorf("Azure租户ID不能为空")
+	}
+	if config.ClientID == "" {
+		return fmt.Errorf("服务主体Client ID不能为空")
+	}
+	if config.ClientSecret == "" {
+		return fmt.Errorf("服务主体Client Secret不能为空")
+	}
+	if config.SubscriptionID == "" {
+		return fmt.Errorf("Azure订阅ID不能为空")
+	}
+	if config.ResourceGroup == "" {
+		return fmt.Errorf("资源组不能为空")
+	}
+	if config.ClusterName == "" {
+		return fmt.Errorf("AKS集群名称不能为空")
+	}
+	return nil
+}
+
+func parseGKEServiceAccount(content string) (*gkeServiceAccount, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("服务账号JSON不能为空")
+	}
+	var sa gkeServiceAccount
+	if err := json.Unmarshal([]byte(content), &sa); err != nil {
+		return nil, fmt.Errorf("解析服务账号JSON失败: %w", err)
+	}
+	if sa.Type != "service_account" {
+		return nil, fmt.Errorf("仅支持服务账号类型的JSON密钥，当前类型为[%s]", sa.Type)
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("服务账号JSON缺少 client_email 或 private_key")
+	}
+	return &sa, nil
+}
+
+// gkeTokenSource 使用服务账号签发 JWT 换取访问令牌，GKE 直接接受该令牌访问 API Server
+func gkeTokenSource(config *GKEAuthConfig) (oauth2.TokenSource, error) {
+	sa, err := parseGKEServiceAccount(config.ServiceAccountJSON)
+	if err != nil {
+		return nil, err
+	}
+	tokenURL := sa.TokenURI
+	if tokenURL == "" {
+		tokenURL = gkeDefaultTokenURL
+	}
+	jc := &jwt.Config{
+		Email:        sa.ClientEmail,
+		PrivateKey:   []byte(sa.PrivateKey),
+		PrivateKeyID: sa.PrivateKeyID,
+		TokenURL:     tokenURL,
+		Scopes:       []string{gkeScope},
+	}
+	return jc.TokenSource(context.Background()), nil
+}
+
+// aksTokenSource 使用服务主体以客户端凭据方式获取 Azure AD 令牌
+func aksTokenSource(config *AKSAuthConfig, scope string) oauth2.TokenSource {
+	cc := &clientcredentials.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		TokenURL:     fmt.Sprintf("%s/%s/oauth2/v2.0/token", azureLoginEndpoint, url.PathEscape(config.TenantID)),
+		Scopes:       []string{scope},
+	}
+	return cc.TokenSource(context.Background())
+}
+
+// NewCloudTokenSource 根据保存的云厂商认证配置创建访问集群用的令牌源，令牌在过期前自动续期
+func NewCloudTokenSource(provider string, credential string) (oauth2.TokenSource, error) {
+	var ts oauth2.TokenSource
+	switch provider {
+	case CloudProviderGKE:
+		var config GKEAuthConfig
+		if err := json.Unmarshal([]byte(credential), &config); err != nil {
+			return nil, fmt.Errorf("解析GKE认证配置失败: %w", err)
+		}
+		src, err := gkeTokenSource(&config)
+		if err != nil {
+			return nil, err
+		}
+		ts = src
+	case CloudProviderAKS:
+		var config AKSAuthConfig
+		if err := json.Unmarshal([]byte(credential), &config); err != nil {
+			return nil, fmt.Errorf("解析AKS认证配置失败: %w", err)
+		}
+		ts = aksTokenSource(&config, aksServerScope)
+	default:
+		return nil, fmt.Errorf("不支持的云厂商类型[%s]", provider)
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, ts, cloudTokenRenewal), nil
+}
+
+// applyCloudAuth 为云厂商集群的 rest.Config 注入令牌，每次请求时从集群当前的令牌源获取令牌，
+// 令牌源按需续期；认证配置更新后无需重新连接即使用新凭据
+func (c *clusterService) applyCloudAuth(config *ClusterConfig) error {
+	// 先获取一次令牌，凭据错误时尽早暴露
+	if _, err := (cloudClusterTokenSource{config}).Token(); err != nil {
+		klog.V(4).Infof("获取云厂商集群[%s]令牌失败: %v", config.GetClusterID(), err)
+		return fmt.Errorf("获取%s集群访问令牌失败: %w", strings.ToUpper(config.CloudProvider), err)
+	}
+	config.restConfig.BearerToken = ""
+	config.restConfig.BearerTokenFile = ""
+	config.restConfig.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return &oauth2.Transport{Source: cloudClusterTokenSource{config}, Base: rt}
+	}
+	klog.V(6).Infof("成功为云厂商集群[%s]设置令牌认证", config.GetClusterID())
+	return nil
+}
+
+// cloudClusterTokenSource 从集群当前的令牌源获取令牌
+type cloudClusterTokenSource struct {
+	config *ClusterConfig
+}
+
+func (s cloudClusterTokenSource) Token() (*oauth2.Token, error) {
+	ts, err := s.config.cloudTokenSource()
+	if err != nil {
+		return nil, err
+	}
+	return ts.Token()
+}
+
+// cloudTokenSource 返回集群当前的令牌源，认证配置更新后首次调用时重新创建
+func (c *ClusterConfig) cloudTokenSource() (oauth2.TokenSource, error) {
+	c.cloudMu.Lock()
+	defer c.cloudMu.Unlock()
+	if c.tokenSource == nil {
+		ts, err := NewCloudTokenSource(c.CloudProvider, c.cloudCredential)
+		if err != nil {
+			return nil, err
+		}
+		c.tokenSource = ts
+	}
+	return c.tokenSource, nil
+}
+
+// setCloudCredential 更新认证配置并丢弃旧的令牌源，配置未变化时返回 false
+func (c *ClusterConfig) setCloudCredential(credential string) bool {
+	c.cloudMu.Lock()
+	defer c.cloudMu.Unlock()
+	if c.cloudCredential == credential {
+		return false
+	}
+	c.cloudCredential = credential
+	c.tokenSource = nil
+	return true
+}
+
+// GenerateGKEKubeconfig 调用 GKE API 获取集群地址与 CA，生成不含凭据的 kubeconfig
+func (c *clusterService) GenerateGKEKubeconfig(config *GKEAuthConfig) (string, error) {
+	if err := c.validateGKEConfig(config); err != nil {
+		return "", fmt.Errorf("GKE配置验证失败: %w", err)
+	}
+	ts, err := gkeTokenSource(config)
+	if err != nil {
+		return "", err
+	}
+
+	api := fmt.Sprintf("%s/projects/%s/locations/%s/clusters/%s", gkeAPIEndpoint,
+		url.PathEscape(config.ProjectID), url.PathEscape(config.Location), url.PathEscape(config.ClusterName))
+	var cluster struct {
+		Endpoint   string `json:"endpoint"`
+		MasterAuth struct {
+			ClusterCaCertificate string `json:"clusterCaCertificate"`
+		} `json:"masterAuth"`
+	}
+	if err := cloudAPICall(ts, http.MethodGet, api, &cluster); err != nil {
+		return "", fmt.Errorf("查询GKE集群信息失败: %w", err)
+	}
+	if cluster.Endpoint == "" {
+		return "", fmt.Errorf("GKE集群[%s]未返回访问地址", config.ClusterName)
+	}
+	ca, err := base64.StdEncoding.DecodeString(cluster.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return "", fmt.Errorf("解析GKE集群CA证书失败: %w", err)
+	}
+
+	// 与 gcloud 生成的 context 名称保持一致
+	name := fmt.Sprintf("gke_%s_%s_%s", config.ProjectID, config.Location, config.ClusterName)
+	return buildCloudKubeconfig(name, "https://"+cluster.Endpoint, ca)
+}
+
+// GenerateAKSKubeconfig 调用 Azure ARM API 获取集群地址与 CA，生成不含凭据的 kubeconfig
+// 集群需启用 Azure AD 集成，服务主体需具备读取集群凭据及访问集群的权限
+func (c *clusterService) GenerateAKSKubeconfig(config *AKSAuthConfig) (string, error) {
+	if err := c.validateAKSConfig(config); err != nil {
+		return "", fmt.Errorf("AKS配置验证失败: %w", err)
+	}
+
+	api := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s/listClusterUserCredential?api-version=%s",
+		azureARMEndpoint, url.PathEscape(config.SubscriptionID), url.PathEscape(config.ResourceGroup), url.PathEscape(config.ClusterName), aksAPIVersion)
+	var result struct {
+		Kubeconfigs []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"kubeconfigs"`
+	}
+	if err := cloudAPICall(aksTokenSource(config, azureARMScope), http.MethodPost, api, &result); err != nil {
+		return "", fmt.Errorf("查询AKS集群凭据失败: %w", err)
+	}
+	if len(result.Kubeconfigs) == 0 {
+		return "", fmt.Errorf("AKS集群[%s]未返回kubeconfig", config.ClusterName)
+	}
+	content, err := base64.StdEncoding.DecodeString(result.Kubeconfigs[0].Value)
+	if err != nil {
+		return "", fmt.Errorf("解析AKS集群kubeconfig失败: %w", err)
+	}
+	kc, err := clientcmd.Load(content)
+	if err != nil {
+		return "", fmt.Errorf("解析AKS集群kubeconfig失败: %w", err)
+	}
+
+	// 只取集群地址与 CA，用户凭据改由服务主体令牌提供
+	for _, cluster := range kc.Clusters {
+		return buildCloudKubeconfig(config.ClusterName, cluster.Server, cluster.CertificateAuthorityData)
+	}
+	return "", fmt.Errorf("AKS集群[%s]的kubeconfig中没有集群信息", config.ClusterName)
+}
+
+// buildCloudKubeconfig 生成仅包含集群地址与 CA 的 kubeconfig，访问令牌在连接时注入
+func buildCloudKubeconfig(name string, server string, ca []byte) (string, error) {
+	kc := clientcmdapi.NewConfig()
+	kc.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: ca,
+	}
+	kc.AuthInfos[name] = &clientcmdapi.AuthInfo{}
+	kc.Contexts[name] = &clientcmdapi.Context{
+		Cluster:  name,
+		AuthInfo: name,
+	}
+	kc.CurrentContext = name
+	content, err := clientcmd.Write(*kc)
+	if err != nil {
+		return "", fmt.Errorf("生成kubeconfig失败: %w", err)
+	}
+	return string(content), nil
+}
+
+// cloudAPICall 携带令牌调用云厂商 API，并将 JSON 响应解析到 out
+func cloudAPICall(ts oauth2.TokenSource, method string, api string, out any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cloudAPITimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, api, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := oauth2.NewClient(ctx, ts).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/hashicorp/yamux"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/agent"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// agentClusterFileName Agent 集群的 FileName，集群ID为 Agent/<名称>
+const agentClusterFileName = "Agent"
+
+type agentService struct {
+	sessions sync.Map // Agent 名称 -> *agentSession
+}
+
+type agentSession struct {
+	session    *yamux.Session
+	remoteAddr string
+}
+
+// GenerateToken 为 Agent 生成新的注册 Token，旧 Token 立即失效，Token 仅返回这一次
+func (a *agentService) GenerateToken(id uint) (*models.ClusterAgent, string, error) {
+	var item models.ClusterAgent
+	if err := dao.DB().First(&item, id).Error; err != nil {
+		return nil, "", fmt.Errorf("Agent 不存在: %w", err)
+	}
+	token := "k8ma-" + utils.RandNLengthString(40)
+	item.TokenHash = hashAgentToken(token)
+	item.TokenPrefix = token[:10]
+	item.ConnectedAt = nil
+	item.ExpiresAt = nil
+	if item.TokenTTLHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(item.TokenTTLHours) * time.Hour)
+		item.ExpiresAt = &expiresAt
+	}
+	err := dao.DB().Model(&item).Select("token_hash", "token_prefix", "connected_at", "expires_at").Updates(&item).Error
+	if err != nil {
+		return nil, "", err
+	}
+	// 断开使用旧 Token 建立的隧道
+	a.Close(item.Name)
+	return &item, token, nil
+}
+
+// Authenticate 校验 Agent 携带的注册 Token
+func (a *agentService) Authenticate(token string) (*models.ClusterAgent, error) {
+	if token == "" {
+		return nil, fmt.Errorf("缺少注册 Token")
+	}
+	var item models.ClusterAgent
+	if err := dao.DB().Where("token_hash = ?", hashAgentToken(token)).First(&item).Error; err != nil {
+		return nil, fmt.Errorf("注册 Token 无效")
+	}
+	if item.Expired() {
+		return nil, fmt.Errorf("注册 Token 已过期，请重新生成")
+	}
+	return &item, nil
+}
+
+// Attach 接管 Agent 建立的连接，在其上创建多路复用会话，并连接对应集群。
+// 同名 Agent 重复连接时，旧的会话会被关闭
+func (a *agentService) Attach(item *models.ClusterAgent, conn net.Conn, remoteAddr, version string) error {
+	session, err := yamux.Client(conn, agent.YamuxConfig())
+	if err != nil {
+		return err
+	}
+	s := &agentSession{session: session, remoteAddr: remoteAddr}
+	if old, ok := a.sessions.Swap(item.Name, s); ok {
+		klog.V(6).Infof("Agent[%s] 重复连接，关闭旧隧道 %s", item.Name, old.(*agentSession).remoteAddr)
+		_ = old.(*agentSession).session.Close()
+	}
+
+	now := time.Now()
+	dao.DB().Model(&models.ClusterAgent{}).Where("id = ?", item.ID).Updates(map[string]any{
+		"status":            models.ClusterAgentStatusOnline,
+		"agent_version":     version,
+		"remote_addr":       remoteAddr,
+		"connected_at":      &now,
+		"last_heartbeat_at": &now,
+	})
+	klog.V(4).Infof("Agent[%s] 已连接，来源 %s，版本 %s", item.Name, remoteAddr, version)
+
+	go a.acceptStreams(item, s)
+	go a.waitClose(item, s)
+
+	clusterID := agentClusterID(item.Name)
+	if ClusterService().GetClusterByID(clusterID) == nil {
+		ClusterService().AddToClusterList(newAgentClusterConfig(item.Name))
+	}
+	go ClusterService().Connect(clusterID)
+	return nil
+}
+
+// acceptStreams 接收 Agent 打开的心跳流
+func (a *agentService) acceptStreams(item *models.ClusterAgent, s *agentSession) {
+	for {
+		stream, err := s.session.AcceptStream()
+		if err != nil {
+			return
+		}
+		go a.readHeartbeats(item, s, stream)
+	}
+}
+
+// readHeartbeats 读取心跳并记录，超时未收到心跳时关闭隧道
+func (a *agentService) readHeartbeats(item *models.ClusterAgent, s *agentSession, stream *yamux.Stream) {
+	defer stream.Close()
+	dec := json.NewDecoder(stream)
+	for {
+		_ = stream.SetReadDeadline(time.Now().Add(agent.HeartbeatTimeout))
+		var hb agent.Heartbeat
+		if err := dec.Decode(&hb); err != nil {
+			if !s.session.IsClosed() {
+				klog.V(4).Infof("Agent[%s] 心跳中断: %v，关闭隧道", item.Name, err)
+				_ = s.session.Close()
+			}
+			return
+		}
+		now := time.Now()
+		dao.DB().Model(&models.ClusterAgent{}).Where("id = ?", item.ID).Updates(map[string]any{
+			"last_heartbeat_at": &now,
+			"kube_version":      hb.KubeVersion,
+		})
+	}
+}
+
+// waitClose 隧道断开后标记 Agent 离线并断开集群
+func (a *agentService) waitClose(item *models.ClusterAgent, s *agentSession) {
+	<-s.session.CloseChan()
+	// 已被新的连接替换时，不影响集群状态
+	if !a.sessions.CompareAndDelete(item.Name, s) {
+		return
+	}
+	klog.V(4).Infof("Agent[%s] 隧道已断开", item.Name)
+	dao.DB().Model(&models.ClusterAgent{}).Where("id = ?", item.ID).Update("status", models.ClusterAgentStatusOffline)
+	ClusterService().Disconnect(agentClusterID(item.Name))
+}
+
+// Close 关闭 Agent 的隧道
+func (a *agentService) Close(name string) {
+	if v, ok := a.sessions.Load(name); ok {
+		_ = v.(*agentSession).session.Close()
+	}
+}
+
+// IsOnline 判断 Agent 隧道是否已建立
+func (a *agentService) IsOnline(name string) bool {
+	_, ok := a.sessions.Load(name)
+	return ok
+}
+
+// RestConfig 构建经由 Agent 隧道访问 API Server 的配置，每个请求连接对应隧道中的一个流。
+// 认证由 Agent 使用集群内 ServiceAccount 完成，k8m 侧无需凭据
+func (a *agentService) RestConfig(name string) (*rest.Config, error) {
+	if !a.IsOnline(name) {
+		return nil, fmt.Errorf("Agent[%s]未连接", name)
+	}
+	return &rest.Config{
+		Host: "http://k8m-agent",
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			v, ok := a.sessions.Load(name)
+			if !ok {
+				return nil, fmt.Errorf("Agent[%s]未连接", name)
+			}
+			return v.(*agentSession).session.Open()
+		},
+	}, nil
+}
+
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func agentClusterID(name string) string {
+	return agentClusterFileName + "/" + name
+}
+
+func newAgentClusterConfig(name string) *ClusterConfig {
+	return &ClusterConfig{
+		FileName:             agentClusterFileName,
+		ContextName:          name,
+		ClusterName:          name,
+		Server:               "agent://" + name,
+		ClusterConnectStatus: constants.ClusterConnectStatusDisconnected,
+		Source:               ClusterConfigSourceAgent,
+	}
+}
+
+// ScanClustersInAgent 将数据库中的 Agent 同步到集群列表，已删除的 Agent 断开并移除
+func (c *clusterService) ScanClustersInAgent() {
+	var list []*models.ClusterAgent
+	if err := dao.DB().Model(&models.ClusterAgent{}).Find(&list).Error; err != nil {
+		klog.Errorf("查询 Agent 失败: %v", err)
+		return
+	}
+	names := make(map[string]bool, len(list))
+	for _, item := range list {
+		names[item.Name] = true
+		c.AddToClusterList(newAgentClusterConfig(item.Name))
+	}
+	for _, cc := range c.AllClusters() {
+		if cc.Source == ClusterConfigSourceAgent && !names[cc.ContextName] {
+			AgentService().Close(cc.ContextName)
+			c.Disconnect(cc.GetClusterID())
+		}
+	}
+	c.clusterConfigs = slice.Filter(c.clusterConfigs, func(index int, cc *ClusterConfig) bool {
+		return cc.Source != ClusterConfigSourceAgent || names[cc.ContextName]
+	})
+}
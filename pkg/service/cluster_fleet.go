@@ -0,0 +1,65 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	FleetStatusSuccess = "success"
+	FleetStatusFailed  = "failed"
+	FleetStatusSkipped = "skipped"
+)
+
+// fleetConcurrency 批量操作同时执行的集群数量
+const fleetConcurrency = 5
+
+// FleetResult 批量操作在单个集群上的执行结果
+type FleetResult struct {
+	Cluster  string `json:"cluster"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	Data     any    `json:"data,omitempty"`
+	Duration int64  `json:"duration"` // 耗时，单位毫秒
+}
+
+// RunOnClusters 在标签选择器匹配的集群上并发执行操作，结果按集群汇总，顺序与集群列表一致。
+// 未连接的集群不执行，标记为跳过
+func (c *clusterService) RunOnClusters(selector string, fn func(cluster string) (any, error)) ([]*FleetResult, error) {
+	clusters, err := c.MatchClusters(selector)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*FleetResult, len(clusters))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fleetConcurrency)
+	for i, cc := range clusters {
+		clusterID := cc.GetClusterID()
+		if !c.IsConnected(clusterID) {
+			results[i] = &FleetResult{Cluster: clusterID, Status: FleetStatusSkipped, Message: "集群未连接"}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, clusterID string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			start := time.Now()
+			data, err := fn(clusterID)
+			r := &FleetResult{Cluster: clusterID, Status: FleetStatusSuccess, Data: data}
+			if err != nil {
+				klog.V(6).Infof("批量操作 集群[%s]执行失败: %v", clusterID, err)
+				r.Status = FleetStatusFailed
+				r.Message = err.Error()
+			}
+			r.Duration = time.Since(start).Milliseconds()
+			results[i] = r
+		}(i, clusterID)
+	}
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// ParseClusterLabels 解析形如 env=prod,region=eu 的集群标签，键值需符合 Kubernetes 标签规范
+func ParseClusterLabels(s string) (labels.Set, error) {
+	if strings.TrimSpace(s) == "" {
+		return labels.Set{}, nil
+	}
+	set, err := labels.ConvertSelectorToLabelsMap(s)
+	if err != nil {
+		return nil, fmt.Errorf("非法的集群标签 %q: %w", s, err)
+	}
+	return set, nil
+}
+
+// ParseClusterSelector 解析集群标签选择器，语法与 Kubernetes 标签选择器一致，
+// 如 env=prod,region in (eu,us),!deprecated。为避免误操作全部集群，选择器不能为空
+func ParseClusterSelector(selector string) (labels.Selector, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("非法的标签选择器 %q: %w", selector, err)
+	}
+	if sel.Empty() {
+		return nil, fmt.Errorf("标签选择器不能为空")
+	}
+	return sel, nil
+}
+
+// ClusterLabels 获取全部集群的标签，key 为集群ID
+func (c *clusterService) ClusterLabels() map[string]labels.Set {
+	result := make(map[string]labels.Set)
+	var list []*models.ClusterLabel
+	if err := dao.DB().Model(&models.ClusterLabel{}).Find(&list).Error; err != nil {
+		klog.Errorf("查询集群标签失败: %v", err)
+		return result
+	}
+	for _, item := range list {
+		set, err := ParseClusterLabels(item.Labels)
+		if err != nil {
+			klog.V(6).Infof("集群[%s]标签解析失败: %v", item.Cluster, err)
+			continue
+		}
+		result[item.Cluster] = set
+	}
+	return result
+}
+
+// SaveClusterLabels 保存集群标签，标签为空时删除记录。
+// 标签变化会影响按分组授权的展开结果，因此同时清除集群授权缓存
+func (c *clusterService) SaveClusterLabels(clusterID string, labelStr string, username string) error {
+	set, err := ParseClusterLabels(labelStr)
+	if err != nil {
+		return err
+	}
+	defer UserService().ClearCacheByKey("cluster")
+
+	if len(set) == 0 {
+		return dao.DB().Where("cluster = ?", clusterID).Delete(&models.ClusterLabel{}).Error
+	}
+	var item models.ClusterLabel
+	err = dao.DB().Where("cluster = ?", clusterID).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dao.DB().Create(&models.ClusterLabel{
+			Cluster:   clusterID,
+			Labels:    set.String(),
+			CreatedBy: username,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	return dao.DB().Model(&item).Update("labels", set.String()).Error
+}
+
+// MatchClusters 获取标签选择器匹配的集群，包含未连接的集群
+func (c *clusterService) MatchClusters(selector string) ([]*ClusterConfig, error) {
+	sel, err := ParseClusterSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	all := c.ClusterLabels()
+	var result []*ClusterConfig
+	for _, cc := range c.AllClusters() {
+		if sel.Matches(all[cc.GetClusterID()]) {
+			result = append(result, cc)
+		}
+	}
+	return result, nil
+}
+
+// GroupClusters 获取集群分组当前匹配的集群
+func (c *clusterService) GroupClusters(name string) ([]*ClusterConfig, error) {
+	var group models.ClusterGroup
+	if err := dao.DB().Where("name = ?", name).First(&group).Error; err != nil {
+		return nil, fmt.Errorf("集群分组[%s]不存在: %w", name, err)
+	}
+	return c.MatchClusters(group.Selector)
+}
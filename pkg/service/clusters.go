@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,6 +21,7 @@ import (
 	"github.com/weibaohui/k8m/pkg/plugins/modules/k8sgpt/service/analysis"
 	"github.com/weibaohui/kom/kom"
 	komaws "github.com/weibaohui/kom/kom/aws"
+	"golang.org/x/oauth2"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -70,13 +72,19 @@ type ClusterConfig struct {
 	IsInCluster             bool                           `json:"isInCluster,omitempty"`             // 是否为集群内运行获取到的配置
 	watchStatus             sync.Map                       // watch 类型为key，比如pod,deploy,node,pvc,sc
 	restConfig              *rest.Config                   // 直连rest.Config
+	impersonating           bool                           // 是否已开启用户模拟模式
 	kubeConfig              []byte                         // 集群配置.kubeconfig原始文件内容
 	Source                  ClusterConfigSource            `json:"source,omitempty"`                 // 配置文件来源
 	K8sGPTProblemsCount     int                            `json:"k8s_gpt_problems_count,omitempty"` // k8sGPT 扫描结果
 	K8sGPTProblemsResult    *analysis.ResultWithStatus     `json:"k8s_gpt_problems,omitempty"`       // k8sGPT 扫描结果
 	NotAfter                *time.Time                     `json:"not_after,omitempty"`
-	AWSConfig               *komaws.EKSAuthConfig          `json:"aws_config,omitempty"` // AWS EKS配置信息
-	IsAWSEKS                bool                           `json:"is_aws_eks,omitempty"` // 标识是否为AWS EKS集群
+	AWSConfig               *komaws.EKSAuthConfig          `json:"aws_config,omitempty"`     // AWS EKS配置信息
+	IsAWSEKS                bool                           `json:"is_aws_eks,omitempty"`     // 标识是否为AWS EKS集群
+	Labels                  string                         `json:"labels,omitempty"`         // 集群标签，形如 env=prod,region=eu，保存在数据库中，展示时填充
+	CloudProvider           string                         `json:"cloud_provider,omitempty"` // 云厂商集群类型，gke 或 aks
+	cloudCredential         string                         // 云厂商认证配置 JSON
+	tokenSource             oauth2.TokenSource             // 云厂商集群访问令牌源，过期前自动续期
+	cloudMu                 sync.Mutex                     // 保护 cloudCredential 与 tokenSource
 
 	// kom 集群注册配置项
 	DBID     uint    `json:"id,omitempty"`        // 数据库ID
@@ -84,6 +92,8 @@ type ClusterConfig struct {
 	Timeout  int     `json:"timeout,omitempty"`   // 请求超时时间，单位为秒，默认为 30 秒
 	QPS      float32 `json:"qps,omitempty"`       // 每秒查询数限制，默认为 200
 	Burst    int     `json:"burst,omitempty"`     // 突发请求数限制，默认为 2000
+	// 用户模拟模式，开启后以实际操作人的身份访问集群，见 impersonationIdentity
+	Impersonate bool `json:"impersonate,omitempty"`
 }
 type ClusterConfigSource string
 
@@ -91,6 +101,9 @@ var ClusterConfigSourceFile ClusterConfigSource = "File"
 var ClusterConfigSourceDB ClusterConfigSource = "DB"
 var ClusterConfigSourceInCluster ClusterConfigSource = "InCluster"
 var ClusterConfigSourceAWS ClusterConfigSource = "AWS"
+var ClusterConfigSourceAgent ClusterConfigSource = "Agent"
+var ClusterConfigSourceGKE ClusterConfigSource = "GKE"
+var ClusterConfigSourceAKS ClusterConfigSource = "AKS"
 
 // 记录每个集群的watch 启动情况
 // watch 有多种类型，需要记录
@@ -148,6 +161,18 @@ func (c *ClusterConfig) GetClusterID() string {
 	return id
 }
 
+// MatchPattern 集群是否匹配通配符模式。
+// 包含 / 的模式按集群ID（文件名/context名称）匹配；否则按 context 名称或集群名称匹配，如 prod-* 匹配 context 名称以 prod- 开头的集群
+func (c *ClusterConfig) MatchPattern(pattern string) bool {
+	if strings.Contains(pattern, "/") {
+		return utils.MatchWildcard(pattern, c.GetClusterID())
+	}
+	if c.IsInCluster && utils.MatchWildcard(pattern, "InCluster") {
+		return true
+	}
+	return utils.MatchWildcard(pattern, c.ContextName) || utils.MatchWildcard(pattern, c.ClusterName)
+}
+
 func (c *ClusterConfig) GetRestConfig() *rest.Config {
 	return c.restConfig
 }
@@ -221,82 +246,96 @@ func (c *clusterService) ResolveClusterID(clusterIdMd5 string) (string, error) {
 
 // GetCertificateExpiry 获取集群证书的过期时间
 func (c *ClusterConfig) GetCertificateExpiry() time.Time {
+	cert := c.GetClientCertificate()
+	if cert == nil {
+		return time.Time{}
+	}
+	return cert.NotAfter.Local()
+}
+
+// GetClientCertificate 解析 kubeconfig 中当前 context 使用的客户端证书，未使用证书认证时返回 nil
+func (c *ClusterConfig) GetClientCertificate() *x509.Certificate {
 	// 检查 kubeConfig 是否为空
 	if len(c.kubeConfig) == 0 {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] kubeConfig为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
 	config, err := clientcmd.Load(c.kubeConfig)
 	if err != nil {
 		klog.V(8).Infof("设置NotAfter, 解析文件[%s]失败: %v", c.ClusterID, err)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查 config 是否为空
 	if config == nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] config为空", c.ClusterID)
-		return time.Time{}
+		return nil
+	}
+
+	// 同一文件中的多个 context 各自对应一个集群，优先使用集群自身的 context
+	if c.ContextName != "" && config.Contexts[c.ContextName] != nil {
+		config.CurrentContext = c.ContextName
 	}
 
 	// 检查 CurrentContext 是否为空
 	if config.CurrentContext == "" {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] CurrentContext为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查 Contexts 是否为空
 	if config.Contexts == nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] Contexts为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查当前 context 是否存在
 	currentContext, contextExists := config.Contexts[config.CurrentContext]
 	if !contextExists || currentContext == nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] 当前context[%s]不存在", c.ClusterID, config.CurrentContext)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查 AuthInfos 是否为空
 	if config.AuthInfos == nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] AuthInfos为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查 AuthInfo 名称是否为空
 	if currentContext.AuthInfo == "" {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] AuthInfo名称为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
 	// 获取 authInfo
 	authInfo, exists := config.AuthInfos[currentContext.AuthInfo]
 	if !exists || authInfo == nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] authInfo[%s]不存在", c.ClusterID, currentContext.AuthInfo)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查证书数据是否为空
 	if len(authInfo.ClientCertificateData) == 0 {
 		klog.V(8).Infof("设置NotAfter, 集群[%s] ClientCertificateData为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
 	// 解析证书
 	cert, err := utils.ParseCertificate(authInfo.ClientCertificateData)
 	if err != nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s]解析证书失败: %v", c.ClusterID, err)
-		return time.Time{}
+		return nil
 	}
 
 	// 检查证书是否为空
 	if cert == nil {
 		klog.V(8).Infof("设置NotAfter, 集群[%s]解析出的证书为空", c.ClusterID)
-		return time.Time{}
+		return nil
 	}
 
-	return cert.NotAfter.Local()
+	return cert
 }
 
 // IsConnected 判断集群是否连接
@@ -391,6 +430,7 @@ func (c *clusterService) disconnectWithOption(clusterID string, stopReconnect bo
 	// 清理本地状态
 	cc.ServerVersion = ""
 	cc.restConfig = nil
+	cc.impersonating = false
 	cc.Err = ""
 	cc.ClusterConnectStatus = constants.ClusterConnectStatusDisconnected
 	cc.watchStatus.Range(func(key, value interface{}) bool {
@@ -421,6 +461,7 @@ func (c *clusterService) Scan() {
 	c.ScanClustersInDir(cfg.KubeConfig)
 
 	c.ScanClustersInDB()
+	c.ScanClustersInAgent()
 }
 
 // AllClusters 获取所有集群
@@ -547,7 +588,8 @@ func (c *clusterService) ScanClustersInDB() {
 	}
 
 	for i, cc := range c.clusterConfigs {
-		if cc.Source == ClusterConfigSourceDB || cc.Source == ClusterConfigSourceAWS {
+		if cc.Source == ClusterConfigSourceDB || cc.Source == ClusterConfigSourceAWS ||
+			cc.Source == ClusterConfigSourceGKE || cc.Source == ClusterConfigSourceAKS {
 			// 查一下list中是否存在
 			filter := slice.Filter(list, func(index int, item *models.KubeConfig) bool {
 				if item.Server == cc.Server && item.User == cc.UserName && item.Cluster == cc.ClusterName {
@@ -555,6 +597,10 @@ func (c *clusterService) ScanClustersInDB() {
 				}
 				return false
 			})
+			// 云厂商凭据更新后，丢弃旧的令牌源，已建立的连接在下一次请求时使用新凭据获取令牌
+			if len(filter) > 0 && cc.CloudProvider != "" && cc.setCloudCredential(filter[0].CloudCredential) {
+				klog.V(4).Infof("云厂商集群[%s]认证配置已更新", cc.GetClusterID())
+			}
 			if len(filter) == 0 {
 				// 在数据库中也不存在
 				// 从list中删除
@@ -605,6 +651,8 @@ func (c *clusterService) ScanClustersInDB() {
 						QPS:      item.QPS,
 						Burst:    item.Burst,
 						DBID:     item.ID,
+
+						Impersonate: item.Impersonate,
 					}
 					if item.DisplayName != "" {
 						clusterConfig.FileName = item.DisplayName
@@ -625,6 +673,17 @@ func (c *clusterService) ScanClustersInDB() {
 						clusterConfig.IsAWSEKS = true
 						clusterConfig.FileName = string(ClusterConfigSourceAWS)
 					}
+					// GKE/AKS 使用云厂商原生认证，令牌在连接时获取并自动续期
+					switch item.CloudProvider {
+					case CloudProviderGKE:
+						clusterConfig.Source = ClusterConfigSourceGKE
+					case CloudProviderAKS:
+						clusterConfig.Source = ClusterConfigSourceAKS
+					}
+					if item.CloudProvider != "" {
+						clusterConfig.CloudProvider = item.CloudProvider
+						clusterConfig.cloudCredential = item.CloudCredential
+					}
 					clusterConfig.Server = cluster.Server
 					c.AddToClusterList(clusterConfig)
 				}
@@ -802,6 +861,14 @@ func (c *clusterService) LoadRestConfig(config *ClusterConfig) error {
 			config.ClusterConnectStatus = constants.ClusterConnectStatusFailed
 			return err
 		}
+	} else if config.Source == ClusterConfigSourceAgent {
+		// Agent 模式，经由反向隧道访问
+		restConfig, err = AgentService().RestConfig(config.ContextName)
+		if err != nil {
+			config.Err = err.Error()
+			config.ClusterConnectStatus = constants.ClusterConnectStatusFailed
+			return err
+		}
 	} else {
 		// 集群外模式
 		lines := strings.Split(string(config.kubeConfig), "\n")
@@ -815,6 +882,19 @@ func (c *clusterService) LoadRestConfig(config *ClusterConfig) error {
 
 	}
 	config.restConfig = restConfig
+	config.impersonating = false
+
+	if config.CloudProvider != "" && restConfig != nil {
+		if err = c.applyCloudAuth(config); err != nil {
+			config.Err = err.Error()
+			config.ClusterConnectStatus = constants.ClusterConnectStatusFailed
+			return err
+		}
+	}
+
+	if config.Impersonate && restConfig != nil {
+		c.applyImpersonation(config)
+	}
 
 	if config.IsAWSEKS {
 		theaws := kom.Clusters().GetClusterById(config.ClusterID)
@@ -1040,13 +1120,14 @@ func (c *clusterService) buildRegisterOptions(clusterConfig *ClusterConfig) []ko
 }
 
 // UpdateClusterConfig 更新已加载集群的配置参数
-// @Description 根据数据库ID更新已加载集群的ProxyURL、Timeout、QPS、Burst配置，并重新注册已连接的集群
+// @Description 根据数据库ID更新已加载集群的ProxyURL、Timeout、QPS、Burst、用户模拟模式配置，并重新注册已连接的集群
 // @Param dbID 数据库中的集群配置ID
 // @Param proxyURL HTTP代理URL
 // @Param timeout 请求超时时间（秒）
 // @Param qps 每秒查询数限制
 // @Param burst 突发请求数限制
-func (c *clusterService) UpdateClusterConfig(dbID uint, proxyURL string, timeout int, qps float32, burst int) error {
+// @Param impersonate 是否开启用户模拟模式
+func (c *clusterService) UpdateClusterConfig(dbID uint, proxyURL string, timeout int, qps float32, burst int, impersonate bool) error {
 	klog.V(6).Infof("开始更新集群配置，数据库ID: %d", dbID)
 
 	// 查找对应的集群配置
@@ -1070,15 +1151,17 @@ func (c *clusterService) UpdateClusterConfig(dbID uint, proxyURL string, timeout
 	oldTimeout := targetCluster.Timeout
 	oldQPS := targetCluster.QPS
 	oldBurst := targetCluster.Burst
+	oldImpersonate := targetCluster.Impersonate
 
 	// 更新配置参数
 	targetCluster.ProxyURL = proxyURL
 	targetCluster.Timeout = timeout
 	targetCluster.QPS = qps
 	targetCluster.Burst = burst
+	targetCluster.Impersonate = impersonate
 
-	klog.V(6).Infof("集群 %s 配置更新: ProxyURL [%s->%s], Timeout [%d->%d], QPS [%.2f->%.2f], Burst [%d->%d]",
-		targetCluster.ClusterID, oldProxyURL, proxyURL, oldTimeout, timeout, oldQPS, qps, oldBurst, burst)
+	klog.V(6).Infof("集群 %s 配置更新: ProxyURL [%s->%s], Timeout [%d->%d], QPS [%.2f->%.2f], Burst [%d->%d], Impersonate [%t->%t]",
+		targetCluster.ClusterID, oldProxyURL, proxyURL, oldTimeout, timeout, oldQPS, qps, oldBurst, burst, oldImpersonate, impersonate)
 
 	// 如果集群已连接，需要重新注册以应用新配置
 	if targetCluster.ClusterConnectStatus == constants.ClusterConnectStatusConnected {
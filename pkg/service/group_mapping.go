@@ -0,0 +1,305 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/klog/v2"
+)
+
+// ldapUserSource LDAP 登录创建的用户来源
+const ldapUserSource = "ldap_config"
+
+// defaultLdapGroupAttribute 未配置时使用的 LDAP 用户组属性
+const defaultLdapGroupAttribute = "memberOf"
+
+type groupMappingService struct {
+	ldapSyncMu sync.Mutex // 同一时间只执行一次 LDAP 同步
+}
+
+var groupMappingSvc = &groupMappingService{}
+
+// GroupMappingService 用户组映射服务
+func GroupMappingService() *groupMappingService {
+	return groupMappingSvc
+}
+
+// Apply 按映射规则同步用户的 k8m 用户组与集群授权，用户每次通过 SSO、LDAP 登录及 LDAP 定时同步时调用。
+// source 为 sso/ldap，sourceName 为 SSO 配置名称，externalGroups 为 IdP 返回的用户组
+func (g *groupMappingService) Apply(username, source, sourceName string, externalGroups []string) error {
+	var mappings []*models.GroupMapping
+	err := dao.DB().Where("enabled = ? AND source = ?", true, source).
+		Where("source_name = '' OR source_name IS NULL OR source_name = ?", sourceName).
+		Order("id asc").Find(&mappings).Error
+	if err != nil {
+		return err
+	}
+
+	var groups []string
+	grants := make(map[uint]*models.GroupMapping)
+	for _, m := range mappings {
+		if !slices.ContainsFunc(externalGroups, m.Matches) {
+			continue
+		}
+		switch m.TargetType {
+		case models.GroupMappingTargetUserGroup:
+			if m.UserGroup != "" && !slices.Contains(groups, m.UserGroup) {
+				groups = append(groups, m.UserGroup)
+			}
+		case models.GroupMappingTargetClusterRole:
+			grants[m.ID] = m
+		}
+	}
+
+	groupsChanged, err := g.syncUserGroups(username, groups)
+	if err != nil {
+		return err
+	}
+	grantsChanged, err := g.syncClusterGrants(username, grants)
+	if err != nil {
+		return err
+	}
+	if groupsChanged || grantsChanged {
+		UserService().ClearCacheByKey(username)
+		klog.V(6).Infof("用户[%s]按映射规则同步用户组%v，集群授权%d条", username, groups, len(grants))
+	}
+	return nil
+}
+
+// syncUserGroups 移出上次由规则加入、本次未命中的用户组，加入本次命中的用户组，手工维护的用户组保持不变
+func (g *groupMappingService) syncUserGroups(username string, groups []string) (bool, error) {
+	var user models.User
+	if err := dao.DB().Select("id", "group_names", "mapped_group_names").Where("username = ?", username).First(&user).Error; err != nil {
+		return false, err
+	}
+	previous := utils.SplitAndTrim(user.MappedGroupNames, ",")
+	var result []string
+	for _, name := range utils.SplitAndTrim(user.GroupNames, ",") {
+		if !slices.Contains(previous, name) && !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	for _, name := range groups {
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+
+	groupNames := strings.Join(result, ",")
+	mapped := strings.Join(groups, ",")
+	if groupNames == user.GroupNames && mapped == user.MappedGroupNames {
+		return false, nil
+	}
+	err := dao.DB().Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]any{
+		"group_names":        groupNames,
+		"mapped_group_names": mapped,
+	}).Error
+	return err == nil, err
+}
+
+// syncClusterGrants 使由规则生成的集群授权与本次命中的规则一致
+func (g *groupMappingService) syncClusterGrants(username string, grants map[uint]*models.GroupMapping) (bool, error) {
+	var existing []*models.ClusterUserRole
+	err := dao.DB().Where("username = ? AND authorization_type = ? AND mapping_id <> 0", username, constants.ClusterAuthorizationTypeUser).
+		Find(&existing).Error
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	kept := make(map[uint]bool)
+	for _, row := range existing {
+		m, ok := grants[row.MappingID]
+		if ok && !kept[m.ID] && row.Cluster == m.Cluster && row.Role == m.Role && row.CustomRole == m.CustomRole && row.Namespaces == m.Namespaces {
+			kept[m.ID] = true
+			continue
+		}
+		if err := dao.DB().Delete(&models.ClusterUserRole{}, row.ID).Error; err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	for id, m := range grants {
+		if kept[id] {
+			continue
+		}
+		row := &models.ClusterUserRole{
+			Cluster:           m.Cluster,
+			Username:          username,
+			Role:              m.Role,
+			CustomRole:        m.CustomRole,
+			Namespaces:        m.Namespaces,
+			AuthorizationType: constants.ClusterAuthorizationTypeUser,
+			MappingID:         m.ID,
+		}
+		if err := dao.DB().Create(row).Error; err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// LdapGroupAttribute LDAP 用户组属性，默认为 memberOf
+func LdapGroupAttribute(config *models.LDAPConfig) string {
+	if config.GroupAttribute != "" {
+		return config.GroupAttribute
+	}
+	return defaultLdapGroupAttribute
+}
+
+// StartLdapSync 启动 LDAP 定时同步，每分钟检查启用的 LDAP 配置是否到达同步间隔
+func (g *groupMappingService) StartLdapSync() {
+	inst := cron.New()
+	_, err := inst.AddFunc("@every 1m", g.syncDueLdapConfig)
+	if err != nil {
+		klog.Errorf("新增LDAP用户同步任务报错: %v", err)
+		return
+	}
+	inst.Start()
+	klog.V(6).Infof("新增LDAP用户同步任务【@every 1m】")
+}
+
+func (g *groupMappingService) syncDueLdapConfig() {
+	// 与登录使用同一份LDAP配置
+	var config models.LDAPConfig
+	err := dao.DB().Where("enabled = ?", true).Order("id desc").Limit(1).Find(&config).Error
+	if err != nil || config.ID == 0 || !config.SyncEnabled {
+		return
+	}
+	interval := config.SyncInterval
+	if interval <= 0 {
+		interval = 60
+	}
+	now := time.Now()
+	// 多实例部署时通过条件更新抢占本轮同步，只有一个实例执行
+	result := dao.DB().Model(&models.LDAPConfig{}).
+		Where("id = ? AND (last_sync_at IS NULL OR last_sync_at <= ?)", config.ID, now.Add(-time.Duration(interval)*time.Minute)).
+		Update("last_sync_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	_, _ = g.SyncLdap(&config)
+}
+
+// SyncLdap 执行一次 LDAP 同步并记录结果
+func (g *groupMappingService) SyncLdap(config *models.LDAPConfig) (string, error) {
+	if !g.ldapSyncMu.TryLock() {
+		return "", errors.New("LDAP同步正在进行中")
+	}
+	defer g.ldapSyncMu.Unlock()
+
+	now := time.Now()
+	msg, err := g.syncLdapUsers(config)
+	if err != nil {
+		msg = "同步失败: " + err.Error()
+		klog.Errorf("LDAP[%s]同步失败: %v", config.Name, err)
+	} else {
+		klog.V(6).Infof("LDAP[%s]同步完成: %s", config.Name, msg)
+	}
+	if e := dao.DB().Model(&models.LDAPConfig{}).Where("id = ?", config.ID).Updates(map[string]any{
+		"last_sync_at":     now,
+		"last_sync_result": msg,
+	}).Error; e != nil {
+		klog.V(6).Infof("LDAP[%s]同步结果保存失败: %v", config.Name, e)
+	}
+	return msg, err
+}
+
+// syncLdapUsers 逐个查询 LDAP 登录创建的用户：目录中已不存在的禁用，其余按映射规则同步用户组与集群授权。
+// 查询出错的用户跳过，不会因目录暂时不可用而误禁用
+func (g *groupMappingService) syncLdapUsers(config *models.LDAPConfig) (string, error) {
+	conn, err := UserService().ldapConnection(config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+		return "", fmt.Errorf("LDAP绑定失败: %w", err)
+	}
+
+	var users []*models.User
+	if err := dao.DB().Select("id", "username", "disabled").Where("source = ?", ldapUserSource).Find(&users).Error; err != nil {
+		return "", err
+	}
+	attr := LdapGroupAttribute(config)
+	var synced, disabled, failed, missing int
+	// 先查询全部用户，缺失比例超过上限时中止，避免 Base DN、过滤器配置错误导致大量误禁用
+	entries := make([]*ldap.Entry, len(users))
+	errs := make([]error, len(users))
+	for i, user := range users {
+		entries[i], errs[i] = findLdapUser(conn, config, user.Username, attr)
+		if errs[i] == nil && entries[i] == nil && !user.Disabled {
+			missing++
+		}
+	}
+	if limit := config.SyncMaxMissing; missing > 0 && limit < 100 && missing*100 > len(users)*limit {
+		return "", fmt.Errorf("共%d个LDAP用户，目录中缺失%d个，超过上限%d%%，已中止同步，未禁用任何用户", len(users), missing, limit)
+	}
+
+	for i, user := range users {
+		entry, err := entries[i], errs[i]
+		if err != nil {
+			failed++
+			klog.V(6).Infof("LDAP同步查询用户[%s]失败: %v", user.Username, err)
+			continue
+		}
+		if entry == nil {
+			if !user.Disabled {
+				if err := dao.DB().Model(&models.User{}).Where("id = ?", user.ID).Update("disabled", true).Error; err != nil {
+					failed++
+					continue
+				}
+				disabled++
+				klog.V(4).Infof("LDAP中已不存在用户[%s]，已禁用", user.Username)
+				UserService().ClearCacheByKey(user.Username)
+				if _, err := SessionService().RevokeUser(user.Username, "system", "LDAP中已不存在该用户"); err != nil {
+					klog.V(6).Infof("吊销用户[%s]会话失败: %v", user.Username, err)
+				}
+			}
+			continue
+		}
+		if user.Disabled {
+			continue
+		}
+		if err := g.Apply(user.Username, models.GroupMappingSourceLDAP, "", entry.GetAttributeValues(attr)); err != nil {
+			failed++
+			klog.V(6).Infof("LDAP同步用户[%s]映射规则失败: %v", user.Username, err)
+			continue
+		}
+		synced++
+	}
+	return fmt.Sprintf("共%d个LDAP用户，同步%d个，禁用%d个，失败%d个", len(users), synced, disabled, failed), nil
+}
+
+// findLdapUser 按用户过滤器查找用户，未找到时返回 nil
+func findLdapUser(conn *ldap.Conn, config *models.LDAPConfig, username string, attributes ...string) (*ldap.Entry, error) {
+	req := ldap.NewSearchRequest(
+		config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.DerefAlways,
+		0,
+		0,
+		false,
+		fmt.Sprintf("(%v=%v)", config.UserFilter, ldap.EscapeFilter(username)),
+		append([]string{"dn"}, attributes...),
+		nil)
+	cur, err := conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(cur.Entries) == 0 {
+		return nil, nil
+	}
+	return cur.Entries[0], nil
+}
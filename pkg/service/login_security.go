@@ -0,0 +1,290 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/models"
+	"k8s.io/klog/v2"
+)
+
+// loginHistoryRetention 登录记录保留时长
+const loginHistoryRetention = 90 * 24 * time.Hour
+
+var (
+	ErrAccountLocked   = errors.New("登录失败次数过多，账户已锁定")
+	ErrPasswordExpired = errors.New("密码已过期，请设置新密码")
+)
+
+// LoginPolicy 登录锁定与密码策略，来自平台配置
+type LoginPolicy struct {
+	MaxFailures        int           // 同一来源IP连续失败多少次后锁定，0 表示不锁定
+	AccountMaxFailures int           // 所有来源IP累计失败多少次后锁定账户，0 表示不限制
+	LockDuration       time.Duration // 锁定时长，同时作为统计失败次数的时间窗口
+	PasswordMinLength  int
+	RequireUpper       bool
+	RequireLower       bool
+	RequireDigit       bool
+	RequireSpecial     bool
+	PasswordExpireDays int // 0 表示永不过期
+}
+
+type loginSecurityService struct{}
+
+var loginSecuritySvc = &loginSecurityService{}
+
+// LoginSecurityService 登录记录、账户锁定与密码策略服务
+func LoginSecurityService() *loginSecurityService {
+	return loginSecuritySvc
+}
+
+// Policy 读取当前的登录与密码策略，配置读取失败时使用默认值
+func (s *loginSecurityService) Policy() *LoginPolicy {
+	policy := &LoginPolicy{MaxFailures: 5, AccountMaxFailures: 20, LockDuration: 15 * time.Minute, PasswordMinLength: 6}
+	m, err := ConfigService().GetConfig()
+	if err != nil {
+		klog.V(6).Infof("读取登录策略失败，使用默认值: %v", err)
+		return policy
+	}
+	policy.MaxFailures = m.LoginMaxFailures
+	policy.AccountMaxFailures = m.LoginAccountMaxFailures
+	if m.LoginLockMinutes > 0 {
+		policy.LockDuration = time.Duration(m.LoginLockMinutes) * time.Minute
+	}
+	policy.PasswordMinLength = m.PasswordMinLength
+	policy.RequireUpper = m.PasswordRequireUpper
+	policy.RequireLower = m.PasswordRequireLower
+	policy.RequireDigit = m.PasswordRequireDigit
+	policy.RequireSpecial = m.PasswordRequireSpecial
+	policy.PasswordExpireDays = m.PasswordExpireDays
+	return policy
+}
+
+// Record 记录一次登录尝试并发送登录审计事件，err 为空表示登录成功。
+// 锁定期间被拒绝的请求不计入失败次数；同一来源IP失败次数达到上限时锁定该来源IP的登录，
+// 所有来源IP累计失败次数达到账户上限时锁定账户在所有来源IP的登录
+func (s *loginSecurityService) Record(r *http.Request, username, method string, err error) {
+	h := &models.LoginHistory{
+		Username:  username,
+		Method:    method,
+		ClientIP:  clientIP(r),
+		UserAgent: truncate(r.UserAgent(), 512),
+		Result:    models.LoginResultSuccess,
+	}
+	if err != nil {
+		h.Result = models.LoginResultFailure
+		if errors.Is(err, ErrAccountLocked) {
+			h.Result = models.LoginResultRejected
+		}
+		h.Message = truncate(err.Error(), 255)
+	}
+	if err := dao.DB().Create(h).Error; err != nil {
+		klog.Errorf("保存用户[%s]登录记录失败: %v", username, err)
+	}
+	AuditService().EmitLogin(r, username, method, err)
+
+	if h.Result == models.LoginResultFailure && username != "" {
+		s.lockIfExceeded(h)
+	}
+}
+
+// lockIfExceeded 统计上次登录成功、解锁或锁定之后，锁定时长窗口内的失败次数：
+// 同一来源IP达到上限时锁定该来源IP的登录；所有来源IP累计达到账户上限时锁定账户，防止攻击者轮换来源IP绕过锁定
+func (s *loginSecurityService) lockIfExceeded(failure *models.LoginHistory) {
+	policy := s.Policy()
+	if policy.MaxFailures > 0 {
+		window, err := s.window(failure.Username, failure.ClientIP, policy.LockDuration)
+		if err != nil {
+			klog.Errorf("统计用户[%s]登录失败次数失败: %v", failure.Username, err)
+		} else if count := window.Failures(); count >= policy.MaxFailures {
+			s.lock(failure, failure.ClientIP, fmt.Sprintf("连续登录失败%d次，锁定%d分钟", count, int(policy.LockDuration.Minutes())))
+		}
+	}
+	if policy.AccountMaxFailures > 0 {
+		window, err := s.accountWindow(failure.Username, policy.LockDuration)
+		if err != nil {
+			klog.Errorf("统计用户[%s]登录失败次数失败: %v", failure.Username, err)
+		} else if count := window.Failures(); count >= policy.AccountMaxFailures {
+			s.lock(failure, models.LoginAccountIP, fmt.Sprintf("所有来源IP累计登录失败%d次，锁定账户%d分钟", count, int(policy.LockDuration.Minutes())))
+		}
+	}
+}
+
+// lock 写入锁定记录，ip 为 models.LoginAccountIP 时锁定账户在所有来源IP的登录
+func (s *loginSecurityService) lock(failure *models.LoginHistory, ip, message string) {
+	locked := &models.LoginHistory{
+		Username: failure.Username,
+		Method:   failure.Method,
+		ClientIP: ip,
+		Result:   models.LoginResultLocked,
+		Message:  message,
+	}
+	if err := dao.DB().Create(locked).Error; err != nil {
+		klog.Errorf("锁定用户[%s]失败: %v", failure.Username, err)
+		return
+	}
+	klog.V(4).Infof("用户[%s]在[%s]%s", failure.Username, ip, message)
+}
+
+// window 用户在来源IP于锁定时长窗口内的登录记录，解锁记录不区分来源IP
+func (s *loginSecurityService) window(username, ip string, lockDuration time.Duration) (models.LoginWindow, error) {
+	var items []*models.LoginHistory
+	err := dao.DB().
+		Where("username = ? AND created_at > ? AND (client_ip = ? OR result = ?)",
+			username, time.Now().Add(-lockDuration), ip, models.LoginResultUnlocked).
+		Order("id asc").Find(&items).Error
+	return items, err
+}
+
+// accountWindow 用户在所有来源IP于锁定时长窗口内的登录记录。
+// 只包含账户级锁定记录，单个来源IP的锁定不重新统计账户的失败次数
+func (s *loginSecurityService) accountWindow(username string, lockDuration time.Duration) (models.LoginWindow, error) {
+	var items []*models.LoginHistory
+	err := dao.DB().
+		Where("username = ? AND created_at > ? AND (result <> ? OR client_ip = ?)",
+			username, time.Now().Add(-lockDuration), models.LoginResultLocked, models.LoginAccountIP).
+		Order("id asc").Find(&items).Error
+	return items, err
+}
+
+// CheckLocked 用户在请求来源IP或账户处于锁定期时返回 ErrAccountLocked，锁定期满自动解除
+func (s *loginSecurityService) CheckLocked(r *http.Request, username string) error {
+	if username == "" {
+		return nil
+	}
+	policy := s.Policy()
+	if policy.MaxFailures > 0 {
+		window, err := s.window(username, clientIP(r), policy.LockDuration)
+		if err != nil {
+			klog.V(6).Infof("查询用户[%s]锁定状态失败: %v", username, err)
+		} else if err := lockedError(window, policy.LockDuration); err != nil {
+			return err
+		}
+	}
+	if policy.AccountMaxFailures > 0 {
+		window, err := s.accountWindow(username, policy.LockDuration)
+		if err != nil {
+			klog.V(6).Infof("查询用户[%s]锁定状态失败: %v", username, err)
+		} else if err := lockedError(window, policy.LockDuration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockedError 处于锁定期时返回带解除时间的 ErrAccountLocked
+func lockedError(window models.LoginWindow, lockDuration time.Duration) error {
+	until, locked := window.LockedUntil(lockDuration)
+	if !locked || time.Now().After(until) {
+		return nil
+	}
+	return fmt.Errorf("%w，请于%s后重试", ErrAccountLocked, until.Format("15:04:05"))
+}
+
+// Unlock 管理员解除账户在所有来源IP的锁定，解锁后重新统计失败次数
+func (s *loginSecurityService) Unlock(username, operator string) error {
+	return dao.DB().Create(&models.LoginHistory{
+		Username: username,
+		Result:   models.LoginResultUnlocked,
+		Message:  "管理员解除锁定",
+		Operator: operator,
+	}).Error
+}
+
+// ValidatePassword 按密码策略校验本地用户的新密码
+func (s *loginSecurityService) ValidatePassword(password string) error {
+	policy := s.Policy()
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special = true
+		}
+	}
+	var rules []string
+	if n := len([]rune(password)); n < policy.PasswordMinLength {
+		rules = append(rules, fmt.Sprintf("长度不少于%d位", policy.PasswordMinLength))
+	}
+	if policy.RequireUpper && !upper {
+		rules = append(rules, "包含大写字母")
+	}
+	if policy.RequireLower && !lower {
+		rules = append(rules, "包含小写字母")
+	}
+	if policy.RequireDigit && !digit {
+		rules = append(rules, "包含数字")
+	}
+	if policy.RequireSpecial && !special {
+		rules = append(rules, "包含特殊字符")
+	}
+	if len(rules) > 0 {
+		return fmt.Errorf("密码不符合安全要求，需要%s", strings.Join(rules, "、"))
+	}
+	return nil
+}
+
+// PasswordExpired 本地用户的密码是否已过期，从未修改过密码的用户按创建时间计算
+func (s *loginSecurityService) PasswordExpired(user *models.User) bool {
+	days := s.Policy().PasswordExpireDays
+	if days <= 0 {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > time.Duration(days)*24*time.Hour
+}
+
+// SetPassword 校验并设置本地用户的密码，重新生成盐值并记录修改时间
+func (s *loginSecurityService) SetPassword(username, password string) error {
+	if err := s.ValidatePassword(password); err != nil {
+		return err
+	}
+	salt := utils.RandNLengthString(8)
+	psw, err := utils.AesEncrypt([]byte(fmt.Sprintf("%s%s", password, salt)))
+	if err != nil {
+		return err
+	}
+	return dao.DB().Model(&models.User{}).Where("username = ?", username).Updates(map[string]any{
+		"password":            base64.StdEncoding.EncodeToString(psw),
+		"salt":                salt,
+		"password_changed_at": time.Now(),
+	}).Error
+}
+
+// Start 启动定时任务，清理超过保留时长的登录记录
+func (s *loginSecurityService) Start() {
+	inst := cron.New()
+	if _, err := inst.AddFunc("@every 1h", s.cleanup); err != nil {
+		klog.Errorf("新增登录记录清理任务报错: %v", err)
+		return
+	}
+	inst.Start()
+	klog.V(6).Infof("新增登录记录清理任务【@every 1h】")
+}
+
+func (s *loginSecurityService) cleanup() {
+	result := dao.DB().Where("created_at < ?", time.Now().Add(-loginHistoryRetention)).Delete(&models.LoginHistory{})
+	if result.Error != nil {
+		klog.V(6).Infof("清理登录记录失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		klog.V(6).Infof("清理登录记录%d条", result.RowsAffected)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/weibaohui/k8m/internal/dao"
 	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/audit"
 	"github.com/weibaohui/k8m/pkg/models"
 )
 
@@ -32,6 +33,25 @@ func (s *operationLogService) Add(m *models.OperationLog, params ...any) {
 			m.Params += utils.ToJSON(param)
 		}
 	}
+	e := &audit.Event{
+		Type:      audit.EventOperation,
+		Action:    m.Action,
+		User:      m.UserName,
+		Role:      m.Role,
+		Cluster:   m.Cluster,
+		Namespace: m.Namespace,
+		Group:     m.Group,
+		Kind:      m.Kind,
+		Name:      m.Name,
+		Result:    audit.ResultSuccess,
+		Detail:    m.Params,
+	}
+	if m.ActionResult != "success" {
+		e.Result = audit.ResultFailure
+		e.Message = m.ActionResult
+	}
+	AuditService().Emit(e)
+
 	s.bufferMux.Lock()
 	s.buffer = append(s.buffer, m)
 	if len(s.buffer) >= 100 {
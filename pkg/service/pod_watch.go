@@ -8,6 +8,7 @@ import (
 	"github.com/robfig/cron/v3"
 	utils2 "github.com/weibaohui/k8m/pkg/comm/utils"
 	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/plugins/api"
 	"github.com/weibaohui/kom/kom"
 	"github.com/weibaohui/kom/utils"
 	corev1 "k8s.io/api/core/v1"
@@ -324,12 +325,15 @@ func (p *podService) watchSingleCluster(selectedCluster string) watch.Interface
 				// 新增Pod时，保存Pod标签
 				p.UpdatePodLabels(selectedCluster, pod.Namespace, pod.Name, pod.Labels)
 				klog.V(6).Infof("%s 添加Pod [ %s/%s ] 标签数量: %d\n", selectedCluster, pod.Namespace, pod.Name, len(pod.Labels))
+				// 上报Pod状态，由AI插件判断是否需要进行故障根因分析
+				api.PodDiagnosisService().ObservePod(selectedCluster, &pod)
 			case watch.Modified:
 				p.RemoveCacheAllocatedStatus(selectedCluster, &pod)
 				p.CacheAllocatedStatus(selectedCluster, &pod)
 				// 修改Pod时，更新Pod标签
 				p.UpdatePodLabels(selectedCluster, pod.Namespace, pod.Name, pod.Labels)
 				klog.V(6).Infof("%s 修改Pod [ %s/%s ] 标签数量: %d\n", selectedCluster, pod.Namespace, pod.Name, len(pod.Labels))
+				api.PodDiagnosisService().ObservePod(selectedCluster, &pod)
 			case watch.Deleted:
 				p.RemoveCacheAllocatedStatus(selectedCluster, &pod)
 				p.ReducePodCount(selectedCluster, &pod)
@@ -0,0 +1,677 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils"
+	"github.com/weibaohui/k8m/pkg/comm/utils/scim"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// scimUserSource SCIM 创建的用户来源
+const scimUserSource = "scim"
+
+// scimOperator SCIM 同步操作在会话吊销等记录中的操作人
+const scimOperator = "scim"
+
+// isLocalUser 在 k8m 中创建的本地用户
+func isLocalUser(m *models.User) bool {
+	return m.Source == "" || m.Source == "db"
+}
+
+// scimUserScope SCIM 只能看到来自外部身份源（SCIM、单点登录、LDAP）的用户，本地用户只能在 k8m 中维护
+func scimUserScope(db *gorm.DB) *gorm.DB {
+	return db.Where("source NOT IN ?", []string{"", "db"})
+}
+
+type scimService struct{}
+
+var scimSvc = &scimService{}
+
+// ScimService SCIM 2.0 用户与用户组同步服务
+func ScimService() *scimService {
+	return scimSvc
+}
+
+// GenerateToken 生成 SCIM 访问令牌，令牌仅返回这一次
+func (s *scimService) GenerateToken(name, description, operator string) (*models.SCIMToken, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("名称不能为空")
+	}
+	var count int64
+	if err := dao.DB().Model(&models.SCIMToken{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count > 0 {
+		return nil, "", fmt.Errorf("令牌名称[%s]已存在", name)
+	}
+	token := "k8ms-" + utils.RandNLengthString(40)
+	item := &models.SCIMToken{
+		Name:        name,
+		Description: description,
+		TokenHash:   hashScimToken(token),
+		TokenPrefix: token[:10],
+		Enabled:     true,
+		CreatedBy:   operator,
+	}
+	if err := dao.DB().Create(item).Error; err != nil {
+		return nil, "", err
+	}
+	return item, token, nil
+}
+
+// Authenticate 校验 IdP 携带的 SCIM 访问令牌
+func (s *scimService) Authenticate(token string) (*models.SCIMToken, error) {
+	if token == "" {
+		return nil, fmt.Errorf("缺少 SCIM 令牌")
+	}
+	var item models.SCIMToken
+	if err := dao.DB().Where("token_hash = ?", hashScimToken(token)).First(&item).Error; err != nil {
+		return nil, fmt.Errorf("SCIM 令牌无效")
+	}
+	if !item.Enabled {
+		return nil, fmt.Errorf("SCIM 令牌已停用")
+	}
+	// 最近使用时间每分钟最多更新一次，避免同步期间频繁写库
+	if now := time.Now(); item.LastUsedAt == nil || now.Sub(*item.LastUsedAt) > time.Minute {
+		if err := dao.DB().Model(&item).Update("last_used_at", now).Error; err != nil {
+			klog.V(6).Infof("更新 SCIM 令牌[%s]使用时间失败: %v", item.Name, err)
+		}
+	}
+	return &item, nil
+}
+
+func hashScimToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListUsers 查询用户，支持按 userName、id 过滤，不包含本地用户
+func (s *scimService) ListUsers(filter *scim.Filter, start, count int) (*scim.ListResponse, error) {
+	db := dao.DB().Model(&models.User{}).Scopes(scimUserScope)
+	if filter != nil {
+		switch filter.Attr {
+		case "username":
+			db = db.Where("username = ?", filter.Value)
+		case "id":
+			db = db.Where("id = ?", filter.Value)
+		case "externalid":
+			// 不保存外部ID，按外部ID查询时视为不存在，由 IdP 按 userName 匹配
+			return scim.NewListResponse(0, start, []*scim.User{}, 0), nil
+		default:
+			return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidFilter, fmt.Sprintf("不支持按 %s 过滤用户", filter.Attr))
+		}
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var users []*models.User
+	if count > 0 {
+		if err := db.Select(scimUserColumns).Order("id asc").Offset(start - 1).Limit(count).Find(&users).Error; err != nil {
+			return nil, err
+		}
+	}
+	groups, err := s.groupIDs()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*scim.User, 0, len(users))
+	for _, u := range users {
+		items = append(items, toScimUser(u, groups))
+	}
+	return scim.NewListResponse(total, start, items, len(items)), nil
+}
+
+var scimUserColumns = []string{"id", "username", "source", "group_names", "disabled", "created_at", "updated_at"}
+
+// GetUser 按ID查询用户
+func (s *scimService) GetUser(id string) (*scim.User, error) {
+	m, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.groupIDs()
+	if err != nil {
+		return nil, err
+	}
+	return toScimUser(m, groups), nil
+}
+
+// CreateUser 创建用户，用户名已存在时返回 409，IdP 可按 userName 查询后关联；与本地用户同名时同样返回 409
+func (s *scimService) CreateUser(u *scim.User) (*scim.User, error) {
+	username := strings.TrimSpace(u.UserName)
+	if username == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "userName 不能为空")
+	}
+	var count int64
+	if err := dao.DB().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, scim.NewError(http.StatusConflict, scim.ErrUniqueness, fmt.Sprintf("用户 %s 已存在", username))
+	}
+	m := &models.User{
+		Username: username,
+		Source:   scimUserSource,
+		Disabled: u.Active != nil && !bool(*u.Active),
+	}
+	if err := dao.DB().Create(m).Error; err != nil {
+		return nil, err
+	}
+	klog.V(4).Infof("SCIM 创建用户[%s]", username)
+	return s.GetUser(strconv.Itoa(int(m.ID)))
+}
+
+// ReplaceUser 使用 PUT 请求替换用户，只处理 active，不支持修改用户名
+func (s *scimService) ReplaceUser(id string, u *scim.User) (*scim.User, error) {
+	m, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUserName(m, u.UserName); err != nil {
+		return nil, err
+	}
+	if u.Active != nil {
+		if err := s.setActive(m, bool(*u.Active)); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUser(id)
+}
+
+// PatchUser 使用 PATCH 请求修改用户，active 为 false 时停用用户并吊销其全部会话
+func (s *scimService) PatchUser(id string, req *scim.PatchRequest) (*scim.User, error) {
+	m, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := scim.ApplyUserPatch(req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUserName(m, patch.UserName); err != nil {
+		return nil, err
+	}
+	if patch.Active != nil {
+		if err := s.setActive(m, *patch.Active); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUser(id)
+}
+
+// DeleteUser 删除用户及其集群授权，同时删除其通行密钥并吊销全部会话。平台管理员不能通过 SCIM 删除
+func (s *scimService) DeleteUser(id string) error {
+	m, err := s.findUser(id)
+	if err != nil {
+		return err
+	}
+	if err := checkScimManaged(m); err != nil {
+		return err
+	}
+	err = dao.DB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("authorization_type = ? AND username = ?", constants.ClusterAuthorizationTypeUser, m.Username).
+			Delete(&models.ClusterUserRole{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, m.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+	if err := WebAuthnService().ResetUser(m.Username); err != nil {
+		klog.Errorf("删除用户[%s]的通行密钥失败: %v", m.Username, err)
+	}
+	s.revoke(m.Username, "SCIM 删除用户")
+	klog.V(4).Infof("SCIM 删除用户[%s]", m.Username)
+	return nil
+}
+
+func (s *scimService) findUser(id string) (*models.User, error) {
+	var m models.User
+	err := dao.DB().Select(scimUserColumns).Scopes(scimUserScope).Where("id = ?", id).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, scim.NotFound("User", id)
+	}
+	return &m, err
+}
+
+// checkScimManaged 本地用户与平台管理员（含内置管理员）只能在 k8m 中维护，拒绝 SCIM 修改
+func checkScimManaged(m *models.User) error {
+	if isLocalUser(m) {
+		return scim.NewError(http.StatusForbidden, "", fmt.Sprintf("用户 %s 为本地用户，不能通过 SCIM 修改", m.Username))
+	}
+	if m.Username == flag.Init().AdminUserName {
+		return scim.NewError(http.StatusForbidden, "", fmt.Sprintf("用户 %s 为平台管理员，不能通过 SCIM 修改", m.Username))
+	}
+	roles, err := UserService().GetRolesByGroupNames(utils.SplitAndTrim(m.GroupNames, ","))
+	if err != nil {
+		return err
+	}
+	if slices.Contains(roles, constants.RolePlatformAdmin) {
+		return scim.NewError(http.StatusForbidden, "", fmt.Sprintf("用户 %s 为平台管理员，不能通过 SCIM 修改", m.Username))
+	}
+	return nil
+}
+
+// checkUserName 用户名关联着集群授权、登录会话等数据，不支持通过 SCIM 修改
+func checkUserName(m *models.User, username string) error {
+	if username != "" && username != m.Username {
+		return scim.NewError(http.StatusBadRequest, scim.ErrMutability, "不支持修改 userName")
+	}
+	return nil
+}
+
+// setActive 启用或停用用户，停用后立即吊销其全部会话。平台管理员不能通过 SCIM 启用或停用
+func (s *scimService) setActive(m *models.User, active bool) error {
+	if m.Disabled == !active {
+		return nil
+	}
+	if err := checkScimManaged(m); err != nil {
+		return err
+	}
+	if err := dao.DB().Model(&models.User{}).Where("id = ?", m.ID).Update("disabled", !active).Error; err != nil {
+		return err
+	}
+	m.Disabled = !active
+	if active {
+		UserService().ClearCacheByKey(m.Username)
+		klog.V(4).Infof("SCIM 启用用户[%s]", m.Username)
+		return nil
+	}
+	s.revoke(m.Username, "SCIM 停用用户")
+	klog.V(4).Infof("SCIM 停用用户[%s]", m.Username)
+	return nil
+}
+
+// revoke 清除用户缓存并吊销其全部会话，失败只记录日志
+func (s *scimService) revoke(username, reason string) {
+	UserService().ClearCacheByKey(username)
+	if _, err := SessionService().RevokeUser(username, scimOperator, reason); err != nil {
+		klog.Errorf("吊销用户[%s]会话失败: %v", username, err)
+	}
+}
+
+// groupIDs 用户组名称与ID的对应关系
+func (s *scimService) groupIDs() (map[string]uint, error) {
+	var groups []*models.UserGroup
+	if err := dao.DB().Select("id", "group_name").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]uint, len(groups))
+	for _, g := range groups {
+		result[g.GroupName] = g.ID
+	}
+	return result, nil
+}
+
+func toScimUser(m *models.User, groups map[string]uint) *scim.User {
+	u := &scim.User{
+		Schemas:  []string{scim.SchemaUser},
+		ID:       strconv.Itoa(int(m.ID)),
+		UserName: m.Username,
+		Active:   scim.NewBool(!m.Disabled),
+		Meta:     &scim.Meta{ResourceType: "User", Created: m.CreatedAt, LastModified: m.UpdatedAt},
+	}
+	for _, name := range utils.SplitAndTrim(m.GroupNames, ",") {
+		if id, ok := groups[name]; ok {
+			u.Groups = append(u.Groups, scim.Ref{Value: strconv.Itoa(int(id)), Display: name})
+		}
+	}
+	return u
+}
+
+// ListGroups 查询用户组，支持按 displayName、id 过滤，withMembers 为 false 时不返回成员
+func (s *scimService) ListGroups(filter *scim.Filter, start, count int, withMembers bool) (*scim.ListResponse, error) {
+	db := dao.DB().Model(&models.UserGroup{})
+	if filter != nil {
+		switch filter.Attr {
+		case "displayname":
+			db = db.Where("group_name = ?", filter.Value)
+		case "id":
+			db = db.Where("id = ?", filter.Value)
+		case "externalid":
+			return scim.NewListResponse(0, start, []*scim.Group{}, 0), nil
+		default:
+			return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidFilter, fmt.Sprintf("不支持按 %s 过滤用户组", filter.Attr))
+		}
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var groups []*models.UserGroup
+	if count > 0 {
+		if err := db.Select("id", "group_name", "created_at", "updated_at").Order("id asc").Offset(start - 1).Limit(count).Find(&groups).Error; err != nil {
+			return nil, err
+		}
+	}
+	items := make([]*scim.Group, 0, len(groups))
+	for _, g := range groups {
+		item, err := s.toScimGroup(g, withMembers)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return scim.NewListResponse(total, start, items, len(items)), nil
+}
+
+// GetGroup 按ID查询用户组
+func (s *scimService) GetGroup(id string, withMembers bool) (*scim.Group, error) {
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toScimGroup(g, withMembers)
+}
+
+// CreateGroup 创建用户组。新用户组的角色为普通用户，集群授权需在 k8m 中配置
+func (s *scimService) CreateGroup(g *scim.Group) (*scim.Group, error) {
+	name := strings.TrimSpace(g.DisplayName)
+	if name == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "displayName 不能为空")
+	}
+	if err := s.checkGroupName(name); err != nil {
+		return nil, err
+	}
+	ids, err := s.checkMembers(nil, refIDs(g.Members))
+	if err != nil {
+		return nil, err
+	}
+	m := &models.UserGroup{
+		GroupName:   name,
+		Role:        constants.RoleGuest,
+		Description: "由 SCIM 同步创建",
+	}
+	if err := dao.DB().Create(m).Error; err != nil {
+		return nil, err
+	}
+	if err := s.setMembers(name, ids); err != nil {
+		return nil, err
+	}
+	klog.V(4).Infof("SCIM 创建用户组[%s]", name)
+	return s.GetGroup(strconv.Itoa(int(m.ID)), true)
+}
+
+// ReplaceGroup 使用 PUT 请求替换用户组的名称与成员
+func (s *scimService) ReplaceGroup(id string, g *scim.Group) (*scim.Group, error) {
+	m, err := s.findManagedGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(g.DisplayName)
+	if name == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "displayName 不能为空")
+	}
+	return s.updateGroup(m, name, refIDs(g.Members))
+}
+
+// PatchGroup 使用 PATCH 请求修改用户组的名称与成员
+func (s *scimService) PatchGroup(id string, req *scim.PatchRequest) (*scim.Group, error) {
+	m, err := s.findManagedGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupMembers(m.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(members))
+	for _, u := range members {
+		if !isLocalUser(u) {
+			ids = append(ids, strconv.Itoa(int(u.ID)))
+		}
+	}
+	patch, err := scim.ApplyGroupPatch(ids, req.Operations)
+	if err != nil {
+		return nil, err
+	}
+	name := m.GroupName
+	if patch.DisplayName != "" {
+		name = patch.DisplayName
+	}
+	return s.updateGroup(m, name, patch.Members)
+}
+
+// DeleteGroup 删除用户组，并将其从成员的用户组中移出。平台管理员用户组不能通过 SCIM 删除
+func (s *scimService) DeleteGroup(id string) error {
+	m, err := s.findManagedGroup(id)
+	if err != nil {
+		return err
+	}
+	if err := s.setMembers(m.GroupName, nil); err != nil {
+		return err
+	}
+	if err := dao.DB().Delete(&models.UserGroup{}, m.ID).Error; err != nil {
+		return err
+	}
+	UserService().ClearCacheByKey(m.GroupName)
+	klog.V(4).Infof("SCIM 删除用户组[%s]", m.GroupName)
+	return nil
+}
+
+func (s *scimService) updateGroup(m *models.UserGroup, name string, ids []string) (*scim.Group, error) {
+	current, err := s.groupMembers(m.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	if ids, err = s.checkMembers(current, ids); err != nil {
+		return nil, err
+	}
+	if name != m.GroupName {
+		if err := s.renameGroup(m, name); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.setMembers(name, ids); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(strconv.Itoa(int(m.ID)), true)
+}
+
+// renameGroup 修改用户组名称，同步修改成员的用户组与按用户组的集群授权
+func (s *scimService) renameGroup(m *models.UserGroup, name string) error {
+	if err := s.checkGroupName(name); err != nil {
+		return err
+	}
+	members, err := s.groupMembers(m.GroupName)
+	if err != nil {
+		return err
+	}
+	err = dao.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserGroup{}).Where("id = ?", m.ID).Update("group_name", name).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.ClusterUserRole{}).
+			Where("authorization_type = ? AND username = ?", constants.ClusterAuthorizationTypeUserGroup, m.GroupName).
+			Update("username", name).Error
+		if err != nil {
+			return err
+		}
+		for _, u := range members {
+			names := utils.SplitAndTrim(u.GroupNames, ",")
+			for i, n := range names {
+				if n == m.GroupName {
+					names[i] = name
+				}
+			}
+			if err := tx.Model(&models.User{}).Where("id = ?", u.ID).Update("group_names", strings.Join(names, ",")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	UserService().ClearCacheByKey(m.GroupName)
+	for _, u := range members {
+		UserService().ClearCacheByKey(u.Username)
+	}
+	klog.V(4).Infof("SCIM 将用户组[%s]重命名为[%s]", m.GroupName, name)
+	m.GroupName = name
+	return nil
+}
+
+func (s *scimService) checkGroupName(name string) error {
+	var count int64
+	if err := dao.DB().Model(&models.UserGroup{}).Where("group_name = ?", name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return scim.NewError(http.StatusConflict, scim.ErrUniqueness, fmt.Sprintf("用户组 %s 已存在", name))
+	}
+	return nil
+}
+
+// checkMembers 校验成员ID均为 SCIM 可见的用户，新加入的成员不能是平台管理员。
+// 本地用户与平台管理员的成员关系不受 SCIM 修改，返回的成员ID保留了用户组中这些原有成员
+func (s *scimService) checkMembers(current []*models.User, ids []string) ([]string, error) {
+	var users []*models.User
+	if len(ids) > 0 {
+		if err := dao.DB().Select(scimUserColumns).Scopes(scimUserScope).Where("id IN ?", ids).Find(&users).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(users) != len(ids) {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrInvalidValue, "成员中包含不存在的用户")
+	}
+	isCurrent := func(u *models.User) bool {
+		return slices.ContainsFunc(current, func(c *models.User) bool { return c.ID == u.ID })
+	}
+	for _, u := range users {
+		if isCurrent(u) {
+			continue
+		}
+		if err := checkScimManaged(u); err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range current {
+		id := strconv.Itoa(int(u.ID))
+		if slices.Contains(ids, id) {
+			continue
+		}
+		if checkScimManaged(u) != nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// setMembers 使用户组的成员与 ids 一致，只修改成员变化的用户
+func (s *scimService) setMembers(groupName string, ids []string) error {
+	current, err := s.groupMembers(groupName)
+	if err != nil {
+		return err
+	}
+	var added []*models.User
+	if len(ids) > 0 {
+		if err := dao.DB().Select("id", "username", "group_names").Where("id IN ?", ids).Find(&added).Error; err != nil {
+			return err
+		}
+	}
+	for _, u := range slices.Concat(current, added) {
+		names := utils.SplitAndTrim(u.GroupNames, ",")
+		has := slices.Contains(names, groupName)
+		want := slices.Contains(ids, strconv.Itoa(int(u.ID)))
+		switch {
+		case want && !has:
+			names = append(names, groupName)
+		case !want && has:
+			names = slices.DeleteFunc(names, func(n string) bool { return n == groupName })
+		default:
+			continue
+		}
+		if err := dao.DB().Model(&models.User{}).Where("id = ?", u.ID).Update("group_names", strings.Join(names, ",")).Error; err != nil {
+			return err
+		}
+		u.GroupNames = strings.Join(names, ",")
+		UserService().ClearCacheByKey(u.Username)
+	}
+	return nil
+}
+
+// groupMembers 用户组的成员
+func (s *scimService) groupMembers(groupName string) ([]*models.User, error) {
+	var users []*models.User
+	err := dao.DB().Select("id", "username", "source", "group_names").
+		Where("group_names LIKE ?", "%"+groupName+"%").Order("id asc").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(users, func(u *models.User) bool {
+		return !slices.Contains(utils.SplitAndTrim(u.GroupNames, ","), groupName)
+	}), nil
+}
+
+func (s *scimService) findGroup(id string) (*models.UserGroup, error) {
+	var m models.UserGroup
+	err := dao.DB().Select("id", "group_name", "role", "created_at", "updated_at").Where("id = ?", id).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, scim.NotFound("Group", id)
+	}
+	return &m, err
+}
+
+// findManagedGroup 查询 SCIM 可修改的用户组，平台管理员用户组只能在 k8m 中维护
+func (s *scimService) findManagedGroup(id string) (*models.UserGroup, error) {
+	m, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Role == constants.RolePlatformAdmin {
+		return nil, scim.NewError(http.StatusForbidden, "", fmt.Sprintf("用户组 %s 为平台管理员用户组，不能通过 SCIM 修改", m.GroupName))
+	}
+	return m, nil
+}
+
+func (s *scimService) toScimGroup(m *models.UserGroup, withMembers bool) (*scim.Group, error) {
+	g := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.Itoa(int(m.ID)),
+		DisplayName: m.GroupName,
+		Meta:        &scim.Meta{ResourceType: "Group", Created: m.CreatedAt, LastModified: m.UpdatedAt},
+	}
+	if !withMembers {
+		return g, nil
+	}
+	members, err := s.groupMembers(m.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range members {
+		// 本地用户对 SCIM 不可见
+		if !isLocalUser(u) {
+			g.Members = append(g.Members, scim.Ref{Value: strconv.Itoa(int(u.ID)), Display: u.Username})
+		}
+	}
+	return g, nil
+}
+
+// refIDs 成员引用中的用户ID，去除重复
+func refIDs(refs []scim.Ref) []string {
+	var ids []string
+	for _, ref := range refs {
+		if ref.Value != "" && !slices.Contains(ids, ref.Value) {
+			ids = append(ids, ref.Value)
+		}
+	}
+	return ids
+}
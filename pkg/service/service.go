@@ -33,6 +33,7 @@ var localUserService = &userService{
 var localOperationLogService = NewOperationLogService()
 var localShellLogService = &shellLogService{}
 var localLeaderService = &leaderService{}
+var localAgentService = &agentService{}
 
 // init 中文函数注释：在 service 初始化时向 lease 包注入 ClusterID → RestConfig 的解析器，避免循环引入。
 func init() {
@@ -62,6 +63,11 @@ func NodeService() *nodeService {
 func ClusterService() *clusterService {
 	return localClusterService
 }
+
+// AgentService 获取集群 Agent 隧道服务实例
+func AgentService() *agentService {
+	return localAgentService
+}
 func StorageClassService() *storageClassService {
 	return localStorageClassService
 }
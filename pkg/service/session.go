@@ -0,0 +1,345 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/robfig/cron/v3"
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/constants"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// sessionAccessTokenTTL 访问令牌有效期，过期后前端使用刷新令牌换取新的访问令牌
+	sessionAccessTokenTTL = 30 * time.Minute
+	// sessionIdleTTL 会话空闲超时，期间未刷新则需重新登录
+	sessionIdleTTL = 24 * time.Hour
+	// sessionMaxTTL 会话最长有效期，到期后无论是否活跃都需重新登录
+	sessionMaxTTL = 7 * 24 * time.Hour
+	// sessionRetention 过期、吊销的会话保留时长，便于查看登录记录
+	sessionRetention = 7 * 24 * time.Hour
+)
+
+var (
+	ErrSessionInvalid = errors.New("登录已失效，请重新登录")
+	ErrUserDisabled   = errors.New("用户已被禁用")
+)
+
+// TokenPair 登录或刷新后返回给前端的令牌
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+}
+
+type sessionService struct {
+	mu       sync.RWMutex
+	revoked  map[string]time.Time // 已吊销的会话ID -> 会话过期时间，过期后移出
+	syncedAt time.Time            // 上次从数据库同步吊销列表的时间
+}
+
+var sessionSvc = &sessionService{revoked: make(map[string]time.Time)}
+
+// SessionService 登录会话服务
+func SessionService() *sessionService {
+	return sessionSvc
+}
+
+// Create 为登录成功的用户创建会话并签发令牌，loginType 为登录方式
+func (s *sessionService) Create(r *http.Request, username, loginType string) (*TokenPair, error) {
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	sid, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.UserSession{
+		SessionID:        sid[:32],
+		Username:         username,
+		RefreshTokenHash: hashSessionToken(refreshToken),
+		LoginType:        loginType,
+		ClientIP:         clientIP(r),
+		UserAgent:        truncate(r.UserAgent(), 512),
+		LastActiveAt:     now,
+		ExpiresAt:        now.Add(sessionIdleTTL),
+	}
+	if err := dao.DB().Create(session).Error; err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("用户[%s]通过[%s]登录，创建会话[%d]", username, loginType, session.ID)
+	LoginSecurityService().Record(r, username, loginType, nil)
+	return s.issue(session, refreshToken, now)
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌与刷新令牌，并顺延会话有效期。
+// 刷新令牌每次使用后即作废，已作废的令牌再次出现时视为泄露，吊销整个会话
+func (s *sessionService) Refresh(refreshToken string) (*TokenPair, error) {
+	session, err := s.findByRefreshToken(refreshToken)
+	if errors.Is(err, ErrSessionInvalid) {
+		s.revokeReused(refreshToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !session.Active(now) || s.IsRevoked(session.SessionID) {
+		return nil, ErrSessionInvalid
+	}
+	if UserService().IsUserDisabled(session.Username) {
+		_, _ = s.RevokeUser(session.Username, "system", "用户已被禁用")
+		return nil, ErrUserDisabled
+	}
+
+	newToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := now.Add(sessionIdleTTL)
+	if maxAt := session.CreatedAt.Add(sessionMaxTTL); expiresAt.After(maxAt) {
+		expiresAt = maxAt
+	}
+	// 以旧令牌哈希为条件更新，并发使用同一刷新令牌时只有一个请求成功
+	result := dao.DB().Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, session.RefreshTokenHash).
+		Updates(map[string]any{
+			"refresh_token_hash": hashSessionToken(newToken),
+			"prev_token_hash":    session.RefreshTokenHash,
+			"last_active_at":     now,
+			"expires_at":         expiresAt,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		_, _ = s.revoke(dao.DB().Where("id = ?", session.ID), "system", "刷新令牌被重复使用")
+		return nil, ErrSessionInvalid
+	}
+	session.ExpiresAt = expiresAt
+	return s.issue(session, newToken, now)
+}
+
+// revokeReused 刷新令牌是某个会话已作废的令牌时，吊销该会话
+func (s *sessionService) revokeReused(refreshToken string) {
+	if refreshToken == "" {
+		return
+	}
+	var session models.UserSession
+	if err := dao.DB().Where("prev_token_hash = ?", hashSessionToken(refreshToken)).First(&session).Error; err != nil {
+		return
+	}
+	klog.V(4).Infof("用户[%s]会话[%d]的已作废刷新令牌被再次使用，吊销该会话", session.Username, session.ID)
+	_, _ = s.revoke(dao.DB().Where("id = ?", session.ID), "system", "刷新令牌被重复使用")
+}
+
+// Logout 退出登录，吊销刷新令牌所属的会话
+func (s *sessionService) Logout(refreshToken string) error {
+	session, err := s.findByRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	_, err = s.revoke(dao.DB().Where("id = ?", session.ID), session.Username, "退出登录")
+	return err
+}
+
+// RevokeUser 吊销用户的全部会话，用于禁用用户、重置2FA、强制下线
+func (s *sessionService) RevokeUser(username, operator, reason string) (int, error) {
+	return s.revoke(dao.DB().Where("username = ?", username), operator, reason)
+}
+
+// RevokeByIDs 按会话记录ID吊销，username 不为空时只吊销该用户的会话
+func (s *sessionService) RevokeByIDs(ids []int64, username, operator, reason string) (int, error) {
+	db := dao.DB().Where("id in ?", ids)
+	if username != "" {
+		db = db.Where("username = ?", username)
+	}
+	return s.revoke(db, operator, reason)
+}
+
+// RevokeOthers 吊销用户除当前会话外的其他会话
+func (s *sessionService) RevokeOthers(username, currentSID string) (int, error) {
+	return s.revoke(dao.DB().Where("username = ? AND session_id <> ?", username, currentSID), username, "退出其他会话")
+}
+
+// Validate 校验访问令牌对应的会话是否仍然有效。
+// 登录令牌携带会话ID，按吊销列表校验；API 令牌不携带会话ID，校验用户是否被禁用
+func (s *sessionService) Validate(username, sid string) error {
+	if sid != "" {
+		if s.IsRevoked(sid) {
+			return ErrSessionInvalid
+		}
+		return nil
+	}
+	if username != "" && UserService().IsUserDisabled(username) {
+		return ErrUserDisabled
+	}
+	return nil
+}
+
+// IsRevoked 会话是否已被吊销
+func (s *sessionService) IsRevoked(sid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[sid]
+	return ok
+}
+
+// Start 加载吊销列表，并启动定时任务：同步其他实例吊销的会话、清理过期会话
+func (s *sessionService) Start() {
+	s.syncRevoked()
+	inst := cron.New()
+	if _, err := inst.AddFunc("@every 30s", s.syncRevoked); err != nil {
+		klog.Errorf("新增会话吊销列表同步任务报错: %v", err)
+		return
+	}
+	if _, err := inst.AddFunc("@every 1h", s.cleanup); err != nil {
+		klog.Errorf("新增过期会话清理任务报错: %v", err)
+		return
+	}
+	inst.Start()
+	klog.V(6).Infof("新增会话吊销列表同步任务【@every 30s】")
+}
+
+func (s *sessionService) issue(session *models.UserSession, refreshToken string, now time.Time) (*TokenPair, error) {
+	expiresAt := now.Add(sessionAccessTokenTTL)
+	if expiresAt.After(session.ExpiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+	token, err := UserService().generateJWTToken(session.Username, expiresAt, jwt.MapClaims{
+		constants.JwtSessionID: session.SessionID,
+		"iat":                  now.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(expiresAt.Sub(now).Seconds()),
+	}, nil
+}
+
+func (s *sessionService) findByRefreshToken(refreshToken string) (*models.UserSession, error) {
+	if refreshToken == "" {
+		return nil, ErrSessionInvalid
+	}
+	var session models.UserSession
+	err := dao.DB().Where("refresh_token_hash = ?", hashSessionToken(refreshToken)).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionInvalid
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// revoke 吊销查询范围内仍有效的会话，并立即加入本实例的吊销列表
+func (s *sessionService) revoke(db *gorm.DB, operator, reason string) (int, error) {
+	now := time.Now()
+	var sessions []*models.UserSession
+	if err := db.Where("revoked_at IS NULL AND expires_at > ?", now).Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+	ids := make([]uint, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	err := dao.DB().Model(&models.UserSession{}).Where("id in ?", ids).Updates(map[string]any{
+		"revoked_at":    now,
+		"revoked_by":    operator,
+		"revoke_reason": reason,
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	for _, session := range sessions {
+		s.revoked[session.SessionID] = session.ExpiresAt
+	}
+	s.mu.Unlock()
+	klog.V(4).Infof("%s吊销会话%d个，原因: %s", operator, len(sessions), reason)
+	return len(sessions), nil
+}
+
+// syncRevoked 从数据库同步吊销列表，多实例部署时其他实例吊销的会话在一个同步周期内生效
+func (s *sessionService) syncRevoked() {
+	now := time.Now()
+	db := dao.DB().Model(&models.UserSession{}).Select("session_id", "expires_at").
+		Where("revoked_at IS NOT NULL AND expires_at > ?", now)
+	if !s.syncedAt.IsZero() {
+		// 多查一段时间，容忍实例间的时钟偏差
+		db = db.Where("revoked_at >= ?", s.syncedAt.Add(-time.Minute))
+	}
+	var sessions []*models.UserSession
+	if err := db.Find(&sessions).Error; err != nil {
+		klog.V(6).Infof("同步会话吊销列表失败: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range sessions {
+		s.revoked[session.SessionID] = session.ExpiresAt
+	}
+	for sid, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, sid)
+		}
+	}
+	s.syncedAt = now
+}
+
+// cleanup 删除过期、吊销超过保留时长的会话记录
+func (s *sessionService) cleanup() {
+	before := time.Now().Add(-sessionRetention)
+	result := dao.DB().Where("expires_at < ? OR revoked_at < ?", before, before).Delete(&models.UserSession{})
+	if result.Error != nil {
+		klog.V(6).Infof("清理过期会话失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		klog.V(6).Infof("清理过期会话%d个", result.RowsAffected)
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientIP 获取登录来源IP，开启 --trusted-proxy 时才使用 X-Forwarded-For
+func clientIP(r *http.Request) string {
+	return truncate(flag.Init().ClientIP(r), 64)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"github.com/weibaohui/k8m/pkg/comm/utils/audit"
 	"github.com/weibaohui/k8m/pkg/models"
 )
 
@@ -9,4 +10,16 @@ type shellLogService struct {
 
 func (s *shellLogService) Add(m *models.ShellLog) {
 	_ = m.Save(nil)
+	AuditService().Emit(&audit.Event{
+		Type:      audit.EventShell,
+		Action:    "exec",
+		User:      m.UserName,
+		Role:      m.Role,
+		Cluster:   m.Cluster,
+		Namespace: m.Namespace,
+		Kind:      "Pod",
+		Name:      m.PodName + "/" + m.ContainerName,
+		Result:    audit.ResultSuccess,
+		Detail:    m.Command,
+	})
 }
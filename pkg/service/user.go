@@ -195,6 +195,7 @@ func (u *userService) GetClusterNames(username string) ([]string, error) {
 // 最终结果包含两种情况：
 // 1. 用户授权类型为用户
 // 2. 用户授权类型为用户组,当前用户所在的用户组，如果有授权，那么也提取出来
+// 授权给集群分组的条目，会展开为分组当前匹配的每个集群
 func (u *userService) GetClusters(username string) ([]*models.ClusterUserRole, error) {
 	cacheKey := u.formatCacheKey("user:clusters:%s", username)
 
@@ -202,10 +203,14 @@ func (u *userService) GetClusters(username string) ([]*models.ClusterUserRole, e
 		params := &dao.Params{}
 		params.PerPage = 10000000
 		clusterRole := &models.ClusterUserRole{}
+		// 临时授权到期后即不再生效，不依赖定时回收的及时性
+		notExpired := func(db *gorm.DB) *gorm.DB {
+			return db.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+		}
 		queryFunc := func(db *gorm.DB) *gorm.DB {
 			return db.Where(" username = ?", username)
 		}
-		items, _, err := clusterRole.List(params, queryFunc)
+		items, _, err := clusterRole.List(params, queryFunc, notExpired)
 		if err != nil {
 			return nil, err
 		}
@@ -218,34 +223,121 @@ func (u *userService) GetClusters(username string) ([]*models.ClusterUserRole, e
 				// 查找用户组对应的授权
 				if items2, _, err := clusterRole.List(params, func(db *gorm.DB) *gorm.DB {
 					return db.Where("authorization_type=? and  username in ? ", constants.ClusterAuthorizationTypeUserGroup, groupNameList)
-				}); err == nil {
+				}, notExpired); err == nil {
 					items = append(items, items2...)
 				}
 			}
 		}
-		return items, nil
+		return u.expandClusterGroupRoles(items), nil
 	})
 
 	return result, err
 }
 
+// GetCustomRole 获取自定义角色及其规则，用于 kom 回调中的权限校验，角色变更后需清除 custom_role 缓存
+func (u *userService) GetCustomRole(name string) (*models.CustomRole, error) {
+	cacheKey := u.formatCacheKey("user:custom_role:%s", name)
+
+	return utils.GetOrSetCache(CacheService().CacheInstance(), cacheKey, 5*time.Minute, func() (*models.CustomRole, error) {
+		role := &models.CustomRole{}
+		if err := dao.DB().Where("name = ?", name).First(role).Error; err != nil {
+			return nil, fmt.Errorf("自定义角色[%s]不存在: %w", name, err)
+		}
+		return role, nil
+	})
+}
+
+// expandClusterGroupRoles 将授权给集群分组或通配集群的条目展开为当前匹配的每个集群，展开后的条目沿用原授权ID
+func (u *userService) expandClusterGroupRoles(items []*models.ClusterUserRole) []*models.ClusterUserRole {
+	result := make([]*models.ClusterUserRole, 0, len(items))
+	groups := make(map[string][]*ClusterConfig)
+	for _, item := range items {
+		// 用户组映射规则生成的授权，集群可以使用 * 通配，按当前集群列表展开
+		if item.ClusterGroup == "" && strings.Contains(item.Cluster, "*") {
+			for _, cc := range ClusterService().AllClusters() {
+				if cc.MatchPattern(item.Cluster) {
+					expanded := *item
+					expanded.Cluster = cc.GetClusterID()
+					result = append(result, &expanded)
+				}
+			}
+			continue
+		}
+		if item.ClusterGroup == "" {
+			result = append(result, item)
+			continue
+		}
+		clusters, ok := groups[item.ClusterGroup]
+		if !ok {
+			var err error
+			clusters, err = ClusterService().GroupClusters(item.ClusterGroup)
+			if err != nil {
+				klog.V(6).Infof("展开集群分组[%s]授权失败: %v", item.ClusterGroup, err)
+			}
+			groups[item.ClusterGroup] = clusters
+		}
+		for _, cc := range clusters {
+			expanded := *item
+			expanded.Cluster = cc.GetClusterID()
+			result = append(result, &expanded)
+		}
+	}
+	return result
+}
+
 // GenerateJWTTokenOnlyUserName  生成 Token，仅包含Username
+// 不关联登录会话，用于 API 令牌等长期凭证；用户登录使用 SessionService().Create
 func (u *userService) GenerateJWTTokenOnlyUserName(username string, duration time.Duration) (string, error) {
+	return u.generateJWTToken(username, time.Now().Add(duration), nil)
+}
+
+// GenerateMCPKeyToken 生成 MCP Key 绑定的 Token，携带密钥ID与使用范围，仅可用于 /mcp/ 接口
+func (u *userService) GenerateMCPKeyToken(username string, keyID uint, duration time.Duration) (string, error) {
+	return u.generateJWTToken(username, time.Now().Add(duration), jwt.MapClaims{
+		constants.JwtKeyID: keyID,
+		constants.JwtScope: constants.JwtScopeMCP,
+	})
+}
+
+// generateJWTToken 生成 Token，extra 为附加的 claims
+func (u *userService) generateJWTToken(username string, expiresAt time.Time, extra jwt.MapClaims) (string, error) {
 	if username == "" {
 		return "", errors.New("username cannot be empty")
 	}
 	name := constants.JwtUserName
 
-	var token = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		name:              username,
 		"isPlatformAdmin": u.IsUserPlatformAdmin(username), //前端展示平台管理员使用，没有其他用处
-		"exp":             time.Now().Add(duration).Unix(),
-	})
+		"exp":             expiresAt.Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	var token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	cfg := flag.Init()
 	var jwtSecret = []byte(cfg.JwtTokenSecret)
 	return token.SignedString(jwtSecret)
 }
 
+// IsUserDisabled 用户是否被禁用，未在数据库中的用户（如临时管理员）视为未禁用
+func (u *userService) IsUserDisabled(username string) bool {
+	cacheKey := u.formatCacheKey("user:disabled:%s", username)
+
+	disabled, err := utils.GetOrSetCache(CacheService().CacheInstance(), cacheKey, time.Minute, func() (bool, error) {
+		var users []models.User
+		if err := dao.DB().Select("disabled").Where("username = ?", username).Limit(1).Find(&users).Error; err != nil {
+			return false, err
+		}
+		return len(users) > 0 && users[0].Disabled, nil
+	})
+	if err != nil {
+		klog.V(6).Infof("查询用户[%s]禁用状态失败: %v", username, err)
+		return false
+	}
+	return disabled
+}
+
 // GetGroupNames 获取用户所在的用户组
 // return: 用户组名称列表
 func (u *userService) GetGroupNames(username string) ([]string, error) {
@@ -340,51 +432,27 @@ func (u *userService) ldapConnection(config *models.LDAPConfig) (*ldap.Conn, err
 	return conn, nil
 }
 
-// ldap搜索
+// ldap搜索，同时获取用户组属性，用于用户组映射
 func (u *userService) searchRequest(conn *ldap.Conn, username string, config *models.LDAPConfig) (*ldap.Entry, error) {
-	var (
-		cur              *ldap.SearchResult
-		ldapFieldsFilter = []string{
-			"dn",
-		}
-	)
-
-	// 解密管理员密码
-	bindPassword, err := utils.AesDecrypt(config.BindPassword)
-	if err != nil {
-		klog.Errorf("LDAP密码解密失败: %v", err)
-		return nil, errors.New("LDAP配置错误")
-	}
-
-	err = conn.Bind(config.BindDN, string(bindPassword))
+	// 管理员密码查询时已由模型解密
+	err := conn.Bind(config.BindDN, config.BindPassword)
 	if err != nil {
 		klog.Errorf("LDAP绑定失败: %v", err)
 		return nil, errors.New("LDAP认证失败")
 	}
 
-	sql := ldap.NewSearchRequest(
-		config.BaseDN,
-		ldap.ScopeWholeSubtree,
-		ldap.DerefAlways,
-		0,
-		0,
-		false,
-		fmt.Sprintf("(%v=%v)", config.UserFilter, username),
-		ldapFieldsFilter,
-		nil)
-
-	cur, err = conn.Search(sql)
+	entry, err := findLdapUser(conn, config, username, LdapGroupAttribute(config))
 	if err != nil {
 		klog.Errorf("LDAP搜索用户失败: %v", err)
 		return nil, errors.New("用户搜索失败")
 	}
 
-	if len(cur.Entries) == 0 {
+	if entry == nil {
 		klog.Errorf("LDAP中未找到用户: %s", username)
 		return nil, errors.New("用户不存在")
 	}
 
-	return cur.Entries[0], nil
+	return entry, nil
 }
 
 // LoginWithLdap 登录ldap
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/weibaohui/k8m/internal/dao"
+	"github.com/weibaohui/k8m/pkg/comm/utils/webauthn"
+	"github.com/weibaohui/k8m/pkg/flag"
+	"github.com/weibaohui/k8m/pkg/models"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+)
+
+// webAuthnChallengeTTL 挑战值有效期，与浏览器端超时时间保持一致
+const webAuthnChallengeTTL = 2 * time.Minute
+
+// 挑战值用途，签名时写入令牌，防止注册与登录的挑战值混用
+const (
+	webAuthnPurposeRegister     = "register"
+	webAuthnPurposeLogin        = "login"
+	webAuthnPurposePasswordless = "passwordless"
+)
+
+var ErrWebAuthnFailed = errors.New("通行密钥验证失败")
+
+// WebAuthnChallenge 返回给前端的挑战，完成时需原样提交 ChallengeToken
+type WebAuthnChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+	Options        any    `json:"options"`
+}
+
+// webAuthnChallengeState 挑战令牌中携带的状态，经签名后交给前端保存，多实例部署无需共享会话
+type webAuthnChallengeState struct {
+	Challenge    string `json:"c"`
+	Purpose      string `json:"p"`
+	Username     string `json:"u,omitempty"`
+	Passwordless bool   `json:"pl,omitempty"`
+	ExpiresAt    int64  `json:"e"`
+}
+
+type webAuthnService struct{}
+
+// WebAuthnService 通行密钥（Passkey）服务，用于二次验证与免密登录
+func WebAuthnService() *webAuthnService {
+	return &webAuthnService{}
+}
+
+// BeginRegistration 生成注册参数。passwordless 为 true 时要求可发现凭据与用户验证，注册后可免密登录
+func (s *webAuthnService) BeginRegistration(r *http.Request, username string, passwordless bool) (*WebAuthnChallenge, error) {
+	var count int64
+	if err := dao.DB().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("用户[%s]不存在，无法注册通行密钥", username)
+	}
+	creds, err := s.credentials(username)
+	if err != nil {
+		return nil, err
+	}
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		exclude = append(exclude, s.descriptor(cred))
+	}
+
+	challenge, token, err := s.newChallenge(webAuthnPurposeRegister, username, passwordless)
+	if err != nil {
+		return nil, err
+	}
+	user := webauthn.UserEntity{ID: s.userHandle(username), Name: username, DisplayName: username}
+	return &WebAuthnChallenge{
+		ChallengeToken: token,
+		Options:        s.relyingParty(r).NewCreationOptions(challenge, user, exclude, passwordless),
+	}, nil
+}
+
+// FinishRegistration 校验注册结果并保存凭据
+func (s *webAuthnService) FinishRegistration(r *http.Request, username, name, challengeToken string, resp *webauthn.AttestationResponse) (*models.WebAuthnCredential, error) {
+	state, err := s.consumeChallenge(challengeToken, webAuthnPurposeRegister)
+	if err != nil {
+		return nil, err
+	}
+	if state.Username != username {
+		return nil, ErrWebAuthnFailed
+	}
+	challenge, _ := webauthn.DecodeBase64URL(state.Challenge)
+	cred, err := s.relyingParty(r).VerifyRegistration(challenge, resp, state.Passwordless)
+	if err != nil {
+		klog.V(6).Infof("用户[%s]注册通行密钥失败: %v", username, err)
+		return nil, fmt.Errorf("%w: %v", ErrWebAuthnFailed, err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "通行密钥 " + time.Now().Format("2006-01-02 15:04")
+	}
+	m := &models.WebAuthnCredential{
+		Username:       username,
+		Name:           truncate(name, 100),
+		CredentialID:   webauthn.URLEncodedBase64(cred.ID).String(),
+		PublicKey:      webauthn.URLEncodedBase64(cred.PublicKey).String(),
+		SignCount:      cred.SignCount,
+		AAGUID:         hex.EncodeToString(cred.AAGUID),
+		Transports:     truncate(strings.Join(resp.Response.Transports, ","), 100),
+		Passwordless:   state.Passwordless,
+		BackupEligible: cred.BackupEligible,
+	}
+	if err := dao.DB().Create(m).Error; err != nil {
+		return nil, fmt.Errorf("保存通行密钥失败，该密钥可能已注册: %w", err)
+	}
+	if err := s.syncUserFlag(username); err != nil {
+		return nil, err
+	}
+	klog.V(4).Infof("用户[%s]注册通行密钥[%s]", username, m.Name)
+	return m, nil
+}
+
+// BeginLogin 密码校验通过后生成二次验证的登录参数，用户未注册通行密钥时返回 nil
+func (s *webAuthnService) BeginLogin(r *http.Request, username string) (*WebAuthnChallenge, error) {
+	creds, err := s.credentials(username)
+	if err != nil || len(creds) == 0 {
+		return nil, err
+	}
+	allow := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		allow = append(allow, s.descriptor(cred))
+	}
+	challenge, token, err := s.newChallenge(webAuthnPurposeLogin, username, false)
+	if err != nil {
+		return nil, err
+	}
+	return &WebAuthnChallenge{
+		ChallengeToken: token,
+		Options:        s.relyingParty(r).NewRequestOptions(challenge, allow, "preferred"),
+	}, nil
+}
+
+// FinishLogin 校验二次验证的签名
+func (s *webAuthnService) FinishLogin(r *http.Request, username, challengeToken string, resp *webauthn.AssertionResponse) error {
+	state, err := s.consumeChallenge(challengeToken, webAuthnPurposeLogin)
+	if err != nil {
+		return err
+	}
+	if state.Username != username || resp == nil {
+		return ErrWebAuthnFailed
+	}
+	_, err = s.verifyAssertion(r, state, resp, func(db *gorm.DB) *gorm.DB {
+		return db.Where("username = ?", username)
+	}, false)
+	return err
+}
+
+// BeginPasswordless 生成免密登录参数，由浏览器列出当前域名下的可发现凭据
+func (s *webAuthnService) BeginPasswordless(r *http.Request) (*WebAuthnChallenge, error) {
+	challenge, token, err := s.newChallenge(webAuthnPurposePasswordless, "", false)
+	if err != nil {
+		return nil, err
+	}
+	return &WebAuthnChallenge{
+		ChallengeToken: token,
+		Options:        s.relyingParty(r).NewRequestOptions(challenge, nil, "required"),
+	}, nil
+}
+
+// FinishPasswordless 校验免密登录的签名并返回用户名。只接受注册为免密登录且完成了用户验证的凭据
+func (s *webAuthnService) FinishPasswordless(r *http.Request, challengeToken string, resp *webauthn.AssertionResponse) (string, error) {
+	state, err := s.consumeChallenge(challengeToken, webAuthnPurposePasswordless)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", ErrWebAuthnFailed
+	}
+	cred, err := s.verifyAssertion(r, state, resp, func(db *gorm.DB) *gorm.DB {
+		return db.Where("passwordless = ?", true)
+	}, true)
+	if err != nil {
+		return "", err
+	}
+	// 浏览器返回的用户标识须与凭据所属用户一致
+	if len(resp.Response.UserHandle) > 0 && !hmac.Equal(resp.Response.UserHandle, s.userHandle(cred.Username)) {
+		return "", ErrWebAuthnFailed
+	}
+	return cred.Username, nil
+}
+
+// Delete 删除用户的通行密钥，username 为空时不限制所属用户
+func (s *webAuthnService) Delete(username string, ids []int64) error {
+	db := dao.DB().Where("id in ?", ids)
+	if username != "" {
+		db = db.Where("username = ?", username)
+	}
+	var creds []*models.WebAuthnCredential
+	if err := db.Find(&creds).Error; err != nil {
+		return err
+	}
+	if len(creds) == 0 {
+		return nil
+	}
+	usernames := map[string]struct{}{}
+	credIDs := make([]uint, 0, len(creds))
+	for _, cred := range creds {
+		usernames[cred.Username] = struct{}{}
+		credIDs = append(credIDs, cred.ID)
+	}
+	if err := dao.DB().Where("id in ?", credIDs).Delete(&models.WebAuthnCredential{}).Error; err != nil {
+		return err
+	}
+	for name := range usernames {
+		if err := s.syncUserFlag(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetUser 删除用户的全部通行密钥，用于管理员重置2FA
+func (s *webAuthnService) ResetUser(username string) error {
+	if err := dao.DB().Where("username = ?", username).Delete(&models.WebAuthnCredential{}).Error; err != nil {
+		return err
+	}
+	return s.syncUserFlag(username)
+}
+
+// verifyAssertion 按凭据ID查找凭据并校验签名，成功后更新签名计数与使用时间
+func (s *webAuthnService) verifyAssertion(r *http.Request, state *webAuthnChallengeState, resp *webauthn.AssertionResponse, scope func(*gorm.DB) *gorm.DB, requireUV bool) (*models.WebAuthnCredential, error) {
+	var cred models.WebAuthnCredential
+	err := dao.DB().Scopes(scope).Where("credential_id = ?", webauthn.URLEncodedBase64(resp.RawID).String()).First(&cred).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: 通行密钥未注册", ErrWebAuthnFailed)
+		}
+		return nil, err
+	}
+	publicKey, err := webauthn.DecodeBase64URL(cred.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	challenge, _ := webauthn.DecodeBase64URL(state.Challenge)
+	signCount, err := s.relyingParty(r).VerifyAssertion(challenge, resp, publicKey, cred.SignCount, requireUV)
+	if err != nil {
+		klog.V(4).Infof("用户[%s]的通行密钥[%s]验证失败: %v", cred.Username, cred.Name, err)
+		return nil, fmt.Errorf("%w: %v", ErrWebAuthnFailed, err)
+	}
+
+	now := time.Now()
+	err = dao.DB().Model(&models.WebAuthnCredential{}).Where("id = ?", cred.ID).Updates(map[string]any{
+		"sign_count":   signCount,
+		"last_used_at": now,
+	}).Error
+	if err != nil {
+		klog.V(6).Infof("更新通行密钥[%d]使用记录失败: %v", cred.ID, err)
+	}
+	return &cred, nil
+}
+
+func (s *webAuthnService) credentials(username string) ([]*models.WebAuthnCredential, error) {
+	var creds []*models.WebAuthnCredential
+	err := dao.DB().Where("username = ?", username).Order("id").Find(&creds).Error
+	return creds, err
+}
+
+func (s *webAuthnService) descriptor(cred *models.WebAuthnCredential) webauthn.CredentialDescriptor {
+	id, _ := webauthn.DecodeBase64URL(cred.CredentialID)
+	d := webauthn.CredentialDescriptor{Type: "public-key", ID: id}
+	if cred.Transports != "" {
+		d.Transports = strings.Split(cred.Transports, ",")
+	}
+	return d
+}
+
+// syncUserFlag 按用户剩余的通行密钥数量更新用户的通行密钥启用状态
+func (s *webAuthnService) syncUserFlag(username string) error {
+	var count int64
+	if err := dao.DB().Model(&models.WebAuthnCredential{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return err
+	}
+	return dao.DB().Model(&models.User{}).Where("username = ?", username).Update("webauthn_enabled", count > 0).Error
+}
+
+// userHandle 注册时的用户标识，由用户名派生，不直接暴露用户名
+func (s *webAuthnService) userHandle(username string) []byte {
+	mac := hmac.New(sha256.New, []byte(flag.Init().JwtTokenSecret))
+	mac.Write([]byte("webauthn-user|" + username))
+	return mac.Sum(nil)[:16]
+}
+
+// relyingParty 以访问地址的域名作为依赖方ID，访问地址由 --external-url 或受信任代理的转发头确定
+func (s *webAuthnService) relyingParty(r *http.Request) *webauthn.RelyingParty {
+	// 浏览器上报的来源只包含协议、域名与端口，--external-url 中的路径需去掉
+	origin := flag.Init().RequestOrigin(r)
+	host := origin
+	if u, err := url.Parse(origin); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+		host = u.Hostname()
+	}
+	return &webauthn.RelyingParty{
+		ID:     host,
+		Name:   "k8m",
+		Origin: origin,
+	}
+}
+
+// newChallenge 生成挑战值及其签名令牌
+func (s *webAuthnService) newChallenge(purpose, username string, passwordless bool) ([]byte, string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, "", err
+	}
+	payload, err := json.Marshal(webAuthnChallengeState{
+		Challenge:    webauthn.URLEncodedBase64(challenge).String(),
+		Purpose:      purpose,
+		Username:     username,
+		Passwordless: passwordless,
+		ExpiresAt:    time.Now().Add(webAuthnChallengeTTL).Unix(),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return challenge, encoded + "." + s.challengeMAC(encoded), nil
+}
+
+// consumeChallenge 校验挑战令牌并标记为已使用，有效期内拒绝重放。
+// 记录保存在本实例缓存中，多实例部署时由令牌有效期兜底
+func (s *webAuthnService) consumeChallenge(token, purpose string) (*webAuthnChallengeState, error) {
+	encoded, mac, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(mac), []byte(s.challengeMAC(encoded))) {
+		return nil, fmt.Errorf("%w: 挑战令牌无效", ErrWebAuthnFailed)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 挑战令牌无效", ErrWebAuthnFailed)
+	}
+	var state webAuthnChallengeState
+	if err := json.Unmarshal(payload, &state); err != nil || state.Purpose != purpose {
+		return nil, fmt.Errorf("%w: 挑战令牌无效", ErrWebAuthnFailed)
+	}
+	if time.Now().Unix() > state.ExpiresAt {
+		return nil, fmt.Errorf("%w: 验证已超时，请重试", ErrWebAuthnFailed)
+	}
+
+	cache := CacheService().CacheInstance()
+	key := "webauthn:challenge:" + state.Challenge
+	if _, found := cache.Get(key); found {
+		return nil, fmt.Errorf("%w: 挑战令牌已被使用", ErrWebAuthnFailed)
+	}
+	cache.SetWithTTL(key, true, 1, webAuthnChallengeTTL)
+	cache.Wait()
+	return &state, nil
+}
+
+func (s *webAuthnService) challengeMAC(encoded string) string {
+	mac := hmac.New(sha256.New, []byte(flag.Init().JwtTokenSecret))
+	mac.Write([]byte("webauthn-challenge|" + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
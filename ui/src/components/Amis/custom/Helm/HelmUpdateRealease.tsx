@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
-import { Button, Col, Form, Row, Typography, message } from 'antd';
-import Editor from '@monaco-editor/react';
+import { Button, Col, Form, Input, Modal, Row, Space, Table, Tag, Typography, message } from 'antd';
+import Editor, { DiffEditor } from '@monaco-editor/react';
 import { fetcher } from '@/components/Amis/fetcher';
 import { getCurrentClusterId } from '@/utils/utils';
 
@@ -8,9 +8,40 @@ interface HelmUpdateReleaseProps {
     data: Record<string, any>
 }
 
+interface ManifestChange {
+    kind: string;
+    api_version: string;
+    namespace: string;
+    name: string;
+    action: 'added' | 'removed' | 'changed' | 'unchanged';
+    old?: string;
+    new?: string;
+}
+
+interface ReleasePreview {
+    chart: string;
+    version: string;
+    revision: number;
+    added: number;
+    removed: number;
+    changed: number;
+    unchanged: number;
+    changes: ManifestChange[];
+}
+
+const actionTags: Record<string, { color: string, label: string }> = {
+    added: { color: 'green', label: '新增' },
+    removed: { color: 'red', label: '删除' },
+    changed: { color: 'orange', label: '变更' },
+    unchanged: { color: 'default', label: '无变化' },
+};
+
 const HelmUpdateRelease = React.forwardRef<HTMLSpanElement, HelmUpdateReleaseProps>(({ data }, _) => {
     const [values, setValues] = useState('');
+    const [version, setVersion] = useState('');
     const [loading, setLoading] = useState(false);
+    const [preview, setPreview] = useState<ReleasePreview | null>(null);
+    const [selected, setSelected] = useState<ManifestChange | null>(null);
     const [clusterInfo, setClusterInfo] = useState('');
 
     useEffect(() => {
@@ -48,20 +79,56 @@ const HelmUpdateRelease = React.forwardRef<HTMLSpanElement, HelmUpdateReleasePro
     }, [namespace, releaseName, revision]);
 
 
+    // 先预览渲染后的清单差异，确认后再执行升级
+    const handlePreview = async () => {
+        setLoading(true);
+        try {
+            const response = await fetcher({
+                url: '/k8s/plugins/helm/release/upgrade/preview',
+                method: 'post',
+                data: {
+                    values,
+                    version,
+                    name: releaseName,
+                    namespace: namespace
+                }
+            });
+            const result = response.data as any;
+            if (result?.status !== 0) {
+                message.error(result?.msg || '预览失败');
+                return;
+            }
+            const data = result.data as ReleasePreview;
+            setPreview(data);
+            setSelected(data.changes.find(c => c.action !== 'unchanged') || null);
+        } catch (error) {
+            message.error('预览失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
     const handleSubmit = async () => {
 
         setLoading(true);
         try {
-            await fetcher({
+            const response = await fetcher({
                 url: '/k8s/plugins/helm/release/upgrade',
                 method: 'post',
                 data: {
                     values,
+                    version,
                     name: releaseName,
                     namespace: namespace
                 }
             });
+            const result = response.data as any;
+            if (result?.status !== 0) {
+                message.error(result?.msg || '更新失败');
+                return;
+            }
             message.success('更新成功');
+            setPreview(null);
         } catch (error) {
             message.error('更新失败');
         } finally {
@@ -74,14 +141,21 @@ const HelmUpdateRelease = React.forwardRef<HTMLSpanElement, HelmUpdateReleasePro
             <Form layout="horizontal" labelCol={{ span: 4 }} wrapperCol={{ span: 20 }}>
 
                 <Form.Item label="更新操作">
-                    <Button
-                        type="primary"
-                        onClick={handleSubmit}
-                        loading={loading}
-                        style={{ marginRight: 16 }}
-                    >
-                        提交更新
-                    </Button>
+                    <Space>
+                        <Input
+                            value={version}
+                            onChange={(e) => setVersion(e.target.value.trim())}
+                            placeholder="目标版本，留空则使用当前版本"
+                            style={{ width: 240 }}
+                        />
+                        <Button
+                            type="primary"
+                            onClick={handlePreview}
+                            loading={loading}
+                        >
+                            预览并更新
+                        </Button>
+                    </Space>
 
 
                 </Form.Item>
@@ -131,6 +205,61 @@ const HelmUpdateRelease = React.forwardRef<HTMLSpanElement, HelmUpdateReleasePro
 
 
             </Form>
+            <Modal
+                title={preview ? `变更预览：${preview.chart} ${preview.version}（当前版本号 ${preview.revision}）` : '变更预览'}
+                open={preview !== null}
+                width="90%"
+                okText="确认更新"
+                cancelText="取消"
+                confirmLoading={loading}
+                onOk={handleSubmit}
+                onCancel={() => setPreview(null)}
+            >
+                {preview && (
+                    <Row gutter={16}>
+                        <Col span={8}>
+                            <Typography.Paragraph>
+                                新增 {preview.added}，变更 {preview.changed}，删除 {preview.removed}，无变化 {preview.unchanged}
+                            </Typography.Paragraph>
+                            <Table<ManifestChange>
+                                size="small"
+                                rowKey={(c) => `${c.kind}/${c.namespace}/${c.name}`}
+                                dataSource={preview.changes}
+                                pagination={false}
+                                scroll={{ y: 'calc(100vh - 400px)' }}
+                                onRow={(c) => ({ onClick: () => setSelected(c) })}
+                                rowClassName={(c) => c === selected ? 'ant-table-row-selected' : ''}
+                                columns={[
+                                    {
+                                        title: '操作',
+                                        dataIndex: 'action',
+                                        width: 80,
+                                        render: (a: string) => <Tag color={actionTags[a]?.color}>{actionTags[a]?.label || a}</Tag>
+                                    },
+                                    { title: '类型', dataIndex: 'kind', width: 120 },
+                                    { title: '名称', dataIndex: 'name' },
+                                ]}
+                            />
+                        </Col>
+                        <Col span={16}>
+                            <div style={{ border: '1px solid #d9d9d9', borderRadius: '4px' }}>
+                                <DiffEditor
+                                    height="calc(100vh - 360px)"
+                                    language="yaml"
+                                    original={selected?.old || ''}
+                                    modified={selected?.new || ''}
+                                    options={{
+                                        readOnly: true,
+                                        minimap: { enabled: false },
+                                        scrollBeyondLastLine: false,
+                                        automaticLayout: true,
+                                    }}
+                                />
+                            </div>
+                        </Col>
+                    </Row>
+                )}
+            </Modal>
         </div>
     );
 });
@@ -3,6 +3,7 @@ import {fetcherResult} from "amis-core/lib/types";
 import {message} from "antd";
 import axios from "axios";
 import {ProcessK8sUrlWithCluster} from "@/utils/utils.ts";
+import {refreshAccessToken} from "@/utils/auth.ts";
 
 
 export const fetcher = ({url, method = 'get', data, config}: FetcherConfig): Promise<fetcherResult> => {
@@ -37,9 +38,19 @@ export const fetcher = ({url, method = 'get', data, config}: FetcherConfig): Pro
     // 请求发送之前的拦截
     ajax.interceptors.response.use(
         response => response, // 请求成功
-        error => {
+        async error => {
             if (error.response && error.response.status === 401) {
-                // 如果是401，跳转到登录页面
+                // 访问令牌过期或失效时先尝试刷新，刷新成功后重试一次
+                const original = error.config;
+                if (original && !original._retried) {
+                    original._retried = true;
+                    const token = await refreshAccessToken();
+                    if (token) {
+                        original.headers.Authorization = `Bearer ${token}`;
+                        return ajax.request(original);
+                    }
+                }
+                // 刷新失败，跳转到登录页面
                 window.location.href = '/#/login';
             }
             if (error.response && error.response.status === 512) {
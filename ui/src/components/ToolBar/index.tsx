@@ -4,6 +4,7 @@ import styles from './index.module.scss';
 import { UserOutlined, GlobalOutlined } from '@ant-design/icons';
 import { useEffect, useState } from 'react';
 import { jwtDecode } from 'jwt-decode';
+import { logout } from '@/utils/auth';
 
 interface DecodedToken {
     username: string;
@@ -32,8 +33,8 @@ const Toolbar = () => {
         }
     }, []);
 
-    const handleLogout = () => {
-        localStorage.removeItem("token");
+    const handleLogout = async () => {
+        await logout();
         navigate('/login');
     };
 
@@ -70,6 +71,12 @@ const Toolbar = () => {
             icon: <i className="fa-solid fa-server"></i>,
             onClick: () => navigate('/user/profile/my_clusters')
         },
+        {
+            key: "user_profile_sessions",
+            label: "登录会话",
+            icon: <i className="fa-solid fa-laptop"></i>,
+            onClick: () => navigate('/user/profile/my_sessions')
+        },
         {
             key: 'divider-2',
             type: 'divider'
@@ -9,6 +9,8 @@ import styles from './index.module.scss'
 import FloatingChatGPTButton from './FloatingChatGPTButton'
 import { fetcher } from '@/components/Amis/fetcher'
 import I18nTranslateProvider from '@/components/I18n/I18nTranslateProvider';
+import { startTokenRefresher } from '@/utils/auth';
+import '@/utils/webauthn';
 
 const App = () => {
     const { pathname } = useLocation()
@@ -28,6 +30,9 @@ const App = () => {
         }
     }, [navigate, pathname])
 
+    // 访问令牌到期前自动续期
+    useEffect(() => startTokenRefresher(), [])
+
     const [produtcName, setProdutcName] = useState("k8m");
 
     useEffect(() => {
@@ -3,11 +3,14 @@ import { useNavigate } from 'react-router-dom'
 import {
     UserOutlined,
     LockOutlined,
-    SafetyOutlined
+    SafetyOutlined,
+    KeyOutlined
 } from '@ant-design/icons'
 import styles from './index.module.scss'
 import { useCallback, useEffect, useState } from 'react'
 import { encrypt, decrypt } from '@/utils/crypto'
+import { saveTokens } from '@/utils/auth'
+import { getAssertion, isWebAuthnSupported, loginWithPasskey } from '@/utils/webauthn'
 
 const FormItem = Form.Item
 
@@ -23,6 +26,8 @@ const Login = () => {
     const [loadingSSO, setLoadingSSO] = useState<Record<string, boolean>>({});
     const [isLdap, setIsLdap] = useState(false);
     const [ldapEnabled, setLdapEnabled] = useState(false);
+    // 密码过期时需设置新密码后重新登录
+    const [passwordExpired, setPasswordExpired] = useState(false);
 
     // 获取SSO配置
     useEffect(() => {
@@ -70,30 +75,62 @@ const Login = () => {
         }
     }, [form]);
 
+    const [passkeyLoading, setPasskeyLoading] = useState(false);
+
+    const onPasskeyLogin = useCallback(async () => {
+        setPasskeyLoading(true);
+        try {
+            const data = await loginWithPasskey();
+            message.success('登录成功');
+            saveTokens(data);
+            navigate('/');
+        } catch (error: any) {
+            message.error(error?.message || '通行密钥登录失败');
+        } finally {
+            setPasskeyLoading(false);
+        }
+    }, [navigate]);
+
     const onSubmit = useCallback(() => {
         form.validateFields().then(async (values) => {
             try {
                 const encryptedPassword = encrypt(values.password);
-                const res = await fetch('/auth/login', {
+                const body = {
+                    username: values.username,
+                    password: encryptedPassword,  // 发送加密后的密码
+                    code: values.code, // 添加2FA验证码
+                    loginType: isLdap ? 1 : 0, // 0: 普通登录, 1: LDAP登录
+                    new_password: passwordExpired && values.new_password ? encrypt(values.new_password) : undefined
+                };
+                const post = (payload: any) => fetch('/auth/login', {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json'
                     },
-                    body: JSON.stringify({
-                        username: values.username,
-                        password: encryptedPassword,  // 发送加密后的密码
-                        code: values.code, // 添加2FA验证码
-                        loginType: isLdap ? 1 : 0 // 0: 普通登录, 1: LDAP登录
-                    }),
+                    body: JSON.stringify(payload),
                 });
-                const data = await res.json();
+                let res = await post(body);
+                let data = await res.json();
+                // 未填写验证码且已注册通行密钥时，调起浏览器使用通行密钥完成2FA
+                if (res.status === 401 && data.two_fa?.webauthn && !values.code) {
+                    try {
+                        const credential = await getAssertion(data.two_fa.webauthn.options);
+                        res = await post({
+                            ...body,
+                            webauthn: {challenge_token: data.two_fa.webauthn.challenge_token, credential}
+                        });
+                        data = await res.json();
+                    } catch {
+                        // 取消通行密钥验证时提示输入验证码
+                    }
+                }
                 if (res.ok) {
                     message.success('登录成功');
-                    localStorage.setItem('token', data.token);
-                    // 记住密码逻辑
+                    saveTokens(data);
+                    // 记住密码逻辑，过期后设置了新密码时记住新密码
                     const rememberData = {
                         username: values.username,
-                        password: encryptedPassword,  // 存储加密后的密码
+                        password: body.new_password || encryptedPassword,  // 存储加密后的密码
                         remember: values.remember,
                     };
 
@@ -105,13 +142,16 @@ const Login = () => {
 
                     navigate('/');
                 } else {
+                    if (data.password_expired) {
+                        setPasswordExpired(true);
+                    }
                     message.error(data.message || '登录失败');
                 }
             } catch (error) {
                 message.error('网络错误');
             }
         });
-    }, [navigate, form,isLdap]);
+    }, [navigate, form, isLdap, passwordExpired]);
 
     return <section className={styles.login}>
         <div className={styles.content}>
@@ -138,10 +178,31 @@ const Login = () => {
                         placeholder='请输入密码'
                     />
                 </FormItem>
+                {passwordExpired && (
+                    <>
+                        <FormItem name='new_password' rules={[{ required: true, message: '密码已过期，请输入新密码' }]}>
+                            <Input.Password prefix={<LockOutlined />} placeholder='密码已过期，请输入新密码' />
+                        </FormItem>
+                        <FormItem
+                            name='confirm_password'
+                            dependencies={['new_password']}
+                            rules={[
+                                { required: true, message: '请再次输入新密码' },
+                                ({ getFieldValue }) => ({
+                                    validator: (_, value) => !value || getFieldValue('new_password') === value
+                                        ? Promise.resolve()
+                                        : Promise.reject(new Error('两次输入的密码不一致')),
+                                }),
+                            ]}
+                        >
+                            <Input.Password prefix={<LockOutlined />} placeholder='请再次输入新密码' />
+                        </FormItem>
+                    </>
+                )}
                 <FormItem name='code'>
                     <Input
                         prefix={<SafetyOutlined />}
-                        placeholder='请输入2FA验证码，未开启可不填'
+                        placeholder='请输入2FA验证码，未开启或使用通行密钥可不填'
                     />
                 </FormItem>
                 <div style={{ display: 'flex', justifyContent: 'flex-start', gap: '24px', alignItems: 'center' }}>
@@ -157,6 +218,11 @@ const Login = () => {
                 <FormItem>
                     <Button type='primary' block onClick={onSubmit}>登 录</Button>
                 </FormItem>
+                {isWebAuthnSupported() && (
+                    <FormItem>
+                        <Button block icon={<KeyOutlined />} loading={passkeyLoading} onClick={onPasskeyLogin}>使用通行密钥登录</Button>
+                    </FormItem>
+                )}
                 {ssoConfigs.length > 0 && (
                     <div style={{ marginTop: 16, textAlign: 'center' }}>
                         <div style={{ display: 'flex', alignItems: 'center', margin: '24px 0' }}>
@@ -418,6 +418,14 @@ export const initialMenu: MenuItem[] = [
                 customEvent: '() => loadJsonPage("/admin/cluster/cluster_all")',
                 order: 1,
             },
+            {
+                key: 'cluster_fleet',
+                title: '集群分组与批量操作',
+                icon: 'fa-solid fa-layer-group',
+                eventType: 'custom',
+                customEvent: '() => loadJsonPage("/admin/cluster/cluster_fleet")',
+                order: 1.5,
+            },
             {
                 key: 'system_config',
                 title: '参数设置',
@@ -444,6 +452,22 @@ export const initialMenu: MenuItem[] = [
                 customEvent: '() => loadJsonPage("/admin/user/user_group")',
                 order: 6,
             },
+            {
+                key: 'custom_role_management',
+                title: '自定义角色',
+                icon: 'fa-solid fa-user-shield',
+                eventType: 'custom',
+                customEvent: '() => loadJsonPage("/admin/user/custom_role")',
+                order: 6.5,
+            },
+            {
+                key: 'group_mapping_management',
+                title: '用户组映射',
+                icon: 'fa-solid fa-diagram-project',
+                eventType: 'custom',
+                customEvent: '() => loadJsonPage("/admin/user/group_mapping")',
+                order: 6.6,
+            },
              
             {
                 key: 'condition_reverse',
@@ -477,6 +501,14 @@ export const initialMenu: MenuItem[] = [
                 customEvent: '() => loadJsonPage("/admin/config/ldap_config")',
                 order: 11,
             },
+            {
+                key: 'scim_token',
+                title: 'SCIM同步',
+                icon: 'fa-solid fa-users-gear',
+                eventType: 'custom',
+                customEvent: '() => loadJsonPage("/admin/config/scim_token")',
+                order: 11.5,
+            },
             {
                 key: 'operation_audit',
                 title: '操作审计',
@@ -499,6 +531,22 @@ export const initialMenu: MenuItem[] = [
                         customEvent: '() => loadJsonPage("/log/shell")',
                         order: 2,
                     },
+                    {
+                        key: 'login_history',
+                        title: '登录记录',
+                        icon: 'fa-solid fa-user-lock',
+                        eventType: 'custom',
+                        customEvent: '() => loadJsonPage("/admin/user/login_history")',
+                        order: 3,
+                    },
+                    {
+                        key: 'audit_sink',
+                        title: '审计转发',
+                        icon: 'fa-solid fa-tower-broadcast',
+                        eventType: 'custom',
+                        customEvent: '() => loadJsonPage("/admin/config/audit_sink")',
+                        order: 4,
+                    },
                 ],
             },
             {
@@ -533,6 +581,14 @@ export const initialMenu: MenuItem[] = [
                 eventType: 'custom',
                 customEvent: '() => loadJsonPage("/user/profile/my_clusters")',
                 order: 2,
+            },
+            {
+                key: 'user_profile_sessions',
+                title: '登录会话',
+                icon: 'fa-solid fa-laptop',
+                eventType: 'custom',
+                customEvent: '() => loadJsonPage("/user/profile/my_sessions")',
+                order: 3,
             }
         ],
     },
@@ -0,0 +1,95 @@
+import axios from 'axios';
+import {jwtDecode} from 'jwt-decode';
+
+// 登录令牌管理：访问令牌有效期较短，过期前使用刷新令牌续期，会话被吊销后跳转登录页
+
+interface TokenPair {
+    token: string;
+    refresh_token?: string;
+}
+
+let refreshing: Promise<string | null> | null = null;
+
+export const saveTokens = (data: TokenPair) => {
+    localStorage.setItem('token', data.token);
+    if (data.refresh_token) {
+        localStorage.setItem('refresh_token', data.refresh_token);
+    }
+};
+
+export const clearTokens = () => {
+    localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
+};
+
+// 多个标签页共享令牌，刷新令牌每次使用后作废，跨标签页串行刷新；浏览器不支持时直接执行
+const withRefreshLock = <T>(fn: () => Promise<T>): Promise<T> => {
+    if (typeof navigator !== 'undefined' && navigator.locks) {
+        return navigator.locks.request('k8m-token-refresh', fn);
+    }
+    return fn();
+};
+
+// 使用刷新令牌换取新的访问令牌，并发调用时只发起一次请求，失败返回 null
+export const refreshAccessToken = (): Promise<string | null> => {
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (!refreshToken) {
+        return Promise.resolve(null);
+    }
+    if (!refreshing) {
+        refreshing = withRefreshLock(async () => {
+            // 等待期间其他标签页已完成刷新，直接使用新令牌
+            const current = localStorage.getItem('refresh_token');
+            if (current !== refreshToken) {
+                return current ? localStorage.getItem('token') : null;
+            }
+            try {
+                const res = await axios.post('/auth/refresh', {refresh_token: refreshToken});
+                saveTokens(res.data);
+                return res.data.token as string;
+            } catch {
+                clearTokens();
+                return null;
+            }
+        }).finally(() => {
+            refreshing = null;
+        });
+    }
+    return refreshing;
+};
+
+// 访问令牌即将过期时提前续期，WebSocket、SSE 等直接读取令牌的连接也能拿到有效令牌
+export const ensureFreshToken = async (aheadSeconds = 120) => {
+    const token = localStorage.getItem('token');
+    if (!token) {
+        return;
+    }
+    try {
+        const {exp} = jwtDecode<{ exp?: number }>(token);
+        if (exp && exp - Date.now() / 1000 > aheadSeconds) {
+            return;
+        }
+    } catch {
+        // 无法解析的令牌直接尝试续期
+    }
+    await refreshAccessToken();
+};
+
+export const startTokenRefresher = () => {
+    ensureFreshToken();
+    const timer = window.setInterval(ensureFreshToken, 60 * 1000);
+    return () => window.clearInterval(timer);
+};
+
+// 退出登录，吊销服务端会话
+export const logout = async () => {
+    const refreshToken = localStorage.getItem('refresh_token');
+    clearTokens();
+    if (refreshToken) {
+        try {
+            await axios.post('/auth/logout', {refresh_token: refreshToken});
+        } catch {
+            // 会话已失效时忽略
+        }
+    }
+};
@@ -0,0 +1,148 @@
+import axios from 'axios';
+import {ensureFreshToken} from '@/utils/auth';
+import {encrypt} from '@/utils/crypto';
+
+// 通行密钥（WebAuthn）：服务端以 base64url 传递二进制字段，调用浏览器接口前后需与 ArrayBuffer 互转
+
+export interface WebAuthnChallenge {
+    challenge_token: string;
+    options: any;
+}
+
+const toBuffer = (value: string): ArrayBuffer => {
+    const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+    const binary = atob(base64 + '='.repeat((4 - base64.length % 4) % 4));
+    const bytes = new Uint8Array(binary.length);
+    for (let i = 0; i < binary.length; i++) {
+        bytes[i] = binary.charCodeAt(i);
+    }
+    return bytes.buffer;
+};
+
+const toBase64URL = (buffer: ArrayBuffer | null): string | undefined => {
+    if (!buffer) {
+        return undefined;
+    }
+    let binary = '';
+    new Uint8Array(buffer).forEach(b => binary += String.fromCharCode(b));
+    return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+};
+
+const toDescriptors = (list?: any[]) => list?.map(c => ({...c, id: toBuffer(c.id)}));
+
+export const isWebAuthnSupported = () => typeof window !== 'undefined' && !!window.PublicKeyCredential;
+
+// 调用浏览器创建凭据，返回提交给服务端的注册结果
+export const createCredential = async (options: any) => {
+    const credential = await navigator.credentials.create({
+        publicKey: {
+            ...options,
+            challenge: toBuffer(options.challenge),
+            user: {...options.user, id: toBuffer(options.user.id)},
+            excludeCredentials: toDescriptors(options.excludeCredentials),
+        }
+    }) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error('未创建通行密钥');
+    }
+    const response = credential.response as AuthenticatorAttestationResponse;
+    return {
+        id: credential.id,
+        rawId: toBase64URL(credential.rawId),
+        type: credential.type,
+        response: {
+            clientDataJSON: toBase64URL(response.clientDataJSON),
+            attestationObject: toBase64URL(response.attestationObject),
+            transports: response.getTransports ? response.getTransports() : [],
+        },
+    };
+};
+
+// 调用浏览器使用凭据签名，返回提交给服务端的验证结果
+export const getAssertion = async (options: any) => {
+    const credential = await navigator.credentials.get({
+        publicKey: {
+            ...options,
+            challenge: toBuffer(options.challenge),
+            allowCredentials: toDescriptors(options.allowCredentials),
+        }
+    }) as PublicKeyCredential | null;
+    if (!credential) {
+        throw new Error('未选择通行密钥');
+    }
+    const response = credential.response as AuthenticatorAssertionResponse;
+    return {
+        id: credential.id,
+        rawId: toBase64URL(credential.rawId),
+        type: credential.type,
+        response: {
+            clientDataJSON: toBase64URL(response.clientDataJSON),
+            authenticatorData: toBase64URL(response.authenticatorData),
+            signature: toBase64URL(response.signature),
+            userHandle: toBase64URL(response.userHandle),
+        },
+    };
+};
+
+const postWithToken = async (url: string, body: any) => {
+    await ensureFreshToken();
+    const res = await axios.post(url, body, {
+        headers: {Authorization: `Bearer ${localStorage.getItem('token') || ''}`}
+    });
+    if (res.data?.status !== 0) {
+        throw new Error(res.data?.msg || '请求失败');
+    }
+    return res.data.data;
+};
+
+// 为当前用户注册通行密钥，passwordless 为 true 时可用于免密登录。
+// 注册前需确认身份：提供当前密码（启用2FA时同时提供验证码），未提供密码时使用已注册的通行密钥确认
+export const registerPasskey = async (name: string, passwordless: boolean, password = '', code = '') => {
+    if (!isWebAuthnSupported()) {
+        throw new Error('当前浏览器不支持通行密钥，或页面未通过 HTTPS 访问');
+    }
+    const url = '/mgm/user/profile/webauthn/register/begin';
+    let begin = await postWithToken(url, {passwordless, password: password ? encrypt(password) : '', code});
+    if (begin?.step_up) {
+        const stepUp: WebAuthnChallenge | null = begin.step_up.webauthn;
+        if (!stepUp) {
+            throw new Error('请输入当前密码确认身份');
+        }
+        const assertion = await getAssertion(stepUp.options);
+        begin = await postWithToken(url, {passwordless, step_up_token: stepUp.challenge_token, assertion});
+    }
+    const credential = await createCredential(begin.options);
+    await postWithToken('/mgm/user/profile/webauthn/register/finish', {
+        name,
+        passwordless,
+        challenge_token: begin.challenge_token,
+        credential,
+    });
+};
+
+// 免密登录，成功返回令牌
+export const loginWithPasskey = async () => {
+    if (!isWebAuthnSupported()) {
+        throw new Error('当前浏览器不支持通行密钥，或页面未通过 HTTPS 访问');
+    }
+    const begin = await axios.post('/auth/webauthn/login/begin');
+    const credential = await getAssertion(begin.data.options);
+    const res = await axios.post('/auth/webauthn/login/finish', {
+        challenge_token: begin.data.challenge_token,
+        credential,
+    }, {validateStatus: () => true});
+    if (res.status !== 200) {
+        throw new Error(res.data?.message || '通行密钥登录失败');
+    }
+    return res.data;
+};
+
+declare global {
+    interface Window {
+        registerPasskey: typeof registerPasskey;
+    }
+}
+
+if (typeof window !== 'undefined') {
+    window.registerPasskey = registerPasskey;
+}